@@ -9,6 +9,7 @@ import (
 	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/trap"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/stats"
 	"github.com/toni-moreno/snmpcollector/pkg/data/utils"
@@ -70,10 +71,12 @@ var (
 	mutex sync.RWMutex
 	// devices is the runtime snmp devices map
 	devices map[string]*device.SnmpDevice
-	// influxdb is the runtime devices output db map
-	influxdb map[string]*output.InfluxDB
+	// outdb is the runtime devices output db map
+	outdb map[string]output.Output
 
 	selfmonProc *selfmon.SelfMon
+	// trapProc is the trap receiver, nil if not enabled
+	trapProc *trap.Receiver
 	// gatherWg synchronizes device specific goroutines
 	gatherWg sync.WaitGroup
 	senderWg sync.WaitGroup
@@ -113,23 +116,15 @@ func CheckAndUnSetReloadProcess() bool {
 	return retval
 }
 
-// PrepareInfluxDBs initializes all configured output DBs in the SQL database.
+// PrepareOutputs initializes all configured output backends in the SQL database.
 // If there is no "default" key, creates a dummy output db which does nothing.
-func PrepareInfluxDBs() map[string]*output.InfluxDB {
-	idb := make(map[string]*output.InfluxDB)
-
-	var defFound bool
-	for k, c := range DBConfig.Influxdb {
-		if k == "default" {
-			defFound = true
-		}
-		idb[k] = output.NewNotInitInfluxDB(c)
-	}
-	if defFound == false {
+func PrepareOutputs() map[string]output.Output {
+	odb := output.NewOutputs(&DBConfig)
+	if _, ok := odb["default"]; !ok {
 		log.Warn("No Output default found influxdb devices found !!")
-		idb["default"] = output.DummyDB
+		odb["default"] = output.DummyDB
 	}
-	return idb
+	return odb
 }
 
 // GetDevice returns the snmp device with the given id.
@@ -177,18 +172,46 @@ func GetDevStats() map[string]*stats.GatherStats {
 	return devstats
 }
 
-// StopInfluxOut stops sending data to output influxDB servers.
-func StopInfluxOut(idb map[string]*output.InfluxDB) {
-	for k, v := range idb {
-		log.Infof("Stopping Influxdb out %s", k)
+// GetDevLastPoints returns the points built on the last gather cycle for each device.
+func GetDevLastPoints() map[string][]*output.Point {
+	devpoints := make(map[string][]*output.Point)
+	mutex.RLock()
+	for k, v := range devices {
+		devpoints[k] = v.GetLastPoints()
+	}
+	mutex.RUnlock()
+	return devpoints
+}
+
+// GetOutputsFailover returns the fail over circuit breaker status of each output with a standby backend.
+func GetOutputsFailover() map[string]*output.BreakerStatus {
+	res := make(map[string]*output.BreakerStatus)
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for k, v := range outdb {
+		fr, ok := v.(output.FailoverReporter)
+		if !ok {
+			continue
+		}
+		if st := fr.FailoverStatus(); st != nil {
+			res[k] = st
+		}
+	}
+	return res
+}
+
+// StopOutputs stops sending data to output backends.
+func StopOutputs(odb map[string]output.Output) {
+	for k, v := range odb {
+		log.Infof("Stopping output %s", k)
 		v.StopSender()
 	}
 }
 
-// ReleaseInfluxOut closes the influxDB connections and releases the associated resources.
-func ReleaseInfluxOut(idb map[string]*output.InfluxDB) {
-	for k, v := range idb {
-		log.Infof("Release Influxdb resources %s", k)
+// ReleaseOutputs closes the output backend connections and releases the associated resources.
+func ReleaseOutputs(odb map[string]output.Output) {
+	for k, v := range odb {
+		log.Infof("Release output resources %s", k)
 		v.End()
 	}
 }
@@ -213,18 +236,59 @@ func init() {
 	go Bus.Start()
 }
 
-func initSelfMonitoring(idb map[string]*output.InfluxDB) {
-	log.Debugf("INFLUXDB2: %+v", idb)
+// runtimeDevices returns the current runtime devices
+func runtimeDevices() []*device.SnmpDevice {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	devs := make([]*device.SnmpDevice, 0, len(devices))
+	for _, d := range devices {
+		devs = append(devs, d)
+	}
+	return devs
+}
+
+// TrapReceiverStart starts listening for traps if enabled, the trap sources are matched with the runtime devices
+func TrapReceiverStart() {
+	if !MainConfig.Trap.Enabled {
+		return
+	}
+	trapProc = trap.NewReceiver(MainConfig.Trap, DBConfig.TrapRules, runtimeDevices)
+	if err := trapProc.Start(); err != nil {
+		log.Errorf("Error on start trap receiver: %s", err)
+		trapProc = nil
+	}
+}
+
+// TrapReceiverStop stops the trap receiver if running
+func TrapReceiverStop() {
+	if trapProc == nil {
+		return
+	}
+	trapProc.Stop()
+	trapProc = nil
+}
+
+// GetTrapStats returns the trap receiver counters
+func GetTrapStats() (*trap.Stats, error) {
+	if trapProc == nil {
+		return nil, fmt.Errorf("trap receiver is not enabled")
+	}
+	st := trapProc.GetStats()
+	return &st, nil
+}
+
+func initSelfMonitoring(odb map[string]output.Output) {
+	log.Debugf("OUTPUTS: %+v", odb)
 	selfmonProc = selfmon.NewNotInit(&MainConfig.Selfmon)
 
 	if MainConfig.Selfmon.Enabled {
-		if val, ok := idb["default"]; ok {
+		if val, ok := odb["default"]; ok {
 			// only executed if a "default" influxdb exist
 			val.Init()
 			val.StartSender(&senderWg)
 
 			selfmonProc.Init()
-			selfmonProc.SetOutDB(idb)
+			selfmonProc.SetOutDB(odb)
 			selfmonProc.SetOutput(val)
 
 			log.Printf("SELFMON enabled %+v", MainConfig.Selfmon)
@@ -232,7 +296,7 @@ func initSelfMonitoring(idb map[string]*output.InfluxDB) {
 			selfmonProc.StartGather(&gatherWg)
 		} else {
 			MainConfig.Selfmon.Enabled = false
-			log.Errorf("SELFMON disabled becaouse of no default db found !!! SELFMON[ %+v ]  INFLUXLIST[ %+v]\n", MainConfig.Selfmon, idb)
+			log.Errorf("SELFMON disabled becaouse of no default db found !!! SELFMON[ %+v ]  OUTPUTLIST[ %+v]\n", MainConfig.Selfmon, odb)
 		}
 	} else {
 		log.Printf("SELFMON disabled %+v\n", MainConfig.Selfmon)
@@ -275,9 +339,11 @@ func AddDeviceInRuntime(k string, cfg *config.SnmpDeviceCfg) {
 	dev.SetSelfMonitoring(selfmonProc)
 
 	// send a db map to initialize each one its own db if needed
-	outdb, _ := dev.GetOutSenderFromMap(influxdb)
-	outdb.Init()
-	outdb.StartSender(&senderWg)
+	outs, _ := dev.GetOutSenderFromMap(outdb)
+	for _, out := range outs {
+		out.Init()
+		out.StartSender(&senderWg)
+	}
 
 	mutex.Lock()
 	devices[k] = dev
@@ -297,10 +363,10 @@ func AddDeviceInRuntime(k string, cfg *config.SnmpDeviceCfg) {
 // LoadConf loads the DB conf and initializes the device metric config.
 func LoadConf() {
 	MainConfig.Database.LoadDbConfig(&DBConfig)
-	influxdb = PrepareInfluxDBs()
+	outdb = PrepareOutputs()
 
 	// begin self monitoring process if needed, before all goroutines
-	initSelfMonitoring(influxdb)
+	initSelfMonitoring(outdb)
 	config.InitMetricsCfg(&DBConfig)
 }
 
@@ -308,12 +374,15 @@ func LoadConf() {
 func Start() {
 	LoadConf()
 	DeviceProcessStart()
+	TrapReceiverStart()
 }
 
 // End stops all devices polling.
 func End() (time.Duration, error) {
 	start := time.Now()
 	log.Infof("END: begin device Gather processes stop... at %s", start.String())
+	// no more trap points should be sent to the outputs
+	TrapReceiverStop()
 	// Stop all device processes and its measurements. Once finished they will be removed
 	// from the bus and node closed (snmp connections for measurements will be closed)
 	DeviceProcessStop()
@@ -329,11 +398,11 @@ func End() (time.Duration, error) {
 	// log.Info("DEBUG Gather WAIT %+v", GatherWg)
 	// log.Info("DEBUG SENDER WAIT %+v", senderWg)
 	// stop all Output Emitter
-	StopInfluxOut(influxdb)
+	StopOutputs(outdb)
 	log.Info("END: waiting for all Sender goroutines stop..")
 	senderWg.Wait()
 	log.Info("END: releasing Sender Resources")
-	ReleaseInfluxOut(influxdb)
+	ReleaseOutputs(outdb)
 	log.Infof("END: Finished from %s to %s [Duration : %s]", start.String(), time.Now().String(), time.Since(start).String())
 	return time.Since(start), nil
 }
@@ -354,6 +423,7 @@ func ReloadConf() (time.Duration, error) {
 	log.Info("RELOADCONF: Starting all device processes again...")
 	// Initialize Devices in Runtime map
 	DeviceProcessStart()
+	TrapReceiverStart()
 
 	log.Infof("RELOADCONF END: Finished from %s to %s [Duration : %s]", start.String(), time.Now().String(), time.Since(start).String())
 	CheckAndUnSetReloadProcess()
@@ -101,10 +101,11 @@ func (b *Bus) Start() {
 	for {
 		select {
 		case received := <-b.in:
-			nodes := make([]*Node, len(b.nodes))
+			var nodes []*Node
 			switch received.receiver {
 			case "all":
 				b.nodeLock.Lock()
+				nodes = make([]*Node, len(b.nodes))
 				copy(nodes, b.nodes)
 				b.nodeLock.Unlock()
 			default:
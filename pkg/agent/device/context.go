@@ -0,0 +1,113 @@
+package device
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
+	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
+)
+
+// ctxDiscovery is a measurement gathered from each SNMP context found walking its context OID
+type ctxDiscovery struct {
+	group string
+	cfg   *config.MeasurementCfg
+	// static contexts, gathered even if they are not found
+	static map[string]bool
+	// running measurements by context
+	meas map[string]*measurement.Measurement
+}
+
+func newCtxDiscovery(group string, c *config.MeasurementCfg) *ctxDiscovery {
+	return &ctxDiscovery{
+		group:  group,
+		cfg:    c,
+		static: make(map[string]bool),
+		meas:   make(map[string]*measurement.Measurement),
+	}
+}
+
+// pduContext returns the context found on a context OID: the value if it is a string or else the last index
+// (as the VLAN on vtpVlanState)
+func pduContext(pdu gosnmp.SnmpPDU) string {
+	if pdu.Type == gosnmp.OctetString {
+		return strings.TrimSpace(snmp.PduVal2str(pdu))
+	}
+	return pdu.Name[strings.LastIndex(pdu.Name, ".")+1:]
+}
+
+// ctxFound are the contexts found on each discovery walk, walks with errors are not included
+type ctxFound struct {
+	found map[*ctxDiscovery]map[string]bool
+	err   error
+}
+
+// discoverContexts walks the context OIDs of the measurements with its own client. It could take long
+// on slow devices, so it is run outside the device gather loop, which gets the result on a channel.
+func (d *SnmpDevice) discoverContexts(params snmp.ConnectionParams) ctxFound {
+	res := ctxFound{found: make(map[*ctxDiscovery]map[string]bool)}
+	cli := snmp.Client{
+		ID:               d.cfg.ID + "-contexts",
+		DisableBulk:      d.cfg.DisableBulk,
+		ConnectionParams: params,
+		Log:              d.log,
+	}
+	if _, err := cli.Connect(d.cfg.SystemOIDs); err != nil {
+		d.log.Errorf("unable to connect to discover the SNMP contexts: %v", err)
+		res.err = err
+		return res
+	}
+	defer cli.Release()
+
+	for _, cd := range d.ctxDiscovery {
+		found := make(map[string]bool)
+		err := cli.Walk(cd.cfg.ContextOID, func(pdu gosnmp.SnmpPDU) error {
+			if c := pduContext(pdu); len(c) > 0 {
+				found[c] = true
+			}
+			return nil
+		})
+		if err != nil {
+			d.log.Errorf("discovering the SNMP contexts of measurement %s from %s: %v", cd.cfg.ID, cd.cfg.ContextOID, err)
+			res.err = err
+			continue
+		}
+		res.found[cd] = found
+	}
+	return res
+}
+
+// updateContexts starts the measurements of the new contexts found and stops the ones of the contexts
+// no longer found. The static contexts are never stopped, neither the ones of the failed walks.
+func (d *SnmpDevice) updateContexts(res ctxFound, start, stop func(*measurement.Measurement)) {
+	for _, cd := range d.ctxDiscovery {
+		found, ok := res.found[cd]
+		if !ok {
+			continue
+		}
+		added := make([]string, 0, len(found))
+		for c := range found {
+			if _, ok := cd.meas[c]; !ok {
+				added = append(added, c)
+			}
+		}
+		sort.Strings(added)
+		for _, c := range added {
+			d.Infof("starting measurement %s on discovered SNMP context %s", cd.cfg.ID, c)
+			m := d.addMeasurement(cd.group, cd.cfg, c)
+			cd.meas[c] = m
+			start(m)
+		}
+		for c, m := range cd.meas {
+			if found[c] || cd.static[c] {
+				continue
+			}
+			d.Infof("stopping measurement %s on SNMP context %s, no longer found", cd.cfg.ID, c)
+			stop(m)
+			d.removeMeasurement(m)
+			delete(cd.meas, c)
+		}
+	}
+}
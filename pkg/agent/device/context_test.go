@@ -0,0 +1,118 @@
+package device
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
+)
+
+func Test_pduContext(t *testing.T) {
+	tests := []struct {
+		pdu  gosnmp.SnmpPDU
+		want string
+	}{
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.4.1.9.9.46.1.3.1.1.2.1.100", Type: gosnmp.Integer, Value: 1}, "100"},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.1.1", Type: gosnmp.OctetString, Value: []byte(" vrf-blue ")}, "vrf-blue"},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.1.1", Type: gosnmp.OctetString, Value: []byte("")}, ""},
+	}
+	for _, tt := range tests {
+		if got := pduContext(tt.pdu); got != tt.want {
+			t.Errorf("context of %s %v: %q, expected %q", tt.pdu.Name, tt.pdu.Value, got, tt.want)
+		}
+	}
+}
+
+func Test_updateContexts(t *testing.T) {
+	SetDBConfig(&config.DBConfig{})
+	d := &SnmpDevice{
+		cfg:        &config.SnmpDeviceCfg{ID: "sw1"},
+		log:        logrus.New(),
+		TagMap:     map[string]string{"device": "sw1"},
+		measRoutes: make(map[string]output.Output),
+		measOutput: make(map[*measurement.Measurement]output.Output),
+	}
+	mcfg := &config.MeasurementCfg{ID: "dot1dTpFdb", Name: "mac", ContextOID: ".1.3.6.1.4.1.9.9.46.1.3.1.1.2", ContextTag: "vlan"}
+	cd := newCtxDiscovery("g1", mcfg)
+	cd.static["1"] = true
+	cd.meas["1"] = d.addMeasurement("g1", mcfg, "1")
+	other := newCtxDiscovery("g1", &config.MeasurementCfg{ID: "vrf", Name: "vrf", ContextOID: ".1.3.6.1.2.1"})
+	d.ctxDiscovery = []*ctxDiscovery{cd, other}
+
+	var started, stopped []string
+	start := func(m *measurement.Measurement) { started = append(started, m.ID) }
+	stop := func(m *measurement.Measurement) { stopped = append(stopped, m.ID) }
+	ids := func() []string {
+		var res []string
+		for _, m := range d.Measurements {
+			res = append(res, m.ID)
+		}
+		sort.Strings(res)
+		return res
+	}
+
+	cycles := []struct {
+		name    string
+		found   map[*ctxDiscovery]map[string]bool
+		started []string
+		stopped []string
+		running []string
+	}{
+		{
+			name:    "new contexts",
+			found:   map[*ctxDiscovery]map[string]bool{cd: {"20": true, "10": true, "1": true}},
+			started: []string{"dot1dTpFdb@10", "dot1dTpFdb@20"},
+			running: []string{"dot1dTpFdb@1", "dot1dTpFdb@10", "dot1dTpFdb@20"},
+		},
+		{
+			name:    "same contexts",
+			found:   map[*ctxDiscovery]map[string]bool{cd: {"20": true, "10": true}},
+			running: []string{"dot1dTpFdb@1", "dot1dTpFdb@10", "dot1dTpFdb@20"},
+		},
+		{
+			// the static context 1 is never stopped
+			name:    "removed context",
+			found:   map[*ctxDiscovery]map[string]bool{cd: {"20": true, "30": true}, other: {"blue": true}},
+			started: []string{"dot1dTpFdb@30", "vrf@blue"},
+			stopped: []string{"dot1dTpFdb@10"},
+			running: []string{"dot1dTpFdb@1", "dot1dTpFdb@20", "dot1dTpFdb@30", "vrf@blue"},
+		},
+		{
+			// the contexts of the failed walks are kept
+			name:    "failed walk",
+			found:   map[*ctxDiscovery]map[string]bool{other: {}},
+			stopped: []string{"vrf@blue"},
+			running: []string{"dot1dTpFdb@1", "dot1dTpFdb@20", "dot1dTpFdb@30"},
+		},
+		{
+			name:    "context found again",
+			found:   map[*ctxDiscovery]map[string]bool{cd: {"10": true}},
+			started: []string{"dot1dTpFdb@10"},
+			stopped: []string{"dot1dTpFdb@20", "dot1dTpFdb@30"},
+			running: []string{"dot1dTpFdb@1", "dot1dTpFdb@10"},
+		},
+	}
+	for _, c := range cycles {
+		started, stopped = nil, nil
+		d.updateContexts(ctxFound{found: c.found}, start, stop)
+		sort.Strings(stopped)
+		if !cmp.Equal(c.started, started) || !cmp.Equal(c.stopped, stopped) {
+			t.Errorf("%s: started %v and stopped %v, expected %v and %v", c.name, started, stopped, c.started, c.stopped)
+		}
+		if got := ids(); !cmp.Equal(c.running, got) {
+			t.Errorf("%s: measurements %v, expected %v", c.name, got, c.running)
+		}
+	}
+
+	// each measurement queries its own context
+	for c, m := range cd.meas {
+		if m.Context != c || m.ID != "dot1dTpFdb@"+c {
+			t.Errorf("measurement %s on context %q, expected %s", m.ID, m.Context, c)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package device
+
+import (
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
+)
+
+// deviceCredential is the credential name used for the device own SNMP version and credentials
+const deviceCredential = "device"
+
+func toCredential(name string, c *config.SnmpDeviceCfg) snmp.Credential {
+	return snmp.Credential{
+		Name:        name,
+		SnmpVersion: c.SnmpVersion,
+		Community:   c.Community,
+		V3Params: snmp.V3Params{
+			SecLevel:        c.V3SecLevel,
+			AuthUser:        c.V3AuthUser,
+			AuthPass:        c.V3AuthPass,
+			PrivPass:        c.V3PrivPass,
+			PrivProt:        c.V3PrivProt,
+			AuthProt:        c.V3AuthProt,
+			ContextName:     c.V3ContextName,
+			ContextEngineID: c.V3ContextEngineID,
+		},
+	}
+}
+
+// NewCredentialSet returns the credentials to try in order when connecting to the device, first its
+// credential profile or own credentials and then its fallback profiles. It returns nil if the device
+// has no fallback profiles.
+func NewCredentialSet(c *config.SnmpDeviceCfg, creds map[string]*config.CredentialCfg) (*snmp.CredentialSet, error) {
+	if len(c.FallbackCredentials) == 0 {
+		return nil, nil
+	}
+	dc, err := c.ResolveCredential(creds)
+	if err != nil {
+		return nil, err
+	}
+	name := deviceCredential
+	if len(c.Credential) > 0 {
+		name = c.Credential
+	}
+	list := []snmp.Credential{toCredential(name, dc)}
+
+	fallbacks, err := c.GetFallbackCredentials(creds)
+	if err != nil {
+		return nil, err
+	}
+	for _, fb := range fallbacks {
+		fc := *c
+		fb.Apply(&fc)
+		list = append(list, toCredential(fb.ID, &fc))
+	}
+	return snmp.NewCredentialSet(list), nil
+}
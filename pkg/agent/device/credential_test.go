@@ -0,0 +1,75 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
+)
+
+func Test_NewCredentialSet(t *testing.T) {
+	creds := map[string]*config.CredentialCfg{
+		"v2c": {ID: "v2c", SnmpVersion: "2c", Community: "private"},
+		"v3": {
+			ID: "v3", SnmpVersion: "3", V3SecLevel: "AuthPriv", V3AuthUser: "snmpuser", V3AuthPass: "authpass", V3AuthProt: "SHA",
+			V3PrivPass: "privpass", V3PrivProt: "AES", V3ContextName: "ctx",
+		},
+	}
+	v2c := snmp.Credential{Name: "v2c", SnmpVersion: "2c", Community: "private"}
+	v3 := snmp.Credential{Name: "v3", SnmpVersion: "3", V3Params: snmp.V3Params{
+		SecLevel: "AuthPriv", AuthUser: "snmpuser", AuthPass: "authpass", AuthProt: "SHA", PrivPass: "privpass", PrivProt: "AES", ContextName: "ctx",
+	}}
+	tests := []struct {
+		name string
+		dev  config.SnmpDeviceCfg
+		want []snmp.Credential
+		ok   bool
+	}{
+		{
+			// a single credential is not a set
+			name: "without fallbacks",
+			dev:  config.SnmpDeviceCfg{ID: "sw1", SnmpVersion: "2c", Community: "public", Credential: "v3"},
+			ok:   true,
+		},
+		{
+			name: "own credentials first",
+			dev:  config.SnmpDeviceCfg{ID: "sw1", SnmpVersion: "2c", Community: "public", FallbackCredentials: []string{"v3", "v2c"}},
+			want: []snmp.Credential{{Name: "device", SnmpVersion: "2c", Community: "public"}, v3, v2c},
+			ok:   true,
+		},
+		{
+			name: "profile first",
+			dev:  config.SnmpDeviceCfg{ID: "sw1", SnmpVersion: "1", Community: "public", Credential: "v3", FallbackCredentials: []string{"v2c"}},
+			want: []snmp.Credential{v3, v2c},
+			ok:   true,
+		},
+		{
+			name: "unknown profile",
+			dev:  config.SnmpDeviceCfg{ID: "sw1", Credential: "v4", FallbackCredentials: []string{"v2c"}},
+		},
+		{
+			name: "unknown fallback profile",
+			dev:  config.SnmpDeviceCfg{ID: "sw1", Credential: "v3", FallbackCredentials: []string{"v2c", "v4"}},
+		},
+	}
+	for _, tt := range tests {
+		dev := tt.dev
+		set, err := NewCredentialSet(&dev, creds)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error %v", tt.name, err)
+			continue
+		}
+		var got []snmp.Credential
+		if set != nil {
+			got = set.List()
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: credentials (-want +got):\n%s", tt.name, diff)
+		}
+		// the device config is not modified
+		if diff := cmp.Diff(tt.dev, dev); diff != "" {
+			t.Errorf("%s: device modified (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
@@ -52,8 +52,11 @@ type SnmpDevice struct {
 	Measurements []*measurement.Measurement
 	// Variable map
 	VarMap map[string]interface{}
-	// Influx client shared between measurement goroutines to send data and stats to the backend
-	Influx *output.InfluxDB `json:"-"`
+	// Output backend shared between measurement goroutines to send data and stats to the backend
+	Output output.Output `json:"-"`
+	// outputs for measurements with its own routing by "group/measurement" (device outputs included)
+	measRoutes map[string]output.Output
+	measOutput map[*measurement.Measurement]output.Output
 	// LastError     time.Time
 	// Runtime stats
 	stats stats.GatherStats  // Runtime Internal statistic
@@ -67,6 +70,12 @@ type SnmpDevice struct {
 	DeviceConnected bool
 	// StateDebug is false by default and change to active when the API enable the debug of all measurement SNMP connections
 	StateDebug bool
+	// credentials tried in order on connect, nil if the device has no fallback credentials
+	credentials *snmp.CredentialSet
+	// adaptive GETBULK max repetitions and OIDs per GET, nil if the device has not the adaptive PDU size
+	pduSize *snmp.PDUSize
+	// measurements gathered from the SNMP contexts discovered on the device
+	ctxDiscovery []*ctxDiscovery
 
 	Node      *bus.Node `json:"-"`
 	isStopped chan bool `json:"-"`
@@ -84,6 +93,11 @@ func New(c *config.SnmpDeviceCfg) *SnmpDevice {
 	return &dev
 }
 
+// GetID return the device ID
+func (d *SnmpDevice) GetID() string {
+	return d.cfg.ID
+}
+
 // GetLogFilePath return current LogFile
 func (d *SnmpDevice) GetLogFilePath() string {
 	return d.cfg.LogFile
@@ -109,6 +123,8 @@ func (d *SnmpDevice) ToJSON() ([]byte, error) {
 		Stats        *stats.GatherStats // Public info for thread safe accessing to the data ()
 		CurLogLevel  string
 		StateDebug   bool
+		Credential   string        // credential which worked on the last connection, only with fallback credentials
+		PDUSize      *snmp.PDUSize // learned max repetitions and OIDs per GET, only with adaptive PDU size
 	}{
 		TagMap:       d.TagMap,
 		Freq:         d.Freq,
@@ -117,6 +133,8 @@ func (d *SnmpDevice) ToJSON() ([]byte, error) {
 		Stats:        d.Stats,
 		CurLogLevel:  d.CurLogLevel,
 		StateDebug:   d.StateDebug,
+		Credential:   d.workingCredential(),
+		PDUSize:      d.pduSize,
 	}, "", "  ")
 	if err != nil {
 		d.Errorf("Error on Get JSON data from device")
@@ -126,6 +144,25 @@ func (d *SnmpDevice) ToJSON() ([]byte, error) {
 	return result, err
 }
 
+// workingCredential returns the name of the credential which worked on the last connection
+func (d *SnmpDevice) workingCredential() string {
+	if d.credentials == nil {
+		return ""
+	}
+	return d.credentials.Working()
+}
+
+// GetLastPoints returns the points built on the last gather cycle for all device measurements
+func (d *SnmpDevice) GetLastPoints() []*output.Point {
+	d.rtData.RLock()
+	defer d.rtData.RUnlock()
+	var pts []*output.Point
+	for _, m := range d.Measurements {
+		pts = append(pts, m.GetLastPoints()...)
+	}
+	return pts
+}
+
 // GetBasicStats get basic info for this device
 func (d *SnmpDevice) GetBasicStats() *stats.GatherStats {
 	d.statsData.RLock()
@@ -160,22 +197,89 @@ func (d *SnmpDevice) getBasicStats() *stats.GatherStats {
 	return stat
 }
 
-// GetOutSenderFromMap to get info about the sender will use
-func (d *SnmpDevice) GetOutSenderFromMap(influxdb map[string]*output.InfluxDB) (*output.InfluxDB, error) {
+// GetOutSenderFromMap resolves the outputs this device will send data to, the main OutDB,
+// the ExtraOutDBs, the OutDBs configured on its measurement groups and the measurement
+// output overrides. Returns the list of all different outputs needed to be initialized
+// before start gathering
+func (d *SnmpDevice) GetOutSenderFromMap(outdb map[string]output.Output) ([]output.Output, error) {
 	if len(d.cfg.OutDB) == 0 {
 		d.Warnf("No OutDB configured on the device")
 	}
 	var ok bool
+	var main output.Output
 	name := d.cfg.OutDB
-	if d.Influx, ok = influxdb[name]; !ok {
+	if main, ok = outdb[name]; !ok {
 		// we assume there is always a default db
-		if d.Influx, ok = influxdb["default"]; !ok {
+		if main, ok = outdb["default"]; !ok {
 			// but
-			return nil, fmt.Errorf("No influx config for snmp device: %s", d.cfg.ID)
+			return nil, fmt.Errorf("No output config for snmp device: %s", d.cfg.ID)
+		}
+	}
+	used := make(map[string]output.Output)
+	d.Output = output.NewFanOut(d.resolveOutputs(outdb, main, nil, nil, used))
+
+	d.measRoutes = make(map[string]output.Output)
+	for _, mg := range d.cfg.MeasurementGroups {
+		g, ok := cfg.GetGroups[mg]
+		if !ok {
+			continue
+		}
+		for _, id := range g.Measurements {
+			mcfg, ok := cfg.Measurements[id]
+			if !ok {
+				continue
+			}
+			if len(g.OutDBs) == 0 && len(mcfg.OutDB) == 0 && len(mcfg.OutDatabase) == 0 && len(mcfg.OutRetention) == 0 {
+				continue
+			}
+			d.measRoutes[mg+"/"+id] = output.NewFanOut(d.resolveOutputs(outdb, main, g, mcfg, used))
+		}
+	}
+
+	all := make([]output.Output, 0, len(used))
+	for _, o := range used {
+		all = append(all, o)
+	}
+	return all, nil
+}
+
+// resolveOutputs returns the output list for a measurement of a measurement group (the device
+// ones if both are nil), all found outputs are also added to the used map
+func (d *SnmpDevice) resolveOutputs(outdb map[string]output.Output, main output.Output, g *config.MGroupsCfg, mcfg *config.MeasurementCfg, used map[string]output.Output) []output.Output {
+	if mcfg != nil && len(mcfg.OutDB) > 0 {
+		if o, ok := outdb[mcfg.OutDB]; ok {
+			main = o
+		} else {
+			d.Warnf("OutDB %s on measurement %s not found, using the device one", mcfg.OutDB, mcfg.ID)
 		}
 	}
+	used[main.ID()] = main
+	outs := []output.Output{main}
+	if mcfg != nil {
+		outs[0] = output.NewRoutedOutput(main, output.Route{Database: mcfg.OutDatabase, Retention: mcfg.OutRetention})
+	}
+	names := d.cfg.ExtraOutDBs
+	if g != nil {
+		names = append(append([]string{}, names...), g.OutDBs...)
+	}
+	for _, name := range names {
+		o, ok := outdb[name]
+		if !ok {
+			d.Warnf("Extra OutDB %s not found, skipping", name)
+			continue
+		}
+		used[o.ID()] = o
+		outs = append(outs, o)
+	}
+	return outs
+}
 
-	return d.Influx, nil
+// getMeasOutput returns the output for a measurement, the device one if not overridden by the measurement or its group
+func (d *SnmpDevice) getMeasOutput(m *measurement.Measurement) output.Output {
+	if o, ok := d.measOutput[m]; ok {
+		return o
+	}
+	return d.Output
 }
 
 // ForceGather send message to force a data gather execution
@@ -238,6 +342,8 @@ InitDevMeasurements  does the following
 func (d *SnmpDevice) InitDevMeasurements() {
 	// Alloc array
 	d.Measurements = make([]*measurement.Measurement, 0, 0)
+	d.measOutput = make(map[*measurement.Measurement]output.Output)
+	d.ctxDiscovery = nil
 	d.Debugf("---Init device measurements from groups %s------------------", d.cfg.Host)
 	// for this device get MeasurementGroups and search all measurements
 
@@ -272,21 +378,19 @@ func (d *SnmpDevice) InitDevMeasurements() {
 				d.Warnf("no measurement configured with name %s in host : %s", val, d.cfg.Host)
 			} else {
 				d.Debugf("MEASUREMENT CFG KEY: %s VALUE %s", val, mVal.Name)
-
-				// Pass a logger with predefined values to distinguish this host-measurement
-				measLog := d.log.WithFields(logrus.Fields{
-					"device":      d.cfg.Host,
-					"measurement": val,
-				})
-
-				mstat := stats.GatherStats{}
-				mstat.Init("measurement", mVal.Name, d.TagMap, measLog)
-				mstat.SetSelfMonitoring(d.selfmon)
-				// creating a new measurement runtime object and asigning to array
-				// MeasFilters and MFitlers used in the InitFilters function used in the initialization of the measurement goroutine
-				imeas := measurement.New(mVal, d.cfg.MeasFilters, cfg.MFilters, d.cfg.Active, measLog)
-				imeas.SetStats(mstat)
-				d.Measurements = append(d.Measurements, imeas)
+				if !mVal.HasContexts() {
+					d.addMeasurement(devMeas, mVal, "")
+					continue
+				}
+				// one measurement for each SNMP context, the discovered ones are added when the gather starts
+				cd := newCtxDiscovery(devMeas, mVal)
+				for _, c := range mVal.GetContexts() {
+					cd.static[c] = true
+					cd.meas[c] = d.addMeasurement(devMeas, mVal, c)
+				}
+				if len(mVal.ContextOID) > 0 {
+					d.ctxDiscovery = append(d.ctxDiscovery, cd)
+				}
 			}
 		}
 	}
@@ -296,6 +400,58 @@ func (d *SnmpDevice) InitDevMeasurements() {
 	// useful to inicialize counter all value and test device snmp availability
 }
 
+// addMeasurement creates the runtime measurement of the group measurement config, gathered from the SNMP
+// context if not empty, and adds it to the device measurements
+func (d *SnmpDevice) addMeasurement(group string, mVal *config.MeasurementCfg, context string) *measurement.Measurement {
+	// Pass a logger with predefined values to distinguish this host-measurement
+	fields := logrus.Fields{
+		"device":      d.cfg.Host,
+		"measurement": mVal.ID,
+	}
+	tagMap := d.TagMap
+	if len(context) > 0 {
+		fields["context"] = context
+		tagMap = make(map[string]string, len(d.TagMap)+1)
+		for k, v := range d.TagMap {
+			tagMap[k] = v
+		}
+		tagMap[mVal.ContextTag] = context
+	}
+	measLog := d.log.WithFields(fields)
+
+	d.rtData.Lock()
+	defer d.rtData.Unlock()
+	mstat := stats.GatherStats{}
+	mstat.Init("measurement", mVal.Name, tagMap, measLog)
+	mstat.SetSelfMonitoring(d.selfmon)
+	// creating a new measurement runtime object and asigning to array
+	// MeasFilters and MFitlers used in the InitFilters function used in the initialization of the measurement goroutine
+	imeas := measurement.New(mVal, d.cfg.MeasFilters, cfg.MFilters, d.DeviceActive, measLog)
+	if len(context) > 0 {
+		imeas.SetContext(context)
+	}
+	imeas.SetStats(mstat)
+	imeas.SetDeviceTag(d.cfg.DeviceTagName)
+	d.Measurements = append(d.Measurements, imeas)
+	if o, ok := d.measRoutes[group+"/"+mVal.ID]; ok {
+		d.measOutput[imeas] = o
+	}
+	return imeas
+}
+
+// removeMeasurement removes the measurement from the device measurements
+func (d *SnmpDevice) removeMeasurement(m *measurement.Measurement) {
+	d.rtData.Lock()
+	defer d.rtData.Unlock()
+	for i, im := range d.Measurements {
+		if im == m {
+			d.Measurements = append(d.Measurements[:i], d.Measurements[i+1:]...)
+			break
+		}
+	}
+	delete(d.measOutput, m)
+}
+
 /*
 Init  does the following
 
@@ -449,47 +605,139 @@ func (d *SnmpDevice) GetSystemOIDs() []string {
 	return d.cfg.SystemOIDs
 }
 
-// StartGather Main GoRutine method to begin snmp data collecting
-func (d *SnmpDevice) StartGather() {
-	d.Infof("Initializating gather process for device on host (%s)", d.cfg.Host)
+// getConnectionParams returns the config needed to establish a SNMP connection with the device
+func (d *SnmpDevice) getConnectionParams() (snmp.ConnectionParams, error) {
+	// credentials from the device profile if set
+	var creds map[string]*config.CredentialCfg
+	if cfg != nil {
+		creds = cfg.Credentials
+	}
+	dc, err := d.cfg.ResolveCredential(creds)
+	if err != nil {
+		return snmp.ConnectionParams{}, err
+	}
+
+	credentials, err := NewCredentialSet(d.cfg, creds)
+	if err != nil {
+		return snmp.ConnectionParams{}, err
+	}
+
+	// all measurement requests are multiplexed on a single connection
+	var session *snmp.Session
+	if !dc.DisableSharedSession {
+		session = snmp.NewSession(d.log)
+	}
 
 	// Define a default value for maxOids if its zero
-	maxOids := d.cfg.MaxOids
+	maxOids := dc.MaxOids
 	if maxOids <= 0 {
 		maxOids = DEFAULT_MAX_OIDS
 	}
 
-	// Organize the config needed to establish a SNMP connection.
-	// Will be used when a new snmp connection is needed.
-	connectionParams := snmp.ConnectionParams{
-		Host:           d.cfg.Host,
-		Port:           d.cfg.Port,
-		Timeout:        d.cfg.Timeout,
-		Retries:        d.cfg.Retries,
-		SnmpVersion:    d.cfg.SnmpVersion,
-		Community:      d.cfg.Community,
-		MaxRepetitions: d.cfg.MaxRepetitions,
+	// max repetitions and OIDs per GET learned by all the device measurements
+	var pduSize *snmp.PDUSize
+	if dc.AdaptivePDUSize {
+		pduSize = snmp.NewPDUSize(dc.MaxRepetitions, maxOids)
+	}
+
+	return snmp.ConnectionParams{
+		Host:           dc.Host,
+		Port:           dc.Port,
+		Transport:      dc.Transport,
+		Timeout:        dc.Timeout,
+		Retries:        dc.Retries,
+		SnmpVersion:    dc.SnmpVersion,
+		Community:      dc.Community,
+		MaxRepetitions: dc.MaxRepetitions,
 		MaxOids:        maxOids,
-		Debug:          d.cfg.SnmpDebug,
+		Debug:          dc.SnmpDebug,
 		V3Params: snmp.V3Params{
-			SecLevel:        d.cfg.V3SecLevel,
-			AuthUser:        d.cfg.V3AuthUser,
-			AuthPass:        d.cfg.V3AuthPass,
-			PrivPass:        d.cfg.V3PrivPass,
-			PrivProt:        d.cfg.V3PrivProt,
-			AuthProt:        d.cfg.V3AuthProt,
-			ContextName:     d.cfg.V3ContextName,
-			ContextEngineID: d.cfg.V3ContextEngineID,
+			SecLevel:        dc.V3SecLevel,
+			AuthUser:        dc.V3AuthUser,
+			AuthPass:        dc.V3AuthPass,
+			PrivPass:        dc.V3PrivPass,
+			PrivProt:        dc.V3PrivProt,
+			AuthProt:        dc.V3AuthProt,
+			ContextName:     dc.V3ContextName,
+			ContextEngineID: dc.V3ContextEngineID,
 		},
+		Credentials: credentials,
+		Limiter:     snmp.NewLimiter(dc.RateLimit, dc.MaxInFlight),
+		Session:     session,
+		PDUSize:     pduSize,
+	}, nil
+}
+
+// measNodeID returns the identifier of the measurement on the device bus and of its SNMP client
+func (d *SnmpDevice) measNodeID(m *measurement.Measurement) string {
+	return fmt.Sprintf("%s-%s", d.cfg.ID, m.ID)
+}
+
+// startMeasurement starts the measurement gather goroutine, controlled by the device bus
+func (d *SnmpDevice) startMeasurement(meas *measurement.Measurement, deviceControlBus *bus.Bus, deviceWG *sync.WaitGroup, connectionParams snmp.ConnectionParams, gatherLock *sync.Mutex) {
+	// measurements started after a runtime debug change should use it
+	connectionParams.Debug = d.StateDebug
+	out := d.getMeasOutput(meas)
+	// Start gather goroutine for device and add it to the wait group for gather goroutines
+	deviceWG.Add(1)
+	go func(m *measurement.Measurement) {
+		defer deviceWG.Done()
+
+		identifier := d.measNodeID(m)
+
+		// Add the measurement as a node to the bus
+		node := bus.NewNode(identifier)
+		deviceControlBus.Join(node)
+
+		// Create the SNMP client for each measurement.
+		// This client is just the data needed to connect, it does not start any connection yet.
+		// Here is created just the building blocks to be able to create the goSNMP client.
+		// We leave to the Measurement to handle the creation and destruction of that client.
+		// The measurement context, if any, overrides the device community or v3 context name.
+		params := connectionParams
+		params.Context = m.ContextParams()
+		snmpClient := snmp.Client{
+			ID:               identifier,
+			DisableBulk:      d.cfg.DisableBulk,
+			ConnectionParams: params,
+			Log:              m.Log,
+		}
+
+		// Start the loop that will gather metrics and handle signals
+		m.GatherLoop(node, snmpClient, d.Freq, d.cfg.UpdateFltFreq, d.VarMap, d.TagMap, d.cfg.SystemOIDs, out, gatherLock)
+
+		// If measurement exists, remove it from the bus, close the created node and the snmp connection
+		deviceControlBus.Leave(node)
+		node.Close()
+		snmpClient.Release()
+	}(meas)
+}
+
+// StartGather Main GoRutine method to begin snmp data collecting
+func (d *SnmpDevice) StartGather() {
+	d.Infof("Initializating gather process for device on host (%s)", d.cfg.Host)
+
+	// Organize the config needed to establish a SNMP connection.
+	// Will be used when a new snmp connection is needed.
+	connectionParams, err := d.getConnectionParams()
+	if err != nil {
+		d.log.Errorf("SNMP credentials: %v", err)
+		return
 	}
 
 	// Check if the values are valid, for example, if we have a community if the connection is v2c
-	err := connectionParams.Validation()
+	err = connectionParams.Validation()
 	if err != nil {
 		d.log.Errorf("SNMP parameter validation: %v", err)
 		return
 	}
 
+	// shared by all measurement clients to remember the working credential
+	d.rtData.Lock()
+	d.credentials = connectionParams.Credentials
+	d.pduSize = connectionParams.PDUSize
+	d.rtData.Unlock()
+
 	d.Infof("Device on host (%s) is active=%v. Setting up", d.cfg.Host, d.DeviceActive)
 
 	d.InitDevMeasurements()
@@ -510,36 +758,32 @@ func (d *SnmpDevice) StartGather() {
 	}
 
 	for _, meas := range d.Measurements {
-		// Start gather goroutine for device and add it to the wait group for gather goroutines
-		deviceWG.Add(1)
-		go func(m *measurement.Measurement) {
-			defer deviceWG.Done()
-
-			identifier := fmt.Sprintf("%s-%s", d.cfg.ID, m.ID)
-
-			// Add the measurement as a node to the bus
-			node := bus.NewNode(identifier)
-			deviceControlBus.Join(node)
-
-			// Create the SNMP client for each measurement.
-			// This client is just the data needed to connect, it does not start any connection yet.
-			// Here is created just the building blocks to be able to create the goSNMP client.
-			// We leave to the Measurement to handle the creation and destruction of that client.
-			snmpClient := snmp.Client{
-				ID:               identifier,
-				DisableBulk:      d.cfg.DisableBulk,
-				ConnectionParams: connectionParams,
-				Log:              m.Log,
-			}
-
-			// Start the loop that will gather metrics and handle signals
-			m.GatherLoop(node, snmpClient, d.Freq, d.cfg.UpdateFltFreq, d.VarMap, d.TagMap, d.cfg.SystemOIDs, d.Influx, gatherLock)
+		d.startMeasurement(meas, deviceControlBus, &deviceWG, connectionParams, gatherLock)
+	}
 
-			// If measurement exists, remove it from the bus, close the created node and the snmp connection
-			deviceControlBus.Leave(node)
-			node.Close()
-			snmpClient.Release()
-		}(meas)
+	// contexts are discovered again every filter update cycle, or on the next cycle if it failed
+	ctxStart := func(m *measurement.Measurement) {
+		d.startMeasurement(m, deviceControlBus, &deviceWG, connectionParams, gatherLock)
+	}
+	ctxStop := func(m *measurement.Measurement) {
+		deviceControlBus.Send(d.measNodeID(m), &bus.Message{Type: bus.Exit})
+	}
+	// the discovery walk runs on its own goroutine so bus messages are not blocked, only one at a time
+	ctxResult := make(chan ctxFound, 1)
+	ctxRunning := false
+	ctxCycles, ctxFailed := 0, len(d.ctxDiscovery) > 0
+	discoverContexts := func() {
+		ctxCycles = 0
+		if !d.DeviceActive || ctxRunning {
+			return
+		}
+		ctxRunning = true
+		go func() {
+			ctxResult <- d.discoverContexts(connectionParams)
+		}()
+	}
+	if ctxFailed {
+		discoverContexts()
 	}
 
 	deviceTicker := time.NewTicker(time.Duration(d.cfg.Freq) * time.Second)
@@ -558,7 +802,17 @@ func (d *SnmpDevice) StartGather() {
 			d.statsData.Unlock()
 			d.stats.Send()
 			d.stats.ResetCounters()
+			if len(d.ctxDiscovery) > 0 {
+				ctxCycles++
+				if ctxFailed || (d.cfg.UpdateFltFreq > 0 && ctxCycles >= d.cfg.UpdateFltFreq) {
+					discoverContexts()
+				}
+			}
 			// Try to reconnect after d.cfg.Freq seconds
+		case res := <-ctxResult:
+			ctxRunning = false
+			ctxFailed = res.err != nil
+			d.updateContexts(res, ctxStart, ctxStop)
 		case val := <-d.Node.Read:
 			d.Infof("Received Message: %s (%+v)", val.Type, val.Data)
 			switch val.Type {
@@ -618,7 +872,12 @@ func (d *SnmpDevice) StartGather() {
 				d.CurLogLevel = d.log.Level.String()
 				d.rtData.Unlock()
 
-			default: // exit, snmpresethard, snmpdebug, setsnmpmaxrep, forcegather, filterupdate
+			case bus.FilterUpdate:
+				deviceControlBus.Broadcast(val)
+				if len(d.ctxDiscovery) > 0 {
+					discoverContexts()
+				}
+			default: // exit, snmpresethard, snmpdebug, setsnmpmaxrep, forcegather
 				d.Infof("invoked %+v, passing message to measurements", val)
 				// Blocking operation. Waits till all measurements have received it
 				deviceControlBus.Broadcast(val)
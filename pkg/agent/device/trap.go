@@ -0,0 +1,76 @@
+package device
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
+)
+
+// GetTrapSource returns the device host addresses and the gosnmp object able to decode the traps
+// and informs sent by the device with the same version and credentials used to poll it
+func (d *SnmpDevice) GetTrapSource() ([]string, *gosnmp.GoSNMP, error) {
+	params, err := d.getConnectionParams()
+	if err != nil {
+		return nil, nil, err
+	}
+	// traps are sent with the same credentials which work to poll the device
+	d.rtData.RLock()
+	if d.credentials != nil {
+		params.SetCredential(d.credentials.Current())
+	}
+	d.rtData.RUnlock()
+	decoder, err := snmp.GetTrapDecoder(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	host, _, err := snmp.SplitHost(d.cfg.Host, d.cfg.Port)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid host: %v", err)
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("name lookup: %v", err)
+	}
+	// the trap source addresses are compared in their canonical form (IPv6 could be written in many ways)
+	for i, a := range addrs {
+		if ip := net.ParseIP(a); ip != nil {
+			addrs[i] = ip.String()
+		}
+	}
+	return addrs, decoder, nil
+}
+
+// SendTrapPoint sends a point built from a received trap or inform through the device outputs,
+// the device tags are added to the point. The point is enqueued without blocking the caller,
+// it returns false if it has been discarded because the output queue is full
+func (d *SnmpDevice) SendTrapPoint(name string, tags map[string]string, fields map[string]interface{}, t time.Time) (bool, error) {
+	if d.Output == nil {
+		return false, fmt.Errorf("no output configured on device %s", d.cfg.ID)
+	}
+	ptags := make(map[string]string, len(tags)+len(d.TagMap))
+	for k, v := range tags {
+		ptags[k] = v
+	}
+	deviceTags := make([]string, 0, len(d.TagMap))
+	for k, v := range d.TagMap {
+		ptags[k] = v
+		deviceTags = append(deviceTags, k)
+	}
+	pt, err := output.NewPoint(name, ptags, fields, t)
+	if err != nil {
+		return false, err
+	}
+	pt.Meta.DeviceTag = d.cfg.DeviceTagName
+	pt.Meta.DeviceTags = deviceTags
+	d.Debugf("GENERATED TRAP POINT[%s] value: %+v", name, pt)
+	pts := []*output.Point{pt}
+	if nb, ok := d.Output.(output.NonBlockingSender); ok {
+		return nb.TrySend(pts), nil
+	}
+	d.Output.Send(pts)
+	return true, nil
+}
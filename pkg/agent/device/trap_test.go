@@ -0,0 +1,68 @@
+package device
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// testTrapOutput records the points sent, with full set the points are discarded
+type testTrapOutput struct {
+	full bool
+	pts  []*output.Point
+}
+
+func (o *testTrapOutput) ID() string                     { return "test" }
+func (o *testTrapOutput) Init()                          {}
+func (o *testTrapOutput) End()                           {}
+func (o *testTrapOutput) StartSender(wg *sync.WaitGroup) {}
+func (o *testTrapOutput) StopSender()                    {}
+func (o *testTrapOutput) GetResetStats() *output.Stats   { return &output.Stats{} }
+func (o *testTrapOutput) Send(pts []*output.Point)       { o.TrySend(pts) }
+
+func (o *testTrapOutput) TrySend(pts []*output.Point) bool {
+	if o.full {
+		return false
+	}
+	o.pts = append(o.pts, pts...)
+	return true
+}
+
+func Test_SendTrapPoint(t *testing.T) {
+	d := &SnmpDevice{
+		cfg:    &config.SnmpDeviceCfg{ID: "sw1", DeviceTagName: "device"},
+		log:    logrus.New(),
+		TagMap: map[string]string{"device": "sw1", "site": "bcn"},
+	}
+	if _, err := d.SendTrapPoint("events", map[string]string{}, map[string]interface{}{"message": "down"}, time.Now()); err == nil {
+		t.Errorf("point sent without output")
+	}
+
+	out := &testTrapOutput{}
+	d.Output = out
+	tags := map[string]string{"trap": "linkdown"}
+	sent, err := d.SendTrapPoint("events", tags, map[string]interface{}{"message": "down"}, time.Now())
+	if err != nil || !sent {
+		t.Fatalf("send: %t %v", sent, err)
+	}
+	// the caller tags are not modified
+	if !cmp.Equal(map[string]string{"trap": "linkdown"}, tags) {
+		t.Errorf("caller tags modified: %v", tags)
+	}
+	if len(out.pts) != 1 || !cmp.Equal(map[string]string{"trap": "linkdown", "device": "sw1", "site": "bcn"}, out.pts[0].Tags) {
+		t.Fatalf("points sent %v", out.pts)
+	}
+	if out.pts[0].Meta.DeviceTag != "device" || len(out.pts[0].Meta.DeviceTags) != 2 {
+		t.Errorf("point meta %+v", out.pts[0].Meta)
+	}
+
+	out.full = true
+	if sent, err := d.SendTrapPoint("events", tags, map[string]interface{}{"message": "down"}, time.Now()); err != nil || sent {
+		t.Errorf("send with full queue: %t %v", sent, err)
+	}
+}
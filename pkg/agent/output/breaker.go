@@ -0,0 +1,170 @@
+package output
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	// BreakerClosed data is written to the primary backend
+	BreakerClosed = "closed"
+	// BreakerOpen data is written to the standby backend
+	BreakerOpen = "open"
+	// BreakerHalfOpen the primary backend health is being checked
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerStatus is the circuit breaker runtime state and total counters
+type BreakerStatus struct {
+	Standby             string
+	State               string
+	ConsecutiveFailures int
+	Failovers           int64
+	Failbacks           int64
+	LastChange          time.Time
+}
+
+// FailoverReporter could be implemented by outputs able to fail over to a standby backend
+type FailoverReporter interface {
+	// FailoverStatus returns the circuit breaker status, nil if fail over is not configured
+	FailoverStatus() *BreakerStatus
+}
+
+// BreakerStats circuit breaker state and counters since last reset
+type BreakerStats struct {
+	Enabled       bool
+	State         string
+	Failovers     int64
+	Failbacks     int64
+	StandbyWrites int64
+}
+
+// CircuitBreaker opens after threshold consecutive failures on the primary backend and
+// half-opens every retry interval to check if the primary is healthy again
+type CircuitBreaker struct {
+	standby   string
+	threshold int
+	retry     time.Duration
+	mutex     sync.Mutex
+	state     string
+	failures  int
+	lastProbe time.Time
+	status    BreakerStatus
+	// counters since last reset
+	failovers     int64
+	failbacks     int64
+	standbyWrites int64
+}
+
+// NewCircuitBreaker creates a closed circuit breaker, threshold and retry lower than 1 are set to 1
+func NewCircuitBreaker(standby string, threshold int, retry time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	if retry < time.Second {
+		retry = time.Second
+	}
+	return &CircuitBreaker{standby: standby, threshold: threshold, retry: retry, state: BreakerClosed}
+}
+
+func (cb *CircuitBreaker) setState(st string) {
+	cb.state = st
+	cb.status.LastChange = time.Now()
+}
+
+// Success resets the consecutive failures on the primary backend
+func (cb *CircuitBreaker) Success() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.failures = 0
+}
+
+// Failure counts a primary backend failure, returns true if the breaker has been opened
+func (cb *CircuitBreaker) Failure() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state != BreakerClosed {
+		return false
+	}
+	cb.failures++
+	if cb.failures < cb.threshold {
+		return false
+	}
+	cb.setState(BreakerOpen)
+	cb.lastProbe = time.Now()
+	cb.failovers++
+	cb.status.Failovers++
+	return true
+}
+
+// IsOpen returns true if data should be sent to the standby backend
+func (cb *CircuitBreaker) IsOpen() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.state != BreakerClosed
+}
+
+// AllowProbe returns true (and half-opens the breaker) if the primary backend should be checked now
+func (cb *CircuitBreaker) AllowProbe() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state != BreakerOpen || time.Since(cb.lastProbe) < cb.retry {
+		return false
+	}
+	cb.setState(BreakerHalfOpen)
+	cb.lastProbe = time.Now()
+	return true
+}
+
+// ProbeResult closes the breaker if the primary backend is healthy or opens it again if not
+func (cb *CircuitBreaker) ProbeResult(ok bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state != BreakerHalfOpen {
+		return
+	}
+	if !ok {
+		cb.setState(BreakerOpen)
+		return
+	}
+	cb.setState(BreakerClosed)
+	cb.failures = 0
+	cb.failbacks++
+	cb.status.Failbacks++
+}
+
+// StandbyWrite counts a batch written to the standby backend
+func (cb *CircuitBreaker) StandbyWrite() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.standbyWrites++
+}
+
+// GetStatus returns the current state and total counters
+func (cb *CircuitBreaker) GetStatus() *BreakerStatus {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	st := cb.status
+	st.Standby = cb.standby
+	st.State = cb.state
+	st.ConsecutiveFailures = cb.failures
+	return &st
+}
+
+// GetResetStats returns the current state and counters since last reset
+func (cb *CircuitBreaker) GetResetStats() BreakerStats {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	st := BreakerStats{
+		Enabled:       true,
+		State:         cb.state,
+		Failovers:     cb.failovers,
+		Failbacks:     cb.failbacks,
+		StandbyWrites: cb.standbyWrites,
+	}
+	cb.failovers = 0
+	cb.failbacks = 0
+	cb.standbyWrites = 0
+	return st
+}
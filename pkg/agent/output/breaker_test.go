@@ -0,0 +1,123 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+// testBreakerCooldown makes the next probe allowed without waiting the retry interval
+func testBreakerCooldown(cb *CircuitBreaker) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.lastProbe = time.Now().Add(-cb.retry)
+}
+
+func Test_NewCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker("standby", 0, 0)
+	if cb.threshold != 1 || cb.retry != time.Second {
+		t.Errorf("threshold %d and retry %s, expected 1 and 1s", cb.threshold, cb.retry)
+	}
+	if cb.IsOpen() {
+		t.Errorf("new breaker is open")
+	}
+	if st := cb.GetStatus(); st.State != BreakerClosed || st.Standby != "standby" {
+		t.Errorf("status %+v", st)
+	}
+}
+
+func Test_CircuitBreakerOpen(t *testing.T) {
+	for _, threshold := range []int{1, 3} {
+		cb := NewCircuitBreaker("standby", threshold, time.Minute)
+		// successes reset the consecutive failures
+		for i := 1; i < threshold; i++ {
+			cb.Failure()
+		}
+		cb.Success()
+		for i := 1; i < threshold; i++ {
+			if cb.Failure() || cb.IsOpen() {
+				t.Fatalf("threshold %d: opened after %d failures", threshold, i)
+			}
+		}
+		if st := cb.GetStatus(); st.ConsecutiveFailures != threshold-1 {
+			t.Errorf("threshold %d: %d consecutive failures", threshold, st.ConsecutiveFailures)
+		}
+		if !cb.Failure() || !cb.IsOpen() {
+			t.Fatalf("threshold %d: not opened at threshold", threshold)
+		}
+		// already open
+		if cb.Failure() {
+			t.Errorf("threshold %d: opened twice", threshold)
+		}
+		if st := cb.GetStatus(); st.State != BreakerOpen || st.Failovers != 1 {
+			t.Errorf("threshold %d: status %+v", threshold, st)
+		}
+	}
+}
+
+func Test_CircuitBreakerProbe(t *testing.T) {
+	cb := NewCircuitBreaker("standby", 1, time.Minute)
+	if cb.AllowProbe() {
+		t.Fatalf("probe allowed on closed breaker")
+	}
+	cb.Failure()
+
+	// open -> half-open only after the retry interval
+	if cb.AllowProbe() {
+		t.Fatalf("probe allowed before the retry interval")
+	}
+	testBreakerCooldown(cb)
+	if !cb.AllowProbe() {
+		t.Fatalf("probe not allowed after the retry interval")
+	}
+	if st := cb.GetStatus(); st.State != BreakerHalfOpen || !cb.IsOpen() {
+		t.Fatalf("state %s after probe allowed, expected %s", st.State, BreakerHalfOpen)
+	}
+	if cb.AllowProbe() {
+		t.Errorf("second probe allowed while half-open")
+	}
+
+	// half-open -> open, waits again the retry interval
+	cb.ProbeResult(false)
+	if st := cb.GetStatus(); st.State != BreakerOpen {
+		t.Fatalf("state %s after failed probe, expected %s", st.State, BreakerOpen)
+	}
+	if cb.AllowProbe() {
+		t.Errorf("probe allowed just after a failed probe")
+	}
+
+	// half-open -> closed
+	testBreakerCooldown(cb)
+	cb.AllowProbe()
+	cb.ProbeResult(true)
+	if st := cb.GetStatus(); st.State != BreakerClosed || cb.IsOpen() || st.ConsecutiveFailures != 0 || st.Failbacks != 1 {
+		t.Fatalf("status %+v after healthy probe", st)
+	}
+	// results without probe are ignored
+	cb.ProbeResult(false)
+	if cb.IsOpen() {
+		t.Errorf("opened by a result without probe")
+	}
+}
+
+func Test_CircuitBreakerStats(t *testing.T) {
+	cb := NewCircuitBreaker("standby", 1, time.Minute)
+	cb.Failure()
+	cb.StandbyWrite()
+	cb.StandbyWrite()
+	testBreakerCooldown(cb)
+	cb.AllowProbe()
+	cb.ProbeResult(true)
+
+	want := BreakerStats{Enabled: true, State: BreakerClosed, Failovers: 1, Failbacks: 1, StandbyWrites: 2}
+	if st := cb.GetResetStats(); st != want {
+		t.Errorf("stats %+v, expected %+v", st, want)
+	}
+	want = BreakerStats{Enabled: true, State: BreakerClosed}
+	if st := cb.GetResetStats(); st != want {
+		t.Errorf("stats after reset %+v, expected %+v", st, want)
+	}
+	// status counters are totals
+	if st := cb.GetStatus(); st.Failovers != 1 || st.Failbacks != 1 {
+		t.Errorf("status %+v", st)
+	}
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"strings"
+	"sync"
+)
+
+// NonBlockingSender could be implemented by outputs able to enqueue data without blocking
+// the caller when its sender queue is full
+type NonBlockingSender interface {
+	// TrySend enqueues points, returns false if they have been discarded
+	TrySend(pts []*Point) bool
+}
+
+// FanOut sends the same data to several outputs, each target keeps its own sender queue
+// so a slow target will not block the others.
+type FanOut struct {
+	outs []Output
+}
+
+// NewFanOut creates an output that writes to all the given outputs, duplicated targets are
+// removed and if only one target remains it is returned as is
+func NewFanOut(outs []Output) Output {
+	uniq := []Output{}
+	seen := make(map[string]bool)
+	for _, o := range outs {
+		if o == nil || seen[o.ID()] {
+			continue
+		}
+		seen[o.ID()] = true
+		uniq = append(uniq, o)
+	}
+	if len(uniq) == 1 {
+		return uniq[0]
+	}
+	return &FanOut{outs: uniq}
+}
+
+// ID returns all target IDs
+func (f *FanOut) ID() string {
+	ids := make([]string, 0, len(f.outs))
+	for _, o := range f.outs {
+		ids = append(ids, o.ID())
+	}
+	return strings.Join(ids, ",")
+}
+
+// Outputs returns the target outputs
+func (f *FanOut) Outputs() []Output {
+	return f.outs
+}
+
+// Init initializes all targets (already initialized targets will be skipped by themselves)
+func (f *FanOut) Init() {
+	for _, o := range f.outs {
+		o.Init()
+	}
+}
+
+// End does nothing, targets are shared and released from the runtime output map
+func (f *FanOut) End() {
+}
+
+// StartSender begins the sender goroutine on all targets
+func (f *FanOut) StartSender(wg *sync.WaitGroup) {
+	for _, o := range f.outs {
+		o.StartSender(wg)
+	}
+}
+
+// StopSender does nothing, targets are shared and stopped from the runtime output map
+func (f *FanOut) StopSender() {
+}
+
+// Send enqueues points on all targets, targets with a full queue will discard data
+// if they can not be written without blocking, discarded points are counted on the
+// target stats (PDropped)
+func (f *FanOut) Send(pts []*Point) {
+	f.TrySend(pts)
+}
+
+// TrySend enqueues points on all targets as Send does, returns false if any target discarded them
+func (f *FanOut) TrySend(pts []*Point) bool {
+	sent := true
+	for _, o := range f.outs {
+		nb, ok := o.(NonBlockingSender)
+		if !ok {
+			o.Send(pts)
+			continue
+		}
+		if !nb.TrySend(pts) {
+			log.Warnf("Output %s sender queue is full, discarding %d points", o.ID(), len(pts))
+			sent = false
+		}
+	}
+	return sent
+}
+
+// GetResetStats returns empty stats. Targets are shared by several devices and their stats,
+// including the points discarded by the fan-out, are reported by selfmon from the runtime output
+// map; reading them here would reset them before selfmon gets them
+func (f *FanOut) GetResetStats() *Stats {
+	return &Stats{}
+}
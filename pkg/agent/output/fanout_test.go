@@ -0,0 +1,41 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func Test_FanOutDropped(t *testing.T) {
+	// sender goroutines are not started, so queues are never drained
+	f1 := &File{cfg: &config.FileCfg{ID: "f1"}, iChan: make(chan []*Point, 1)}
+	f2 := &File{cfg: &config.FileCfg{ID: "f2"}, iChan: make(chan []*Point, 3)}
+
+	if out := NewFanOut([]Output{f1, nil, f1}); out != f1 {
+		t.Fatalf("fan-out with a single target returned %#v", out)
+	}
+	out := NewFanOut([]Output{f1, f2, f1})
+	if out.ID() != "f1,f2" {
+		t.Errorf("fan-out ID %q, expected f1,f2", out.ID())
+	}
+	out.Send(testInfluxPoints("m", 2))
+	out.Send(testInfluxPoints("m", 2))
+	// TrySend reports the points discarded by any target
+	if out.(NonBlockingSender).TrySend(testInfluxPoints("m", 2)) {
+		t.Errorf("fan-out try send ok with the f1 queue full")
+	}
+
+	// the fan-out does not reset the target stats
+	if st := out.GetResetStats(); st.PDropped != 0 || st.PSent != 0 {
+		t.Errorf("fan-out stats %+v, expected empty", st)
+	}
+	if st := f1.GetResetStats(); st.PDropped != 4 {
+		t.Errorf("f1 dropped %d points, expected 4", st.PDropped)
+	}
+	if st := f2.GetResetStats(); st.PDropped != 0 {
+		t.Errorf("f2 dropped %d points, expected 0", st.PDropped)
+	}
+	if st := f1.GetResetStats(); st.PDropped != 0 {
+		t.Errorf("f1 dropped %d points after reset", st.PDropped)
+	}
+}
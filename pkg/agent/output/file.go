@@ -0,0 +1,422 @@
+package output
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// fileRotateCheck interval to check segment time rotation
+const fileRotateCheck = 10 * time.Second
+
+// fileSegmentTimeFormat is the time part of the segment file names <id>-<time>.<ext>[.gz]
+const fileSegmentTimeFormat = "20060102T150405.000"
+
+// File writes data to rotating segment files in influx line protocol or JSON lines format
+type File struct {
+	cfg         *config.FileCfg
+	stats       Stats
+	initialized bool
+	imutex      sync.Mutex
+	started     bool
+	smutex      sync.Mutex
+
+	iChan  chan []*Point
+	chExit chan bool
+	dir    string
+	ext    string
+	// only accessed from the sender goroutine
+	file     *os.File
+	size     int64
+	openTime time.Time
+}
+
+// filePoint is the JSON lines representation of a point
+type filePoint struct {
+	Measurement string                 `json:"measurement"`
+	Tags        map[string]string      `json:"tags"`
+	Fields      map[string]interface{} `json:"fields"`
+	Time        time.Time              `json:"time"`
+}
+
+func init() {
+	Register("file", func(dbc *config.DBConfig) map[string]Output {
+		outs := make(map[string]Output)
+		for k, c := range dbc.File {
+			outs[k] = NewNotInitFile(c)
+		}
+		return outs
+	})
+}
+
+// NewNotInitFile Create Object in memory but not initialized until ready connection needed
+func NewNotInitFile(c *config.FileCfg) *File {
+	return &File{cfg: c}
+}
+
+// ID return the file output ID
+func (f *File) ID() string {
+	return f.cfg.ID
+}
+
+// GetResetStats return output stats and reset its counters
+func (f *File) GetResetStats() *Stats {
+	return f.stats.GetResetStats()
+}
+
+// Init initializes runtime info
+func (f *File) Init() {
+	f.imutex.Lock()
+	defer f.imutex.Unlock()
+	if f.initialized {
+		log.Infof("Sender thread to : %s  already Initialized (skipping Initialization)", f.cfg.ID)
+		return
+	}
+	f.dir = f.cfg.Directory
+	if len(f.dir) == 0 {
+		f.dir = filepath.Join("files", f.cfg.ID)
+	}
+	if !filepath.IsAbs(f.dir) {
+		f.dir = filepath.Join(dataDir, f.dir)
+	}
+	f.ext = ".lp"
+	if f.cfg.Format == "json" {
+		f.ext = ".jsonl"
+	}
+	if f.cfg.BufferSize <= 0 {
+		f.cfg.BufferSize = 65535
+	}
+	log.Infof("Initializing file output with id = [ %s ] on directory %s", f.cfg.ID, f.dir)
+	f.iChan = make(chan []*Point, f.cfg.BufferSize)
+	f.chExit = make(chan bool)
+	f.initialized = true
+}
+
+// End releases runtime resources
+func (f *File) End() {
+	f.imutex.Lock()
+	defer f.imutex.Unlock()
+	if !f.initialized {
+		return
+	}
+	close(f.iChan)
+	close(f.chExit)
+	f.initialized = false
+}
+
+// StartSender begins sender loop
+func (f *File) StartSender(wg *sync.WaitGroup) {
+	f.smutex.Lock()
+	defer f.smutex.Unlock()
+	if f.started {
+		log.Infof("Sender thread to : %s  already started (skipping Goroutine creation)", f.cfg.ID)
+		return
+	}
+	f.started = true
+	wg.Add(1)
+	go f.startSenderGo(rand.Int(), wg)
+}
+
+// StopSender finalize sender goroutines
+func (f *File) StopSender() {
+	f.smutex.Lock()
+	started := f.started
+	f.smutex.Unlock()
+	if started {
+		f.chExit <- true
+		return
+	}
+	log.Infof("Can not stop Sender [%s] becaouse of it is already stopped", f.cfg.ID)
+}
+
+// Send enqueues points to be written
+func (f *File) Send(pts []*Point) {
+	f.iChan <- pts
+}
+
+// TrySend enqueues points without blocking, returns false if the queue is full
+func (f *File) TrySend(pts []*Point) bool {
+	select {
+	case f.iChan <- pts:
+		return true
+	default:
+		f.stats.DropUpdate(int64(len(pts)))
+		return false
+	}
+}
+
+// encode converts points to the configured format, one line per point
+func (f *File) encode(pts []*Point) ([]byte, int64) {
+	var b strings.Builder
+	var fields int64
+	for _, p := range pts {
+		var line string
+		if f.cfg.Format == "json" {
+			data, err := json.Marshal(&filePoint{Measurement: p.Name, Tags: p.Tags, Fields: p.Fields, Time: p.Time})
+			if err != nil {
+				log.Warnf("Error on convert point %s to json on file output %s: %s", p.Name, f.cfg.ID, err)
+				continue
+			}
+			line = string(data)
+		} else {
+			pt, err := ToInfluxPoint(p)
+			if err != nil {
+				log.Warnf("Error on convert point %s to line protocol on file output %s: %s", p.Name, f.cfg.ID, err)
+				continue
+			}
+			line = pt.String()
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+		fields += int64(len(p.Fields))
+	}
+	return []byte(b.String()), fields
+}
+
+// segmentPrefix is the common prefix of all segment file names
+func (f *File) segmentPrefix() string {
+	return f.cfg.ID + "-"
+}
+
+// isSegment checks if the file name is a plain or compressed segment of this output,
+// other outputs could share the directory with IDs starting with the same prefix
+func (f *File) isSegment(name string) bool {
+	if !strings.HasPrefix(name, f.segmentPrefix()) {
+		return false
+	}
+	ts := strings.TrimSuffix(strings.TrimPrefix(name, f.segmentPrefix()), ".gz")
+	if !strings.HasSuffix(ts, f.ext) {
+		return false
+	}
+	_, err := time.Parse(fileSegmentTimeFormat, strings.TrimSuffix(ts, f.ext))
+	return err == nil
+}
+
+// segmentName returns the segment file name for the time, the time is moved forward
+// if a segment (plain or compressed) already exists with the same name
+func (f *File) segmentName(t time.Time) (string, time.Time) {
+	for {
+		name := filepath.Join(f.dir, f.segmentPrefix()+t.Format(fileSegmentTimeFormat)+f.ext)
+		_, err := os.Stat(name)
+		_, gzerr := os.Stat(name + ".gz")
+		if err != nil && gzerr != nil {
+			return name, t
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// open creates a new segment file
+func (f *File) open() error {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+	name, now := f.segmentName(time.Now())
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	log.Debugf("File output %s opened new segment %s", f.cfg.ID, name)
+	f.file = file
+	f.size = 0
+	f.openTime = now
+	return nil
+}
+
+// close closes the current segment and runs the segments maintenance
+func (f *File) close() {
+	if f.file == nil {
+		return
+	}
+	name := f.file.Name()
+	if err := f.file.Close(); err != nil {
+		log.Errorf("Error on close segment %s: %s", name, err)
+	}
+	f.file = nil
+	f.maintenance(name)
+}
+
+// needRotate checks if size or time limits have been reached on the current segment
+func (f *File) needRotate() bool {
+	if f.file == nil || f.size == 0 {
+		return false
+	}
+	if f.cfg.MaxSize > 0 && f.size >= int64(f.cfg.MaxSize)*1024*1024 {
+		return true
+	}
+	if f.cfg.RotateInterval > 0 && time.Since(f.openTime) >= time.Duration(f.cfg.RotateInterval)*time.Minute {
+		return true
+	}
+	return false
+}
+
+// write appends encoded points to the current segment
+func (f *File) write(pts []*Point) {
+	bufferPercent := (float32(len(f.iChan)) * 100.0) / float32(f.cfg.BufferSize)
+	start := time.Now()
+	data, fields := f.encode(pts)
+	if len(data) == 0 {
+		return
+	}
+	err := f.writeData(data)
+	elapsed := time.Since(start)
+	if err != nil {
+		f.stats.WriteErrUpdate(elapsed, bufferPercent)
+		log.Errorf("ERROR on write to file output %s: %s", f.cfg.ID, err)
+		return
+	}
+	f.stats.WriteOkUpdate(int64(len(pts)), fields, elapsed, bufferPercent)
+	if f.needRotate() {
+		f.close()
+	}
+}
+
+func (f *File) writeData(data []byte) error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		// next write will be done on a new segment
+		f.close()
+	}
+	return err
+}
+
+// closedSegments returns all closed segments (plain or compressed) sorted from older to newer
+func (f *File) closedSegments() ([]string, error) {
+	files, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var segs []string
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !f.isSegment(name) {
+			continue
+		}
+		path := filepath.Join(f.dir, name)
+		if f.file != nil && path == f.file.Name() {
+			continue
+		}
+		segs = append(segs, path)
+	}
+	sort.Strings(segs)
+	return segs, nil
+}
+
+// compressFile gzips the segment file and removes the plain one
+func compressFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(name + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
+
+// maintenance compresses the closed segment (if enabled) and removes the oldest segments over MaxFiles
+func (f *File) maintenance(name string) {
+	if f.cfg.Compress && len(name) > 0 {
+		if err := compressFile(name); err != nil {
+			log.Errorf("Error on compress segment %s: %s", name, err)
+		}
+	}
+	if f.cfg.MaxFiles <= 0 {
+		return
+	}
+	segs, err := f.closedSegments()
+	if err != nil {
+		log.Errorf("Error on get file output %s segments: %s", f.cfg.ID, err)
+		return
+	}
+	for i := 0; i < len(segs)-f.cfg.MaxFiles; i++ {
+		log.Infof("File output %s max files (%d) exceeded, removing segment %s", f.cfg.ID, f.cfg.MaxFiles, segs[i])
+		if err := os.Remove(segs[i]); err != nil {
+			log.Errorf("Error on remove segment %s: %s", segs[i], err)
+		}
+	}
+}
+
+// closeLeftovers compress (if enabled) segments left by previous runs
+func (f *File) closeLeftovers() {
+	segs, err := f.closedSegments()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("Error on get file output %s segments: %s", f.cfg.ID, err)
+		}
+		return
+	}
+	for _, s := range segs {
+		if f.cfg.Compress && strings.HasSuffix(s, f.ext) {
+			if err := compressFile(s); err != nil {
+				log.Errorf("Error on compress segment %s: %s", s, err)
+			}
+		}
+	}
+	f.maintenance("")
+}
+
+func (f *File) startSenderGo(r int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	log.Infof("beginning File Sender thread: [%s]", f.cfg.ID)
+	f.closeLeftovers()
+	t := time.NewTicker(fileRotateCheck)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if f.needRotate() {
+				f.close()
+			}
+		case <-f.chExit:
+			// need to flush all data
+			chanlen := len(f.iChan)
+			log.Infof("Flushing %d batches of data in file output %s ", chanlen, f.cfg.ID)
+			for i := 0; i < chanlen; i++ {
+				f.write(<-f.iChan)
+			}
+			f.close()
+			log.Infof("EXIT from File sender process for output [%s] ", f.cfg.ID)
+			f.smutex.Lock()
+			f.started = false
+			f.smutex.Unlock()
+			return
+		case pts := <-f.iChan:
+			if pts == nil {
+				continue
+			}
+			f.write(pts)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package output
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func testFileOutput(t *testing.T, c *config.FileCfg) *File {
+	c.ID = "out"
+	c.Directory = t.TempDir()
+	f := NewNotInitFile(c)
+	f.Init()
+	t.Cleanup(f.End)
+	return f
+}
+
+// testFileSegments returns the file names on the output directory
+func testFileSegments(t *testing.T, f *File) []string {
+	t.Helper()
+	files, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		t.Fatalf("read dir: %s", err)
+	}
+	var names []string
+	for _, fi := range files {
+		names = append(names, fi.Name())
+	}
+	return names
+}
+
+// testFileLines returns the lines of a plain or compressed segment
+func testFileLines(t *testing.T, name string) []string {
+	t.Helper()
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+	defer file.Close()
+	var data []byte
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("gzip %s: %s", name, err)
+		}
+		data, err = ioutil.ReadAll(zr)
+	} else {
+		data, err = ioutil.ReadAll(file)
+	}
+	if err != nil {
+		t.Fatalf("read %s: %s", name, err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func testFileCreate(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, n := range names {
+		if err := ioutil.WriteFile(filepath.Join(dir, n), []byte("m value=1i 1600000000000000000\n"), 0o644); err != nil {
+			t.Fatalf("write %s: %s", n, err)
+		}
+	}
+}
+
+func Test_FileClosedSegments(t *testing.T) {
+	f := testFileOutput(t, &config.FileCfg{})
+	os.MkdirAll(f.dir, 0o755)
+	testFileCreate(t, f.dir,
+		"out-20240101T000001.000.lp.gz",
+		"out-20240101T000000.000.lp",
+		"out-20240101T000002.500.lp",
+		// other outputs and files
+		"out-b-20240101T000000.000.lp",
+		"out-20240101T000000.000.jsonl",
+		"out-notes.lp",
+		"out-20240101T000000.000.lp.tmp",
+		"out-20240101.lp",
+		"other-20240101T000000.000.lp",
+	)
+	os.Mkdir(filepath.Join(f.dir, "out-20240101T000003.000.lp"), 0o755)
+	// the current segment is not closed
+	f.file, _ = os.Open(filepath.Join(f.dir, "out-20240101T000002.500.lp"))
+	defer f.file.Close()
+
+	segs, err := f.closedSegments()
+	if err != nil {
+		t.Fatalf("closed segments: %s", err)
+	}
+	want := []string{
+		filepath.Join(f.dir, "out-20240101T000000.000.lp"),
+		filepath.Join(f.dir, "out-20240101T000001.000.lp.gz"),
+	}
+	if !cmp.Equal(want, segs) {
+		t.Errorf("closed segments %v, expected %v", segs, want)
+	}
+}
+
+func Test_FileRotate(t *testing.T) {
+	f := testFileOutput(t, &config.FileCfg{MaxSize: 1, RotateInterval: 60})
+
+	// size rotation, the segment is closed after the write which reaches MaxSize
+	big := testInfluxPoints("big", 1)
+	big[0].Fields["data"] = strings.Repeat("x", 1024*1024)
+	f.write(testInfluxPoints("m", 2))
+	f.write(big)
+	if f.file != nil {
+		t.Fatalf("segment not closed after reaching max size")
+	}
+	f.write(testInfluxPoints("m", 1))
+	if segs := testFileSegments(t, f); len(segs) != 2 {
+		t.Fatalf("segments %v, expected 2", segs)
+	}
+
+	// time rotation
+	if f.needRotate() {
+		t.Errorf("rotation needed on a new segment")
+	}
+	f.openTime = time.Now().Add(-time.Hour)
+	if !f.needRotate() {
+		t.Errorf("rotation not needed after the rotate interval")
+	}
+	f.write(testInfluxPoints("m", 1))
+	if f.file != nil {
+		t.Fatalf("segment not closed after the rotate interval")
+	}
+	segs := testFileSegments(t, f)
+	if len(segs) != 2 {
+		t.Fatalf("segments %v, expected 2", segs)
+	}
+	for _, s := range segs {
+		if !f.isSegment(s) {
+			t.Errorf("invalid segment name %s", s)
+		}
+	}
+	if lines := testFileLines(t, filepath.Join(f.dir, segs[0])); len(lines) != 3 {
+		t.Errorf("%d lines on first segment, expected 3", len(lines))
+	}
+	if lines := testFileLines(t, filepath.Join(f.dir, segs[1])); len(lines) != 2 {
+		t.Errorf("%d lines on second segment, expected 2", len(lines))
+	}
+	if st := f.GetResetStats(); st.PSent != 5 || st.WriteErrors != 0 {
+		t.Errorf("%d points written, %d errors", st.PSent, st.WriteErrors)
+	}
+
+	// empty segments are not rotated
+	f.write(testInfluxPoints("m", 1))
+	f.size = 0
+	f.openTime = time.Now().Add(-time.Hour)
+	if f.needRotate() {
+		t.Errorf("rotation needed on an empty segment")
+	}
+}
+
+func Test_FileSegmentName(t *testing.T) {
+	f := testFileOutput(t, &config.FileCfg{})
+	os.MkdirAll(f.dir, 0o755)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	testFileCreate(t, f.dir, "out-20240101T000000.000.lp", "out-20240101T000000.001.lp.gz")
+	// existing segments are not reused
+	name, ts := f.segmentName(now)
+	if filepath.Base(name) != "out-20240101T000000.002.lp" || !ts.Equal(now.Add(2*time.Millisecond)) {
+		t.Errorf("segment name %s at %s", name, ts)
+	}
+}
+
+func Test_FileCompress(t *testing.T) {
+	f := testFileOutput(t, &config.FileCfg{Compress: true, Format: "json"})
+	os.MkdirAll(f.dir, 0o755)
+	// segments left by a previous run
+	testFileCreate(t, f.dir, "out-20240101T000000.000.jsonl", "out-20240101T000001.000.jsonl.gz", "out-20240101T000002.000.lp")
+	f.closeLeftovers()
+	want := []string{"out-20240101T000000.000.jsonl.gz", "out-20240101T000001.000.jsonl.gz", "out-20240101T000002.000.lp"}
+	if got := testFileSegments(t, f); !cmp.Equal(want, got) {
+		t.Errorf("files after leftovers %v, expected %v", got, want)
+	}
+	if lines := testFileLines(t, filepath.Join(f.dir, want[0])); !cmp.Equal([]string{"m value=1i 1600000000000000000"}, lines) {
+		t.Errorf("compressed leftover lines %v", lines)
+	}
+
+	// closed segments are compressed
+	f.write(testInfluxPoints("m", 2))
+	name := f.file.Name()
+	f.close()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("plain segment %s not removed: %v", name, err)
+	}
+	lines := testFileLines(t, name+".gz")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"measurement":"m","tags":{"device":"router1"},"fields":{"value":0}`) {
+		t.Errorf("compressed segment lines %v", lines)
+	}
+}
+
+func Test_FileMaxFiles(t *testing.T) {
+	f := testFileOutput(t, &config.FileCfg{MaxFiles: 2})
+	os.MkdirAll(f.dir, 0o755)
+	testFileCreate(t, f.dir,
+		"out-20240101T000000.000.lp",
+		"out-20240101T000001.000.lp.gz",
+		"out-20240101T000002.000.lp",
+		"out-b-20240101T000000.000.lp",
+	)
+	// leftovers over MaxFiles are removed on start
+	f.closeLeftovers()
+	want := []string{"out-20240101T000001.000.lp.gz", "out-20240101T000002.000.lp", "out-b-20240101T000000.000.lp"}
+	if got := testFileSegments(t, f); !cmp.Equal(want, got) {
+		t.Errorf("files after leftovers %v, expected %v", got, want)
+	}
+
+	// the oldest segment is removed when a new one is closed
+	f.write(testInfluxPoints("m", 1))
+	name := filepath.Base(f.file.Name())
+	// the current segment is not counted
+	if got := testFileSegments(t, f); len(got) != 4 {
+		t.Errorf("files with an open segment %v", got)
+	}
+	f.close()
+	want = []string{"out-20240101T000002.000.lp", "out-b-20240101T000000.000.lp", name}
+	if got := testFileSegments(t, f); !cmp.Equal(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })) {
+		t.Errorf("files after close %v, expected %v", got, want)
+	}
+}
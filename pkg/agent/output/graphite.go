@@ -0,0 +1,350 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// DefaultGraphitePathTemplate is used when no PathTemplate has been configured
+const DefaultGraphitePathTemplate = "snmpcollector.{device}.{measurement}.{index}.{field}"
+
+// graphiteMaxPendingBatches max number of batches kept in memory while the server is unreachable
+const graphiteMaxPendingBatches = 10
+
+var graphitePlaceholder = regexp.MustCompile(`\{(device|measurement|index|field|tag:[^}]+)\}`)
+
+var graphiteInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
+
+// Graphite sends data to a carbon server with the plaintext protocol
+type Graphite struct {
+	cfg         *config.GraphiteCfg
+	stats       Stats
+	initialized bool
+	imutex      sync.Mutex
+	started     bool
+	smutex      sync.Mutex
+
+	iChan  chan []*Point
+	chExit chan bool
+	// only accessed from the sender goroutine
+	conn     net.Conn
+	lastDial time.Time
+	buf      bytes.Buffer
+	lines    int
+	points   int64
+}
+
+func init() {
+	Register("graphite", func(dbc *config.DBConfig) map[string]Output {
+		outs := make(map[string]Output)
+		for k, c := range dbc.Graphite {
+			outs[k] = NewNotInitGraphite(c)
+		}
+		return outs
+	})
+}
+
+// NewNotInitGraphite Create Object in memory but not initialized until ready connection needed
+func NewNotInitGraphite(c *config.GraphiteCfg) *Graphite {
+	return &Graphite{cfg: c}
+}
+
+// PingGraphite checks a TCP connection can be established with the graphite server
+func PingGraphite(cfg *config.GraphiteCfg) (time.Duration, string, error) {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, time.Duration(cfg.Timeout)*time.Second)
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Errorf("Error on connect to graphite server %s: %s", addr, err)
+		return elapsed, "", err
+	}
+	conn.Close()
+	return elapsed, fmt.Sprintf("Connected to %s", conn.RemoteAddr()), nil
+}
+
+// ID return the graphite output ID
+func (g *Graphite) ID() string {
+	return g.cfg.ID
+}
+
+// GetResetStats return output stats and reset its counters
+func (g *Graphite) GetResetStats() *Stats {
+	return g.stats.GetResetStats()
+}
+
+// Init initializes runtime info
+func (g *Graphite) Init() {
+	g.imutex.Lock()
+	defer g.imutex.Unlock()
+	if g.initialized {
+		log.Infof("Sender thread to : %s  already Initialized (skipping Initialization)", g.cfg.ID)
+		return
+	}
+	log.Infof("Initializing graphite output with id = [ %s ] to %s:%d", g.cfg.ID, g.cfg.Host, g.cfg.Port)
+	if len(g.cfg.PathTemplate) == 0 {
+		g.cfg.PathTemplate = DefaultGraphitePathTemplate
+	}
+	if g.cfg.BufferSize <= 0 {
+		g.cfg.BufferSize = 65535
+	}
+	if g.cfg.BatchSize <= 0 {
+		g.cfg.BatchSize = 1000
+	}
+	// time.NewTicker panics with non positive intervals
+	if g.cfg.FlushInterval <= 0 {
+		g.cfg.FlushInterval = 10
+	}
+	// a zero write deadline would fail all writes
+	if g.cfg.Timeout <= 0 {
+		g.cfg.Timeout = 10
+	}
+	g.iChan = make(chan []*Point, g.cfg.BufferSize)
+	g.chExit = make(chan bool)
+	g.initialized = true
+}
+
+// End releases runtime resources
+func (g *Graphite) End() {
+	g.imutex.Lock()
+	defer g.imutex.Unlock()
+	if !g.initialized {
+		return
+	}
+	close(g.iChan)
+	close(g.chExit)
+	g.initialized = false
+}
+
+// StartSender begins sender loop
+func (g *Graphite) StartSender(wg *sync.WaitGroup) {
+	g.smutex.Lock()
+	defer g.smutex.Unlock()
+	if g.started {
+		log.Infof("Sender thread to : %s  already started (skipping Goroutine creation)", g.cfg.ID)
+		return
+	}
+	g.started = true
+	wg.Add(1)
+	go g.startSenderGo(rand.Int(), wg)
+}
+
+// StopSender finalize sender goroutines
+func (g *Graphite) StopSender() {
+	g.smutex.Lock()
+	started := g.started
+	g.smutex.Unlock()
+	if started {
+		g.chExit <- true
+		return
+	}
+	log.Infof("Can not stop Sender [%s] becaouse of it is already stopped", g.cfg.ID)
+}
+
+// Send enqueues points to be written
+func (g *Graphite) Send(pts []*Point) {
+	g.iChan <- pts
+}
+
+// TrySend enqueues points without blocking, returns false if the queue is full
+func (g *Graphite) TrySend(pts []*Point) bool {
+	select {
+	case g.iChan <- pts:
+		return true
+	default:
+		g.stats.DropUpdate(int64(len(pts)))
+		return false
+	}
+}
+
+// graphiteValue formats a field value, strings can not be sent to graphite
+func graphiteValue(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32), true
+	case int:
+		return strconv.FormatInt(int64(val), 10), true
+	case int32:
+		return strconv.FormatInt(int64(val), 10), true
+	case int64:
+		return strconv.FormatInt(val, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(val), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10), true
+	case uint64:
+		return strconv.FormatUint(val, 10), true
+	case bool:
+		if val {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
+
+// graphiteSanitize replaces all characters not allowed in a path node
+func graphiteSanitize(s string) string {
+	return graphiteInvalidChars.ReplaceAllString(s, "_")
+}
+
+// GraphitePath builds the metric path for a point field from the template
+// placeholders are replaced by its sanitized values and empty nodes are removed
+func GraphitePath(tmpl string, p *Point, field string) string {
+	path := graphitePlaceholder.ReplaceAllStringFunc(tmpl, func(ph string) string {
+		name := ph[1 : len(ph)-1]
+		switch {
+		case name == "device":
+			return graphiteSanitize(p.Tags[p.Meta.DeviceTag])
+		case name == "measurement":
+			return graphiteSanitize(p.Name)
+		case name == "field":
+			return graphiteSanitize(field)
+		case name == "index":
+			idx := make([]string, 0, len(p.Meta.IndexTags))
+			for _, t := range p.Meta.IndexTags {
+				idx = append(idx, graphiteSanitize(p.Tags[t]))
+			}
+			return strings.Join(idx, ".")
+		default:
+			return graphiteSanitize(p.Tags[strings.TrimPrefix(name, "tag:")])
+		}
+	})
+	nodes := strings.Split(path, ".")
+	res := nodes[:0]
+	for _, n := range nodes {
+		if len(n) > 0 {
+			res = append(res, n)
+		}
+	}
+	return strings.Join(res, ".")
+}
+
+// addPoints appends points as plaintext lines to the pending buffer
+func (g *Graphite) addPoints(pts []*Point) {
+	now := time.Now()
+	for _, p := range pts {
+		t := p.Time
+		if t.IsZero() {
+			t = now
+		}
+		ts := strconv.FormatInt(t.Unix(), 10)
+		for f, v := range p.Fields {
+			value, ok := graphiteValue(v)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&g.buf, "%s %s %s\n", GraphitePath(g.cfg.PathTemplate, p, f), value, ts)
+			g.lines++
+		}
+		g.points++
+	}
+}
+
+// connect opens a new connection to the server, retries are delayed TimeWriteRetry seconds
+func (g *Graphite) connect() error {
+	if g.conn != nil {
+		return nil
+	}
+	if time.Since(g.lastDial) < TimeWriteRetry*time.Second {
+		return fmt.Errorf("waiting to reconnect")
+	}
+	g.lastDial = time.Now()
+	addr := net.JoinHostPort(g.cfg.Host, strconv.Itoa(g.cfg.Port))
+	conn, err := net.DialTimeout("tcp", addr, time.Duration(g.cfg.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+	log.Infof("Connected to graphite server %s for output %s", addr, g.cfg.ID)
+	g.conn = conn
+	return nil
+}
+
+// flush writes all pending lines, on error lines are kept to be sent on next flush
+// until graphiteMaxPendingBatches batches are pending
+func (g *Graphite) flush() {
+	if g.lines == 0 {
+		return
+	}
+	bufferPercent := (float32(len(g.iChan)) * 100.0) / float32(g.cfg.BufferSize)
+	start := time.Now()
+	err := g.connect()
+	if err == nil {
+		g.conn.SetWriteDeadline(time.Now().Add(time.Duration(g.cfg.Timeout) * time.Second))
+		_, err = g.conn.Write(g.buf.Bytes())
+		if err != nil {
+			g.conn.Close()
+			g.conn = nil
+		}
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		g.stats.WriteErrUpdate(elapsed, bufferPercent)
+		log.Errorf("ERROR on write to graphite output %s (%d lines pending): %s", g.cfg.ID, g.lines, err)
+		if g.lines > graphiteMaxPendingBatches*g.cfg.BatchSize {
+			log.Errorf("Graphite output %s has too much pending data, dropping %d lines", g.cfg.ID, g.lines)
+			g.reset()
+		}
+		return
+	}
+	log.Debugf("OK on write to graphite output %s (%d lines) | elapsed : %s ", g.cfg.ID, g.lines, elapsed.String())
+	g.stats.WriteOkUpdate(g.points, int64(g.lines), elapsed, bufferPercent)
+	g.reset()
+}
+
+func (g *Graphite) reset() {
+	g.buf.Reset()
+	g.lines = 0
+	g.points = 0
+}
+
+func (g *Graphite) startSenderGo(r int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	log.Infof("beginning Graphite Sender thread: [%s]", g.cfg.ID)
+	t := time.NewTicker(time.Duration(g.cfg.FlushInterval) * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			g.flush()
+		case <-g.chExit:
+			// need to flush all data
+			chanlen := len(g.iChan)
+			log.Infof("Flushing %d batches of data in graphite output %s ", chanlen, g.cfg.ID)
+			for i := 0; i < chanlen; i++ {
+				g.addPoints(<-g.iChan)
+			}
+			g.lastDial = time.Time{}
+			g.flush()
+			if g.conn != nil {
+				g.conn.Close()
+				g.conn = nil
+			}
+			log.Infof("EXIT from Graphite sender process for output [%s] ", g.cfg.ID)
+			g.smutex.Lock()
+			g.started = false
+			g.smutex.Unlock()
+			return
+		case pts := <-g.iChan:
+			if pts == nil {
+				continue
+			}
+			g.addPoints(pts)
+			if g.lines >= g.cfg.BatchSize {
+				g.flush()
+			}
+		}
+	}
+}
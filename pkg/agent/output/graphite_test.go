@@ -0,0 +1,239 @@
+package output
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+//--------------------------------------------------------------------
+// Carbon plaintext server stand-in, records the received lines
+//--------------------------------------------------------------------
+
+type testCarbonServer struct {
+	net.Listener
+	mutex sync.Mutex
+	lines []string
+}
+
+func newTestCarbonServer(t *testing.T) *testCarbonServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	s := &testCarbonServer{Listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					s.mutex.Lock()
+					s.lines = append(s.lines, sc.Text())
+					s.mutex.Unlock()
+				}
+			}()
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *testCarbonServer) received() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.lines...)
+}
+
+func testGraphiteOutput(s *testCarbonServer, batchSize int) *Graphite {
+	host, port, _ := net.SplitHostPort(s.Addr().String())
+	p, _ := strconv.Atoi(port)
+	g := NewNotInitGraphite(&config.GraphiteCfg{
+		ID:            "test",
+		Host:          host,
+		Port:          p,
+		Timeout:       2,
+		BatchSize:     batchSize,
+		FlushInterval: 10,
+	})
+	g.Init()
+	return g
+}
+
+func testGraphitePoints(n int) []*Point {
+	pts := make([]*Point, n)
+	for i := range pts {
+		pts[i] = &Point{
+			Name:   "ifstats",
+			Tags:   map[string]string{"device": "router1", "ifName": "eth" + strconv.Itoa(i)},
+			Fields: map[string]interface{}{"ifHCInOctets": int64(i)},
+			Time:   time.Unix(1600000000, 0),
+			Meta:   PointMeta{DeviceTag: "device", IndexTags: []string{"ifName"}},
+		}
+	}
+	return pts
+}
+
+//--------------------------------------------------------------------
+// Tests
+//--------------------------------------------------------------------
+
+func Test_GraphitePath(t *testing.T) {
+	p := &Point{
+		Name: "if stats",
+		Tags: map[string]string{"device": "core.sw-1", "ifName": "Gi0/1", "ifAlias": "uplink to fw", "site": ""},
+		Meta: PointMeta{DeviceTag: "device", IndexTags: []string{"ifName", "ifAlias"}},
+	}
+	tests := []struct {
+		tmpl  string
+		field string
+		want  string
+	}{
+		{DefaultGraphitePathTemplate, "ifHCInOctets", "snmpcollector.core_sw-1.if_stats.Gi0_1.uplink_to_fw.ifHCInOctets"},
+		{"{tag:ifAlias}.{field}", "in.octets", "uplink_to_fw.in_octets"},
+		// empty nodes are removed
+		{"net.{tag:site}.{device}..{field}", "in", "net.core_sw-1.in"},
+		{"{tag:unknown}.{measurement}.", "in", "if_stats"},
+		// unknown placeholders are not replaced
+		{"{host}.{field}", "in", "{host}.in"},
+	}
+	for _, tt := range tests {
+		if got := GraphitePath(tt.tmpl, p, tt.field); got != tt.want {
+			t.Errorf("path %s: %q, expected %q", tt.tmpl, got, tt.want)
+		}
+	}
+
+	// points without index
+	p = &Point{Name: "system", Tags: map[string]string{"device": "sw1"}, Meta: PointMeta{DeviceTag: "device"}}
+	if got := GraphitePath(DefaultGraphitePathTemplate, p, "uptime"); got != "snmpcollector.sw1.system.uptime" {
+		t.Errorf("path without index: %q", got)
+	}
+}
+
+func Test_graphiteValue(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+		ok   bool
+	}{
+		{float64(1.5), "1.5", true},
+		{float64(1e21), "1000000000000000000000", true},
+		{float32(0.25), "0.25", true},
+		{int(-3), "-3", true},
+		{int32(7), "7", true},
+		{int64(-9000000000), "-9000000000", true},
+		{uint(3), "3", true},
+		{uint32(4294967295), "4294967295", true},
+		{uint64(18446744073709551615), "18446744073709551615", true},
+		{true, "1", true},
+		{false, "0", true},
+		{"up", "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := graphiteValue(tt.v)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("value %#v: %q %t, expected %q %t", tt.v, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func Test_GraphiteInitDefaults(t *testing.T) {
+	g := NewNotInitGraphite(&config.GraphiteCfg{ID: "test", Host: "127.0.0.1", Port: 1})
+	g.Init()
+	defer g.End()
+	if g.cfg.FlushInterval <= 0 || g.cfg.BatchSize <= 0 || g.cfg.BufferSize <= 0 || g.cfg.Timeout <= 0 {
+		t.Errorf("invalid defaults: flush interval %d, batch size %d, buffer size %d, timeout %d", g.cfg.FlushInterval, g.cfg.BatchSize, g.cfg.BufferSize, g.cfg.Timeout)
+	}
+	if g.cfg.PathTemplate != DefaultGraphitePathTemplate {
+		t.Errorf("path template %q", g.cfg.PathTemplate)
+	}
+	// the sender ticker should not panic without flush interval
+	var wg sync.WaitGroup
+	g.StartSender(&wg)
+	g.StopSender()
+	wg.Wait()
+}
+
+func Test_GraphiteReconnect(t *testing.T) {
+	s := newTestCarbonServer(t)
+	g := testGraphiteOutput(s, 10)
+	defer g.End()
+
+	g.addPoints(testGraphitePoints(2))
+	g.flush()
+	if st := g.GetResetStats(); st.PSent != 2 || st.FieldSent != 2 || st.WriteErrors != 0 {
+		t.Fatalf("first flush: %d points, %d fields sent, %d errors", st.PSent, st.FieldSent, st.WriteErrors)
+	}
+
+	// connection lost, pending lines are kept
+	g.conn.Close()
+	g.addPoints(testGraphitePoints(3))
+	g.flush()
+	if st := g.GetResetStats(); st.WriteErrors != 1 || g.conn != nil || g.lines != 3 {
+		t.Fatalf("write on closed connection: %d errors, %d lines pending", st.WriteErrors, g.lines)
+	}
+
+	// reconnect delayed TimeWriteRetry seconds since the last dial
+	g.flush()
+	if st := g.GetResetStats(); st.WriteErrors != 1 || g.lines != 3 {
+		t.Errorf("flush while waiting to reconnect: %d errors, %d lines pending", st.WriteErrors, g.lines)
+	}
+	g.lastDial = time.Time{}
+	g.addPoints(testGraphitePoints(1))
+	g.flush()
+	if st := g.GetResetStats(); st.PSent != 4 || st.WriteErrors != 0 || g.lines != 0 {
+		t.Errorf("flush after reconnect: %d points sent, %d errors, %d lines pending", st.PSent, st.WriteErrors, g.lines)
+	}
+	g.conn.Close()
+
+	want := []string{
+		"snmpcollector.router1.ifstats.eth0.ifHCInOctets 0 1600000000",
+		"snmpcollector.router1.ifstats.eth1.ifHCInOctets 1 1600000000",
+		"snmpcollector.router1.ifstats.eth0.ifHCInOctets 0 1600000000",
+		"snmpcollector.router1.ifstats.eth1.ifHCInOctets 1 1600000000",
+		"snmpcollector.router1.ifstats.eth2.ifHCInOctets 2 1600000000",
+		"snmpcollector.router1.ifstats.eth0.ifHCInOctets 0 1600000000",
+	}
+	testMqttWaitFor(t, "lines", func() bool { return len(s.received()) >= len(want) })
+	// lines from both connections could be interleaved by the server
+	if got := s.received(); !cmp.Equal(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })) {
+		t.Errorf("received lines %v, expected %v", got, want)
+	}
+}
+
+func Test_GraphitePendingLimit(t *testing.T) {
+	s := newTestCarbonServer(t)
+	g := testGraphiteOutput(s, 2)
+	defer g.End()
+	s.Close()
+
+	// lines are kept until graphiteMaxPendingBatches batches are pending
+	for i := 0; i < graphiteMaxPendingBatches; i++ {
+		g.lastDial = time.Time{}
+		g.addPoints(testGraphitePoints(2))
+		g.flush()
+		if g.lines != 2*(i+1) {
+			t.Fatalf("flush %d: %d lines pending, expected %d", i, g.lines, 2*(i+1))
+		}
+	}
+	g.addPoints(testGraphitePoints(1))
+	g.flush()
+	if g.lines != 0 || g.points != 0 || g.buf.Len() != 0 {
+		t.Errorf("%d lines pending, expected dropped", g.lines)
+	}
+	if st := g.GetResetStats(); st.WriteErrors != graphiteMaxPendingBatches+1 {
+		t.Errorf("%d write errors, expected %d", st.WriteErrors, graphiteMaxPendingBatches+1)
+	}
+}
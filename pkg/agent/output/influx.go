@@ -1,13 +1,20 @@
 package output
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/influxdata/influxdb1-client/models"
 	client "github.com/influxdata/influxdb1-client/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
@@ -26,7 +33,7 @@ func SetLogger(l *logrus.Logger) {
 /*InfluxDB database export */
 type InfluxDB struct {
 	cfg         *config.InfluxCfg
-	stats       InfluxStats
+	stats       Stats
 	initialized bool
 	imutex      sync.Mutex
 	started     bool
@@ -36,6 +43,21 @@ type InfluxDB struct {
 	iChan  chan *client.BatchPoints
 	chExit chan bool
 	client client.Client
+	spool  *Spool
+	// failover to the standby backend (only if configured)
+	standby *config.InfluxCfg
+	sclient client.Client
+	breaker *CircuitBreaker
+	// merged batches waiting to be written by destination (only accessed from the sender goroutine)
+	pending map[string]*pendingBatch
+}
+
+// pendingBatch gathered batches merged until the max batch size or flush interval is reached
+type pendingBatch struct {
+	bp      *client.BatchPoints
+	points  int
+	batches int64
+	first   time.Time
 }
 
 // DummyDB a BD struct needed if no database configured
@@ -49,18 +71,64 @@ var DummyDB = &InfluxDB{
 	client:      nil,
 }
 
+func init() {
+	Register("influxdb", func(dbc *config.DBConfig) map[string]Output {
+		outs := make(map[string]Output)
+		for k, c := range dbc.Influxdb {
+			db := NewNotInitInfluxDB(c)
+			if len(c.StandbyID) > 0 {
+				if s, ok := dbc.Influxdb[c.StandbyID]; ok && s.ID != c.ID {
+					db.standby = s
+				} else {
+					log.Warnf("Standby influx server %s for %s not found, fail over disabled", c.StandbyID, c.ID)
+				}
+			}
+			outs[k] = db
+		}
+		return outs
+	})
+}
+
+// ID return the influx output ID
+func (db *InfluxDB) ID() string {
+	if db.dummy == true {
+		return "dummy"
+	}
+	return db.cfg.ID
+}
+
 // GetResetStats return outdb stats and reset its counters
-func (db *InfluxDB) GetResetStats() *InfluxStats {
+func (db *InfluxDB) GetResetStats() *Stats {
 	if db.dummy == true {
 		log.Debug("Reseting Influxstats for DUMMY DB ")
-		return &InfluxStats{}
+		return &Stats{}
 	}
 	log.Debugf("Reseting Influxstats for DB %s", db.cfg.ID)
-	return db.stats.GetResetStats()
+	st := db.stats.GetResetStats()
+	if db.spool != nil {
+		st.Spool = db.spool.GetResetStats()
+	}
+	if db.breaker != nil {
+		st.Breaker = db.breaker.GetResetStats()
+	}
+	return st
+}
+
+// FailoverStatus returns the failover circuit breaker state, nil if no standby has been configured
+func (db *InfluxDB) FailoverStatus() *BreakerStatus {
+	if db.dummy == true || db.breaker == nil {
+		return nil
+	}
+	return db.breaker.GetStatus()
 }
 
 //BP create a Batch point influx object
 func (db *InfluxDB) BP() (*client.BatchPoints, error) {
+	return db.routeBP(Route{})
+}
+
+// routeBP create a batch point for the route destination, empty route values are set from config
+func (db *InfluxDB) routeBP(r Route) (*client.BatchPoints, error) {
 	if db.dummy == true {
 		bp, _ := client.NewBatchPoints(client.BatchPointsConfig{
 			Database:        "dbdummy",
@@ -73,9 +141,20 @@ func (db *InfluxDB) BP() (*client.BatchPoints, error) {
 	if len(db.cfg.Retention) == 0 {
 		db.cfg.Retention = "autogen"
 	}
+	database := db.cfg.DB
+	if db.cfg.IsAPIv2() {
+		database = db.cfg.Bucket
+	}
+	if len(r.Database) > 0 {
+		database = r.Database
+	}
+	retention := db.cfg.Retention
+	if len(r.Retention) > 0 {
+		retention = r.Retention
+	}
 	bp, err := client.NewBatchPoints(client.BatchPointsConfig{
-		Database:        db.cfg.DB,
-		RetentionPolicy: db.cfg.Retention,
+		Database:        database,
+		RetentionPolicy: retention,
 		Precision:       db.cfg.Precision, //Default precision for Time lib
 	})
 	if err != nil {
@@ -87,35 +166,51 @@ func (db *InfluxDB) BP() (*client.BatchPoints, error) {
 
 // Ping InfluxDB Server
 func Ping(cfg *config.InfluxCfg) (client.Client, time.Duration, string, error) {
-
-	var conf client.HTTPConfig
-	if cfg.EnableSSL {
-		tls, err := utils.GetTLSConfig(cfg.SSLCert, cfg.SSLKey, cfg.SSLCA, cfg.InsecureSkipVerify)
+	var tlsCfg *tls.Config
+	var err error
+	proto := "http"
+	if cfg.EnableSSL && !cfg.IsUDP() {
+		tlsCfg, err = utils.GetTLSConfig(cfg.SSLCert, cfg.SSLKey, cfg.SSLCA, cfg.InsecureSkipVerify)
 		if err != nil {
 			log.Errorf("Error on Create TLS config: %s", err)
 			return nil, 0, "", err
 		}
-		conf = client.HTTPConfig{
-			Addr:      fmt.Sprintf("https://%s:%d", cfg.Host, cfg.Port),
-			Username:  cfg.User,
-			Password:  cfg.Password,
+		proto = "https"
+	}
+	var cli client.Client
+	if cfg.IsUDP() {
+		cli, err = newInfluxUDPClient(influxUDPConfig{
+			Addr:        net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+			PayloadSize: cfg.UDPPayloadSize,
+			RateLimit:   cfg.UDPRateLimit,
+		})
+	} else if cfg.IsAPIv2() {
+		cli, err = newInfluxV2Client(influxV2HTTPConfig{
+			Addr:      fmt.Sprintf("%s://%s:%d", proto, cfg.Host, cfg.Port),
+			Org:       cfg.Org,
+			Bucket:    cfg.Bucket,
+			Token:     cfg.Token,
 			UserAgent: cfg.UserAgent,
 			Timeout:   time.Duration(cfg.Timeout) * time.Second,
-			TLSConfig: tls,
-			Proxy: http.ProxyFromEnvironment,
-		}
+			TLSConfig: tlsCfg,
+			Gzip:      cfg.Gzip,
+		})
 	} else {
-
-		conf = client.HTTPConfig{
-			Addr:      fmt.Sprintf("http://%s:%d", cfg.Host, cfg.Port),
-			Username:  cfg.User,
-			Password:  cfg.Password,
-			UserAgent: cfg.UserAgent,
-			Timeout:   time.Duration(cfg.Timeout) * time.Second,
-			Proxy: http.ProxyFromEnvironment,
+		var encoding client.ContentEncoding
+		if cfg.Gzip {
+			encoding = client.GzipEncoding
 		}
+		cli, err = client.NewHTTPClient(client.HTTPConfig{
+			Addr:          fmt.Sprintf("%s://%s:%d", proto, cfg.Host, cfg.Port),
+			Username:      cfg.User,
+			Password:      cfg.Password,
+			UserAgent:     cfg.UserAgent,
+			Timeout:       time.Duration(cfg.Timeout) * time.Second,
+			TLSConfig:     tlsCfg,
+			Proxy:         http.ProxyFromEnvironment,
+			WriteEncoding: encoding,
+		})
 	}
-	cli, err := client.NewHTTPClient(conf)
 
 	if err != nil {
 		return cli, 0, "", err
@@ -132,6 +227,9 @@ func (db *InfluxDB) Connect() error {
 	}
 	var err error
 	db.client, _, _, err = Ping(db.cfg)
+	if err == nil {
+		autoProvision(db.client, db.cfg)
+	}
 	return err
 }
 
@@ -217,6 +315,27 @@ func (db *InfluxDB) Init() {
 	log.Infof("Connecting to: %s", db.cfg.Host)
 	db.iChan = make(chan *client.BatchPoints, db.cfg.BufferSize)
 	db.chExit = make(chan bool)
+	if db.cfg.SpoolEnabled {
+		var err error
+		dir := filepath.Join(dataDir, "spool", db.cfg.ID)
+		db.spool, err = NewSpool(dir, int64(db.cfg.SpoolMaxSize)*1024*1024, time.Duration(db.cfg.SpoolMaxAge)*time.Hour)
+		if err != nil {
+			log.Errorf("Error on create spool for output %s on %s (spool disabled): %s", db.cfg.ID, dir, err)
+			db.spool = nil
+		} else {
+			log.Infof("Spool for output %s enabled on %s", db.cfg.ID, dir)
+		}
+	}
+	if db.standby != nil {
+		db.breaker = NewCircuitBreaker(db.standby.ID, db.cfg.FailoverThreshold, time.Duration(db.cfg.FailbackInterval)*time.Second)
+		var err error
+		if db.sclient, _, _, err = Ping(db.standby); err != nil {
+			log.Warnf("Standby influx server %s for output %s not available: %s", db.standby.ID, db.cfg.ID, err)
+		} else {
+			autoProvision(db.sclient, db.standby)
+		}
+		log.Infof("Fail over to standby influx server %s enabled for output %s after %d write errors", db.standby.ID, db.cfg.ID, db.cfg.FailoverThreshold)
+	}
 	if err := db.Connect(); err != nil {
 		log.Errorln("failed connecting to: ", db.cfg.Host)
 		log.Errorln("error: ", err)
@@ -235,7 +354,13 @@ func (db *InfluxDB) End() {
 	if db.CheckAndUnSetInitialized() == true {
 		close(db.iChan)
 		close(db.chExit)
-		db.client.Close()
+		if db.client != nil {
+			db.client.Close()
+		}
+		if db.sclient != nil {
+			db.sclient.Close()
+			db.sclient = nil
+		}
 	}
 }
 
@@ -253,14 +378,76 @@ func (db *InfluxDB) StopSender() {
 	log.Infof("Can not stop Sender [%s] becaouse of it is already stopped", db.cfg.ID)
 }
 
+// ToInfluxPoint converts a neutral point in a influx client point
+func ToInfluxPoint(p *Point) (*client.Point, error) {
+	return client.NewPoint(p.Name, p.Tags, p.Fields, p.Time)
+}
+
+// pointsToBP converts neutral points in a new influx batch for the route destination
+func (db *InfluxDB) pointsToBP(pts []*Point, r Route) (*client.BatchPoints, error) {
+	bps, err := db.routeBP(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pts {
+		pt, err := ToInfluxPoint(p)
+		if err != nil {
+			log.Warnf("Error on convert point %s to influx format on DB %s: %s", p.Name, db.cfg.ID, err)
+			continue
+		}
+		(*bps).AddPoint(pt)
+	}
+	return bps, nil
+}
+
 //Send send data
-func (db *InfluxDB) Send(bps *client.BatchPoints) {
+func (db *InfluxDB) Send(pts []*Point) {
+	db.SendRoute(pts, Route{})
+}
+
+// SendRoute send data to other database/retention than the configured ones
+func (db *InfluxDB) SendRoute(pts []*Point, r Route) {
 	if db.dummy == true {
 		return
 	}
+	bps, err := db.pointsToBP(pts, r)
+	if err != nil {
+		log.Warnf("Can not send data to the output DB %s because of batchpoint creation error: %s", db.cfg.ID, err)
+		return
+	}
 	db.iChan <- bps
 }
 
+// TrySend enqueues data without blocking, if the sender queue is full data will be
+// stored in the spool (if enabled). Returns false if data has been discarded
+func (db *InfluxDB) TrySend(pts []*Point) bool {
+	return db.TrySendRoute(pts, Route{})
+}
+
+// TrySendRoute enqueues data for the route destination without blocking
+func (db *InfluxDB) TrySendRoute(pts []*Point, r Route) bool {
+	if db.dummy == true {
+		return true
+	}
+	bps, err := db.pointsToBP(pts, r)
+	if err != nil {
+		log.Warnf("Can not send data to the output DB %s because of batchpoint creation error: %s", db.cfg.ID, err)
+		db.stats.DropUpdate(int64(len(pts)))
+		return false
+	}
+	select {
+	case db.iChan <- bps:
+		return true
+	default:
+	}
+	if db.spool != nil {
+		db.spoolBatchPoint(bps)
+		return true
+	}
+	db.stats.DropUpdate(int64(len(pts)))
+	return false
+}
+
 //Hostname get hostname
 func (db *InfluxDB) Hostname() string {
 	return strings.Split(db.cfg.Host, ":")[0]
@@ -279,8 +466,12 @@ func (db *InfluxDB) StartSender(wg *sync.WaitGroup) {
 	go db.startSenderGo(rand.Int(), wg)
 }
 
-func (db *InfluxDB) sendBatchPoint(data *client.BatchPoints, enqueueonerror bool) {
+// write sends the batchpoint to the backend (primary or standby) and updates the output stats
+func (db *InfluxDB) write(cli client.Client, target string, data *client.BatchPoints) error {
 	var bufferPercent float32
+	if cli == nil {
+		return fmt.Errorf("influx server %s not connected", target)
+	}
 	//number points
 	np := len((*data).Points())
 	//number of total fields
@@ -293,36 +484,229 @@ func (db *InfluxDB) sendBatchPoint(data *client.BatchPoints, enqueueonerror bool
 			nf += len(fields)
 		}
 	}
-	// keep trying until we get it (don't drop the data)
 	startSend := time.Now()
-	err := db.client.Write(*data)
+	err := cli.Write(*data)
 	elapsedSend := time.Since(startSend)
 
 	bufferPercent = (float32(len(db.iChan)) * 100.0) / float32(db.cfg.BufferSize)
 	if err != nil {
 		db.stats.WriteErrUpdate(elapsedSend, bufferPercent)
-		log.Errorf("ERROR on Write batchPoint in DB %s (%d points) | elapsed : %s | Error: %s ", db.cfg.ID, np, elapsedSend.String(), err)
-		// If the queue is not full we will resend after a while
-		if enqueueonerror {
-			log.Debug("queing data again...")
-			if len(db.iChan) < db.cfg.BufferSize {
-				db.iChan <- data
-				time.Sleep(TimeWriteRetry * time.Second)
+		log.Errorf("ERROR on Write batchPoint in DB %s (%d points) | elapsed : %s | Error: %s ", target, np, elapsedSend.String(), err)
+		return err
+	}
+	log.Debugf("OK on Write batchPoint in DB %s (%d points) | elapsed : %s ", target, np, elapsedSend.String())
+	db.stats.WriteOkUpdate(int64(np), int64(nf), elapsedSend, bufferPercent)
+	return nil
+}
+
+// failbackProbe checks the primary backend health while failed over, returns true if
+// data should be sent again to the primary backend
+func (db *InfluxDB) failbackProbe() bool {
+	if !db.breaker.AllowProbe() {
+		return false
+	}
+	var err error
+	if db.client == nil {
+		err = db.Connect()
+	} else {
+		_, _, err = db.client.Ping(time.Duration(db.cfg.Timeout) * time.Second)
+	}
+	db.breaker.ProbeResult(err == nil)
+	if err != nil {
+		log.Debugf("Output DB %s still unreachable, keep sending data to standby %s: %s", db.cfg.ID, db.standby.ID, err)
+		return false
+	}
+	log.Infof("Output DB %s is healthy again, switching back from standby %s", db.cfg.ID, db.standby.ID)
+	return true
+}
+
+// sendStandbyBatchPoint writes the batchpoint in the standby backend
+func (db *InfluxDB) sendStandbyBatchPoint(data *client.BatchPoints, enqueueonerror bool) {
+	if db.sclient == nil {
+		db.sclient, _, _, _ = Ping(db.standby)
+	}
+	if err := db.write(db.sclient, db.standby.ID, data); err == nil {
+		db.breaker.StandbyWrite()
+		return
+	}
+	db.retryBatchPoint(data, enqueueonerror)
+}
+
+func (db *InfluxDB) sendBatchPoint(data *client.BatchPoints, enqueueonerror bool) {
+	if db.breaker != nil && db.breaker.IsOpen() && !db.failbackProbe() {
+		db.sendStandbyBatchPoint(data, enqueueonerror)
+		return
+	}
+	// pending spooled data should be written first to keep the data order
+	if db.spool != nil && db.spool.Len() > 0 {
+		db.spoolBatchPoint(data)
+		return
+	}
+	err := db.write(db.client, db.cfg.ID, data)
+	if err == nil {
+		if db.breaker != nil {
+			db.breaker.Success()
+		}
+		return
+	}
+	if db.breaker != nil && db.breaker.Failure() {
+		log.Warnf("Output DB %s has reached %d consecutive write errors, failing over to standby %s", db.cfg.ID, db.cfg.FailoverThreshold, db.standby.ID)
+		db.sendStandbyBatchPoint(data, enqueueonerror)
+		return
+	}
+	db.retryBatchPoint(data, enqueueonerror)
+}
+
+// retryBatchPoint stores the batchpoint in the spool (if enabled) or enqueues it again to be resent later
+func (db *InfluxDB) retryBatchPoint(data *client.BatchPoints, enqueueonerror bool) {
+	if db.spool != nil {
+		db.spoolBatchPoint(data)
+		return
+	}
+	// If the queue is not full we will resend after a while
+	if enqueueonerror {
+		log.Debug("queing data again...")
+		if len(db.iChan) < db.cfg.BufferSize {
+			db.iChan <- data
+			time.Sleep(TimeWriteRetry * time.Second)
+		}
+	}
+}
+
+// spoolRouteHeader is the first line of each spool segment, a line protocol comment
+// with the batch database and retention policy
+const spoolRouteHeader = "# route "
+
+// spoolBatchPoint stores the batchpoint in the disk spool as line protocol
+func (db *InfluxDB) spoolBatchPoint(data *client.BatchPoints) {
+	var b bytes.Buffer
+	rt := url.Values{}
+	rt.Set("database", (*data).Database())
+	rt.Set("retention", (*data).RetentionPolicy())
+	b.WriteString(spoolRouteHeader + rt.Encode() + "\n")
+	for _, p := range (*data).Points() {
+		b.WriteString(p.String())
+		b.WriteByte('\n')
+	}
+	if err := db.spool.Push(b.Bytes()); err != nil {
+		log.Errorf("ERROR on spool batchPoint for DB %s (%d points): %s", db.cfg.ID, len((*data).Points()), err)
+	}
+}
+
+// replaySpool writes all spooled data in order once the backend is reachable again
+func (db *InfluxDB) replaySpool() {
+	if db.spool.Len() == 0 || db.client == nil {
+		return
+	}
+	if _, _, err := db.client.Ping(time.Duration(db.cfg.Timeout) * time.Second); err != nil {
+		log.Debugf("Output DB %s still unreachable, spool replay delayed (%d pending batches): %s", db.cfg.ID, db.spool.Len(), err)
+		// new data is spooled without any write while there is pending data, so the failed
+		// health checks are the only way to fail over
+		if db.breaker != nil && db.breaker.Failure() {
+			log.Warnf("Output DB %s has reached %d consecutive errors, failing over to standby %s", db.cfg.ID, db.cfg.FailoverThreshold, db.standby.ID)
+		}
+		return
+	}
+	log.Infof("Replaying %d spooled batches to Output DB %s", db.spool.Len(), db.cfg.ID)
+	for db.spool.Len() > 0 {
+		data, err := db.spool.Peek()
+		if err != nil || data == nil {
+			continue
+		}
+		r := Route{}
+		if bytes.HasPrefix(data, []byte(spoolRouteHeader)) {
+			header := data
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				header, data = data[:i], data[i+1:]
+			} else {
+				data = nil
 			}
+			if rt, err := url.ParseQuery(string(header[len(spoolRouteHeader):])); err == nil {
+				r.Database = rt.Get("database")
+				r.Retention = rt.Get("retention")
+			}
+		}
+		pts, err := models.ParsePointsWithPrecision(data, time.Now(), "n")
+		if err != nil {
+			log.Errorf("ERROR on parse spooled data for DB %s, dropping it: %s", db.cfg.ID, err)
+			db.spool.Discard()
+			continue
+		}
+		bp, err := db.routeBP(r)
+		if err != nil {
+			return
 		}
+		for _, p := range pts {
+			(*bp).AddPoint(client.NewPointFrom(p))
+		}
+		if err := db.write(db.client, db.cfg.ID, bp); err != nil {
+			return
+		}
+		db.spool.Pop()
+	}
+}
+
+// mergeBatchPoint adds the batchpoint to the pending one for the same destination, the
+// pending batch is written once BatchSize points have been reached
+func (db *InfluxDB) mergeBatchPoint(data *client.BatchPoints, enqueueonerror bool) {
+	key := (*data).Database() + "|" + (*data).RetentionPolicy()
+	pb, ok := db.pending[key]
+	if !ok {
+		pb = &pendingBatch{bp: data, first: time.Now()}
+		db.pending[key] = pb
 	} else {
-		log.Debugf("OK on Write batchPoint in DB %s (%d points) | elapsed : %s ", db.cfg.ID, np, elapsedSend.String())
-		db.stats.WriteOkUpdate(int64(np), int64(nf), elapsedSend, bufferPercent)
+		(*pb.bp).AddPoints((*data).Points())
+	}
+	pb.points += len((*data).Points())
+	pb.batches++
+	if pb.points >= db.cfg.BatchSize {
+		delete(db.pending, key)
+		db.flushBatch(pb, enqueueonerror)
 	}
 }
 
+// flushPending writes all pending merged batches
+func (db *InfluxDB) flushPending(enqueueonerror bool) {
+	for key, pb := range db.pending {
+		delete(db.pending, key)
+		db.flushBatch(pb, enqueueonerror)
+	}
+}
+
+func (db *InfluxDB) flushBatch(pb *pendingBatch, enqueueonerror bool) {
+	latency := time.Since(pb.first)
+	log.Debugf("Flushing %d merged batches (%d points) to Output DB %s | latency : %s", pb.batches, pb.points, db.cfg.ID, latency)
+	db.stats.FlushUpdate(pb.batches, latency)
+	db.sendBatchPoint(pb.bp, enqueueonerror)
+}
+
 func (db *InfluxDB) startSenderGo(r int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	time.Sleep(5)
 	log.Infof("beginning Influx Sender thread: [%s]", db.cfg.ID)
+	var replay <-chan time.Time
+	if db.spool != nil {
+		t := time.NewTicker(TimeWriteRetry * time.Second)
+		defer t.Stop()
+		replay = t.C
+	}
+	var flush <-chan time.Time
+	if db.cfg.BatchSize > 0 {
+		if db.cfg.FlushInterval <= 0 {
+			db.cfg.FlushInterval = 5
+		}
+		db.pending = make(map[string]*pendingBatch)
+		t := time.NewTicker(time.Duration(db.cfg.FlushInterval) * time.Second)
+		defer t.Stop()
+		flush = t.C
+	}
 	for {
 		select {
+		case <-replay:
+			db.replaySpool()
+		case <-flush:
+			db.flushPending(true)
 		case <-db.chExit:
 			//need to flush all data
 
@@ -332,8 +716,13 @@ func (db *InfluxDB) startSenderGo(r int, wg *sync.WaitGroup) {
 				//flush them
 				data := <-db.iChan
 				//this process only will work if backend is  running ok elsewhere points will be lost
+				if db.pending != nil {
+					db.mergeBatchPoint(data, false)
+					continue
+				}
 				db.sendBatchPoint(data, false)
 			}
+			db.flushPending(false)
 
 			log.Infof("EXIT from Influx sender process for device [%s] ", db.cfg.ID)
 			db.SetStartedAs(false)
@@ -343,8 +732,15 @@ func (db *InfluxDB) startSenderGo(r int, wg *sync.WaitGroup) {
 				log.Warn("null influx input")
 				continue
 			}
-			if db.client == nil {
+			if db.client == nil && db.breaker == nil {
 				log.Warn("db Client not initialized yet!!!!!")
+				if db.spool != nil {
+					db.spoolBatchPoint(data)
+				}
+				continue
+			}
+			if db.pending != nil {
+				db.mergeBatchPoint(data, true)
 				continue
 			}
 
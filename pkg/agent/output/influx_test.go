@@ -0,0 +1,400 @@
+package output
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+//--------------------------------------------------------------------
+// InfluxDB v1 HTTP API stand-in, records the /write requests
+//--------------------------------------------------------------------
+
+// testInfluxWrite a write request received by the server stand-in
+type testInfluxWrite struct {
+	db    string
+	rp    string
+	lines []string
+}
+
+type testInfluxServer struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	down   bool
+	writes []testInfluxWrite
+	pings  int
+}
+
+func newTestInfluxServer(t *testing.T) *testInfluxServer {
+	s := &testInfluxServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if r.URL.Path == "/ping" {
+			s.pings++
+		}
+		if s.down {
+			http.Error(w, `{"error":"server unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/ping":
+			w.Header().Set("X-Influxdb-Version", "1.8.10")
+			w.WriteHeader(http.StatusNoContent)
+		case "/write":
+			var body io.Reader = r.Body
+			if r.Header.Get("Content-Encoding") == "gzip" {
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					t.Errorf("gzip write request: %s", err)
+					return
+				}
+				body = zr
+			}
+			data, err := ioutil.ReadAll(body)
+			if err != nil {
+				t.Errorf("read write request: %s", err)
+			}
+			s.writes = append(s.writes, testInfluxWrite{
+				db:    r.URL.Query().Get("db"),
+				rp:    r.URL.Query().Get("rp"),
+				lines: strings.Split(strings.TrimSpace(string(data)), "\n"),
+			})
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testInfluxServer) setDown(down bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.down = down
+}
+
+func (s *testInfluxServer) received() []testInfluxWrite {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]testInfluxWrite(nil), s.writes...)
+}
+
+// lines returns the number of points written
+func (s *testInfluxServer) lines() int {
+	var n int
+	for _, w := range s.received() {
+		n += len(w.lines)
+	}
+	return n
+}
+
+func testInfluxCfg(s *testInfluxServer, id string) *config.InfluxCfg {
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return &config.InfluxCfg{
+		ID:                id,
+		Host:              host,
+		Port:              p,
+		DB:                "snmp",
+		Retention:         "autogen",
+		Precision:         "s",
+		Timeout:           5,
+		BufferSize:        100,
+		FlushInterval:     5,
+		FailoverThreshold: 3,
+		FailbackInterval:  30,
+	}
+}
+
+// testInfluxPoints returns n points of the measurement, with values from 0 to n-1
+func testInfluxPoints(name string, n int) []*Point {
+	pts := make([]*Point, n)
+	for i := range pts {
+		pts[i] = &Point{
+			Name:   name,
+			Tags:   map[string]string{"device": "router1"},
+			Fields: map[string]interface{}{"value": int64(i)},
+			Time:   time.Unix(1600000000+int64(i), 0),
+		}
+	}
+	return pts
+}
+
+func testInfluxBP(t *testing.T, db *InfluxDB, r Route, name string, n int) *client.BatchPoints {
+	t.Helper()
+	bp, err := db.pointsToBP(testInfluxPoints(name, n), r)
+	if err != nil {
+		t.Fatalf("batch point: %s", err)
+	}
+	return bp
+}
+
+func testInfluxWaitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+//--------------------------------------------------------------------
+// Write batching
+//--------------------------------------------------------------------
+
+func Test_InfluxMergeBatchPoint(t *testing.T) {
+	s := newTestInfluxServer(t)
+	cfg := testInfluxCfg(s, "test")
+	cfg.BatchSize = 5
+	db := NewNotInitInfluxDB(cfg)
+	db.Init()
+	defer db.End()
+	db.pending = make(map[string]*pendingBatch)
+
+	// grouped by database and retention policy
+	db.mergeBatchPoint(testInfluxBP(t, db, Route{}, "a", 2), false)
+	db.mergeBatchPoint(testInfluxBP(t, db, Route{Retention: "long"}, "b", 2), false)
+	db.mergeBatchPoint(testInfluxBP(t, db, Route{Database: "other"}, "c", 2), false)
+	if n := len(s.received()); n != 0 {
+		t.Fatalf("%d writes before reach the batch size", n)
+	}
+	if len(db.pending) != 3 {
+		t.Fatalf("%d pending batches, expected 3", len(db.pending))
+	}
+
+	// flushed by size
+	db.mergeBatchPoint(testInfluxBP(t, db, Route{}, "d", 3), false)
+	writes := s.received()
+	if len(writes) != 1 {
+		t.Fatalf("%d writes after reach the batch size, expected 1", len(writes))
+	}
+	if w := writes[0]; w.db != "snmp" || w.rp != "autogen" || len(w.lines) != 5 {
+		t.Errorf("size flush wrote %d points to %s/%s, expected 5 points to snmp/autogen", len(w.lines), w.db, w.rp)
+	}
+	if _, ok := db.pending["snmp|autogen"]; ok {
+		t.Errorf("flushed batch still pending")
+	}
+
+	// a new batch is started for the same destination
+	db.mergeBatchPoint(testInfluxBP(t, db, Route{}, "e", 1), false)
+	db.flushPending(false)
+	if len(db.pending) != 0 {
+		t.Errorf("%d batches pending after flush", len(db.pending))
+	}
+	got := make(map[string]int)
+	for _, w := range s.received()[1:] {
+		got[w.db+"|"+w.rp] += len(w.lines)
+	}
+	want := map[string]int{"snmp|long": 2, "other|autogen": 2, "snmp|autogen": 1}
+	if len(got) != len(want) {
+		t.Errorf("flushed %v, expected %v", got, want)
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("flushed %d points to %s, expected %d", got[k], k, n)
+		}
+	}
+	st := db.GetResetStats()
+	if st.Flushes != 4 || st.FlushBatches != 5 || st.PSent != 10 || st.WriteSent != 4 {
+		t.Errorf("stats: %d flushes, %d merged batches, %d points, %d writes, expected 4, 5, 10, 4", st.Flushes, st.FlushBatches, st.PSent, st.WriteSent)
+	}
+}
+
+func Test_InfluxBatchFlush(t *testing.T) {
+	tests := []struct {
+		name          string
+		batchSize     int
+		flushInterval int
+		gzip          bool
+		stop          bool // wait the stop flush instead of the flush interval
+		writes        int
+	}{
+		{name: "no batching", batchSize: 0, flushInterval: 1, stop: true, writes: 3},
+		{name: "flush interval", batchSize: 1000, flushInterval: 1, writes: 1},
+		{name: "flush interval gzip", batchSize: 1000, flushInterval: 1, gzip: true, writes: 1},
+		{name: "stop", batchSize: 1000, flushInterval: 60, stop: true, writes: 1},
+	}
+	for _, tt := range tests {
+		s := newTestInfluxServer(t)
+		cfg := testInfluxCfg(s, "test")
+		cfg.BatchSize = tt.batchSize
+		cfg.FlushInterval = tt.flushInterval
+		cfg.Gzip = tt.gzip
+		db := NewNotInitInfluxDB(cfg)
+		db.Init()
+
+		var wg sync.WaitGroup
+		db.StartSender(&wg)
+		for i := 0; i < 3; i++ {
+			db.Send(testInfluxPoints("m", 2))
+		}
+		if !tt.stop {
+			testInfluxWaitFor(t, "flush", func() bool { return s.lines() == 6 })
+		}
+		db.StopSender()
+		wg.Wait()
+		db.End()
+
+		writes := s.received()
+		if len(writes) != tt.writes {
+			t.Errorf("%s: %d writes, expected %d", tt.name, len(writes), tt.writes)
+		}
+		if n := s.lines(); n != 6 {
+			t.Errorf("%s: %d points written, expected 6", tt.name, n)
+		}
+		st := db.GetResetStats()
+		wantFlushes := int64(0)
+		if tt.batchSize > 0 {
+			wantFlushes = 1
+		}
+		if st.Flushes != wantFlushes {
+			t.Errorf("%s: %d flushes, expected %d", tt.name, st.Flushes, wantFlushes)
+		}
+	}
+}
+
+//--------------------------------------------------------------------
+// Spool
+//--------------------------------------------------------------------
+
+func Test_InfluxSpoolReplay(t *testing.T) {
+	SetDataDir(t.TempDir())
+	s := newTestInfluxServer(t)
+	cfg := testInfluxCfg(s, "test")
+	cfg.SpoolEnabled = true
+
+	// batches are spooled while the server is down, with its route header
+	s.setDown(true)
+	db := NewNotInitInfluxDB(cfg)
+	db.Init()
+	db.sendBatchPoint(testInfluxBP(t, db, Route{}, "a", 2), false)
+	s.setDown(false)
+	// pending spooled data is written first, new data is queued after it
+	db.sendBatchPoint(testInfluxBP(t, db, Route{Database: "other", Retention: "long"}, "b", 1), false)
+	if n := len(s.received()); n != 0 {
+		t.Fatalf("%d writes with pending spooled data", n)
+	}
+	data, _ := db.spool.Peek()
+	if header := strings.SplitN(string(data), "\n", 2)[0]; header != "# route database=snmp&retention=autogen" {
+		t.Errorf("segment header %q", header)
+	}
+	db.End()
+
+	// restart, a corrupt segment and a segment without route header (older versions)
+	db = NewNotInitInfluxDB(cfg)
+	db.Init()
+	defer db.End()
+	if db.spool.Len() != 2 {
+		t.Fatalf("%d segments reloaded, expected 2", db.spool.Len())
+	}
+	db.spool.Push([]byte("# route database=snmp&retention=autogen\ngarbage\n"))
+	db.spool.Push([]byte("c value=1i 1600000000000000000\n"))
+
+	s.setDown(true)
+	db.replaySpool()
+	if db.spool.Len() != 4 {
+		t.Fatalf("%d segments after replay with server down, expected 4", db.spool.Len())
+	}
+
+	s.setDown(false)
+	db.replaySpool()
+	if db.spool.Len() != 0 {
+		t.Fatalf("%d segments left after replay", db.spool.Len())
+	}
+	writes := s.received()
+	var got []string
+	for _, w := range writes {
+		got = append(got, w.db+"|"+w.rp+"|"+strconv.Itoa(len(w.lines))+"|"+strings.SplitN(w.lines[0], ",", 2)[0])
+	}
+	want := []string{"snmp|autogen|2|a", "other|long|1|b", "snmp|autogen|1|c value=1i 1600000000"}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("replayed %v, expected %v", got, want)
+	}
+	if st := db.GetResetStats(); st.Spool.Replayed != 3 || st.Spool.Dropped != 1 || st.Spool.Written != 2 {
+		t.Errorf("spool stats %+v, expected 3 replayed, 1 dropped and 2 written", st.Spool)
+	}
+}
+
+//--------------------------------------------------------------------
+// Fail over
+//--------------------------------------------------------------------
+
+func Test_InfluxFailover(t *testing.T) {
+	primary := newTestInfluxServer(t)
+	standby := newTestInfluxServer(t)
+	cfg := testInfluxCfg(primary, "primary")
+	cfg.FailoverThreshold = 2
+	cfg.StandbyID = "standby"
+	db := NewNotInitInfluxDB(cfg)
+	db.standby = testInfluxCfg(standby, "standby")
+	db.Init()
+	defer db.End()
+
+	send := func(name string) {
+		db.sendBatchPoint(testInfluxBP(t, db, Route{}, name, 1), false)
+	}
+	written := func(s *testInfluxServer) string {
+		var names []string
+		for _, w := range s.received() {
+			names = append(names, strings.SplitN(w.lines[0], ",", 2)[0])
+		}
+		return strings.Join(names, ",")
+	}
+
+	send("a")
+	primary.setDown(true)
+	// the first failure is below the threshold, data is lost (no spool)
+	send("b")
+	if db.breaker.IsOpen() {
+		t.Fatalf("failed over below the threshold")
+	}
+	// fail over at the threshold, data sent to the standby
+	send("c")
+	send("d")
+	if st := db.FailoverStatus(); st.State != BreakerOpen || st.Failovers != 1 {
+		t.Fatalf("status %+v after reach the threshold", st)
+	}
+
+	// primary checked after the failback interval, still down
+	testBreakerCooldown(db.breaker)
+	send("e")
+	if st := db.FailoverStatus(); st.State != BreakerOpen {
+		t.Fatalf("state %s after failed health check", st.State)
+	}
+
+	// primary healthy again, not checked until the failback interval
+	primary.setDown(false)
+	send("f")
+	testBreakerCooldown(db.breaker)
+	send("g")
+	if st := db.FailoverStatus(); st.State != BreakerClosed || st.Failbacks != 1 {
+		t.Fatalf("status %+v after healthy check", st)
+	}
+	send("h")
+
+	if got := written(primary); got != "a,g,h" {
+		t.Errorf("primary received %s, expected a,g,h", got)
+	}
+	if got := written(standby); got != "c,d,e,f" {
+		t.Errorf("standby received %s, expected c,d,e,f", got)
+	}
+	want := BreakerStats{Enabled: true, State: BreakerClosed, Failovers: 1, Failbacks: 1, StandbyWrites: 4}
+	if st := db.GetResetStats(); st.Breaker != want || st.WriteErrors != 2 {
+		t.Errorf("stats %+v with %d write errors, expected %+v with 2", st.Breaker, st.WriteErrors, want)
+	}
+}
@@ -0,0 +1,222 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb1-client/models"
+	client "github.com/influxdata/influxdb1-client/v2"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// Provision actions
+const (
+	ProvisionCreate = "create"
+	ProvisionAlter  = "alter"
+	ProvisionNone   = "none"
+)
+
+// ProvisionAction is an InfluxQL statement needed to provision the configured database,
+// retention policy or continuous queries (Action none if the object is already provisioned)
+// swagger:model ProvisionAction
+type ProvisionAction struct {
+	Object    string // database, retention policy or continuous query
+	Name      string
+	Action    string // create, alter or none
+	Statement string
+	Detail    string
+	Error     string // set if the statement failed
+}
+
+// influxQuote quotes an InfluxQL identifier
+func influxQuote(id string) string {
+	return `"` + strings.Replace(strings.Replace(id, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+// influxQuery runs an InfluxQL statement and returns the series of its first result
+func influxQuery(cli client.Client, cmd string, db string) ([]models.Row, error) {
+	resp, err := cli.Query(client.NewQuery(cmd, db, ""))
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	return resp.Results[0].Series, nil
+}
+
+// rowColumn returns the index of the column on the row, -1 if not found
+func rowColumn(row models.Row, name string) int {
+	for i, c := range row.Columns {
+		if c == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowString returns the value as string if the column exists
+func rowString(v []interface{}, i int) string {
+	if i < 0 || i >= len(v) {
+		return ""
+	}
+	s, _ := v[i].(string)
+	return s
+}
+
+// influxRP current retention policy settings
+type influxRP struct {
+	duration time.Duration
+	shard    time.Duration
+}
+
+// ProvisionPlan compares the server state with the configured database, retention policy and
+// continuous queries and returns the statements needed to provision them (nothing is changed)
+func ProvisionPlan(cli client.Client, cfg *config.InfluxCfg) ([]*ProvisionAction, error) {
+	if cfg.IsUDP() || cfg.IsAPIv2() {
+		return nil, fmt.Errorf("auto provisioning is only supported with the InfluxDB v1 HTTP API")
+	}
+	duration, err := config.InfluxDuration(cfg.RetentionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention duration: %s", err)
+	}
+	var shard time.Duration
+	if len(cfg.ShardDuration) > 0 {
+		if shard, err = config.InfluxDuration(cfg.ShardDuration); err != nil {
+			return nil, fmt.Errorf("invalid shard duration: %s", err)
+		}
+	}
+	cqs, err := cfg.GetContinuousQueries()
+	if err != nil {
+		return nil, err
+	}
+	var actions []*ProvisionAction
+	db := influxQuote(cfg.DB)
+
+	// database
+	rows, err := influxQuery(cli, "SHOW DATABASES", "")
+	if err != nil {
+		return nil, fmt.Errorf("error on get databases: %s", err)
+	}
+	dbExists := false
+	for _, row := range rows {
+		for _, v := range row.Values {
+			if rowString(v, 0) == cfg.DB {
+				dbExists = true
+			}
+		}
+	}
+	if dbExists {
+		actions = append(actions, &ProvisionAction{Object: "database", Name: cfg.DB, Action: ProvisionNone, Detail: "already exists"})
+	} else {
+		actions = append(actions, &ProvisionAction{Object: "database", Name: cfg.DB, Action: ProvisionCreate, Statement: "CREATE DATABASE " + db})
+	}
+
+	// retention policy
+	rps := make(map[string]influxRP)
+	if dbExists {
+		rows, err = influxQuery(cli, "SHOW RETENTION POLICIES ON "+db, "")
+		if err != nil {
+			return nil, fmt.Errorf("error on get retention policies: %s", err)
+		}
+		for _, row := range rows {
+			ni, di, si := rowColumn(row, "name"), rowColumn(row, "duration"), rowColumn(row, "shardGroupDuration")
+			for _, v := range row.Values {
+				d, _ := time.ParseDuration(rowString(v, di))
+				s, _ := time.ParseDuration(rowString(v, si))
+				rps[rowString(v, ni)] = influxRP{duration: d, shard: s}
+			}
+		}
+	} else {
+		// created with the database
+		rps["autogen"] = influxRP{}
+	}
+	rp := cfg.Retention
+	if len(rp) == 0 {
+		rp = "autogen"
+	}
+	clauses := " DURATION " + cfg.RetentionDuration
+	if shard > 0 {
+		clauses += " SHARD DURATION " + cfg.ShardDuration
+	}
+	cur, rpExists := rps[rp]
+	switch {
+	case !rpExists:
+		actions = append(actions, &ProvisionAction{Object: "retention policy", Name: rp, Action: ProvisionCreate,
+			Statement: fmt.Sprintf("CREATE RETENTION POLICY %s ON %s%s REPLICATION 1", influxQuote(rp), db, clauses)})
+	case cur.duration != duration || (shard > 0 && dbExists && cur.shard != shard):
+		actions = append(actions, &ProvisionAction{Object: "retention policy", Name: rp, Action: ProvisionAlter,
+			Statement: fmt.Sprintf("ALTER RETENTION POLICY %s ON %s%s", influxQuote(rp), db, clauses),
+			Detail:    fmt.Sprintf("current duration %s shard duration %s", cur.duration, cur.shard)})
+	case shard > 0 && !dbExists:
+		// autogen shard duration depends on the server defaults
+		actions = append(actions, &ProvisionAction{Object: "retention policy", Name: rp, Action: ProvisionAlter,
+			Statement: fmt.Sprintf("ALTER RETENTION POLICY %s ON %s%s", influxQuote(rp), db, clauses)})
+	default:
+		actions = append(actions, &ProvisionAction{Object: "retention policy", Name: rp, Action: ProvisionNone, Detail: "already exists with the same durations"})
+	}
+
+	// continuous queries, existing ones are not modified
+	if len(cqs) == 0 {
+		return actions, nil
+	}
+	existing := make(map[string]bool)
+	if dbExists {
+		rows, err = influxQuery(cli, "SHOW CONTINUOUS QUERIES", "")
+		if err != nil {
+			return nil, fmt.Errorf("error on get continuous queries: %s", err)
+		}
+		for _, row := range rows {
+			if row.Name != cfg.DB {
+				continue
+			}
+			ni := rowColumn(row, "name")
+			for _, v := range row.Values {
+				existing[rowString(v, ni)] = true
+			}
+		}
+	}
+	for _, cq := range cqs {
+		if existing[cq.Name] {
+			actions = append(actions, &ProvisionAction{Object: "continuous query", Name: cq.Name, Action: ProvisionNone, Detail: "already exists (not compared)"})
+			continue
+		}
+		actions = append(actions, &ProvisionAction{Object: "continuous query", Name: cq.Name, Action: ProvisionCreate,
+			Statement: fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s BEGIN %s END", influxQuote(cq.Name), db, cq.Query)})
+	}
+	return actions, nil
+}
+
+// Provision runs the statements needed to provision the configured database, retention policy and
+// continuous queries, it stops on the first failed statement
+func Provision(cli client.Client, cfg *config.InfluxCfg) ([]*ProvisionAction, error) {
+	actions, err := ProvisionPlan(cli, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range actions {
+		if a.Action == ProvisionNone {
+			continue
+		}
+		if _, err := influxQuery(cli, a.Statement, cfg.DB); err != nil {
+			a.Error = err.Error()
+			return actions, fmt.Errorf("error on %s %s %s: %s", a.Action, a.Object, a.Name, err)
+		}
+		log.Infof("Influx server %s provisioned: %s", cfg.ID, a.Statement)
+	}
+	return actions, nil
+}
+
+// autoProvision provisions the server if enabled, errors are only logged (writes will fail until fixed)
+func autoProvision(cli client.Client, cfg *config.InfluxCfg) {
+	if !cfg.AutoProvision || cli == nil {
+		return
+	}
+	if _, err := Provision(cli, cfg); err != nil {
+		log.Errorf("Error on auto provisioning influx server %s: %s", cfg.ID, err)
+	}
+}
@@ -0,0 +1,247 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb1-client/models"
+	client "github.com/influxdata/influxdb1-client/v2"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// testProvClient answers the SHOW queries with canned series and records the other statements,
+// statements starting with fail get an error
+type testProvClient struct {
+	client.Client
+	dbs   []string
+	rps   [][]interface{} // name, duration, shardGroupDuration
+	cqs   map[string][]string
+	fail  string
+	stmts []string
+}
+
+func (c *testProvClient) Query(q client.Query) (*client.Response, error) {
+	var row models.Row
+	switch {
+	case q.Command == "SHOW DATABASES":
+		row = models.Row{Name: "databases", Columns: []string{"name"}}
+		for _, db := range c.dbs {
+			row.Values = append(row.Values, []interface{}{db})
+		}
+	case strings.HasPrefix(q.Command, "SHOW RETENTION POLICIES ON "):
+		row = models.Row{Columns: []string{"name", "duration", "shardGroupDuration", "replicaN", "default"}}
+		for _, rp := range c.rps {
+			row.Values = append(row.Values, append(rp, 1, false))
+		}
+	case q.Command == "SHOW CONTINUOUS QUERIES":
+		var rows []models.Row
+		for db, names := range c.cqs {
+			r := models.Row{Name: db, Columns: []string{"name", "query"}}
+			for _, n := range names {
+				r.Values = append(r.Values, []interface{}{n, "CREATE CONTINUOUS QUERY ..."})
+			}
+			rows = append(rows, r)
+		}
+		return &client.Response{Results: []client.Result{{Series: rows}}}, nil
+	default:
+		c.stmts = append(c.stmts, q.Command)
+		if len(c.fail) > 0 && strings.HasPrefix(q.Command, c.fail) {
+			return &client.Response{Results: []client.Result{{Err: "permission denied"}}}, nil
+		}
+		return &client.Response{Results: []client.Result{{}}}, nil
+	}
+	return &client.Response{Results: []client.Result{{Series: []models.Row{row}}}}, nil
+}
+
+func (c *testProvClient) Close() error { return nil }
+
+func Test_ProvisionPlan(t *testing.T) {
+	const cqs = `cq_1h: SELECT mean(*) INTO "snmp"."long".:MEASUREMENT FROM /.*/ GROUP BY time(1h), *
+		cq_1d: SELECT mean(*) INTO "snmp"."long".:MEASUREMENT FROM /.*/ GROUP BY time(1d), *`
+	none := func(object, name, detail string) *ProvisionAction {
+		return &ProvisionAction{Object: object, Name: name, Action: ProvisionNone, Detail: detail}
+	}
+	rpNone := none("retention policy", "snmp_rp", "already exists with the same durations")
+	dbNone := none("database", "snmp", "already exists")
+	tests := []struct {
+		name string
+		cli  *testProvClient
+		cfg  config.InfluxCfg
+		want []*ProvisionAction
+	}{
+		{
+			name: "missing database",
+			cli:  &testProvClient{dbs: []string{"_internal", "snmp2"}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "30d", ShardDuration: "1d", ContinuousQueries: cqs},
+			want: []*ProvisionAction{
+				{Object: "database", Name: "snmp", Action: ProvisionCreate, Statement: `CREATE DATABASE "snmp"`},
+				{Object: "retention policy", Name: "snmp_rp", Action: ProvisionCreate, Statement: `CREATE RETENTION POLICY "snmp_rp" ON "snmp" DURATION 30d SHARD DURATION 1d REPLICATION 1`},
+				{Object: "continuous query", Name: "cq_1h", Action: ProvisionCreate, Statement: `CREATE CONTINUOUS QUERY "cq_1h" ON "snmp" BEGIN SELECT mean(*) INTO "snmp"."long".:MEASUREMENT FROM /.*/ GROUP BY time(1h), * END`},
+				{Object: "continuous query", Name: "cq_1d", Action: ProvisionCreate, Statement: `CREATE CONTINUOUS QUERY "cq_1d" ON "snmp" BEGIN SELECT mean(*) INTO "snmp"."long".:MEASUREMENT FROM /.*/ GROUP BY time(1d), * END`},
+			},
+		},
+		{
+			// autogen is created with the database with an INF duration
+			name: "missing database autogen",
+			cli:  &testProvClient{},
+			cfg:  config.InfluxCfg{DB: "snmp", RetentionDuration: "INF"},
+			want: []*ProvisionAction{
+				{Object: "database", Name: "snmp", Action: ProvisionCreate, Statement: `CREATE DATABASE "snmp"`},
+				none("retention policy", "autogen", "already exists with the same durations"),
+			},
+		},
+		{
+			name: "missing database autogen durations",
+			cli:  &testProvClient{},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "autogen", RetentionDuration: "INF", ShardDuration: "1w"},
+			want: []*ProvisionAction{
+				{Object: "database", Name: "snmp", Action: ProvisionCreate, Statement: `CREATE DATABASE "snmp"`},
+				{Object: "retention policy", Name: "autogen", Action: ProvisionAlter, Statement: `ALTER RETENTION POLICY "autogen" ON "snmp" DURATION INF SHARD DURATION 1w`},
+			},
+		},
+		{
+			name: "missing retention policy",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"autogen", "0s", "168h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "30d"},
+			want: []*ProvisionAction{
+				dbNone,
+				{Object: "retention policy", Name: "snmp_rp", Action: ProvisionCreate, Statement: `CREATE RETENTION POLICY "snmp_rp" ON "snmp" DURATION 30d REPLICATION 1`},
+			},
+		},
+		{
+			name: "same durations",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"autogen", "0s", "168h0m0s"}, {"snmp_rp", "720h0m0s", "24h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "4w2d", ShardDuration: "24h"},
+			want: []*ProvisionAction{dbNone, rpNone},
+		},
+		{
+			// the shard duration is not compared if not configured
+			name: "server shard duration",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"snmp_rp", "720h0m0s", "24h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "30d"},
+			want: []*ProvisionAction{dbNone, rpNone},
+		},
+		{
+			name: "different duration",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"snmp_rp", "168h0m0s", "24h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "30d"},
+			want: []*ProvisionAction{
+				dbNone,
+				{Object: "retention policy", Name: "snmp_rp", Action: ProvisionAlter, Statement: `ALTER RETENTION POLICY "snmp_rp" ON "snmp" DURATION 30d`, Detail: "current duration 168h0m0s shard duration 24h0m0s"},
+			},
+		},
+		{
+			name: "different shard duration",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"snmp_rp", "720h0m0s", "24h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "30d", ShardDuration: "1w"},
+			want: []*ProvisionAction{
+				dbNone,
+				{Object: "retention policy", Name: "snmp_rp", Action: ProvisionAlter, Statement: `ALTER RETENTION POLICY "snmp_rp" ON "snmp" DURATION 30d SHARD DURATION 1w`, Detail: "current duration 720h0m0s shard duration 24h0m0s"},
+			},
+		},
+		{
+			// INF durations are shown as 0s
+			name: "INF duration",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"snmp_rp", "0s", "168h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "inf"},
+			want: []*ProvisionAction{dbNone, rpNone},
+		},
+		{
+			name: "INF duration to finite",
+			cli:  &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"snmp_rp", "0s", "168h0m0s"}}},
+			cfg:  config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "52w"},
+			want: []*ProvisionAction{
+				dbNone,
+				{Object: "retention policy", Name: "snmp_rp", Action: ProvisionAlter, Statement: `ALTER RETENTION POLICY "snmp_rp" ON "snmp" DURATION 52w`, Detail: "current duration 0s shard duration 168h0m0s"},
+			},
+		},
+		{
+			// continuous queries with the same name on other databases are not the configured ones
+			name: "existing continuous queries",
+			cli: &testProvClient{
+				dbs: []string{"snmp"},
+				rps: [][]interface{}{{"snmp_rp", "720h0m0s", "24h0m0s"}},
+				cqs: map[string][]string{"snmp": {"cq_1h", "cq_old"}, "other": {"cq_1d"}},
+			},
+			cfg: config.InfluxCfg{DB: "snmp", Retention: "snmp_rp", RetentionDuration: "30d", ContinuousQueries: cqs},
+			want: []*ProvisionAction{
+				dbNone,
+				rpNone,
+				none("continuous query", "cq_1h", "already exists (not compared)"),
+				{Object: "continuous query", Name: "cq_1d", Action: ProvisionCreate, Statement: `CREATE CONTINUOUS QUERY "cq_1d" ON "snmp" BEGIN SELECT mean(*) INTO "snmp"."long".:MEASUREMENT FROM /.*/ GROUP BY time(1d), * END`},
+			},
+		},
+		{
+			name: "quoted names",
+			cli:  &testProvClient{},
+			cfg:  config.InfluxCfg{DB: `snmp "lab"`, Retention: `rp\1`, RetentionDuration: "1h"},
+			want: []*ProvisionAction{
+				{Object: "database", Name: `snmp "lab"`, Action: ProvisionCreate, Statement: `CREATE DATABASE "snmp \"lab\""`},
+				{Object: "retention policy", Name: `rp\1`, Action: ProvisionCreate, Statement: `CREATE RETENTION POLICY "rp\\1" ON "snmp \"lab\"" DURATION 1h REPLICATION 1`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ProvisionPlan(tt.cli, &tt.cfg)
+		if err != nil {
+			t.Errorf("%s: plan: %s", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: plan (-want +got):\n%s", tt.name, diff)
+		}
+		// the plan does not change anything
+		if len(tt.cli.stmts) != 0 {
+			t.Errorf("%s: statements run on plan %v", tt.name, tt.cli.stmts)
+		}
+	}
+
+	// invalid configurations
+	for _, cfg := range []config.InfluxCfg{
+		{DB: "snmp", RetentionDuration: "30x"},
+		{DB: "snmp", RetentionDuration: "30d", ShardDuration: "1y"},
+		{DB: "snmp", RetentionDuration: "30d", ContinuousQueries: "cq: DROP DATABASE snmp"},
+		{DB: "snmp", RetentionDuration: "30d", APIVersion: "v2"},
+		{DB: "snmp", RetentionDuration: "30d", Transport: "udp"},
+	} {
+		if _, err := ProvisionPlan(&testProvClient{}, &cfg); err == nil {
+			t.Errorf("plan with invalid config %+v", cfg)
+		}
+	}
+}
+
+func Test_Provision(t *testing.T) {
+	cfg := &config.InfluxCfg{
+		DB:                "snmp",
+		Retention:         "snmp_rp",
+		RetentionDuration: "30d",
+		ContinuousQueries: "cq_1h: SELECT mean(*) INTO long.:MEASUREMENT FROM /.*/ GROUP BY time(1h), *",
+	}
+	cli := &testProvClient{dbs: []string{"snmp"}, rps: [][]interface{}{{"snmp_rp", "168h0m0s", "24h0m0s"}}}
+	actions, err := Provision(cli, cfg)
+	if err != nil {
+		t.Fatalf("provision: %s", err)
+	}
+	want := []string{
+		`ALTER RETENTION POLICY "snmp_rp" ON "snmp" DURATION 30d`,
+		`CREATE CONTINUOUS QUERY "cq_1h" ON "snmp" BEGIN SELECT mean(*) INTO long.:MEASUREMENT FROM /.*/ GROUP BY time(1h), * END`,
+	}
+	if !cmp.Equal(want, cli.stmts) {
+		t.Errorf("statements %v, expected %v", cli.stmts, want)
+	}
+	if len(actions) != 3 {
+		t.Errorf("%d actions, expected 3", len(actions))
+	}
+
+	// the first failed statement stops the provisioning
+	cli = &testProvClient{fail: "CREATE RETENTION POLICY"}
+	actions, err = Provision(cli, cfg)
+	if err == nil || err.Error() != fmt.Sprintf("error on %s retention policy snmp_rp: permission denied", ProvisionCreate) {
+		t.Errorf("provision error %v", err)
+	}
+	if len(cli.stmts) != 2 || len(actions) != 3 || actions[1].Error != "permission denied" || actions[2].Error != "" {
+		t.Errorf("statements %v actions %+v", cli.stmts, actions)
+	}
+}
@@ -0,0 +1,135 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+// UDPMinPayloadSize is the minimum allowed UDP packet payload size
+const UDPMinPayloadSize = 512
+
+// influxUDPClient implements the influx client.Client interface sending points in line
+// protocol to an UDP listener (InfluxDB 1.x UDP service, Telegraf socket_listener ...).
+// Batches are split into packets not bigger than the payload size and optionally rate limited.
+type influxUDPClient struct {
+	addr        string
+	conn        *net.UDPConn
+	payloadSize int
+	interval    time.Duration // min time between packets (0 no limit)
+	mutex       sync.Mutex
+	next        time.Time
+}
+
+// influxUDPConfig the needed parameters to send data to an UDP listener
+type influxUDPConfig struct {
+	Addr        string
+	PayloadSize int
+	RateLimit   int // max packets per second (0 no limit)
+}
+
+func newInfluxUDPClient(conf influxUDPConfig) (client.Client, error) {
+	raddr, err := net.ResolveUDPAddr("udp", conf.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+	c := &influxUDPClient{
+		addr:        conf.Addr,
+		conn:        conn,
+		payloadSize: conf.PayloadSize,
+	}
+	if c.payloadSize < UDPMinPayloadSize {
+		c.payloadSize = UDPMinPayloadSize
+	}
+	if conf.RateLimit > 0 {
+		c.interval = time.Second / time.Duration(conf.RateLimit)
+	}
+	return c, nil
+}
+
+// Ping can not check an UDP listener, it only returns the remote address as message
+func (c *influxUDPClient) Ping(timeout time.Duration) (time.Duration, string, error) {
+	return 0, "UDP " + c.conn.RemoteAddr().String(), nil
+}
+
+// send writes a packet waiting if needed to keep the configured rate limit
+func (c *influxUDPClient) send(pkt []byte) error {
+	if c.interval > 0 {
+		now := time.Now()
+		if c.next.After(now) {
+			time.Sleep(c.next.Sub(now))
+			now = c.next
+		}
+		c.next = now.Add(c.interval)
+	}
+	_, err := c.conn.Write(pkt)
+	return err
+}
+
+// Write sends all points in line protocol, lines are grouped in packets up to the payload size.
+// Lines bigger than the payload size are sent alone in its own packet (and could be fragmented).
+func (c *influxUDPClient) Write(bp client.BatchPoints) error {
+	// h and m precisions are sent as seconds as on the v2 API
+	precision := bp.Precision()
+	if precision == "h" || precision == "m" {
+		precision = "s"
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var b bytes.Buffer
+	var errs int
+	var lasterr error
+	flush := func() {
+		if b.Len() == 0 {
+			return
+		}
+		if err := c.send(b.Bytes()); err != nil {
+			errs++
+			lasterr = err
+		}
+		b.Reset()
+	}
+	for _, p := range bp.Points() {
+		if p == nil {
+			continue
+		}
+		line := p.PrecisionString(precision) + "\n"
+		if b.Len()+len(line) > c.payloadSize {
+			flush()
+		}
+		if len(line) > c.payloadSize {
+			log.Warnf("Point %s line size %d bigger than the UDP payload size %d on %s", p.Name(), len(line), c.payloadSize, c.addr)
+		}
+		b.WriteString(line)
+	}
+	flush()
+	if errs > 0 {
+		return fmt.Errorf("%d UDP packets failed to %s, last error: %s", errs, c.addr, lasterr)
+	}
+	return nil
+}
+
+// Query is not supported on the UDP client
+func (c *influxUDPClient) Query(q client.Query) (*client.Response, error) {
+	return nil, fmt.Errorf("query not supported on UDP client")
+}
+
+// QueryAsChunk is not supported on the UDP client
+func (c *influxUDPClient) QueryAsChunk(q client.Query) (*client.ChunkedResponse, error) {
+	return nil, fmt.Errorf("query not supported on UDP client")
+}
+
+// Close closes the UDP socket
+func (c *influxUDPClient) Close() error {
+	return c.conn.Close()
+}
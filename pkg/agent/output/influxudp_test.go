@@ -0,0 +1,170 @@
+package output
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+// testUDPListener returns a loopback UDP listener and a function returning the packets received
+// until no more packets arrive
+func testUDPListener(t *testing.T) (string, func() []string) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	read := func() []string {
+		var pkts []string
+		buf := make([]byte, 65535)
+		for {
+			conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return pkts
+			}
+			pkts = append(pkts, string(buf[:n]))
+		}
+	}
+	return conn.LocalAddr().String(), read
+}
+
+func testUDPClient(t *testing.T, conf influxUDPConfig) *influxUDPClient {
+	cli, err := newInfluxUDPClient(conf)
+	if err != nil {
+		t.Fatalf("udp client: %s", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli.(*influxUDPClient)
+}
+
+// testUDPBatch returns a batch with a point for each field value
+func testUDPBatch(t *testing.T, precision string, values ...string) client.BatchPoints {
+	t.Helper()
+	bp, _ := client.NewBatchPoints(client.BatchPointsConfig{Precision: precision})
+	for i, v := range values {
+		p, err := client.NewPoint("m", map[string]string{"id": strconv.Itoa(i)}, map[string]interface{}{"v": v}, time.Unix(1600000000, 123456789))
+		if err != nil {
+			t.Fatalf("point: %s", err)
+		}
+		bp.AddPoint(p)
+	}
+	return bp
+}
+
+func Test_InfluxUDPWrite(t *testing.T) {
+	addr, read := testUDPListener(t)
+	// payload sizes are never lower than UDPMinPayloadSize
+	c := testUDPClient(t, influxUDPConfig{Addr: addr, PayloadSize: 100})
+	if c.payloadSize != UDPMinPayloadSize {
+		t.Fatalf("payload size %d, expected %d", c.payloadSize, UDPMinPayloadSize)
+	}
+
+	values := make([]string, 40)
+	for i := range values {
+		values[i] = strings.Repeat("a", 10+i)
+	}
+	// a line bigger than the payload size
+	values[20] = strings.Repeat("b", 2*UDPMinPayloadSize)
+	bp := testUDPBatch(t, "s", values...)
+	var want []string
+	for _, p := range bp.Points() {
+		want = append(want, p.PrecisionString("s"))
+	}
+	if err := c.Write(bp); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+
+	var got []string
+	pkts := read()
+	for _, p := range pkts {
+		if !strings.HasSuffix(p, "\n") {
+			t.Fatalf("packet not ending on a full line: %q", p)
+		}
+		lines := strings.Split(strings.TrimSuffix(p, "\n"), "\n")
+		// only oversized lines are sent in packets bigger than the payload size
+		if len(p) > UDPMinPayloadSize && (len(lines) != 1 || !strings.Contains(p, values[20])) {
+			t.Errorf("packet of %d bytes with %d lines", len(p), len(lines))
+		}
+		got = append(got, lines...)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("received lines %v, expected %v", got, want)
+	}
+	// packets are filled up to the payload size, the next line does not fit in the packet
+	for i := 0; i < len(pkts)-1; i++ {
+		next := strings.SplitAfter(pkts[i+1], "\n")[0]
+		if len(pkts[i])+len(next) <= UDPMinPayloadSize {
+			t.Errorf("packet %d of %d bytes sent without the next line of %d bytes", i, len(pkts[i]), len(next))
+		}
+	}
+
+	// empty batches send nothing
+	if err := c.Write(testUDPBatch(t, "s")); err != nil {
+		t.Errorf("empty write: %s", err)
+	}
+	if pkts := read(); len(pkts) != 0 {
+		t.Errorf("%d packets sent for an empty batch", len(pkts))
+	}
+}
+
+func Test_InfluxUDPPrecision(t *testing.T) {
+	addr, read := testUDPListener(t)
+	c := testUDPClient(t, influxUDPConfig{Addr: addr, PayloadSize: 1400})
+	tests := []struct {
+		precision string
+		ts        string
+	}{
+		// h and m are sent as seconds
+		{"h", "1600000000"},
+		{"m", "1600000000"},
+		{"s", "1600000000"},
+		{"ms", "1600000000123"},
+		{"ns", "1600000000123456789"},
+	}
+	for _, tt := range tests {
+		if err := c.Write(testUDPBatch(t, tt.precision, "x")); err != nil {
+			t.Fatalf("write: %s", err)
+		}
+		want := `m,id=0 v="x" ` + tt.ts + "\n"
+		if pkts := read(); len(pkts) != 1 || pkts[0] != want {
+			t.Errorf("precision %s: packets %q, expected %q", tt.precision, pkts, want)
+		}
+	}
+}
+
+func Test_InfluxUDPRateLimit(t *testing.T) {
+	addr, read := testUDPListener(t)
+	// 20 packets per second, one packet each 50ms
+	c := testUDPClient(t, influxUDPConfig{Addr: addr, PayloadSize: UDPMinPayloadSize, RateLimit: 20})
+	if c.interval != 50*time.Millisecond {
+		t.Fatalf("interval %s, expected 50ms", c.interval)
+	}
+	values := make([]string, 4)
+	for i := range values {
+		values[i] = strings.Repeat("a", UDPMinPayloadSize/2)
+	}
+	start := time.Now()
+	if err := c.Write(testUDPBatch(t, "s", values...)); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	// the first packet is sent without waiting
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("4 packets sent in %s, expected 150ms", elapsed)
+	}
+	// the rate is kept between writes
+	start = time.Now()
+	if err := c.Write(testUDPBatch(t, "s", "x")); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("next write sent after %s, expected 50ms since the last packet", elapsed)
+	}
+	if pkts := read(); len(pkts) != 5 {
+		t.Errorf("%d packets, expected 5", len(pkts))
+	}
+}
@@ -0,0 +1,235 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+// influxV2Client implements the influx client.Client interface over the
+// InfluxDB 2.x/3.x HTTP API (/api/v2/write and /health endpoints)
+type influxV2Client struct {
+	url        url.URL
+	org        string
+	bucket     string
+	token      string
+	useragent  string
+	gzip       bool
+	httpClient *http.Client
+	transport  *http.Transport
+}
+
+// influxV2HTTPConfig the needed parameters to connect to a v2 write API
+type influxV2HTTPConfig struct {
+	Addr      string
+	Org       string
+	Bucket    string
+	Token     string
+	UserAgent string
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+	Gzip      bool
+}
+
+// v1 precision values are translated to the v2 API ones, h and m are not
+// supported so timestamps will be sent in seconds
+var influxV2Precision = map[string]string{
+	"h":  "s",
+	"m":  "s",
+	"s":  "s",
+	"ms": "ms",
+	"u":  "us",
+	"ns": "ns",
+}
+
+func newInfluxV2Client(conf influxV2HTTPConfig) (client.Client, error) {
+	u, err := url.Parse(conf.Addr)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported protocol scheme: %s, your address must start with http:// or https://", u.Scheme)
+	}
+	tr := &http.Transport{
+		TLSClientConfig: conf.TLSConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	return &influxV2Client{
+		url:       *u,
+		org:       conf.Org,
+		bucket:    conf.Bucket,
+		token:     conf.Token,
+		useragent: conf.UserAgent,
+		gzip:      conf.Gzip,
+		httpClient: &http.Client{
+			Timeout:   conf.Timeout,
+			Transport: tr,
+		},
+		transport: tr,
+	}, nil
+}
+
+func (c *influxV2Client) newRequest(method string, p string, body io.Reader) (*http.Request, error) {
+	u := c.url
+	u.Path = path.Join(u.Path, p)
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.useragent)
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Token "+c.token)
+	}
+	return req, nil
+}
+
+// Ping checks the server health status, returns the server version as message
+func (c *influxV2Client) Ping(timeout time.Duration) (time.Duration, string, error) {
+	now := time.Now()
+	req, err := c.newRequest("GET", "health", nil)
+	if err != nil {
+		return 0, "", err
+	}
+	cl := c.httpClient
+	if timeout > 0 {
+		tc := *c.httpClient
+		tc.Timeout = timeout
+		cl = &tc
+	}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	return c.checkHealth(resp, now)
+}
+
+func (c *influxV2Client) checkHealth(resp *http.Response, start time.Time) (time.Duration, string, error) {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	elapsed := time.Since(start)
+	version := resp.Header.Get("X-Influxdb-Version")
+	// InfluxDB 2.x returns a JSON health check, InfluxDB 3.x could return a plain "OK" text
+	health := struct {
+		Status  string `json:"status"`
+		Version string `json:"version"`
+		Message string `json:"message"`
+	}{}
+	if err := json.Unmarshal(body, &health); err == nil {
+		if len(health.Version) > 0 {
+			version = health.Version
+		}
+		if resp.StatusCode != http.StatusOK || health.Status != "pass" {
+			return 0, version, fmt.Errorf("health status %s : %s", health.Status, health.Message)
+		}
+		return elapsed, version, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, version, fmt.Errorf("health check error [%d]: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if len(version) == 0 {
+		version = strings.TrimSpace(string(body))
+	}
+	return elapsed, version, nil
+}
+
+// Write sends all points in line protocol format to the /api/v2/write endpoint
+func (c *influxV2Client) Write(bp client.BatchPoints) error {
+	precision, ok := influxV2Precision[bp.Precision()]
+	if !ok {
+		precision = "ns"
+	}
+	// h and m precisions are sent as seconds
+	fmtPrecision := bp.Precision()
+	if fmtPrecision == "h" || fmtPrecision == "m" {
+		fmtPrecision = "s"
+	}
+
+	var b bytes.Buffer
+	var w io.Writer = &b
+	var gz *gzip.Writer
+	if c.gzip {
+		gz = gzip.NewWriter(&b)
+		w = gz
+	}
+	for _, p := range bp.Points() {
+		if p == nil {
+			continue
+		}
+		io.WriteString(w, p.PrecisionString(fmtPrecision))
+		io.WriteString(w, "\n")
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+
+	req, err := c.newRequest("POST", "api/v2/write", &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	params := req.URL.Query()
+	params.Set("org", c.org)
+	bucket := c.bucket
+	if len(bp.Database()) > 0 {
+		bucket = bp.Database()
+	}
+	params.Set("bucket", bucket)
+	params.Set("precision", precision)
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		werr := struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}{}
+		if err := json.Unmarshal(body, &werr); err == nil && len(werr.Message) > 0 {
+			return fmt.Errorf("write error [%d] %s: %s", resp.StatusCode, werr.Code, werr.Message)
+		}
+		return fmt.Errorf("write error [%d]: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Query is not supported on the v2 write client
+func (c *influxV2Client) Query(q client.Query) (*client.Response, error) {
+	return nil, fmt.Errorf("query not supported on InfluxDB v2 API client")
+}
+
+// QueryAsChunk is not supported on the v2 write client
+func (c *influxV2Client) QueryAsChunk(q client.Query) (*client.ChunkedResponse, error) {
+	return nil, fmt.Errorf("query not supported on InfluxDB v2 API client")
+}
+
+// Close releases idle connections
+func (c *influxV2Client) Close() error {
+	c.transport.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,215 @@
+package output
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+// testV2Write a /api/v2/write request received by the v2 server stand-in
+type testV2Write struct {
+	params   url.Values
+	auth     string
+	encoding string
+	lines    []string
+}
+
+// newTestInfluxV2Server records the write requests and answers them with status and body,
+// status 0 is 204 No Content
+func newTestInfluxV2Server(t *testing.T, status int, body string) (*httptest.Server, chan testV2Write) {
+	writes := make(chan testV2Write, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		var rd io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip write request: %s", err)
+				return
+			}
+			rd = zr
+		}
+		data, err := ioutil.ReadAll(rd)
+		if err != nil {
+			t.Errorf("read write request: %s", err)
+		}
+		writes <- testV2Write{
+			params:   r.URL.Query(),
+			auth:     r.Header.Get("Authorization"),
+			encoding: r.Header.Get("Content-Encoding"),
+			lines:    strings.Split(strings.TrimSpace(string(data)), "\n"),
+		}
+		if status == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s, writes
+}
+
+func testV2Client(t *testing.T, conf influxV2HTTPConfig) client.Client {
+	cli, err := newInfluxV2Client(conf)
+	if err != nil {
+		t.Fatalf("v2 client: %s", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+// testV2Batch overrides the batch precision, the v1 client does not accept the "u" precision
+type testV2Batch struct {
+	client.BatchPoints
+	precision string
+}
+
+func (b testV2Batch) Precision() string { return b.precision }
+
+func testV2Points(t *testing.T, db string, precision string) client.BatchPoints {
+	t.Helper()
+	bp, _ := client.NewBatchPoints(client.BatchPointsConfig{Database: db})
+	for _, name := range []string{"m1", "m2"} {
+		p, err := client.NewPoint(name, map[string]string{"device": "sw1"}, map[string]interface{}{"value": 1}, time.Unix(1600000000, 123456789))
+		if err != nil {
+			t.Fatalf("point: %s", err)
+		}
+		bp.AddPoint(p)
+	}
+	return testV2Batch{BatchPoints: bp, precision: precision}
+}
+
+func Test_InfluxV2Write(t *testing.T) {
+	s, writes := newTestInfluxV2Server(t, 0, "")
+	tests := []struct {
+		name      string
+		gzip      bool
+		db        string
+		precision string
+		bucket    string
+		ts        string
+	}{
+		{name: "ns", precision: "ns", bucket: "snmp", ts: "1600000000123456789"},
+		{name: "us", precision: "u", bucket: "snmp", ts: "1600000000123456"},
+		{name: "ms", precision: "ms", bucket: "snmp", ts: "1600000000123"},
+		{name: "s", precision: "s", bucket: "snmp", ts: "1600000000"},
+		// h and m are not supported by the v2 API
+		{name: "m", precision: "m", bucket: "snmp", ts: "1600000000"},
+		{name: "h", precision: "h", bucket: "snmp", ts: "1600000000"},
+		{name: "unknown precision", precision: "", bucket: "snmp", ts: "1600000000123456789"},
+		{name: "batch database", db: "other", precision: "s", bucket: "other", ts: "1600000000"},
+		{name: "gzip", gzip: true, precision: "s", bucket: "snmp", ts: "1600000000"},
+	}
+	for _, tt := range tests {
+		c := testV2Client(t, influxV2HTTPConfig{Addr: s.URL, Org: "my org", Bucket: "snmp", Token: "secret", Timeout: 5 * time.Second, Gzip: tt.gzip})
+		if err := c.Write(testV2Points(t, tt.db, tt.precision)); err != nil {
+			t.Errorf("%s: write: %s", tt.name, err)
+			continue
+		}
+		w := <-writes
+		want := testV2Write{
+			params:   url.Values{"org": {"my org"}, "bucket": {tt.bucket}, "precision": {influxV2Precision[tt.precision]}},
+			auth:     "Token secret",
+			encoding: "",
+			lines:    []string{"m1,device=sw1 value=1i " + tt.ts, "m2,device=sw1 value=1i " + tt.ts},
+		}
+		if tt.precision == "" {
+			want.params.Set("precision", "ns")
+		}
+		if tt.gzip {
+			want.encoding = "gzip"
+		}
+		if diff := cmp.Diff(want, w, cmp.AllowUnexported(testV2Write{})); diff != "" {
+			t.Errorf("%s: write request (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	// without token no Authorization header is sent
+	c := testV2Client(t, influxV2HTTPConfig{Addr: s.URL, Org: "org", Bucket: "snmp", Timeout: 5 * time.Second})
+	if err := c.Write(testV2Points(t, "", "s")); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	if w := <-writes; w.auth != "" {
+		t.Errorf("authorization header %q without token", w.auth)
+	}
+}
+
+func Test_InfluxV2WriteError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		err    string
+	}{
+		{http.StatusOK, "", ""},
+		{http.StatusBadRequest, `{"code":"invalid","message":"unable to parse 'm1 value=': missing field value"}`, "write error [400] invalid: unable to parse 'm1 value=': missing field value"},
+		{http.StatusUnauthorized, `{"code":"unauthorized","message":"unauthorized access"}`, "write error [401] unauthorized: unauthorized access"},
+		{http.StatusNotFound, `{"code":"not found","message":"bucket \"snmp\" not found"}`, `write error [404] not found: bucket "snmp" not found`},
+		// non JSON errors are returned as is
+		{http.StatusServiceUnavailable, "service unavailable\n", "write error [503]: service unavailable"},
+		{http.StatusInternalServerError, `{"error":"internal"}`, `write error [500]: {"error":"internal"}`},
+	}
+	for _, tt := range tests {
+		s, _ := newTestInfluxV2Server(t, tt.status, tt.body)
+		c := testV2Client(t, influxV2HTTPConfig{Addr: s.URL, Org: "org", Bucket: "snmp", Timeout: 5 * time.Second})
+		err := c.Write(testV2Points(t, "", "s"))
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("status %d: error %v, expected %q", tt.status, err, tt.err)
+		}
+	}
+}
+
+func Test_InfluxV2Ping(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  string
+		body    string
+		version string
+		ok      bool
+	}{
+		{name: "v2 pass", status: 200, body: `{"name":"influxdb","message":"ready for queries and writes","status":"pass","version":"v2.7.1"}`, version: "v2.7.1", ok: true},
+		{name: "v2 version header", status: 200, header: "v2.7.0", body: `{"status":"pass"}`, version: "v2.7.0", ok: true},
+		{name: "v2 fail", status: 503, body: `{"name":"influxdb","message":"not ready","status":"fail","version":"v2.7.1"}`, version: "v2.7.1"},
+		{name: "v2 fail with status 200", status: 200, body: `{"status":"fail","message":"not ready"}`},
+		// InfluxDB 3.x plain text health check
+		{name: "plain OK", status: 200, body: "OK\n", version: "OK", ok: true},
+		{name: "plain OK version header", status: 200, header: "3.0.1", body: "OK", version: "3.0.1", ok: true},
+		{name: "plain error", status: 401, body: "unauthorized"},
+	}
+	for _, tt := range tests {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				http.NotFound(w, r)
+				return
+			}
+			if tt.header != "" {
+				w.Header().Set("X-Influxdb-Version", tt.header)
+			}
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.body)
+		}))
+		c := testV2Client(t, influxV2HTTPConfig{Addr: s.URL, Timeout: 5 * time.Second})
+		_, version, err := c.Ping(time.Second)
+		if (err == nil) != tt.ok || version != tt.version {
+			t.Errorf("%s: version %q error %v, expected %q", tt.name, version, err, tt.version)
+		}
+		s.Close()
+	}
+
+	if _, err := newInfluxV2Client(influxV2HTTPConfig{Addr: "udp://localhost:8086"}); err == nil {
+		t.Errorf("client created with an udp address")
+	}
+}
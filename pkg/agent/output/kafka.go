@@ -0,0 +1,491 @@
+package output
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/utils"
+	"github.com/xdg-go/scram"
+)
+
+// kafkaMaxPendingBatches max number of batches kept in memory while the cluster is unreachable
+const kafkaMaxPendingBatches = 10
+
+// kafkaMessage a record to be produced, new sarama messages are created on each produce
+// as they are not reusable once sent
+type kafkaMessage struct {
+	topic string
+	key   []byte
+	value []byte
+	ts    time.Time
+}
+
+var kafkaInvalidTopicChars = regexp.MustCompile(`[^a-zA-Z0-9\._\-]`)
+
+// Kafka produces each measurement point as a message keyed by the device tag value, so all points
+// from the same device are kept ordered on the same partition
+type Kafka struct {
+	cfg         *config.KafkaCfg
+	stats       Stats
+	initialized bool
+	imutex      sync.Mutex
+	started     bool
+	smutex      sync.Mutex
+
+	iChan  chan []*Point
+	chExit chan bool
+	// only accessed from the sender goroutine
+	producer sarama.SyncProducer
+	lastFail time.Time
+	pending  []*kafkaMessage
+	fields   int64
+}
+
+func init() {
+	Register("kafka", func(dbc *config.DBConfig) map[string]Output {
+		outs := make(map[string]Output)
+		for k, c := range dbc.Kafka {
+			outs[k] = NewNotInitKafka(c)
+		}
+		return outs
+	})
+}
+
+// NewNotInitKafka Create Object in memory but not initialized until ready connection needed
+func NewNotInitKafka(c *config.KafkaCfg) *Kafka {
+	return &Kafka{cfg: c}
+}
+
+// kafkaBrokers returns the bootstrap brokers list
+func kafkaBrokers(cfg *config.KafkaCfg) []string {
+	var res []string
+	for _, b := range strings.Split(cfg.Brokers, ",") {
+		if b = strings.TrimSpace(b); len(b) > 0 {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// kafkaConfig builds the sarama client config from the cluster config
+func kafkaConfig(cfg *config.KafkaCfg) (*sarama.Config, error) {
+	c := sarama.NewConfig()
+	// record batches and SASL handshake v1 are needed, Kafka 1.0 or newer
+	c.Version = sarama.V1_0_0_0
+	c.ClientID = cfg.ClientID
+	if len(c.ClientID) == 0 {
+		hostname, _ := os.Hostname()
+		c.ClientID = "snmpcollector-" + hostname
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	c.Net.DialTimeout = timeout
+	c.Net.ReadTimeout = timeout
+	c.Net.WriteTimeout = timeout
+	c.Producer.Timeout = timeout
+	c.Producer.RequiredAcks = sarama.RequiredAcks(cfg.RequiredAcks)
+	c.Producer.Return.Successes = true
+	c.Producer.Partitioner = newKafkaPartitioner
+	switch cfg.Compression {
+	case "gzip":
+		c.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		c.Producer.Compression = sarama.CompressionSnappy
+	}
+	if len(cfg.SASLMechanism) > 0 && cfg.SASLMechanism != "none" {
+		c.Net.SASL.Enable = true
+		c.Net.SASL.Mechanism = sarama.SASLMechanism(cfg.SASLMechanism)
+		c.Net.SASL.User = cfg.User
+		c.Net.SASL.Password = cfg.Password
+		switch cfg.SASLMechanism {
+		case sarama.SASLTypeSCRAMSHA256:
+			c.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &kafkaScramClient{hash: scram.SHA256} }
+		case sarama.SASLTypeSCRAMSHA512:
+			c.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &kafkaScramClient{hash: scram.SHA512} }
+		}
+	}
+	if cfg.EnableSSL {
+		tlsConfig, err := utils.GetTLSConfig(cfg.SSLCert, cfg.SSLKey, cfg.SSLCA, cfg.InsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		c.Net.TLS.Enable = true
+		c.Net.TLS.Config = tlsConfig
+	}
+	return c, nil
+}
+
+// kafkaScramClient is the SCRAM conversation needed by sarama for the SCRAM-SHA-* mechanisms
+type kafkaScramClient struct {
+	hash scram.HashGeneratorFcn
+	conv *scram.ClientConversation
+}
+
+// Begin prepares the conversation with the user credentials
+func (s *kafkaScramClient) Begin(user, password, authzID string) error {
+	c, err := s.hash.NewClient(user, password, authzID)
+	if err != nil {
+		return err
+	}
+	s.conv = c.NewConversation()
+	return nil
+}
+
+// Step returns the response to the server challenge
+func (s *kafkaScramClient) Step(challenge string) (string, error) {
+	return s.conv.Step(challenge)
+}
+
+// Done returns true when the conversation is over
+func (s *kafkaScramClient) Done() bool {
+	return s.conv.Done()
+}
+
+// kafkaMurmur2 is the murmur2 hash used by the Java client default partitioner
+func kafkaMurmur2(data []byte) int32 {
+	const seed uint32 = 0x9747b28c
+	const m uint32 = 0x5bd1e995
+	const r = 24
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := length &^ 3
+	switch length % 4 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+// kafkaKeyPartition returns the partition for a key as the Java client default partitioner does,
+// so all messages with the same key are kept ordered on the same partition by any producer
+func kafkaKeyPartition(key []byte, partitions int) int {
+	return int(uint32(kafkaMurmur2(key))&0x7fffffff) % partitions
+}
+
+// kafkaPartitioner sends messages with key to the partition chosen by kafkaKeyPartition (sarama hash
+// partitioners are not compatible with the Java client), messages without key are round robin distributed
+type kafkaPartitioner struct {
+	rr sarama.Partitioner
+}
+
+func newKafkaPartitioner(topic string) sarama.Partitioner {
+	return &kafkaPartitioner{rr: sarama.NewRoundRobinPartitioner(topic)}
+}
+
+// Partition returns the partition for the message
+func (p *kafkaPartitioner) Partition(msg *sarama.ProducerMessage, partitions int32) (int32, error) {
+	if msg.Key == nil {
+		return p.rr.Partition(msg, partitions)
+	}
+	key, err := msg.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+	return int32(kafkaKeyPartition(key, int(partitions))), nil
+}
+
+// RequiresConsistency is true, messages with the same key are never sent to other partition
+func (p *kafkaPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+// KafkaTopic returns the topic for a point, the {measurement} placeholder is replaced by the
+// measurement name (with characters not allowed on topic names replaced by "_")
+func KafkaTopic(tmpl string, p *Point) string {
+	return strings.Replace(tmpl, "{measurement}", kafkaInvalidTopicChars.ReplaceAllString(p.Name, "_"), -1)
+}
+
+// PingKafka checks the metadata could be got from the bootstrap brokers (and the topic exists or could be created)
+func PingKafka(cfg *config.KafkaCfg) (time.Duration, string, error) {
+	start := time.Now()
+	c, err := kafkaConfig(cfg)
+	if err != nil {
+		log.Errorf("Error on create config for kafka output %s: %s", cfg.ID, err)
+		return 0, "", err
+	}
+	cli, err := sarama.NewClient(kafkaBrokers(cfg), c)
+	if err != nil {
+		log.Errorf("Error on get metadata from kafka brokers %s: %s", cfg.Brokers, err)
+		return time.Since(start), "", err
+	}
+	defer cli.Close()
+	msg := fmt.Sprintf("Connected to cluster (%d brokers)", len(cli.Brokers()))
+	if !strings.Contains(cfg.Topic, "{measurement}") {
+		parts, err := cli.Partitions(cfg.Topic)
+		if err != nil {
+			return time.Since(start), "", fmt.Errorf("%s, topic %s: %s", msg, cfg.Topic, err)
+		}
+		msg += fmt.Sprintf(", topic %s with %d partitions", cfg.Topic, len(parts))
+	}
+	return time.Since(start), msg, nil
+}
+
+// ID return the kafka output ID
+func (k *Kafka) ID() string {
+	return k.cfg.ID
+}
+
+// GetResetStats return output stats and reset its counters
+func (k *Kafka) GetResetStats() *Stats {
+	return k.stats.GetResetStats()
+}
+
+// Init initializes runtime info
+func (k *Kafka) Init() {
+	k.imutex.Lock()
+	defer k.imutex.Unlock()
+	if k.initialized {
+		log.Infof("Sender thread to : %s  already Initialized (skipping Initialization)", k.cfg.ID)
+		return
+	}
+	log.Infof("Initializing kafka output with id = [ %s ] to %s topic %s", k.cfg.ID, k.cfg.Brokers, k.cfg.Topic)
+	if k.cfg.BatchSize <= 0 {
+		k.cfg.BatchSize = 1000
+	}
+	if k.cfg.FlushInterval <= 0 {
+		k.cfg.FlushInterval = 1
+	}
+	if k.cfg.BufferSize <= 0 {
+		k.cfg.BufferSize = 65535
+	}
+	k.iChan = make(chan []*Point, k.cfg.BufferSize)
+	k.chExit = make(chan bool)
+	k.initialized = true
+}
+
+// End releases runtime resources
+func (k *Kafka) End() {
+	k.imutex.Lock()
+	defer k.imutex.Unlock()
+	if !k.initialized {
+		return
+	}
+	close(k.iChan)
+	close(k.chExit)
+	k.initialized = false
+}
+
+// StartSender begins sender loop
+func (k *Kafka) StartSender(wg *sync.WaitGroup) {
+	k.smutex.Lock()
+	defer k.smutex.Unlock()
+	if k.started {
+		log.Infof("Sender thread to : %s  already started (skipping Goroutine creation)", k.cfg.ID)
+		return
+	}
+	k.started = true
+	wg.Add(1)
+	go k.startSenderGo(rand.Int(), wg)
+}
+
+// StopSender finalize sender goroutines
+func (k *Kafka) StopSender() {
+	k.smutex.Lock()
+	started := k.started
+	k.smutex.Unlock()
+	if started {
+		k.chExit <- true
+		return
+	}
+	log.Infof("Can not stop Sender [%s] becaouse of it is already stopped", k.cfg.ID)
+}
+
+// Send enqueues points to be produced
+func (k *Kafka) Send(pts []*Point) {
+	k.iChan <- pts
+}
+
+// TrySend enqueues points without blocking, returns false if the queue is full
+func (k *Kafka) TrySend(pts []*Point) bool {
+	select {
+	case k.iChan <- pts:
+		return true
+	default:
+		k.stats.DropUpdate(int64(len(pts)))
+		return false
+	}
+}
+
+// addPoints converts points to messages on the configured format and appends them to the pending ones
+func (k *Kafka) addPoints(pts []*Point) {
+	for _, p := range pts {
+		var value []byte
+		if k.cfg.Format == "json" {
+			data, err := json.Marshal(&filePoint{Measurement: p.Name, Tags: p.Tags, Fields: p.Fields, Time: p.Time})
+			if err != nil {
+				log.Warnf("Error on convert point %s to json on kafka output %s: %s", p.Name, k.cfg.ID, err)
+				continue
+			}
+			value = data
+		} else {
+			pt, err := ToInfluxPoint(p)
+			if err != nil {
+				log.Warnf("Error on convert point %s to line protocol on kafka output %s: %s", p.Name, k.cfg.ID, err)
+				continue
+			}
+			value = []byte(pt.String())
+		}
+		m := &kafkaMessage{topic: KafkaTopic(k.cfg.Topic, p), value: value, ts: p.Time}
+		if dev, ok := p.Tags[p.Meta.DeviceTag]; ok {
+			m.key = []byte(dev)
+		}
+		k.pending = append(k.pending, m)
+		k.fields += int64(len(p.Fields))
+	}
+}
+
+// flush produces all pending messages, on error undelivered messages are kept to be sent on next flush
+// until kafkaMaxPendingBatches batches are pending
+func (k *Kafka) flush() {
+	if len(k.pending) == 0 {
+		return
+	}
+	bufferPercent := (float32(len(k.iChan)) * 100.0) / float32(k.cfg.BufferSize)
+	start := time.Now()
+	var failed []*kafkaMessage
+	var err error
+	if time.Since(k.lastFail) < TimeWriteRetry*time.Second {
+		failed, err = k.pending, fmt.Errorf("waiting to reconnect")
+	} else {
+		if k.producer == nil {
+			var c *sarama.Config
+			if c, err = kafkaConfig(k.cfg); err != nil {
+				log.Errorf("Error on create config for kafka output %s: %s", k.cfg.ID, err)
+				return
+			}
+			k.producer, err = sarama.NewSyncProducer(kafkaBrokers(k.cfg), c)
+		}
+		if err == nil {
+			failed, err = k.produce(k.pending)
+		} else {
+			failed = k.pending
+		}
+		if len(failed) == len(k.pending) {
+			// cluster unreachable, wait before retry
+			k.lastFail = time.Now()
+		}
+	}
+	elapsed := time.Since(start)
+	if len(failed) > 0 {
+		k.stats.WriteErrUpdate(elapsed, bufferPercent)
+		log.Errorf("ERROR on produce to kafka output %s (%d of %d messages pending): %s", k.cfg.ID, len(failed), len(k.pending), err)
+		k.pending = failed
+		if len(k.pending) > kafkaMaxPendingBatches*k.cfg.BatchSize {
+			log.Errorf("Kafka output %s has too much pending data, dropping %d messages", k.cfg.ID, len(k.pending))
+			k.reset()
+		}
+		return
+	}
+	log.Debugf("OK on produce to kafka output %s (%d messages) | elapsed : %s ", k.cfg.ID, len(k.pending), elapsed.String())
+	k.stats.WriteOkUpdate(int64(len(k.pending)), k.fields, elapsed, bufferPercent)
+	k.reset()
+}
+
+// produce sends the messages waiting for the acknowledges (if RequiredAcks != 0), returns the messages
+// not delivered in the same order
+func (k *Kafka) produce(msgs []*kafkaMessage) ([]*kafkaMessage, error) {
+	pms := make([]*sarama.ProducerMessage, 0, len(msgs))
+	for _, m := range msgs {
+		pm := &sarama.ProducerMessage{Topic: m.topic, Value: sarama.ByteEncoder(m.value), Timestamp: m.ts, Metadata: m}
+		if m.key != nil {
+			pm.Key = sarama.ByteEncoder(m.key)
+		}
+		pms = append(pms, pm)
+	}
+	err := k.producer.SendMessages(pms)
+	if err == nil {
+		return nil, nil
+	}
+	perrs, ok := err.(sarama.ProducerErrors)
+	if !ok || len(perrs) == 0 {
+		return msgs, err
+	}
+	lost := make(map[*kafkaMessage]bool, len(perrs))
+	for _, pe := range perrs {
+		lost[pe.Msg.Metadata.(*kafkaMessage)] = true
+	}
+	failed := make([]*kafkaMessage, 0, len(perrs))
+	for _, m := range msgs {
+		if lost[m] {
+			failed = append(failed, m)
+		}
+	}
+	return failed, fmt.Errorf("topic %s: %s", perrs[0].Msg.Topic, perrs[0].Err)
+}
+
+func (k *Kafka) reset() {
+	k.pending = nil
+	k.fields = 0
+}
+
+func (k *Kafka) startSenderGo(r int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	log.Infof("beginning Kafka Sender thread: [%s]", k.cfg.ID)
+	t := time.NewTicker(time.Duration(k.cfg.FlushInterval) * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			k.flush()
+		case <-k.chExit:
+			// need to flush all data
+			chanlen := len(k.iChan)
+			log.Infof("Flushing %d batches of data in kafka output %s ", chanlen, k.cfg.ID)
+			for i := 0; i < chanlen; i++ {
+				k.addPoints(<-k.iChan)
+			}
+			k.lastFail = time.Time{}
+			k.flush()
+			if k.producer != nil {
+				k.producer.Close()
+				k.producer = nil
+			}
+			log.Infof("EXIT from Kafka sender process for output [%s] ", k.cfg.ID)
+			k.smutex.Lock()
+			k.started = false
+			k.smutex.Unlock()
+			return
+		case pts := <-k.iChan:
+			if pts == nil {
+				continue
+			}
+			k.addPoints(pts)
+			if len(k.pending) >= k.cfg.BatchSize {
+				k.flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/go-cmp/cmp"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/xdg-go/scram"
+)
+
+func Test_kafkaMurmur2(t *testing.T) {
+	// reference values from the Java client org.apache.kafka.common.utils.UtilsTest.testMurmur2
+	cases := []struct {
+		key  string
+		hash int32
+	}{
+		{"21", -973932308},
+		{"foobar", -790332482},
+		{"a-little-bit-long-string", -985981536},
+		{"a-little-bit-longer-string", -1486304829},
+		{"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", -58897971},
+		{"abc", 479470107},
+	}
+	for _, c := range cases {
+		if h := kafkaMurmur2([]byte(c.key)); h != c.hash {
+			t.Errorf("murmur2(%q) = %d, expected %d", c.key, h, c.hash)
+		}
+	}
+}
+
+func Test_kafkaKeyPartition(t *testing.T) {
+	// Utils.toPositive(Utils.murmur2(key)) % numPartitions, toPositive(-973932308) = 1173551340
+	// and toPositive(-790332482) = 1357151166
+	cases := []struct {
+		key        string
+		partitions int
+		partition  int
+	}{
+		{"21", 1, 0},
+		{"21", 3, 1173551340 % 3},
+		{"foobar", 10, 1357151166 % 10},
+		{"abc", 7, 479470107 % 7},
+	}
+	for _, c := range cases {
+		if p := kafkaKeyPartition([]byte(c.key), c.partitions); p != c.partition {
+			t.Errorf("partition of %q with %d partitions = %d, expected %d", c.key, c.partitions, p, c.partition)
+		}
+	}
+}
+
+func Test_kafkaPartitioner(t *testing.T) {
+	p := newKafkaPartitioner("snmp")
+	if !p.RequiresConsistency() {
+		t.Errorf("keyed messages could be sent to other partitions")
+	}
+	part, err := p.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder("foobar")}, 10)
+	if err != nil || part != 1357151166%10 {
+		t.Errorf("partition %d %v, expected %d", part, err, 1357151166%10)
+	}
+	// messages without key are round robin distributed
+	var got []int32
+	for i := 0; i < 4; i++ {
+		part, _ := p.Partition(&sarama.ProducerMessage{}, 3)
+		got = append(got, part)
+	}
+	if !cmp.Equal([]int32{0, 1, 2, 0}, got) {
+		t.Errorf("partitions without key %v", got)
+	}
+}
+
+func Test_kafkaConfig(t *testing.T) {
+	tests := []struct {
+		cfg   config.KafkaCfg
+		scram bool
+		ok    bool
+	}{
+		{cfg: config.KafkaCfg{ClientID: "collector1", Compression: "none", SASLMechanism: "none", Timeout: 5}, ok: true},
+		{cfg: config.KafkaCfg{ClientID: "collector1", Compression: "gzip", RequiredAcks: -1, SASLMechanism: "PLAIN", User: "u", Password: "p"}, ok: true},
+		{cfg: config.KafkaCfg{ClientID: "collector1", Compression: "snappy", SASLMechanism: "SCRAM-SHA-256", User: "u", Password: "p"}, scram: true, ok: true},
+		{cfg: config.KafkaCfg{ClientID: "collector1", SASLMechanism: "SCRAM-SHA-512", User: "u", Password: "p"}, scram: true, ok: true},
+		{cfg: config.KafkaCfg{ClientID: "collector1", SASLMechanism: "GSSAPI", User: "u", Password: "p"}},
+		// sarama needs the user on SASL PLAIN
+		{cfg: config.KafkaCfg{ClientID: "collector1", SASLMechanism: "PLAIN"}},
+	}
+	for _, tt := range tests {
+		c, err := kafkaConfig(&tt.cfg)
+		if err == nil {
+			err = c.Validate()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%+v: error %v", tt.cfg, err)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if c.ClientID != "collector1" || c.Producer.RequiredAcks != sarama.RequiredAcks(tt.cfg.RequiredAcks) || !c.Producer.Return.Successes {
+			t.Errorf("%+v: client id %s acks %d", tt.cfg, c.ClientID, c.Producer.RequiredAcks)
+		}
+		if timeout := time.Duration(tt.cfg.Timeout) * time.Second; tt.cfg.Timeout == 0 && c.Net.DialTimeout != 10*time.Second || tt.cfg.Timeout > 0 && c.Net.DialTimeout != timeout {
+			t.Errorf("%+v: timeout %s", tt.cfg, c.Net.DialTimeout)
+		}
+		if c.Net.SASL.Enable != (tt.cfg.SASLMechanism != "none") || (c.Net.SASL.SCRAMClientGeneratorFunc != nil) != tt.scram {
+			t.Errorf("%+v: SASL %t mechanism %s", tt.cfg, c.Net.SASL.Enable, c.Net.SASL.Mechanism)
+		}
+	}
+	c, _ := kafkaConfig(&config.KafkaCfg{Compression: "snappy"})
+	if c.Producer.Compression != sarama.CompressionSnappy || len(c.ClientID) == 0 {
+		t.Errorf("compression %s client id %q", c.Producer.Compression, c.ClientID)
+	}
+}
+
+func Test_kafkaScramClient(t *testing.T) {
+	for _, hash := range []scram.HashGeneratorFcn{scram.SHA256, scram.SHA512} {
+		cli, _ := hash.NewClient("user", "pencil", "")
+		creds := cli.GetStoredCredentials(scram.KeyFactors{Salt: "QSXCR+Q6sek8bf92", Iters: 4096})
+		srv, _ := hash.NewServer(func(user string) (scram.StoredCredentials, error) { return creds, nil })
+
+		for _, pass := range []string{"pencil", "pen"} {
+			c := &kafkaScramClient{hash: hash}
+			if err := c.Begin("user", pass, ""); err != nil {
+				t.Fatalf("begin: %s", err)
+			}
+			conv := srv.NewConversation()
+			var err error
+			var challenge, resp string
+			// sarama starts the conversation with an empty challenge
+			for steps := 0; !c.Done() && err == nil && steps < 5; steps++ {
+				if resp, err = c.Step(challenge); err != nil || c.Done() {
+					break
+				}
+				challenge, err = conv.Step(resp)
+			}
+			if ok := err == nil && c.Done() && conv.Valid(); ok != (pass == "pencil") {
+				t.Errorf("password %s: conversation done %t valid %t error %v", pass, c.Done(), conv.Valid(), err)
+			}
+		}
+	}
+}
+
+func Test_KafkaMessages(t *testing.T) {
+	pts := testInfluxPoints("if stats", 2)
+	pts[0].Meta.DeviceTag = "device"
+	for _, format := range []string{"line", "json"} {
+		k := NewNotInitKafka(&config.KafkaCfg{ID: "out", Topic: "snmp.{measurement}", Format: format})
+		k.addPoints(pts)
+		if len(k.pending) != 2 || k.fields != 2 {
+			t.Fatalf("%s: %d messages %d fields", format, len(k.pending), k.fields)
+		}
+		m := k.pending[0]
+		if m.topic != "snmp.if_stats" || string(m.key) != "router1" || !m.ts.Equal(pts[0].Time) {
+			t.Errorf("%s: topic %s key %q time %s", format, m.topic, m.key, m.ts)
+		}
+		// points without device tag are not keyed
+		if k.pending[1].key != nil {
+			t.Errorf("%s: key %q without device tag", format, k.pending[1].key)
+		}
+		want := `if\ stats,device=router1 value=0i 1600000000000000000`
+		if format == "json" {
+			var fp filePoint
+			if err := json.Unmarshal(m.value, &fp); err != nil || fp.Measurement != "if stats" || fp.Tags["device"] != "router1" {
+				t.Errorf("json message %s: %v", m.value, err)
+			}
+			continue
+		}
+		if string(m.value) != want {
+			t.Errorf("line message %s, expected %s", m.value, want)
+		}
+	}
+}
+
+// newTestKafkaBroker starts a mock broker leader of partitions of the topic, produce requests are
+// answered with the errors set on the response
+func newTestKafkaBroker(t *testing.T, topic string, partitions int32, produce *sarama.MockProduceResponse) *sarama.MockBroker {
+	b := sarama.NewMockBroker(t, 1)
+	md := sarama.NewMockMetadataResponse(t).SetBroker(b.Addr(), b.BrokerID()).SetController(b.BrokerID())
+	for p := int32(0); p < partitions; p++ {
+		md.SetLeader(topic, p, b.BrokerID())
+	}
+	b.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": md,
+		"ProduceRequest":  produce,
+	})
+	t.Cleanup(b.Close)
+	return b
+}
+
+// testKafkaProduced returns the partitions with messages on the produce requests received
+func testKafkaProduced(b *sarama.MockBroker, topic string) []int32 {
+	seen := make(map[int32]bool)
+	for _, rr := range b.History() {
+		if res, ok := rr.Response.(*sarama.ProduceResponse); ok {
+			for p := range res.Blocks[topic] {
+				seen[p] = true
+			}
+		}
+	}
+	var parts []int32
+	for p := range seen {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	return parts
+}
+
+// testKafkaDevicePoints returns a point for each device, keyed by the device name
+func testKafkaDevicePoints(devices ...string) []*Point {
+	var pts []*Point
+	for i, d := range devices {
+		pts = append(pts, &Point{
+			Name:   "m",
+			Tags:   map[string]string{"device": d},
+			Fields: map[string]interface{}{"value": int64(i)},
+			Time:   time.Unix(1600000000, 0),
+			Meta:   PointMeta{DeviceTag: "device"},
+		})
+	}
+	return pts
+}
+
+func testKafkaOutput(t *testing.T, brokers string) *Kafka {
+	k := NewNotInitKafka(&config.KafkaCfg{ID: "out", Brokers: brokers, Topic: "snmp", RequiredAcks: 1, Timeout: 2})
+	k.Init()
+	t.Cleanup(func() {
+		if k.producer != nil {
+			k.producer.Close()
+		}
+		k.End()
+	})
+	return k
+}
+
+func Test_KafkaProduce(t *testing.T) {
+	b := newTestKafkaBroker(t, "snmp", 4, sarama.NewMockProduceResponse(t))
+	k := testKafkaOutput(t, b.Addr())
+	devices := []string{"router1", "router2", "switch1", "switch2", "fw1", "fw2"}
+	want := make(map[int32]bool)
+	for _, d := range devices {
+		want[int32(kafkaKeyPartition([]byte(d), 4))] = true
+	}
+	k.addPoints(testKafkaDevicePoints(devices...))
+	k.flush()
+	if len(k.pending) != 0 {
+		t.Fatalf("%d messages pending", len(k.pending))
+	}
+	if st := k.GetResetStats(); st.PSent != 6 || st.FieldSent != 6 || st.WriteErrors != 0 {
+		t.Errorf("stats %d points %d fields %d errors", st.PSent, st.FieldSent, st.WriteErrors)
+	}
+	// the messages are sent to the partition chosen by the device
+	var parts []int32
+	for p := range want {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	if got := testKafkaProduced(b, "snmp"); !cmp.Equal(parts, got) {
+		t.Errorf("partitions produced %v, expected %v", got, parts)
+	}
+}
+
+func Test_KafkaProduceErrors(t *testing.T) {
+	devices := []string{"router1", "router2", "switch1", "switch2", "fw1", "fw2"}
+	failing := int32(kafkaKeyPartition([]byte("router1"), 4))
+	b := newTestKafkaBroker(t, "snmp", 4, sarama.NewMockProduceResponse(t).SetError("snmp", failing, sarama.ErrNotEnoughReplicas))
+	k := testKafkaOutput(t, b.Addr())
+	k.producer, _ = sarama.NewSyncProducer([]string{b.Addr()}, testKafkaFastRetry(t, k.cfg))
+	k.addPoints(testKafkaDevicePoints(devices...))
+	k.flush()
+
+	// only the messages of the failed partition are kept, in the same order
+	var want, got []string
+	for _, d := range devices {
+		if int32(kafkaKeyPartition([]byte(d), 4)) == failing {
+			want = append(want, d)
+		}
+	}
+	for _, m := range k.pending {
+		got = append(got, string(m.key))
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("pending messages %v, expected %v", got, want)
+	}
+	if st := k.GetResetStats(); st.WriteErrors != 1 || st.PSent != 0 {
+		t.Errorf("stats %d points %d errors", st.PSent, st.WriteErrors)
+	}
+	// partial failures do not delay the next flush
+	if !k.lastFail.IsZero() {
+		t.Errorf("retry delayed after a partial failure")
+	}
+}
+
+// testKafkaFastRetry returns the output config with short retry backoffs
+func testKafkaFastRetry(t *testing.T, cfg *config.KafkaCfg) *sarama.Config {
+	c, err := kafkaConfig(cfg)
+	if err != nil {
+		t.Fatalf("config: %s", err)
+	}
+	c.Producer.Retry.Backoff = 10 * time.Millisecond
+	c.Metadata.Retry.Backoff = 10 * time.Millisecond
+	return c
+}
+
+func Test_KafkaUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	k := testKafkaOutput(t, addr)
+	k.cfg.BatchSize = 1
+	k.addPoints(testKafkaDevicePoints("router1", "router2"))
+	k.flush()
+	if len(k.pending) != 2 || k.producer != nil || k.lastFail.IsZero() {
+		t.Fatalf("%d pending messages, producer %v after a connection error", len(k.pending), k.producer)
+	}
+	// the reconnection is delayed TimeWriteRetry seconds
+	lastFail := k.lastFail
+	k.flush()
+	if !k.lastFail.Equal(lastFail) {
+		t.Errorf("reconnected before TimeWriteRetry")
+	}
+	// pending messages are discarded over kafkaMaxPendingBatches batches
+	for i := 0; i < kafkaMaxPendingBatches; i++ {
+		k.addPoints(testKafkaDevicePoints("router1"))
+	}
+	k.flush()
+	if len(k.pending) != 0 {
+		t.Errorf("%d pending messages over the limit", len(k.pending))
+	}
+	if st := k.GetResetStats(); st.WriteErrors != 3 {
+		t.Errorf("%d write errors, expected 3", st.WriteErrors)
+	}
+}
+
+func Test_PingKafka(t *testing.T) {
+	b := newTestKafkaBroker(t, "snmp", 3, sarama.NewMockProduceResponse(t))
+	tests := []struct {
+		topic string
+		msg   string
+		ok    bool
+	}{
+		{"snmp", "Connected to cluster (1 brokers), topic snmp with 3 partitions", true},
+		// topics with placeholders are not checked
+		{"snmp.{measurement}", "Connected to cluster (1 brokers)", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		_, msg, err := PingKafka(&config.KafkaCfg{ID: "out", Brokers: "  ," + b.Addr(), Topic: tt.topic, Timeout: 2})
+		if (err == nil) != tt.ok || msg != tt.msg {
+			t.Errorf("topic %s: %q %v, expected %q", tt.topic, msg, err, tt.msg)
+		}
+	}
+}
+
+func Test_KafkaSASLPlain(t *testing.T) {
+	b := sarama.NewMockBroker(t, 1)
+	t.Cleanup(b.Close)
+	b.SetHandlerByMap(map[string]sarama.MockResponse{
+		"SaslHandshakeRequest":    sarama.NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{"PLAIN"}),
+		"SaslAuthenticateRequest": sarama.NewMockSaslAuthenticateResponse(t),
+		"MetadataRequest":         sarama.NewMockMetadataResponse(t).SetBroker(b.Addr(), b.BrokerID()).SetLeader("snmp", 0, b.BrokerID()),
+	})
+	_, msg, err := PingKafka(&config.KafkaCfg{ID: "out", Brokers: b.Addr(), Topic: "snmp", SASLMechanism: "PLAIN", User: "user", Password: "pass", Timeout: 2})
+	if err != nil {
+		t.Fatalf("ping: %s", err)
+	}
+	if msg != "Connected to cluster (1 brokers), topic snmp with 1 partitions" {
+		t.Errorf("ping message %q", msg)
+	}
+	var auth []byte
+	for _, rr := range b.History() {
+		if req, ok := rr.Request.(*sarama.SaslAuthenticateRequest); ok {
+			auth = req.SaslAuthBytes
+		}
+	}
+	if !bytes.Equal([]byte("\x00user\x00pass"), auth) {
+		t.Errorf("SASL PLAIN token %q", auth)
+	}
+}
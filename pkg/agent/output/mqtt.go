@@ -0,0 +1,347 @@
+package output
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/utils"
+)
+
+// DefaultMqttTopicTemplate is used when no TopicTemplate has been configured
+const DefaultMqttTopicTemplate = "snmp/{device}/{measurement}/{index}"
+
+var mqttPlaceholder = regexp.MustCompile(`\{(device|measurement|index|tag:[^}]+)\}`)
+
+// Mqtt publishes each measurement row (point) as a JSON message to a MQTT broker,
+// the topic is built from the TopicTemplate. Batches that could not be published
+// are discarded, MQTT consumers usually only need the last values.
+type Mqtt struct {
+	cfg         *config.MqttCfg
+	stats       Stats
+	initialized bool
+	imutex      sync.Mutex
+	started     bool
+	smutex      sync.Mutex
+
+	iChan  chan []*Point
+	chExit chan bool
+	// only accessed from the sender goroutine
+	client   *mqttClient
+	lastDial time.Time
+}
+
+func init() {
+	Register("mqtt", func(dbc *config.DBConfig) map[string]Output {
+		outs := make(map[string]Output)
+		for k, c := range dbc.Mqtt {
+			outs[k] = NewNotInitMqtt(c)
+		}
+		return outs
+	})
+}
+
+// NewNotInitMqtt Create Object in memory but not initialized until ready connection needed
+func NewNotInitMqtt(c *config.MqttCfg) *Mqtt {
+	return &Mqtt{cfg: c}
+}
+
+// mqttOptions builds the connection options from the broker config
+func mqttOptions(cfg *config.MqttCfg) (mqttConnOptions, error) {
+	o := mqttConnOptions{
+		Addr:      net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Version:   mqttV311,
+		ClientID:  cfg.ClientID,
+		User:      cfg.User,
+		Password:  cfg.Password,
+		KeepAlive: time.Duration(cfg.KeepAlive) * time.Second,
+		Timeout:   time.Duration(cfg.Timeout) * time.Second,
+	}
+	if cfg.ProtocolVersion == "5" {
+		o.Version = mqttV5
+	}
+	if len(o.ClientID) == 0 {
+		hostname, _ := os.Hostname()
+		o.ClientID = "snmpcollector-" + hostname + "-" + cfg.ID
+	}
+	if cfg.EnableSSL {
+		var err error
+		o.TLSConfig, err = utils.GetTLSConfig(cfg.SSLCert, cfg.SSLKey, cfg.SSLCA, cfg.InsecureSkipVerify)
+		if err != nil {
+			return o, err
+		}
+		if o.TLSConfig == nil {
+			o.TLSConfig = &tls.Config{}
+		}
+		if len(o.TLSConfig.ServerName) == 0 {
+			o.TLSConfig.ServerName = cfg.Host
+		}
+	}
+	return o, nil
+}
+
+// PingMqtt checks a MQTT session can be established with the broker
+func PingMqtt(cfg *config.MqttCfg) (time.Duration, string, error) {
+	start := time.Now()
+	o, err := mqttOptions(cfg)
+	if err != nil {
+		log.Errorf("Error on create TLS config for MQTT broker %s: %s", cfg.ID, err)
+		return 0, "", err
+	}
+	c, err := mqttDial(o)
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Errorf("Error on connect to MQTT broker %s: %s", o.Addr, err)
+		return elapsed, "", err
+	}
+	c.Close()
+	return elapsed, fmt.Sprintf("Connected to %s (MQTT %s) as %s", o.Addr, cfg.ProtocolVersion, o.ClientID), nil
+}
+
+// ID return the MQTT output ID
+func (m *Mqtt) ID() string {
+	return m.cfg.ID
+}
+
+// GetResetStats return output stats and reset its counters
+func (m *Mqtt) GetResetStats() *Stats {
+	return m.stats.GetResetStats()
+}
+
+// Init initializes runtime info
+func (m *Mqtt) Init() {
+	m.imutex.Lock()
+	defer m.imutex.Unlock()
+	if m.initialized {
+		log.Infof("Sender thread to : %s  already Initialized (skipping Initialization)", m.cfg.ID)
+		return
+	}
+	log.Infof("Initializing MQTT output with id = [ %s ] to %s:%d", m.cfg.ID, m.cfg.Host, m.cfg.Port)
+	if len(m.cfg.TopicTemplate) == 0 {
+		m.cfg.TopicTemplate = DefaultMqttTopicTemplate
+	}
+	if len(m.cfg.ProtocolVersion) == 0 {
+		m.cfg.ProtocolVersion = "3.1.1"
+	}
+	if m.cfg.BufferSize <= 0 {
+		m.cfg.BufferSize = 65535
+	}
+	m.iChan = make(chan []*Point, m.cfg.BufferSize)
+	m.chExit = make(chan bool)
+	m.initialized = true
+}
+
+// End releases runtime resources
+func (m *Mqtt) End() {
+	m.imutex.Lock()
+	defer m.imutex.Unlock()
+	if !m.initialized {
+		return
+	}
+	close(m.iChan)
+	close(m.chExit)
+	m.initialized = false
+}
+
+// StartSender begins sender loop
+func (m *Mqtt) StartSender(wg *sync.WaitGroup) {
+	m.smutex.Lock()
+	defer m.smutex.Unlock()
+	if m.started {
+		log.Infof("Sender thread to : %s  already started (skipping Goroutine creation)", m.cfg.ID)
+		return
+	}
+	m.started = true
+	wg.Add(1)
+	go m.startSenderGo(rand.Int(), wg)
+}
+
+// StopSender finalize sender goroutines
+func (m *Mqtt) StopSender() {
+	m.smutex.Lock()
+	started := m.started
+	m.smutex.Unlock()
+	if started {
+		m.chExit <- true
+		return
+	}
+	log.Infof("Can not stop Sender [%s] becaouse of it is already stopped", m.cfg.ID)
+}
+
+// Send enqueues points to be published
+func (m *Mqtt) Send(pts []*Point) {
+	m.iChan <- pts
+}
+
+// TrySend enqueues points without blocking, returns false if the queue is full
+func (m *Mqtt) TrySend(pts []*Point) bool {
+	select {
+	case m.iChan <- pts:
+		return true
+	default:
+		m.stats.DropUpdate(int64(len(pts)))
+		return false
+	}
+}
+
+// MqttTopic builds the topic for a point from the template, placeholders are replaced by its values
+// (with topic separators and wildcards replaced by "_") and empty topic levels are removed
+func MqttTopic(tmpl string, p *Point) string {
+	topic := mqttPlaceholder.ReplaceAllStringFunc(tmpl, func(ph string) string {
+		name := ph[1 : len(ph)-1]
+		switch {
+		case name == "device":
+			return mqttTopicEscape(p.Tags[p.Meta.DeviceTag])
+		case name == "measurement":
+			return mqttTopicEscape(p.Name)
+		case name == "index":
+			idx := make([]string, 0, len(p.Meta.IndexTags))
+			for _, t := range p.Meta.IndexTags {
+				idx = append(idx, mqttTopicEscape(p.Tags[t]))
+			}
+			return strings.Join(idx, "/")
+		default:
+			return mqttTopicEscape(p.Tags[strings.TrimPrefix(name, "tag:")])
+		}
+	})
+	levels := strings.Split(topic, "/")
+	res := levels[:0]
+	for _, l := range levels {
+		if len(l) > 0 {
+			res = append(res, l)
+		}
+	}
+	return strings.Join(res, "/")
+}
+
+// mqttTopicEscape replaces the characters not allowed in a topic level
+func mqttTopicEscape(s string) string {
+	if !strings.ContainsAny(s, "/+#\x00") {
+		return s
+	}
+	b := []byte(s)
+	for i, ch := range b {
+		switch ch {
+		case '/', '+', '#', 0:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// messages converts points to JSON messages, returns also the number of fields
+func (m *Mqtt) messages(pts []*Point) ([]*mqttMessage, int64) {
+	msgs := make([]*mqttMessage, 0, len(pts))
+	var fields int64
+	for _, p := range pts {
+		data, err := json.Marshal(&filePoint{Measurement: p.Name, Tags: p.Tags, Fields: p.Fields, Time: p.Time})
+		if err != nil {
+			log.Warnf("Error on convert point %s to json on MQTT output %s: %s", p.Name, m.cfg.ID, err)
+			continue
+		}
+		msgs = append(msgs, &mqttMessage{topic: MqttTopic(m.cfg.TopicTemplate, p), payload: data})
+		fields += int64(len(p.Fields))
+	}
+	return msgs, fields
+}
+
+// connect opens a new session with the broker, retries are delayed TimeWriteRetry seconds
+func (m *Mqtt) connect() error {
+	if m.client != nil {
+		if m.client.Alive() {
+			return nil
+		}
+		log.Warnf("MQTT output %s connection lost: %s", m.cfg.ID, m.client.Err())
+		m.disconnect()
+	}
+	if time.Since(m.lastDial) < TimeWriteRetry*time.Second {
+		return fmt.Errorf("waiting to reconnect")
+	}
+	m.lastDial = time.Now()
+	o, err := mqttOptions(m.cfg)
+	if err != nil {
+		return err
+	}
+	c, err := mqttDial(o)
+	if err != nil {
+		return err
+	}
+	log.Infof("Connected to MQTT broker %s for output %s as %s", o.Addr, m.cfg.ID, o.ClientID)
+	m.client = c
+	return nil
+}
+
+func (m *Mqtt) disconnect() {
+	if m.client == nil {
+		return
+	}
+	m.client.Close()
+	m.client = nil
+}
+
+// publish sends points to the broker, on error the connection is closed and points discarded
+func (m *Mqtt) publish(pts []*Point) {
+	msgs, fields := m.messages(pts)
+	if len(msgs) == 0 {
+		return
+	}
+	bufferPercent := (float32(len(m.iChan)) * 100.0) / float32(m.cfg.BufferSize)
+	start := time.Now()
+	var lost int
+	err := m.connect()
+	if err == nil {
+		lost, err = m.client.Publish(msgs, byte(m.cfg.QoS), m.cfg.Retain)
+		if _, rejected := err.(*mqttRejectError); err != nil && !rejected {
+			// connection lost or broker not answering
+			m.disconnect()
+		}
+	} else {
+		lost = len(msgs)
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		m.stats.WriteErrUpdate(elapsed, bufferPercent)
+		log.Errorf("ERROR on publish to MQTT output %s (%d of %d messages lost): %s", m.cfg.ID, lost, len(msgs), err)
+		return
+	}
+	log.Debugf("OK on publish to MQTT output %s (%d messages) | elapsed : %s ", m.cfg.ID, len(msgs), elapsed.String())
+	m.stats.WriteOkUpdate(int64(len(msgs)), fields, elapsed, bufferPercent)
+}
+
+func (m *Mqtt) startSenderGo(r int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	log.Infof("beginning MQTT Sender thread: [%s]", m.cfg.ID)
+	for {
+		select {
+		case <-m.chExit:
+			// need to flush all data
+			chanlen := len(m.iChan)
+			log.Infof("Flushing %d batches of data in MQTT output %s ", chanlen, m.cfg.ID)
+			m.lastDial = time.Time{}
+			for i := 0; i < chanlen; i++ {
+				m.publish(<-m.iChan)
+			}
+			m.disconnect()
+			log.Infof("EXIT from MQTT sender process for output [%s] ", m.cfg.ID)
+			m.smutex.Lock()
+			m.started = false
+			m.smutex.Unlock()
+			return
+		case pts := <-m.iChan:
+			if pts == nil {
+				continue
+			}
+			m.publish(pts)
+		}
+	}
+}
@@ -0,0 +1,773 @@
+package output
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func init() {
+	l := logrus.New()
+	l.Level = logrus.DebugLevel
+	SetLogger(l)
+}
+
+//--------------------------------------------------------------------
+// MQTT broker stand-in, accepts sessions and acknowledges publishes
+// following the QoS 1/2 flows
+//--------------------------------------------------------------------
+
+// MQTT control packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttPubrec     = 5
+	mqttPubrel     = 6
+	mqttPubcomp    = 7
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+// mqttPacket a received control packet
+type mqttPacket struct {
+	ptype byte
+	flags byte
+	body  []byte
+}
+
+func mqttAppendVarInt(b []byte, n int) []byte {
+	for {
+		d := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// mqttReadPacket reads a complete control packet
+func mqttReadPacket(r *bufio.Reader) (mqttPacket, error) {
+	h, err := r.ReadByte()
+	if err != nil {
+		return mqttPacket{}, err
+	}
+	var n, mult int = 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return mqttPacket{}, fmt.Errorf("malformed remaining length")
+		}
+		d, err := r.ReadByte()
+		if err != nil {
+			return mqttPacket{}, err
+		}
+		n += int(d&0x7f) * mult
+		if d&0x80 == 0 {
+			break
+		}
+		mult *= 128
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return mqttPacket{}, err
+	}
+	return mqttPacket{ptype: h >> 4, flags: h & 0x0f, body: body}, nil
+}
+
+// testMqttConnect the CONNECT fields received by the broker stand-in
+type testMqttConnect struct {
+	protocol  string
+	level     byte
+	flags     byte
+	keepAlive int
+	clientID  string
+	user      string
+	password  string
+}
+
+// testMqttPublish a PUBLISH received by the broker stand-in
+type testMqttPublish struct {
+	topic   string
+	qos     byte
+	retain  bool
+	payload string
+}
+
+type testMqttBroker struct {
+	t  *testing.T
+	ln net.Listener
+	// connack return (3.1.1) or reason (5.0) code sent to all sessions
+	connack byte
+	// reason returns the reason code of the acknowledge of a topic (5.0 only)
+	reason func(topic string) byte
+	// noAck disables publish acknowledges
+	noAck bool
+
+	mutex     sync.Mutex
+	conns     []net.Conn
+	connects  []testMqttConnect
+	published []testMqttPublish
+	pubrels   []uint16
+	pings     int
+}
+
+func newTestMqttBroker(t *testing.T) *testMqttBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	b := &testMqttBroker{t: t, ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			b.mutex.Lock()
+			b.conns = append(b.conns, conn)
+			b.mutex.Unlock()
+			go b.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		b.closeConns()
+	})
+	return b
+}
+
+func (b *testMqttBroker) addr() string {
+	return b.ln.Addr().String()
+}
+
+// closeConns drops all the sessions without DISCONNECT
+func (b *testMqttBroker) closeConns() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, c := range b.conns {
+		c.Close()
+	}
+	b.conns = nil
+}
+
+func testMqttString(b []byte) (string, []byte) {
+	if len(b) < 2 {
+		return "", nil
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil
+	}
+	return string(b[2 : 2+n]), b[2+n:]
+}
+
+func testMqttParseConnect(body []byte) testMqttConnect {
+	var c testMqttConnect
+	c.protocol, body = testMqttString(body)
+	if len(body) < 4 {
+		return c
+	}
+	c.level, c.flags = body[0], body[1]
+	c.keepAlive = int(binary.BigEndian.Uint16(body[2:]))
+	body = body[4:]
+	if c.level == mqttV5 && len(body) > 0 {
+		body = body[1+int(body[0]):] // properties
+	}
+	c.clientID, body = testMqttString(body)
+	if c.flags&0x80 != 0 {
+		c.user, body = testMqttString(body)
+	}
+	if c.flags&0x40 != 0 {
+		c.password, _ = testMqttString(body)
+	}
+	return c
+}
+
+func testMqttWrite(w *bufio.Writer, ptype byte, body []byte) error {
+	w.Write(mqttAppendVarInt([]byte{ptype << 4}, len(body)))
+	w.Write(body)
+	return w.Flush()
+}
+
+func (b *testMqttBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	p, err := mqttReadPacket(r)
+	if err != nil {
+		return
+	}
+	if p.ptype != mqttConnect {
+		b.t.Errorf("first packet type %d, expected CONNECT", p.ptype)
+		return
+	}
+	c := testMqttParseConnect(p.body)
+	b.mutex.Lock()
+	b.connects = append(b.connects, c)
+	b.mutex.Unlock()
+	connack := []byte{0, b.connack}
+	if c.level == mqttV5 {
+		connack = append(connack, 0) // no properties
+	}
+	if testMqttWrite(w, mqttConnack, connack) != nil || b.connack != 0 {
+		return
+	}
+
+	for {
+		p, err := mqttReadPacket(r)
+		if err != nil {
+			return
+		}
+		switch p.ptype {
+		case mqttPublish:
+			m := testMqttPublish{qos: (p.flags >> 1) & 0x03, retain: p.flags&0x01 != 0}
+			body := p.body
+			m.topic, body = testMqttString(body)
+			var id []byte
+			if m.qos > 0 {
+				id, body = body[:2], body[2:]
+			}
+			if c.level == mqttV5 {
+				body = body[1+int(body[0]):]
+			}
+			m.payload = string(body)
+			b.mutex.Lock()
+			b.published = append(b.published, m)
+			b.mutex.Unlock()
+			if m.qos == 0 || b.noAck {
+				continue
+			}
+			ack := append([]byte(nil), id...)
+			if b.reason != nil && c.level == mqttV5 {
+				if reason := b.reason(m.topic); reason != 0 {
+					ack = append(ack, reason, 0)
+				}
+			}
+			ptype := byte(mqttPuback)
+			if m.qos == 2 {
+				ptype = mqttPubrec
+			}
+			if testMqttWrite(w, ptype, ack) != nil {
+				return
+			}
+		case mqttPubrel:
+			if p.flags != 0x02 {
+				b.t.Errorf("PUBREL with flags 0x%02x, expected 0x02", p.flags)
+			}
+			b.mutex.Lock()
+			b.pubrels = append(b.pubrels, binary.BigEndian.Uint16(p.body))
+			b.mutex.Unlock()
+			if testMqttWrite(w, mqttPubcomp, p.body[:2]) != nil {
+				return
+			}
+		case mqttPingreq:
+			b.mutex.Lock()
+			b.pings++
+			b.mutex.Unlock()
+			if testMqttWrite(w, mqttPingresp, nil) != nil {
+				return
+			}
+		case mqttDisconnect:
+			return
+		default:
+			b.t.Errorf("unexpected packet type %d", p.ptype)
+		}
+	}
+}
+
+// state returns a copy of the received connects, publishes, PUBREL ids and pings
+func (b *testMqttBroker) state() ([]testMqttConnect, []testMqttPublish, []uint16, int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]testMqttConnect(nil), b.connects...), append([]testMqttPublish(nil), b.published...),
+		append([]uint16(nil), b.pubrels...), b.pings
+}
+
+// waitFor polls cond until it is true or timeout
+func testMqttWaitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testMqttOptions(b *testMqttBroker, version byte) mqttConnOptions {
+	return mqttConnOptions{
+		Addr:      b.addr(),
+		Version:   version,
+		ClientID:  "snmpcollector-test",
+		KeepAlive: 30 * time.Second,
+		Timeout:   2 * time.Second,
+	}
+}
+
+// testMqttIndex returns the message number from its topic
+func testMqttIndex(topic string) int {
+	i, _ := strconv.Atoi(topic[strings.LastIndex(topic, "/")+1:])
+	return i
+}
+
+func testMqttMessages(n int) []*mqttMessage {
+	msgs := make([]*mqttMessage, n)
+	for i := range msgs {
+		msgs[i] = &mqttMessage{topic: "snmp/dev/m/" + strconv.Itoa(i), payload: []byte(strconv.Itoa(i))}
+	}
+	return msgs
+}
+
+//--------------------------------------------------------------------
+// Session
+//--------------------------------------------------------------------
+
+func Test_mqttDialConnect(t *testing.T) {
+	for _, version := range []byte{mqttV311, mqttV5} {
+		b := newTestMqttBroker(t)
+		o := testMqttOptions(b, version)
+		o.User = "user"
+		o.Password = "secret"
+		c, err := mqttDial(o)
+		if err != nil {
+			t.Fatalf("version %d: dial: %s", version, err)
+		}
+		c.Close()
+		connects, _, _, _ := b.state()
+		want := []testMqttConnect{{
+			protocol:  "MQTT",
+			level:     version,
+			flags:     0xc2, // user, password and clean session
+			keepAlive: 30,
+			clientID:  "snmpcollector-test",
+			user:      "user",
+			password:  "secret",
+		}}
+		if diff := cmp.Diff(want, connects, cmp.AllowUnexported(testMqttConnect{})); diff != "" {
+			t.Errorf("version %d: CONNECT mismatch (-want +got):\n%s", version, diff)
+		}
+	}
+}
+
+func Test_mqttDialConnack(t *testing.T) {
+	tests := []struct {
+		version byte
+		code    byte
+		want    string
+	}{
+		{mqttV311, 1, "connection refused: unacceptable protocol version"},
+		{mqttV311, 2, "connection refused: identifier rejected"},
+		{mqttV311, 3, "connection refused: server unavailable"},
+		{mqttV311, 4, "connection refused: bad user name or password"},
+		{mqttV311, 5, "connection refused: not authorized"},
+		{mqttV5, 0x84, "connection refused: unsupported protocol version"},
+		{mqttV5, 0x85, "connection refused: client identifier not valid"},
+		{mqttV5, 0x86, "connection refused: bad user name or password"},
+		{mqttV5, 0x87, "connection refused: not authorized"},
+		{mqttV5, 0x88, "connection refused: server unavailable"},
+		{mqttV5, 0x80, "connection refused: code 0x80"},
+	}
+	for _, tt := range tests {
+		b := newTestMqttBroker(t)
+		b.connack = tt.code
+		c, err := mqttDial(testMqttOptions(b, tt.version))
+		if err == nil {
+			c.Close()
+			t.Errorf("version %d code 0x%02x: connected, expected error", tt.version, tt.code)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("version %d code 0x%02x: error %q, expected %q", tt.version, tt.code, err, tt.want)
+		}
+	}
+}
+
+func Test_mqttDialNoConnack(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply []byte
+	}{
+		{"PINGRESP instead of CONNACK", []byte{mqttPingresp << 4, 0}},
+		{"no answer", nil},
+	}
+	for _, version := range []byte{mqttV311, mqttV5} {
+		for _, tt := range tests {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listen: %s", err)
+			}
+			go func(reply []byte) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				if reply != nil {
+					conn.Write(reply)
+				}
+				time.Sleep(time.Second)
+			}(tt.reply)
+			o := mqttConnOptions{Addr: ln.Addr().String(), Version: version, ClientID: "test", Timeout: 200 * time.Millisecond}
+			start := time.Now()
+			c, err := mqttDial(o)
+			if err == nil {
+				c.Close()
+				t.Errorf("version %d %s: connected, expected error", version, tt.name)
+			}
+			if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+				t.Errorf("version %d %s: dial returned after %s, expected timeout", version, tt.name, elapsed)
+			}
+			ln.Close()
+		}
+	}
+}
+
+//--------------------------------------------------------------------
+// Publish
+//--------------------------------------------------------------------
+
+func Test_mqttPublish(t *testing.T) {
+	for _, version := range []byte{mqttV311, mqttV5} {
+		for _, qos := range []byte{0, 1, 2} {
+			// more messages than the max in flight
+			n := mqttMaxInflight*2 + 10
+			b := newTestMqttBroker(t)
+			c, err := mqttDial(testMqttOptions(b, version))
+			if err != nil {
+				t.Fatalf("dial: %s", err)
+			}
+			msgs := testMqttMessages(n)
+			lost, err := c.Publish(msgs, qos, true)
+			if err != nil || lost != 0 {
+				t.Fatalf("version %d qos %d: %d messages lost: %v", version, qos, lost, err)
+			}
+			testMqttWaitFor(t, "messages", func() bool {
+				_, published, _, _ := b.state()
+				return len(published) == n
+			})
+			c.Close()
+
+			_, published, pubrels, _ := b.state()
+			if version == mqttV5 && qos > 0 {
+				// in flight messages are published concurrently
+				sort.Slice(published, func(i, j int) bool {
+					return testMqttIndex(published[i].topic) < testMqttIndex(published[j].topic)
+				})
+			}
+			for i, m := range published {
+				want := testMqttPublish{topic: msgs[i].topic, qos: qos, retain: true, payload: string(msgs[i].payload)}
+				if m != want {
+					t.Fatalf("version %d qos %d: message %d %+v, expected %+v", version, qos, i, m, want)
+				}
+			}
+			// exactly once: a PUBREL for each PUBREC
+			wantRels := 0
+			if qos == 2 {
+				wantRels = n
+			}
+			if len(pubrels) != wantRels {
+				t.Errorf("version %d qos %d: %d PUBREL, expected %d", version, qos, len(pubrels), wantRels)
+			}
+		}
+	}
+}
+
+func Test_mqttPublishRejected(t *testing.T) {
+	for _, qos := range []byte{1, 2} {
+		b := newTestMqttBroker(t)
+		b.reason = func(topic string) byte {
+			if strings.HasSuffix(topic, "/1") || strings.HasSuffix(topic, "/3") {
+				return 0x87 // not authorized
+			}
+			return 0
+		}
+		c, err := mqttDial(testMqttOptions(b, mqttV5))
+		if err != nil {
+			t.Fatalf("dial: %s", err)
+		}
+		lost, err := c.Publish(testMqttMessages(5), qos, false)
+		rerr, ok := err.(*mqttRejectError)
+		if !ok || rerr.reason != 0x87 {
+			t.Errorf("qos %d: error %v, expected reject reason 0x87", qos, err)
+		}
+		if lost != 2 {
+			t.Errorf("qos %d: %d messages lost, expected 2", qos, lost)
+		}
+		// the session is still usable
+		if !c.Alive() {
+			t.Errorf("qos %d: connection closed after reject", qos)
+		}
+		if lost, err := c.Publish(testMqttMessages(1), qos, false); err != nil || lost != 0 {
+			t.Errorf("qos %d: publish after reject: %d lost, %v", qos, lost, err)
+		}
+		c.Close()
+	}
+}
+
+func Test_mqttPublishAckTimeout(t *testing.T) {
+	for _, version := range []byte{mqttV311, mqttV5} {
+		b := newTestMqttBroker(t)
+		b.noAck = true
+		o := testMqttOptions(b, version)
+		o.Timeout = 200 * time.Millisecond
+		c, err := mqttDial(o)
+		if err != nil {
+			t.Fatalf("version %d: dial: %s", version, err)
+		}
+		lost, err := c.Publish(testMqttMessages(3), 1, false)
+		if err == nil || err.Error() != "timeout waiting acknowledge for 3 messages" {
+			t.Errorf("version %d: error %v, expected acknowledge timeout", version, err)
+		}
+		if lost != 3 {
+			t.Errorf("version %d: %d messages lost, expected 3", version, lost)
+		}
+		c.Close()
+	}
+}
+
+func Test_mqttBrokerDisconnect(t *testing.T) {
+	tests := []struct {
+		version byte
+		// DISCONNECT body, the connection is just closed if nil (3.1.1 brokers don't send DISCONNECT)
+		body []byte
+		want string
+	}{
+		{mqttV311, nil, ""},
+		{mqttV5, nil, ""},
+		{mqttV5, []byte{0x8b, 0}, "disconnected by the broker, reason code 0x8b"},
+	}
+	for _, tt := range tests {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %s", err)
+		}
+		go func(body []byte) {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			w := bufio.NewWriter(conn)
+			p, _ := mqttReadPacket(r)
+			connack := []byte{0, 0}
+			if testMqttParseConnect(p.body).level == mqttV5 {
+				connack = append(connack, 0)
+			}
+			testMqttWrite(w, mqttConnack, connack)
+			if body != nil {
+				// server shutting down
+				testMqttWrite(w, mqttDisconnect, body)
+				time.Sleep(time.Second)
+			}
+		}(tt.body)
+		c, err := mqttDial(mqttConnOptions{Addr: ln.Addr().String(), Version: tt.version, ClientID: "test", Timeout: time.Second})
+		if err != nil {
+			t.Fatalf("dial: %s", err)
+		}
+		testMqttWaitFor(t, "disconnect", func() bool { return !c.Alive() })
+		if err := c.Err(); err == nil || (len(tt.want) > 0 && err.Error() != tt.want) {
+			t.Errorf("version %d: error %v, expected %q", tt.version, err, tt.want)
+		}
+		// publish fails once the connection has been closed
+		if lost, err := c.Publish(testMqttMessages(2), 1, false); err == nil || lost != 2 {
+			t.Errorf("version %d: publish on closed connection: %d lost, %v", tt.version, lost, err)
+		}
+		c.Close()
+		ln.Close()
+	}
+}
+
+//--------------------------------------------------------------------
+// Output: keep alive and reconnect
+//--------------------------------------------------------------------
+
+func testMqttOutput(b *testMqttBroker, version string) *Mqtt {
+	host, port, _ := net.SplitHostPort(b.addr())
+	p, _ := strconv.Atoi(port)
+	m := NewNotInitMqtt(&config.MqttCfg{
+		ID:              "test",
+		Host:            host,
+		Port:            p,
+		ProtocolVersion: version,
+		QoS:             1,
+		KeepAlive:       2,
+		Timeout:         2,
+	})
+	m.Init()
+	return m
+}
+
+func testMqttPoints(n int) []*Point {
+	pts := make([]*Point, n)
+	for i := range pts {
+		pts[i] = &Point{
+			Name:   "ifstats",
+			Tags:   map[string]string{"device": "router1", "ifName": "eth" + strconv.Itoa(i)},
+			Fields: map[string]interface{}{"ifHCInOctets": int64(i)},
+			Time:   time.Unix(1600000000, 0),
+			Meta:   PointMeta{DeviceTag: "device", IndexTags: []string{"ifName"}},
+		}
+	}
+	return pts
+}
+
+func Test_MqttKeepAlive(t *testing.T) {
+	for _, version := range []string{"3.1.1", "5"} {
+		// pings are sent by the client library on idle connections
+		b := newTestMqttBroker(t)
+		m := testMqttOutput(b, version)
+		if err := m.connect(); err != nil {
+			t.Fatalf("version %s: connect: %s", version, err)
+		}
+		testMqttWaitFor(t, "ping", func() bool {
+			_, _, _, pings := b.state()
+			return pings > 0
+		})
+		if !m.client.Alive() {
+			t.Errorf("version %s: connection closed after ping", version)
+		}
+		m.disconnect()
+		m.End()
+
+		// disabled
+		b = newTestMqttBroker(t)
+		m = testMqttOutput(b, version)
+		m.cfg.KeepAlive = 0
+		if err := m.connect(); err != nil {
+			t.Fatalf("version %s: connect: %s", version, err)
+		}
+		time.Sleep(1500 * time.Millisecond)
+		if _, _, _, pings := b.state(); pings != 0 {
+			t.Errorf("version %s: %d pings with keep alive disabled, expected 0", version, pings)
+		}
+		m.disconnect()
+		m.End()
+	}
+}
+
+func Test_MqttReconnect(t *testing.T) {
+	for _, version := range []string{"3.1.1", "5"} {
+		b := newTestMqttBroker(t)
+		m := testMqttOutput(b, version)
+
+		m.publish(testMqttPoints(2))
+		if s := m.GetResetStats(); s.PSent != 2 || s.WriteErrors != 0 {
+			t.Fatalf("version %s: first publish: %d sent, %d errors", version, s.PSent, s.WriteErrors)
+		}
+
+		// connection lost, released on the next publish that has to wait
+		// TimeWriteRetry seconds since the last dial to reconnect
+		b.closeConns()
+		testMqttWaitFor(t, "connection lost", func() bool { return !m.client.Alive() })
+		m.publish(testMqttPoints(3))
+		if s := m.GetResetStats(); s.PSent != 0 || s.WriteErrors != 1 {
+			t.Errorf("version %s: publish while waiting to reconnect: %d sent, %d errors", version, s.PSent, s.WriteErrors)
+		}
+		if m.client != nil {
+			t.Fatalf("version %s: connection not released after lost", version)
+		}
+
+		m.lastDial = time.Time{}
+		m.publish(testMqttPoints(3))
+		if s := m.GetResetStats(); s.PSent != 3 || s.WriteErrors != 0 {
+			t.Errorf("version %s: publish after reconnect: %d sent, %d errors", version, s.PSent, s.WriteErrors)
+		}
+		m.disconnect()
+		m.End()
+
+		connects, published, _, _ := b.state()
+		if len(connects) != 2 {
+			t.Errorf("version %s: %d sessions, expected 2", version, len(connects))
+		}
+		topics := make([]string, 0, len(published))
+		for _, p := range published {
+			topics = append(topics, p.topic)
+		}
+		sort.Strings(topics)
+		want := []string{
+			"snmp/router1/ifstats/eth0", "snmp/router1/ifstats/eth0", "snmp/router1/ifstats/eth1",
+			"snmp/router1/ifstats/eth1", "snmp/router1/ifstats/eth2",
+		}
+		if diff := cmp.Diff(want, topics); diff != "" {
+			t.Errorf("version %s: topics mismatch (-want +got):\n%s", version, diff)
+		}
+	}
+}
+
+//--------------------------------------------------------------------
+// Topics
+//--------------------------------------------------------------------
+
+func Test_MqttTopic(t *testing.T) {
+	p := &Point{
+		Name: "if/stats",
+		Tags: map[string]string{
+			"device":  "core+1",
+			"ifIndex": "3",
+			"ifName":  "Gi0/1",
+			"site":    "mad#2",
+			"nul":     "a\x00b",
+			"empty":   "",
+		},
+		Meta: PointMeta{DeviceTag: "device", IndexTags: []string{"ifIndex", "ifName"}},
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{DefaultMqttTopicTemplate, "snmp/core_1/if_stats/3/Gi0_1"},
+		{"{tag:site}/{device}/{measurement}", "mad_2/core_1/if_stats"},
+		{"snmp/{tag:nul}/{tag:ifName}", "snmp/a_b/Gi0_1"},
+		// empty and unknown tags remove its level
+		{"snmp/{tag:empty}/{tag:unknown}/{device}", "snmp/core_1"},
+		{"/snmp//{device}/", "snmp/core_1"},
+		// unknown placeholders are kept
+		{"snmp/{host}/{tag:}", "snmp/{host}/{tag:}"},
+		{"snmp/{tag:site}-{tag:ifIndex}", "snmp/mad_2-3"},
+	}
+	for _, tt := range tests {
+		if got := MqttTopic(tt.tmpl, p); got != tt.want {
+			t.Errorf("MqttTopic(%q) = %q, expected %q", tt.tmpl, got, tt.want)
+		}
+	}
+
+	// points without index tags
+	p.Meta.IndexTags = nil
+	if got := MqttTopic(DefaultMqttTopicTemplate, p); got != "snmp/core_1/if_stats" {
+		t.Errorf("MqttTopic without index = %q, expected snmp/core_1/if_stats", got)
+	}
+}
+
+func Test_mqttTopicEscape(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":     "plain",
+		"a/b":       "a_b",
+		"+#/":       "___",
+		"x\x00y":    "x_y",
+		"unicode/ñ": "unicode_ñ",
+	} {
+		if got := mqttTopicEscape(in); got != want {
+			t.Errorf("mqttTopicEscape(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package output
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTT sessions are handled by the Eclipse paho clients: paho.mqtt.golang for MQTT 3.1.1
+// and paho.golang for MQTT 5.0 (paho.mqtt.golang does not support 5.0)
+
+// MQTT protocol levels
+const (
+	mqttV311 = 4
+	mqttV5   = 5
+)
+
+// mqttMaxInflight max messages waiting for acknowledge
+const mqttMaxInflight = 100
+
+// mqttMessage a message to be published
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// mqttRejectError is returned when the broker has refused some messages (MQTT 5.0 reason codes)
+type mqttRejectError struct {
+	reason byte
+}
+
+func (e *mqttRejectError) Error() string {
+	return fmt.Sprintf("message rejected by the broker, reason code 0x%02x", e.reason)
+}
+
+// mqttConnOptions the needed parameters to connect to a broker
+type mqttConnOptions struct {
+	Addr      string
+	Version   byte
+	ClientID  string
+	User      string
+	Password  string
+	KeepAlive time.Duration
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+}
+
+// mqttToken is completed once a message has been acknowledged (QoS 1/2) or written (QoS 0)
+type mqttToken interface {
+	Done() <-chan struct{}
+	Error() error
+}
+
+// mqttConn the protocol version specific session
+type mqttConn interface {
+	publish(m *mqttMessage, qos byte, retain bool) mqttToken
+	// lost is closed when the connection has been lost, lostErr returns its cause
+	lost() <-chan struct{}
+	lostErr() error
+	close()
+}
+
+// mqttClient a session with a MQTT broker
+type mqttClient struct {
+	conn    mqttConn
+	timeout time.Duration
+}
+
+// mqttConnackError translates CONNACK return (3.1.1) or reason (5.0) codes
+func mqttConnackError(version byte, code byte) error {
+	if code == 0 {
+		return nil
+	}
+	if version == mqttV311 {
+		switch code {
+		case 1:
+			return fmt.Errorf("connection refused: unacceptable protocol version")
+		case 2:
+			return fmt.Errorf("connection refused: identifier rejected")
+		case 3:
+			return fmt.Errorf("connection refused: server unavailable")
+		case 4:
+			return fmt.Errorf("connection refused: bad user name or password")
+		case 5:
+			return fmt.Errorf("connection refused: not authorized")
+		}
+	} else {
+		switch code {
+		case 0x84:
+			return fmt.Errorf("connection refused: unsupported protocol version")
+		case 0x85:
+			return fmt.Errorf("connection refused: client identifier not valid")
+		case 0x86:
+			return fmt.Errorf("connection refused: bad user name or password")
+		case 0x87:
+			return fmt.Errorf("connection refused: not authorized")
+		case 0x88:
+			return fmt.Errorf("connection refused: server unavailable")
+		}
+	}
+	return fmt.Errorf("connection refused: code 0x%02x", code)
+}
+
+// mqttDial connects to the broker and waits for the CONNACK packet
+func mqttDial(o mqttConnOptions) (*mqttClient, error) {
+	var conn mqttConn
+	var err error
+	if o.Version == mqttV5 {
+		conn, err = mqttDialV5(o)
+	} else {
+		conn, err = mqttDialV311(o)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mqttClient{conn: conn, timeout: o.Timeout}, nil
+}
+
+// Publish sends all messages, with QoS 1 and 2 it waits until all messages have been acknowledged
+// (with up to mqttMaxInflight messages in flight). Returns the number of messages not delivered.
+// On MQTT 5.0 the in flight messages are sent concurrently, so messages from the same batch
+// could be received in a different order.
+func (c *mqttClient) Publish(msgs []*mqttMessage, qos byte, retain bool) (int, error) {
+	if !c.Alive() {
+		return len(msgs), c.Err()
+	}
+	pending := make([]mqttToken, 0, mqttMaxInflight)
+	var failed int
+	var lasterr error
+	next := 0
+	undelivered := func() int { return len(msgs) - next + len(pending) + failed }
+	timeout := time.NewTimer(c.timeout)
+	defer timeout.Stop()
+	for next < len(msgs) || len(pending) > 0 {
+		for next < len(msgs) && len(pending) < mqttMaxInflight {
+			pending = append(pending, c.conn.publish(msgs[next], qos, retain))
+			next++
+		}
+		select {
+		case <-pending[0].Done():
+		case <-timeout.C:
+			return undelivered(), fmt.Errorf("timeout waiting acknowledge for %d messages", len(pending))
+		}
+		err := pending[0].Error()
+		if err != nil {
+			if _, rejected := err.(*mqttRejectError); !rejected {
+				if !c.Alive() {
+					err = c.Err()
+				}
+				return undelivered(), err
+			}
+			failed++
+			lasterr = err
+		}
+		pending = pending[1:]
+		// the timeout is the max time waiting for each acknowledge
+		if !timeout.Stop() {
+			<-timeout.C
+		}
+		timeout.Reset(c.timeout)
+	}
+	return failed, lasterr
+}
+
+// Alive returns false once the connection has been lost
+func (c *mqttClient) Alive() bool {
+	select {
+	case <-c.conn.lost():
+		return false
+	default:
+		return true
+	}
+}
+
+// Err returns why the connection has been lost
+func (c *mqttClient) Err() error {
+	if c.Alive() {
+		return nil
+	}
+	return c.conn.lostErr()
+}
+
+// Close sends DISCONNECT and closes the connection
+func (c *mqttClient) Close() {
+	c.conn.close()
+}
+
+//--------------------------------------------------------------------
+// MQTT 3.1.1 (paho.mqtt.golang)
+//--------------------------------------------------------------------
+
+type mqttConnV311 struct {
+	client mqtt.Client
+	once   sync.Once
+	lostc  chan struct{}
+	err    error
+}
+
+func mqttDialV311(o mqttConnOptions) (*mqttConnV311, error) {
+	c := &mqttConnV311{lostc: make(chan struct{})}
+	keepAlive := o.KeepAlive
+	if keepAlive > 0 && keepAlive < 2*time.Second {
+		// paho.mqtt.golang checks the connection every keep alive/2 seconds (panics if 0)
+		keepAlive = 2 * time.Second
+	}
+	scheme := "tcp://"
+	if o.TLSConfig != nil {
+		scheme = "ssl://"
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(scheme + o.Addr).
+		SetProtocolVersion(mqttV311).
+		SetClientID(o.ClientID).
+		SetUsername(o.User).
+		SetPassword(o.Password).
+		SetCleanSession(true).
+		SetKeepAlive(keepAlive).
+		SetPingTimeout(o.Timeout).
+		SetConnectTimeout(o.Timeout).
+		SetWriteTimeout(o.Timeout).
+		SetTLSConfig(o.TLSConfig).
+		// reconnects are handled by the output
+		SetAutoReconnect(false).
+		SetConnectRetry(false).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			c.setLost(err)
+		})
+	c.client = mqtt.NewClient(opts)
+	tok := c.client.Connect()
+	if !tok.WaitTimeout(2 * o.Timeout) {
+		c.client.Disconnect(0)
+		return nil, fmt.Errorf("timeout waiting CONNACK")
+	}
+	if err := tok.Error(); err != nil {
+		if rc := tok.(*mqtt.ConnectToken).ReturnCode(); rc > 0 && rc < 0x80 {
+			return nil, mqttConnackError(mqttV311, rc)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *mqttConnV311) setLost(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.lostc)
+	})
+}
+
+func (c *mqttConnV311) publish(m *mqttMessage, qos byte, retain bool) mqttToken {
+	return c.client.Publish(m.topic, qos, retain, m.payload)
+}
+
+func (c *mqttConnV311) lost() <-chan struct{} {
+	return c.lostc
+}
+
+func (c *mqttConnV311) lostErr() error {
+	return c.err
+}
+
+func (c *mqttConnV311) close() {
+	if c.client.IsConnectionOpen() {
+		c.client.Disconnect(250)
+	}
+	c.setLost(fmt.Errorf("connection closed"))
+}
+
+//--------------------------------------------------------------------
+// MQTT 5.0 (paho.golang)
+//--------------------------------------------------------------------
+
+type mqttConnV5 struct {
+	client *paho.Client
+	// ctx is cancelled when the connection is lost, pending publishes are aborted
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	err    error
+}
+
+// mqttToken5 completed by the goroutine waiting the publish acknowledge
+type mqttToken5 struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *mqttToken5) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *mqttToken5) Error() error {
+	return t.err
+}
+
+// mqttNoPinger disables the paho.golang keep alive pings
+type mqttNoPinger struct{}
+
+func (mqttNoPinger) Start(net.Conn, time.Duration) {}
+func (mqttNoPinger) Stop()                         {}
+func (mqttNoPinger) PingResp()                     {}
+func (mqttNoPinger) SetDebug(paho.Logger)          {}
+
+func mqttDialV5(o mqttConnOptions) (*mqttConnV5, error) {
+	dialer := &net.Dialer{Timeout: o.Timeout}
+	var conn net.Conn
+	var err error
+	if o.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", o.Addr, o.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", o.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &mqttConnV5{}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	cfg := paho.ClientConfig{
+		// publishes, acknowledges and pings are written from different goroutines
+		Conn:          packets.NewThreadSafeConn(conn),
+		PacketTimeout: 2 * o.Timeout,
+		OnClientError: c.setLost,
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			c.setLost(fmt.Errorf("disconnected by the broker, reason code 0x%02x", d.ReasonCode))
+		},
+	}
+	if o.KeepAlive <= 0 {
+		cfg.PingHandler = mqttNoPinger{}
+	}
+	c.client = paho.NewClient(cfg)
+	cp := &paho.Connect{
+		ClientID:   o.ClientID,
+		KeepAlive:  uint16(o.KeepAlive / time.Second),
+		CleanStart: true,
+	}
+	if len(o.User) > 0 {
+		cp.Username, cp.UsernameFlag = o.User, true
+		if len(o.Password) > 0 {
+			cp.Password, cp.PasswordFlag = []byte(o.Password), true
+		}
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, o.Timeout)
+	defer cancel()
+	ca, err := c.client.Connect(ctx, cp)
+	if err != nil {
+		c.cancel()
+		if ca != nil {
+			return nil, mqttConnackError(mqttV5, ca.ReasonCode)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timeout waiting CONNACK")
+		}
+		return nil, fmt.Errorf("error waiting CONNACK: %s", err)
+	}
+	return c, nil
+}
+
+func (c *mqttConnV5) setLost(err error) {
+	c.once.Do(func() {
+		c.err = err
+		c.cancel()
+	})
+}
+
+func (c *mqttConnV5) publish(m *mqttMessage, qos byte, retain bool) mqttToken {
+	t := &mqttToken5{done: make(chan struct{})}
+	p := &paho.Publish{QoS: qos, Retain: retain, Topic: m.topic, Payload: m.payload}
+	if qos == 0 {
+		// written before returning, keeps the messages order
+		_, t.err = c.client.Publish(c.ctx, p)
+		close(t.done)
+		return t
+	}
+	go func() {
+		defer close(t.done)
+		pr, err := c.client.Publish(c.ctx, p)
+		if pr != nil && pr.ReasonCode >= 0x80 {
+			err = &mqttRejectError{reason: pr.ReasonCode}
+		}
+		t.err = err
+	}()
+	return t
+}
+
+func (c *mqttConnV5) lost() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+func (c *mqttConnV5) lostErr() error {
+	return c.err
+}
+
+func (c *mqttConnV5) close() {
+	c.setLost(fmt.Errorf("connection closed"))
+	c.client.Disconnect(&paho.Disconnect{ReasonCode: 0})
+}
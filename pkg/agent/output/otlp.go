@@ -0,0 +1,423 @@
+package output
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// otlpScope is the instrumentation scope name of all exported metrics
+const otlpScope = "snmpcollector"
+
+// otlpMaxPendingBatches max number of batches kept in memory while the receiver is unreachable
+const otlpMaxPendingBatches = 10
+
+// Otlp sends data to an OpenTelemetry OTLP/HTTP metrics receiver, device tags are sent as
+// resource attributes and the other tags as datapoint attributes, each measurement field is
+// a metric named <measurement>.<field>. Counter increments (COUNTER32/COUNTER64 without rate)
+// are accumulated and sent as cumulative monotonic sums, all other fields as gauges.
+type Otlp struct {
+	cfg         *config.OtlpCfg
+	stats       Stats
+	initialized bool
+	imutex      sync.Mutex
+	started     bool
+	smutex      sync.Mutex
+
+	iChan   chan []*Point
+	chExit  chan bool
+	client  *http.Client
+	headers map[string]string
+	// only accessed from the sender goroutine
+	startTime  time.Time
+	cumulative map[string]*otlpDataPoint
+	resources  []*otlpResource
+	resIndex   map[string]*otlpResource
+	dpoints    int
+	points     int64
+}
+
+func init() {
+	Register("otlp", func(dbc *config.DBConfig) map[string]Output {
+		outs := make(map[string]Output)
+		for k, c := range dbc.Otlp {
+			outs[k] = NewNotInitOtlp(c)
+		}
+		return outs
+	})
+}
+
+// NewNotInitOtlp Create Object in memory but not initialized until ready connection needed
+func NewNotInitOtlp(c *config.OtlpCfg) *Otlp {
+	return &Otlp{cfg: c}
+}
+
+func newOtlpClient(cfg *config.OtlpCfg) *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
+		},
+	}
+}
+
+// parseOtlpHeaders parses headers with format key1=value1,key2=value2
+func parseOtlpHeaders(h string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, kv := range strings.Split(h, ",") {
+		if len(strings.TrimSpace(kv)) == 0 {
+			continue
+		}
+		s := strings.SplitN(kv, "=", 2)
+		if len(s) != 2 || len(strings.TrimSpace(s[0])) == 0 {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", kv)
+		}
+		headers[strings.TrimSpace(s[0])] = strings.TrimSpace(s[1])
+	}
+	return headers, nil
+}
+
+// otlpPost sends an encoded ExportMetricsServiceRequest
+func otlpPost(cli *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("receiver returned status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// PingOtlp sends an empty metrics request to check the receiver is reachable and accepts OTLP/HTTP protobuf
+func PingOtlp(cfg *config.OtlpCfg) (time.Duration, string, error) {
+	headers, err := parseOtlpHeaders(cfg.Headers)
+	if err != nil {
+		return 0, "", err
+	}
+	start := time.Now()
+	err = otlpPost(newOtlpClient(cfg), cfg.URL, headers, []byte{})
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Errorf("Error on send OTLP request to %s: %s", cfg.URL, err)
+		return elapsed, "", err
+	}
+	return elapsed, fmt.Sprintf("Empty metrics request accepted by %s", cfg.URL), nil
+}
+
+// ID return the OTLP output ID
+func (o *Otlp) ID() string {
+	return o.cfg.ID
+}
+
+// GetResetStats return output stats and reset its counters
+func (o *Otlp) GetResetStats() *Stats {
+	return o.stats.GetResetStats()
+}
+
+// Init initializes runtime info
+func (o *Otlp) Init() {
+	o.imutex.Lock()
+	defer o.imutex.Unlock()
+	if o.initialized {
+		log.Infof("Sender thread to : %s  already Initialized (skipping Initialization)", o.cfg.ID)
+		return
+	}
+	log.Infof("Initializing OTLP output with id = [ %s ] to %s", o.cfg.ID, o.cfg.URL)
+	headers, err := parseOtlpHeaders(o.cfg.Headers)
+	if err != nil {
+		log.Errorf("Error on OTLP output %s headers, no extra headers will be sent: %s", o.cfg.ID, err)
+	}
+	o.headers = headers
+	if o.cfg.BufferSize <= 0 {
+		o.cfg.BufferSize = 65535
+	}
+	if o.cfg.BatchSize <= 0 {
+		o.cfg.BatchSize = 1000
+	}
+	// time.NewTicker panics with non positive intervals
+	if o.cfg.FlushInterval <= 0 {
+		o.cfg.FlushInterval = 10
+	}
+	o.client = newOtlpClient(o.cfg)
+	o.startTime = time.Now()
+	o.cumulative = make(map[string]*otlpDataPoint)
+	o.reset()
+	o.iChan = make(chan []*Point, o.cfg.BufferSize)
+	o.chExit = make(chan bool)
+	o.initialized = true
+}
+
+// End releases runtime resources
+func (o *Otlp) End() {
+	o.imutex.Lock()
+	defer o.imutex.Unlock()
+	if !o.initialized {
+		return
+	}
+	close(o.iChan)
+	close(o.chExit)
+	o.initialized = false
+}
+
+// StartSender begins sender loop
+func (o *Otlp) StartSender(wg *sync.WaitGroup) {
+	o.smutex.Lock()
+	defer o.smutex.Unlock()
+	if o.started {
+		log.Infof("Sender thread to : %s  already started (skipping Goroutine creation)", o.cfg.ID)
+		return
+	}
+	o.started = true
+	wg.Add(1)
+	go o.startSenderGo(rand.Int(), wg)
+}
+
+// StopSender finalize sender goroutines
+func (o *Otlp) StopSender() {
+	o.smutex.Lock()
+	started := o.started
+	o.smutex.Unlock()
+	if started {
+		o.chExit <- true
+		return
+	}
+	log.Infof("Can not stop Sender [%s] becaouse of it is already stopped", o.cfg.ID)
+}
+
+// Send enqueues points to be written
+func (o *Otlp) Send(pts []*Point) {
+	o.iChan <- pts
+}
+
+// TrySend enqueues points without blocking, returns false if the queue is full
+func (o *Otlp) TrySend(pts []*Point) bool {
+	select {
+	case o.iChan <- pts:
+		return true
+	default:
+		o.stats.DropUpdate(int64(len(pts)))
+		return false
+	}
+}
+
+// otlpNumber converts a field value to an OTLP number, strings are not supported
+func otlpNumber(v interface{}) (int64, float64, bool, bool) {
+	switch val := v.(type) {
+	case float64:
+		return 0, val, false, true
+	case float32:
+		return 0, float64(val), false, true
+	case int:
+		return int64(val), 0, true, true
+	case int32:
+		return int64(val), 0, true, true
+	case int64:
+		return val, 0, true, true
+	case uint:
+		return otlpUint(uint64(val))
+	case uint32:
+		return int64(val), 0, true, true
+	case uint64:
+		return otlpUint(val)
+	case bool:
+		if val {
+			return 1, 0, true, true
+		}
+		return 0, 0, true, true
+	}
+	return 0, 0, false, false
+}
+
+func otlpUint(v uint64) (int64, float64, bool, bool) {
+	if v > math.MaxInt64 {
+		return 0, float64(v), false, true
+	}
+	return int64(v), 0, true, true
+}
+
+func otlpAttrs(tags map[string]string, keys []string) ([]otlpAttr, string) {
+	sort.Strings(keys)
+	attrs := make([]otlpAttr, 0, len(keys))
+	var id strings.Builder
+	for _, k := range keys {
+		attrs = append(attrs, otlpAttr{Key: k, Value: tags[k]})
+		id.WriteString(k + "=" + tags[k] + ",")
+	}
+	return attrs, id.String()
+}
+
+// resource returns the pending resource with this attributes, creates it if does not exist
+func (o *Otlp) resource(p *Point) (*otlpResource, string) {
+	isDevTag := make(map[string]bool, len(p.Meta.DeviceTags))
+	for _, k := range p.Meta.DeviceTags {
+		isDevTag[k] = true
+	}
+	keys := make([]string, 0, len(p.Meta.DeviceTags))
+	for _, k := range p.Meta.DeviceTags {
+		if _, ok := p.Tags[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	attrs, id := otlpAttrs(p.Tags, keys)
+	if r, ok := o.resIndex[id]; ok {
+		return r, id
+	}
+	if !isDevTag["service.name"] {
+		attrs = append(attrs, otlpAttr{Key: "service.name", Value: otlpScope})
+	}
+	r := &otlpResource{Attrs: attrs, index: make(map[string]*otlpMetric)}
+	o.resIndex[id] = r
+	o.resources = append(o.resources, r)
+	return r, id
+}
+
+// addPoints converts points to OTLP data points on the pending request
+func (o *Otlp) addPoints(pts []*Point) {
+	now := time.Now()
+	for _, p := range pts {
+		t := p.Time
+		if t.IsZero() {
+			t = now
+		}
+		res, resID := o.resource(p)
+		isDevTag := make(map[string]bool, len(p.Meta.DeviceTags))
+		for _, k := range p.Meta.DeviceTags {
+			isDevTag[k] = true
+		}
+		keys := make([]string, 0, len(p.Tags))
+		for k := range p.Tags {
+			if !isDevTag[k] {
+				keys = append(keys, k)
+			}
+		}
+		attrs, attrsID := otlpAttrs(p.Tags, keys)
+		for f, v := range p.Fields {
+			i, fl, isInt, ok := otlpNumber(v)
+			if !ok {
+				continue
+			}
+			name := p.Name + "." + f
+			dp := &otlpDataPoint{Attrs: attrs, Time: uint64(t.UnixNano()), IsInt: isInt, Int: i, Float: fl}
+			isSum := p.Meta.CounterFields[f]
+			if isSum {
+				dp = o.accumulate(resID+"|"+name+"|"+attrsID, dp)
+			}
+			m := res.metric(name, isSum)
+			m.Points = append(m.Points, dp)
+			o.dpoints++
+		}
+		o.points++
+	}
+}
+
+// accumulate adds the counter increment to the series cumulative value since the output start
+func (o *Otlp) accumulate(key string, dp *otlpDataPoint) *otlpDataPoint {
+	c, ok := o.cumulative[key]
+	if !ok {
+		c = &otlpDataPoint{Start: uint64(o.startTime.UnixNano()), IsInt: true}
+		o.cumulative[key] = c
+	}
+	if c.IsInt && dp.IsInt && (dp.Int <= 0 || c.Int <= math.MaxInt64-dp.Int) {
+		c.Int += dp.Int
+	} else {
+		if c.IsInt {
+			c.Float = float64(c.Int)
+			c.IsInt = false
+		}
+		if dp.IsInt {
+			c.Float += float64(dp.Int)
+		} else {
+			c.Float += dp.Float
+		}
+	}
+	return &otlpDataPoint{Attrs: dp.Attrs, Start: c.Start, Time: dp.Time, IsInt: c.IsInt, Int: c.Int, Float: c.Float}
+}
+
+// flush sends all pending data points, on error data is kept to be sent on next flush
+// until otlpMaxPendingBatches batches are pending
+func (o *Otlp) flush() {
+	if o.dpoints == 0 {
+		return
+	}
+	bufferPercent := (float32(len(o.iChan)) * 100.0) / float32(o.cfg.BufferSize)
+	start := time.Now()
+	err := otlpPost(o.client, o.cfg.URL, o.headers, encodeOtlpRequest(o.resources, otlpScope))
+	elapsed := time.Since(start)
+	if err != nil {
+		o.stats.WriteErrUpdate(elapsed, bufferPercent)
+		log.Errorf("ERROR on write to OTLP output %s (%d data points pending): %s", o.cfg.ID, o.dpoints, err)
+		if o.points > int64(otlpMaxPendingBatches*o.cfg.BatchSize) {
+			log.Errorf("OTLP output %s has too much pending data, dropping %d data points", o.cfg.ID, o.dpoints)
+			o.reset()
+		}
+		return
+	}
+	log.Debugf("OK on write to OTLP output %s (%d data points) | elapsed : %s ", o.cfg.ID, o.dpoints, elapsed.String())
+	o.stats.WriteOkUpdate(o.points, int64(o.dpoints), elapsed, bufferPercent)
+	o.reset()
+}
+
+func (o *Otlp) reset() {
+	o.resources = nil
+	o.resIndex = make(map[string]*otlpResource)
+	o.dpoints = 0
+	o.points = 0
+}
+
+func (o *Otlp) startSenderGo(r int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	log.Infof("beginning OTLP Sender thread: [%s]", o.cfg.ID)
+	t := time.NewTicker(time.Duration(o.cfg.FlushInterval) * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			o.flush()
+		case <-o.chExit:
+			// need to flush all data
+			chanlen := len(o.iChan)
+			log.Infof("Flushing %d batches of data in OTLP output %s ", chanlen, o.cfg.ID)
+			for i := 0; i < chanlen; i++ {
+				o.addPoints(<-o.iChan)
+			}
+			o.flush()
+			log.Infof("EXIT from OTLP sender process for output [%s] ", o.cfg.ID)
+			o.smutex.Lock()
+			o.started = false
+			o.smutex.Unlock()
+			return
+		case pts := <-o.iChan:
+			if pts == nil {
+				continue
+			}
+			o.addPoints(pts)
+			if o.points >= int64(o.cfg.BatchSize) {
+				o.flush()
+			}
+		}
+	}
+}
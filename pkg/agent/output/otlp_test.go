@@ -0,0 +1,406 @@
+package output
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//--------------------------------------------------------------------
+// OTLP/HTTP receiver stand-in, decodes the ExportMetricsServiceRequest
+// messages with protowire
+//--------------------------------------------------------------------
+
+// testOtlpPoint a decoded NumberDataPoint
+type testOtlpPoint struct {
+	Attrs map[string]string
+	Start uint64
+	Time  uint64
+	IsInt bool
+	Int   int64
+	Float float64
+}
+
+// testOtlpMetric a decoded Metric with its resource and scope
+type testOtlpMetric struct {
+	Resource    map[string]string
+	Scope       string
+	Name        string
+	Sum         bool
+	Temporality uint64
+	Monotonic   bool
+	Points      []testOtlpPoint
+}
+
+// testPbFields calls fn for each field of the message b, the value is the raw field value
+func testPbFields(t *testing.T, b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("malformed protobuf tag: %s", protowire.ParseError(n))
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			t.Fatalf("malformed protobuf field %d: %s", num, protowire.ParseError(m))
+		}
+		fn(num, typ, b[:m])
+		b = b[m:]
+	}
+}
+
+func testPbBytes(v []byte) []byte {
+	b, _ := protowire.ConsumeBytes(v)
+	return b
+}
+
+func testPbFixed64(v []byte) uint64 {
+	f, _ := protowire.ConsumeFixed64(v)
+	return f
+}
+
+func testPbVarint(v []byte) uint64 {
+	f, _ := protowire.ConsumeVarint(v)
+	return f
+}
+
+// testOtlpDecodeAttr decodes a KeyValue with a string AnyValue
+func testOtlpDecodeAttr(t *testing.T, b []byte, attrs map[string]string) {
+	var key, value string
+	testPbFields(t, b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		switch num {
+		case 1:
+			key = string(testPbBytes(v))
+		case 2:
+			testPbFields(t, testPbBytes(v), func(num protowire.Number, typ protowire.Type, v []byte) {
+				if num != 1 {
+					t.Errorf("attribute %s with AnyValue field %d, expected string_value", key, num)
+				}
+				value = string(testPbBytes(v))
+			})
+		}
+	})
+	attrs[key] = value
+}
+
+func testOtlpDecodePoint(t *testing.T, b []byte) testOtlpPoint {
+	p := testOtlpPoint{Attrs: make(map[string]string)}
+	testPbFields(t, b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		switch num {
+		case 2:
+			p.Start = testPbFixed64(v)
+		case 3:
+			p.Time = testPbFixed64(v)
+		case 4:
+			p.Float = math.Float64frombits(testPbFixed64(v))
+		case 6:
+			p.IsInt = true
+			p.Int = int64(testPbFixed64(v))
+		case 7:
+			testOtlpDecodeAttr(t, testPbBytes(v), p.Attrs)
+		default:
+			t.Errorf("unexpected NumberDataPoint field %d", num)
+		}
+	})
+	return p
+}
+
+func testOtlpDecodeMetric(t *testing.T, b []byte, m *testOtlpMetric) {
+	testPbFields(t, b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		switch num {
+		case 1:
+			m.Name = string(testPbBytes(v))
+		case 5, 7:
+			m.Sum = num == 7
+			testPbFields(t, testPbBytes(v), func(num protowire.Number, typ protowire.Type, v []byte) {
+				switch num {
+				case 1:
+					m.Points = append(m.Points, testOtlpDecodePoint(t, testPbBytes(v)))
+				case 2:
+					m.Temporality = testPbVarint(v)
+				case 3:
+					m.Monotonic = testPbVarint(v) == 1
+				}
+			})
+		default:
+			t.Errorf("unexpected Metric field %d", num)
+		}
+	})
+}
+
+// testOtlpDecode decodes an ExportMetricsServiceRequest
+func testOtlpDecode(t *testing.T, b []byte) []testOtlpMetric {
+	var metrics []testOtlpMetric
+	testPbFields(t, b, func(num protowire.Number, typ protowire.Type, v []byte) {
+		if num != 1 {
+			t.Errorf("unexpected ExportMetricsServiceRequest field %d", num)
+			return
+		}
+		res := make(map[string]string)
+		testPbFields(t, testPbBytes(v), func(num protowire.Number, typ protowire.Type, v []byte) {
+			switch num {
+			case 1:
+				testPbFields(t, testPbBytes(v), func(num protowire.Number, typ protowire.Type, v []byte) {
+					testOtlpDecodeAttr(t, testPbBytes(v), res)
+				})
+			case 2:
+				var scope string
+				testPbFields(t, testPbBytes(v), func(num protowire.Number, typ protowire.Type, v []byte) {
+					switch num {
+					case 1:
+						testPbFields(t, testPbBytes(v), func(num protowire.Number, typ protowire.Type, v []byte) {
+							scope = string(testPbBytes(v))
+						})
+					case 2:
+						m := testOtlpMetric{Resource: res, Scope: scope}
+						testOtlpDecodeMetric(t, testPbBytes(v), &m)
+						metrics = append(metrics, m)
+					}
+				})
+			}
+		})
+	})
+	return metrics
+}
+
+// testOtlpReceiver records the decoded requests, status is the HTTP status returned
+type testOtlpReceiver struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	status   int
+	requests [][]testOtlpMetric
+	headers  []http.Header
+}
+
+func newTestOtlpReceiver(t *testing.T) *testOtlpReceiver {
+	r := &testOtlpReceiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("read request: %s", err)
+		}
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.status != http.StatusOK {
+			http.Error(w, "receiver unavailable", r.status)
+			return
+		}
+		r.requests = append(r.requests, testOtlpDecode(t, body))
+		r.headers = append(r.headers, req.Header)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *testOtlpReceiver) setStatus(status int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.status = status
+}
+
+func (r *testOtlpReceiver) received() ([][]testOtlpMetric, []http.Header) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.requests, r.headers
+}
+
+// testOtlpPoints builds one point for each interface with a counter increment and a gauge
+func testOtlpPoints(t time.Time, inc map[string]interface{}) []*Point {
+	ifaces := make([]string, 0, len(inc))
+	for i := range inc {
+		ifaces = append(ifaces, i)
+	}
+	sort.Strings(ifaces)
+	pts := make([]*Point, 0, len(ifaces))
+	for _, i := range ifaces {
+		pts = append(pts, &Point{
+			Name: "ifstats",
+			Tags: map[string]string{"device": "router1", "site": "mad", "ifName": i},
+			Fields: map[string]interface{}{
+				"ifHCInOctets": inc[i],
+				"ifOperStatus": int64(1),
+				"ifUtil":       12.5,
+				"ifAlias":      "uplink", // strings are not exported
+			},
+			Time: t,
+			Meta: PointMeta{
+				DeviceTag:     "device",
+				IndexTags:     []string{"ifName"},
+				DeviceTags:    []string{"device", "site"},
+				CounterFields: map[string]bool{"ifHCInOctets": true},
+			},
+		})
+	}
+	return pts
+}
+
+//--------------------------------------------------------------------
+// Tests
+//--------------------------------------------------------------------
+
+func Test_OtlpInitDefaults(t *testing.T) {
+	o := NewNotInitOtlp(&config.OtlpCfg{ID: "test", URL: "http://127.0.0.1:1/v1/metrics"})
+	o.Init()
+	defer o.End()
+	if o.cfg.FlushInterval <= 0 || o.cfg.BatchSize <= 0 || o.cfg.BufferSize <= 0 {
+		t.Errorf("invalid defaults: flush interval %d, batch size %d, buffer size %d", o.cfg.FlushInterval, o.cfg.BatchSize, o.cfg.BufferSize)
+	}
+	// the sender ticker should not panic without flush interval
+	var wg sync.WaitGroup
+	o.StartSender(&wg)
+	o.StopSender()
+	wg.Wait()
+}
+
+func Test_OtlpSend(t *testing.T) {
+	r := newTestOtlpReceiver(t)
+	o := NewNotInitOtlp(&config.OtlpCfg{
+		ID:            "test",
+		URL:           r.URL + "/v1/metrics",
+		Headers:       "X-Scope-OrgID=snmp",
+		Timeout:       5,
+		BatchSize:     1000,
+		FlushInterval: 60,
+	})
+	o.Init()
+	defer o.End()
+	start := uint64(o.startTime.UnixNano())
+	t1 := time.Unix(1600000000, 0)
+	t2 := t1.Add(time.Minute)
+
+	// two gathers, flushed on sender stop
+	var wg sync.WaitGroup
+	for _, pts := range [][]*Point{
+		testOtlpPoints(t1, map[string]interface{}{"eth0": int64(100), "eth1": uint64(7)}),
+		testOtlpPoints(t2, map[string]interface{}{"eth0": int64(50), "eth1": 0.5}),
+	} {
+		o.StartSender(&wg)
+		o.Send(pts)
+		o.StopSender()
+		wg.Wait()
+	}
+
+	requests, headers := r.received()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, expected 2", len(requests))
+	}
+	for _, h := range headers {
+		if h.Get("Content-Type") != "application/x-protobuf" || h.Get("X-Scope-OrgID") != "snmp" {
+			t.Errorf("unexpected headers %v", h)
+		}
+	}
+	resource := map[string]string{"device": "router1", "site": "mad", "service.name": otlpScope}
+	point := func(iface string, ts time.Time, start uint64, isInt bool, i int64, f float64) testOtlpPoint {
+		return testOtlpPoint{
+			Attrs: map[string]string{"ifName": iface},
+			Start: start,
+			Time:  uint64(ts.UnixNano()),
+			IsInt: isInt,
+			Int:   i,
+			Float: f,
+		}
+	}
+	want := [][]testOtlpMetric{
+		{
+			{Resource: resource, Scope: otlpScope, Name: "ifstats.ifHCInOctets", Sum: true, Temporality: otlpTemporalityCumulative, Monotonic: true,
+				Points: []testOtlpPoint{point("eth0", t1, start, true, 100, 0), point("eth1", t1, start, true, 7, 0)}},
+			{Resource: resource, Scope: otlpScope, Name: "ifstats.ifOperStatus",
+				Points: []testOtlpPoint{point("eth0", t1, 0, true, 1, 0), point("eth1", t1, 0, true, 1, 0)}},
+			{Resource: resource, Scope: otlpScope, Name: "ifstats.ifUtil",
+				Points: []testOtlpPoint{point("eth0", t1, 0, false, 0, 12.5), point("eth1", t1, 0, false, 0, 12.5)}},
+		},
+		{
+			// counters accumulated since the output start, float increments turn the sum into a double
+			{Resource: resource, Scope: otlpScope, Name: "ifstats.ifHCInOctets", Sum: true, Temporality: otlpTemporalityCumulative, Monotonic: true,
+				Points: []testOtlpPoint{point("eth0", t2, start, true, 150, 0), point("eth1", t2, start, false, 0, 7.5)}},
+			{Resource: resource, Scope: otlpScope, Name: "ifstats.ifOperStatus",
+				Points: []testOtlpPoint{point("eth0", t2, 0, true, 1, 0), point("eth1", t2, 0, true, 1, 0)}},
+			{Resource: resource, Scope: otlpScope, Name: "ifstats.ifUtil",
+				Points: []testOtlpPoint{point("eth0", t2, 0, false, 0, 12.5), point("eth1", t2, 0, false, 0, 12.5)}},
+		},
+	}
+	// metrics are created in field map order
+	for _, req := range requests {
+		sort.Slice(req, func(i, j int) bool { return req[i].Name < req[j].Name })
+		for _, m := range req {
+			sort.Slice(m.Points, func(i, j int) bool { return m.Points[i].Attrs["ifName"] < m.Points[j].Attrs["ifName"] })
+		}
+	}
+	if diff := cmp.Diff(want, requests); diff != "" {
+		t.Errorf("requests mismatch (-want +got):\n%s", diff)
+	}
+	if s := o.GetResetStats(); s.WriteSent != 2 || s.PSent != 4 || s.FieldSent != 12 {
+		t.Errorf("stats: %d writes, %d points, %d fields, expected 2, 4, 12", s.WriteSent, s.PSent, s.FieldSent)
+	}
+}
+
+func Test_OtlpAccumulateOverflow(t *testing.T) {
+	o := NewNotInitOtlp(&config.OtlpCfg{ID: "test", URL: "http://127.0.0.1:1/v1/metrics"})
+	o.Init()
+	defer o.End()
+	dp := o.accumulate("k", &otlpDataPoint{IsInt: true, Int: math.MaxInt64 - 1})
+	if !dp.IsInt || dp.Int != math.MaxInt64-1 {
+		t.Fatalf("first value %+v", dp)
+	}
+	// the sum does not fit on int64
+	dp = o.accumulate("k", &otlpDataPoint{IsInt: true, Int: 10})
+	if dp.IsInt || dp.Float != float64(math.MaxInt64-1)+10 {
+		t.Errorf("overflow value %+v, expected a double", dp)
+	}
+	if dp.Start != uint64(o.startTime.UnixNano()) {
+		t.Errorf("start %d, expected the output start time", dp.Start)
+	}
+}
+
+func Test_OtlpFlushRetry(t *testing.T) {
+	r := newTestOtlpReceiver(t)
+	o := NewNotInitOtlp(&config.OtlpCfg{ID: "test", URL: r.URL, Timeout: 5, BatchSize: 1, FlushInterval: 10})
+	o.Init()
+	defer o.End()
+
+	// data is kept while the receiver is failing
+	r.setStatus(http.StatusServiceUnavailable)
+	o.addPoints(testOtlpPoints(time.Now(), map[string]interface{}{"eth0": int64(1)}))
+	o.flush()
+	if s := o.GetResetStats(); s.WriteErrors != 1 || o.dpoints != 3 {
+		t.Fatalf("after error: %d write errors, %d pending data points", s.WriteErrors, o.dpoints)
+	}
+	r.setStatus(http.StatusOK)
+	o.addPoints(testOtlpPoints(time.Now(), map[string]interface{}{"eth0": int64(1)}))
+	o.flush()
+	requests, _ := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("%d requests, expected 1", len(requests))
+	}
+	var dpoints int
+	for _, m := range requests[0] {
+		dpoints += len(m.Points)
+	}
+	if dpoints != 6 {
+		t.Errorf("%d data points sent, expected 6", dpoints)
+	}
+
+	// too many pending points are dropped
+	r.setStatus(http.StatusInternalServerError)
+	for i := 0; i <= otlpMaxPendingBatches; i++ {
+		o.addPoints(testOtlpPoints(time.Now(), map[string]interface{}{"eth0": int64(1)}))
+		o.flush()
+	}
+	if o.dpoints != 0 {
+		t.Errorf("%d data points pending, expected dropped", o.dpoints)
+	}
+	if err := otlpPost(o.client, r.URL, nil, nil); err == nil || !strings.Contains(err.Error(), "receiver unavailable") {
+		t.Errorf("error %v, expected receiver status message", err)
+	}
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// OTLP metrics protobuf encoding (opentelemetry/proto/collector/metrics/v1 ExportMetricsServiceRequest)
+// only the messages and fields needed for gauges and sums of numbers are encoded
+
+const (
+	// ExportMetricsServiceRequest
+	otlpRequestResourceMetrics protowire.Number = 1
+	// ResourceMetrics
+	otlpResourceMetricsResource     protowire.Number = 1
+	otlpResourceMetricsScopeMetrics protowire.Number = 2
+	// Resource
+	otlpResourceAttributes protowire.Number = 1
+	// ScopeMetrics
+	otlpScopeMetricsScope   protowire.Number = 1
+	otlpScopeMetricsMetrics protowire.Number = 2
+	// InstrumentationScope
+	otlpScopeName protowire.Number = 1
+	// Metric
+	otlpMetricName  protowire.Number = 1
+	otlpMetricGauge protowire.Number = 5
+	otlpMetricSum   protowire.Number = 7
+	// Gauge / Sum
+	otlpDataPoints            protowire.Number = 1
+	otlpSumTemporality        protowire.Number = 2
+	otlpSumIsMonotonic        protowire.Number = 3
+	otlpTemporalityCumulative                  = 2
+	// NumberDataPoint
+	otlpPointStartTime  protowire.Number = 2
+	otlpPointTime       protowire.Number = 3
+	otlpPointAsDouble   protowire.Number = 4
+	otlpPointAsInt      protowire.Number = 6
+	otlpPointAttributes protowire.Number = 7
+	// KeyValue
+	otlpKeyValueKey   protowire.Number = 1
+	otlpKeyValueValue protowire.Number = 2
+	// AnyValue
+	otlpAnyValueString protowire.Number = 1
+)
+
+// otlpAttr is a string attribute
+type otlpAttr struct {
+	Key   string
+	Value string
+}
+
+// otlpDataPoint is a number data point
+type otlpDataPoint struct {
+	Attrs []otlpAttr
+	Start uint64 // unix nano, only for sums
+	Time  uint64 // unix nano
+	IsInt bool
+	Int   int64
+	Float float64
+}
+
+// otlpMetric is a gauge or a cumulative monotonic sum
+type otlpMetric struct {
+	Name   string
+	IsSum  bool
+	Points []*otlpDataPoint
+}
+
+// otlpResource groups all metrics from the same resource
+type otlpResource struct {
+	Attrs   []otlpAttr
+	Metrics []*otlpMetric
+	index   map[string]*otlpMetric
+}
+
+// metric returns the resource metric with this name and kind, creates it if does not exist
+func (r *otlpResource) metric(name string, isSum bool) *otlpMetric {
+	key := name
+	if isSum {
+		key += "|sum"
+	}
+	if m, ok := r.index[key]; ok {
+		return m
+	}
+	m := &otlpMetric{Name: name, IsSum: isSum}
+	r.index[key] = m
+	r.Metrics = append(r.Metrics, m)
+	return m
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func encodeOtlpAttrs(b []byte, num protowire.Number, attrs []otlpAttr) []byte {
+	for _, a := range attrs {
+		value := appendString(nil, otlpAnyValueString, a.Value)
+		kv := appendString(nil, otlpKeyValueKey, a.Key)
+		kv = appendMessage(kv, otlpKeyValueValue, value)
+		b = appendMessage(b, num, kv)
+	}
+	return b
+}
+
+func encodeOtlpDataPoint(p *otlpDataPoint) []byte {
+	var b []byte
+	if p.Start > 0 {
+		b = appendFixed64(b, otlpPointStartTime, p.Start)
+	}
+	b = appendFixed64(b, otlpPointTime, p.Time)
+	if p.IsInt {
+		b = appendFixed64(b, otlpPointAsInt, uint64(p.Int))
+	} else {
+		b = appendFixed64(b, otlpPointAsDouble, math.Float64bits(p.Float))
+	}
+	return encodeOtlpAttrs(b, otlpPointAttributes, p.Attrs)
+}
+
+func encodeOtlpMetric(m *otlpMetric) []byte {
+	var data []byte
+	for _, p := range m.Points {
+		data = appendMessage(data, otlpDataPoints, encodeOtlpDataPoint(p))
+	}
+	b := appendString(nil, otlpMetricName, m.Name)
+	if m.IsSum {
+		data = appendVarint(data, otlpSumTemporality, otlpTemporalityCumulative)
+		data = appendVarint(data, otlpSumIsMonotonic, 1)
+		return appendMessage(b, otlpMetricSum, data)
+	}
+	return appendMessage(b, otlpMetricGauge, data)
+}
+
+// encodeOtlpRequest encodes an ExportMetricsServiceRequest with all resources
+func encodeOtlpRequest(resources []*otlpResource, scope string) []byte {
+	sc := appendString(nil, otlpScopeName, scope)
+	var req []byte
+	for _, r := range resources {
+		sm := appendMessage(nil, otlpScopeMetricsScope, sc)
+		for _, m := range r.Metrics {
+			sm = appendMessage(sm, otlpScopeMetricsMetrics, encodeOtlpMetric(m))
+		}
+		rm := appendMessage(nil, otlpResourceMetricsResource, encodeOtlpAttrs(nil, otlpResourceAttributes, r.Attrs))
+		rm = appendMessage(rm, otlpResourceMetricsScopeMetrics, sm)
+		req = appendMessage(req, otlpRequestResourceMetrics, rm)
+	}
+	return req
+}
@@ -0,0 +1,132 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// Point is the backend neutral representation of a gathered measurement row
+// all output backends should translate it to its own wire format
+type Point struct {
+	Name   string
+	Tags   map[string]string
+	Fields map[string]interface{}
+	Time   time.Time
+	// Meta gather context, could be used by backends to build metric paths, topics or attributes
+	Meta PointMeta
+}
+
+// PointMeta describes the role of some point tags
+type PointMeta struct {
+	// DeviceTag is the tag key with the device identifier (device DeviceTagName)
+	DeviceTag string
+	// IndexTags are the tag keys with the index values on indexed measurements (measurement IndexTag)
+	IndexTags []string
+	// DeviceTags are the tag keys set from the device (DeviceTagName and device ExtraTags)
+	DeviceTags []string
+	// CounterFields are the fields with the increment of a counter since the previous gather
+	CounterFields map[string]bool
+}
+
+// NewPoint creates a new point, returns error if no name or fields has been set or if some
+// field value could not be written by the backends (NaN, +/-Inf or unsupported types)
+func NewPoint(name string, tags map[string]string, fields map[string]interface{}, t time.Time) (*Point, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("point without measurement name is unsupported")
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("point %s without fields is unsupported", name)
+	}
+	for k, v := range fields {
+		if len(k) == 0 {
+			return nil, fmt.Errorf("point %s with empty field names is unsupported", name)
+		}
+		if err := checkFieldValue(v); err != nil {
+			return nil, fmt.Errorf("point %s field %s: %s", name, k, err)
+		}
+	}
+	return &Point{
+		Name:   name,
+		Tags:   tags,
+		Fields: fields,
+		Time:   t,
+	}, nil
+}
+
+// checkFieldValue returns error if the value is not a finite number, a string or a bool
+func checkFieldValue(v interface{}) error {
+	var f float64
+	switch val := v.(type) {
+	case float64:
+		f = val
+	case float32:
+		f = float64(val)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, string, bool:
+		return nil
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	if math.IsInf(f, 0) {
+		return fmt.Errorf("+/-Inf is an unsupported value")
+	}
+	if math.IsNaN(f) {
+		return fmt.Errorf("NaN is an unsupported value")
+	}
+	return nil
+}
+
+// Output is the interface that any backend should implement to receive gathered data
+// from devices and selfmonitoring processes
+type Output interface {
+	// ID returns the configured identifier for this output
+	ID() string
+	// Init initializes runtime resources (connections, buffers)
+	Init()
+	// End releases all runtime resources
+	End()
+	// StartSender begins the sender goroutine
+	StartSender(wg *sync.WaitGroup)
+	// StopSender finalize the sender goroutine flushing pending data
+	StopSender()
+	// Send enqueues points to be written in the backend
+	Send(pts []*Point)
+	// GetResetStats return output stats and reset its counters
+	GetResetStats() *Stats
+}
+
+// Factory creates all outputs for a backend type from the loaded configuration
+type Factory func(dbc *config.DBConfig) map[string]Output
+
+var (
+	factories = make(map[string]Factory)
+	fmutex    sync.Mutex
+)
+
+// Register adds a new output backend type, it should be called from the backend init() function
+func Register(name string, f Factory) {
+	fmutex.Lock()
+	defer fmutex.Unlock()
+	factories[name] = f
+}
+
+// NewOutputs creates (not initialized) all configured outputs for all registered backends,
+// outputs with processors are wrapped to transform points before being sent
+func NewOutputs(dbc *config.DBConfig) map[string]Output {
+	fmutex.Lock()
+	defer fmutex.Unlock()
+	outs := make(map[string]Output)
+	for name, f := range factories {
+		for id, o := range f(dbc) {
+			if _, ok := outs[id]; ok {
+				log.Errorf("Duplicated output ID [%s] on backend %s, skipping...", id, name)
+				continue
+			}
+			outs[id] = o
+		}
+	}
+	return applyProcessors(outs, dbc.Processors)
+}
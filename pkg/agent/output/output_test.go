@@ -0,0 +1,42 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_NewPoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		pname  string
+		fields map[string]interface{}
+		err    string
+	}{
+		{"numbers", "m", map[string]interface{}{"f": 1.5, "f32": float32(2), "i": 1, "i64": int64(-1), "u64": uint64(math.MaxUint64), "u8": uint8(1)}, ""},
+		{"strings and bools", "m", map[string]interface{}{"s": "up", "b": true}, ""},
+		{"no name", "", map[string]interface{}{"f": 1.0}, "without measurement name"},
+		{"no fields", "m", map[string]interface{}{}, "without fields"},
+		{"empty field name", "m", map[string]interface{}{"": 1.0}, "empty field names"},
+		{"NaN", "m", map[string]interface{}{"ok": 1.0, "f": math.NaN()}, "field f: NaN is an unsupported value"},
+		{"Inf", "m", map[string]interface{}{"f": math.Inf(1)}, "field f: +/-Inf is an unsupported value"},
+		{"-Inf float32", "m", map[string]interface{}{"f": float32(math.Inf(-1))}, "field f: +/-Inf is an unsupported value"},
+		{"NaN float32", "m", map[string]interface{}{"f": float32(math.NaN())}, "field f: NaN is an unsupported value"},
+		{"nil", "m", map[string]interface{}{"f": nil}, "field f: unsupported value type <nil>"},
+		{"bytes", "m", map[string]interface{}{"f": []byte("x")}, "field f: unsupported value type []uint8"},
+		{"duration", "m", map[string]interface{}{"f": time.Second}, "field f: unsupported value type time.Duration"},
+	}
+	for _, tt := range tests {
+		p, err := NewPoint(tt.pname, map[string]string{"device": "r1"}, tt.fields, time.Now())
+		if len(tt.err) == 0 {
+			if err != nil || p == nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, expected %q", tt.name, err, tt.err)
+		}
+	}
+}
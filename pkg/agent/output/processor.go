@@ -0,0 +1,350 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// processorInvalidChars are the characters replaced by the sanitize_tag processors
+var processorInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_\-\.]`)
+
+// processor is the runtime version of a configured ProcessorCfg
+type processor struct {
+	cfg   *config.ProcessorCfg
+	meas  *regexp.Regexp
+	key   *regexp.Regexp
+	value *regexp.Regexp
+}
+
+func newProcessor(c *config.ProcessorCfg) (*processor, error) {
+	var err error
+	p := &processor{cfg: c}
+	if p.meas, err = regexp.Compile(c.Measurement); err != nil {
+		return nil, fmt.Errorf("invalid measurement regex %q: %s", c.Measurement, err)
+	}
+	switch c.Type {
+	case "rename_tag", "rename_field", "add_tag":
+	case "drop_field", "lowercase_tag", "sanitize_tag":
+		if p.key, err = regexp.Compile(c.Key); err != nil {
+			return nil, fmt.Errorf("invalid key regex %q: %s", c.Key, err)
+		}
+	case "route":
+		if p.value, err = regexp.Compile(c.Value); err != nil {
+			return nil, fmt.Errorf("invalid value regex %q: %s", c.Value, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown processor type %s", c.Type)
+	}
+	return p, nil
+}
+
+// renameTagMeta keeps the tag roles after a tag rename
+func renameTagMeta(m *PointMeta, old string, name string) {
+	if m.DeviceTag == old {
+		m.DeviceTag = name
+	}
+	for i, t := range m.IndexTags {
+		if t == old {
+			m.IndexTags[i] = name
+		}
+	}
+	for i, t := range m.DeviceTags {
+		if t == old {
+			m.DeviceTags[i] = name
+		}
+	}
+}
+
+// apply transforms the point in place, returns true if the point should be routed
+// to the processor target instead of being sent to the output
+func (p *processor) apply(pt *Point) bool {
+	if !p.meas.MatchString(pt.Name) {
+		return false
+	}
+	c := p.cfg
+	switch c.Type {
+	case "rename_tag":
+		if v, ok := pt.Tags[c.Key]; ok {
+			delete(pt.Tags, c.Key)
+			pt.Tags[c.Value] = v
+			renameTagMeta(&pt.Meta, c.Key, c.Value)
+		}
+	case "rename_field":
+		if v, ok := pt.Fields[c.Key]; ok {
+			delete(pt.Fields, c.Key)
+			pt.Fields[c.Value] = v
+			if pt.Meta.CounterFields[c.Key] {
+				delete(pt.Meta.CounterFields, c.Key)
+				pt.Meta.CounterFields[c.Value] = true
+			}
+		}
+	case "drop_field":
+		for f := range pt.Fields {
+			if p.key.MatchString(f) {
+				delete(pt.Fields, f)
+				delete(pt.Meta.CounterFields, f)
+			}
+		}
+	case "add_tag":
+		pt.Tags[c.Key] = c.Value
+	case "lowercase_tag":
+		for k, v := range pt.Tags {
+			if p.key.MatchString(k) {
+				pt.Tags[k] = strings.ToLower(v)
+			}
+		}
+	case "sanitize_tag":
+		repl := c.Value
+		if len(repl) == 0 {
+			repl = "_"
+		}
+		for k, v := range pt.Tags {
+			if p.key.MatchString(k) {
+				pt.Tags[k] = processorInvalidChars.ReplaceAllString(v, repl)
+			}
+		}
+	case "route":
+		if v, ok := pt.Tags[c.Key]; ok && p.value.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// clonePoint returns a copy of the point that could be modified without affecting other outputs
+func clonePoint(p *Point) *Point {
+	cp := &Point{
+		Name:   p.Name,
+		Tags:   make(map[string]string, len(p.Tags)),
+		Fields: make(map[string]interface{}, len(p.Fields)),
+		Time:   p.Time,
+		Meta: PointMeta{
+			DeviceTag:     p.Meta.DeviceTag,
+			IndexTags:     append([]string(nil), p.Meta.IndexTags...),
+			DeviceTags:    append([]string(nil), p.Meta.DeviceTags...),
+			CounterFields: make(map[string]bool, len(p.Meta.CounterFields)),
+		},
+	}
+	for k, v := range p.Tags {
+		cp.Tags[k] = v
+	}
+	for k, v := range p.Fields {
+		cp.Fields[k] = v
+	}
+	for k, v := range p.Meta.CounterFields {
+		cp.Meta.CounterFields[k] = v
+	}
+	return cp
+}
+
+// ProcessedOutput applies the configured processors to all points before sending them to the output.
+// Points are copied before being processed, so the same points could be safely shared with other outputs.
+type ProcessedOutput struct {
+	out     Output
+	procs   []*processor
+	targets map[string]Output
+}
+
+// NewProcessedOutput creates an output that transforms points with the processors (applied in the given order)
+// before send them to o. Route processors send matching points to its target output from outs as transformed
+// by the processors ordered before the route, the processors after it and the target output ones are not applied.
+// If the target does not exist matching points are dropped.
+func NewProcessedOutput(o Output, procs []*config.ProcessorCfg, outs map[string]Output) Output {
+	po := &ProcessedOutput{out: o, targets: make(map[string]Output)}
+	for _, c := range procs {
+		p, err := newProcessor(c)
+		if err != nil {
+			log.Errorf("Error on processor %s for output %s, skipping: %s", c.ID, o.ID(), err)
+			continue
+		}
+		if c.Type == "route" && len(c.Target) > 0 {
+			t, ok := outs[c.Target]
+			if !ok {
+				log.Warnf("Route target output %s on processor %s not found, matching points will be dropped", c.Target, c.ID)
+			} else {
+				po.targets[c.Target] = t
+			}
+		}
+		po.procs = append(po.procs, p)
+	}
+	if len(po.procs) == 0 {
+		return o
+	}
+	return po
+}
+
+// applyProcessors wraps all outputs with processors configured
+func applyProcessors(outs map[string]Output, procs map[string]*config.ProcessorCfg) map[string]Output {
+	byOut := make(map[string][]*config.ProcessorCfg)
+	for _, p := range procs {
+		for _, o := range p.Outputs {
+			byOut[o] = append(byOut[o], p)
+		}
+	}
+	res := make(map[string]Output, len(outs))
+	for id, o := range outs {
+		pl, ok := byOut[id]
+		if !ok {
+			res[id] = o
+			continue
+		}
+		sort.Slice(pl, func(i, j int) bool {
+			if pl[i].Order != pl[j].Order {
+				return pl[i].Order < pl[j].Order
+			}
+			return pl[i].ID < pl[j].ID
+		})
+		res[id] = NewProcessedOutput(o, pl, outs)
+	}
+	return res
+}
+
+// ID returns the output ID
+func (po *ProcessedOutput) ID() string {
+	return po.out.ID()
+}
+
+// Init initializes the output and all route targets
+func (po *ProcessedOutput) Init() {
+	po.out.Init()
+	for _, t := range po.targets {
+		t.Init()
+	}
+}
+
+// End releases the output, route targets are released from the runtime output map
+func (po *ProcessedOutput) End() {
+	po.out.End()
+}
+
+// StartSender begins the sender goroutine of the output and all route targets
+func (po *ProcessedOutput) StartSender(wg *sync.WaitGroup) {
+	po.out.StartSender(wg)
+	for _, t := range po.targets {
+		t.StartSender(wg)
+	}
+}
+
+// StopSender finalizes the output sender, route targets are stopped from the runtime output map
+func (po *ProcessedOutput) StopSender() {
+	po.out.StopSender()
+}
+
+// GetResetStats returns the output stats
+func (po *ProcessedOutput) GetResetStats() *Stats {
+	return po.out.GetResetStats()
+}
+
+// FailoverStatus returns the output fail over status if supported
+func (po *ProcessedOutput) FailoverStatus() *BreakerStatus {
+	if fr, ok := po.out.(FailoverReporter); ok {
+		return fr.FailoverStatus()
+	}
+	return nil
+}
+
+// process returns the processed points to send to the output and the routed ones by target
+func (po *ProcessedOutput) process(pts []*Point) ([]*Point, map[string][]*Point) {
+	res := make([]*Point, 0, len(pts))
+	var routed map[string][]*Point
+	for _, p := range pts {
+		cp := clonePoint(p)
+		route := false
+		for _, proc := range po.procs {
+			if proc.apply(cp) {
+				route = true
+				if _, ok := po.targets[proc.cfg.Target]; ok {
+					if routed == nil {
+						routed = make(map[string][]*Point)
+					}
+					routed[proc.cfg.Target] = append(routed[proc.cfg.Target], cp)
+				}
+				break
+			}
+		}
+		if route {
+			continue
+		}
+		if len(cp.Fields) == 0 {
+			log.Debugf("Point %s without fields after processing on output %s, dropping", cp.Name, po.out.ID())
+			continue
+		}
+		res = append(res, cp)
+	}
+	return res, routed
+}
+
+// trySend enqueues points without blocking if the output supports it
+func trySend(o Output, pts []*Point) bool {
+	if nb, ok := o.(NonBlockingSender); ok {
+		return nb.TrySend(pts)
+	}
+	o.Send(pts)
+	return true
+}
+
+// sendRouted sends the routed points to its targets
+func (po *ProcessedOutput) sendRouted(routed map[string][]*Point, block bool) {
+	for id, rp := range routed {
+		t := po.targets[id]
+		if block {
+			t.Send(rp)
+			continue
+		}
+		if !trySend(t, rp) {
+			log.Warnf("Output %s sender queue is full, discarding %d routed points", id, len(rp))
+		}
+	}
+}
+
+// Send processes and enqueues points
+func (po *ProcessedOutput) Send(pts []*Point) {
+	res, routed := po.process(pts)
+	if len(res) > 0 {
+		po.out.Send(res)
+	}
+	po.sendRouted(routed, true)
+}
+
+// TrySend processes and enqueues points without blocking
+func (po *ProcessedOutput) TrySend(pts []*Point) bool {
+	res, routed := po.process(pts)
+	po.sendRouted(routed, false)
+	if len(res) == 0 {
+		return true
+	}
+	return trySend(po.out, res)
+}
+
+// SendRoute processes and enqueues points to the route destination, if the output does not
+// support routing points are sent to its configured destination
+func (po *ProcessedOutput) SendRoute(pts []*Point, r Route) {
+	res, routed := po.process(pts)
+	po.sendRouted(routed, true)
+	if len(res) == 0 {
+		return
+	}
+	if rs, ok := po.out.(RouteSender); ok {
+		rs.SendRoute(res, r)
+		return
+	}
+	po.out.Send(res)
+}
+
+// TrySendRoute processes and enqueues points to the route destination without blocking
+func (po *ProcessedOutput) TrySendRoute(pts []*Point, r Route) bool {
+	res, routed := po.process(pts)
+	po.sendRouted(routed, false)
+	if len(res) == 0 {
+		return true
+	}
+	if rs, ok := po.out.(RouteSender); ok {
+		return rs.TrySendRoute(res, r)
+	}
+	return trySend(po.out, res)
+}
@@ -0,0 +1,366 @@
+package output
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// testOutput records the sent points, if full is set TrySend discards them
+type testOutput struct {
+	id     string
+	full   bool
+	mutex  sync.Mutex
+	sent   []*Point
+	routes []Route
+}
+
+func (o *testOutput) ID() string                     { return o.id }
+func (o *testOutput) Init()                          {}
+func (o *testOutput) End()                           {}
+func (o *testOutput) StartSender(wg *sync.WaitGroup) {}
+func (o *testOutput) StopSender()                    {}
+func (o *testOutput) GetResetStats() *Stats          { return &Stats{} }
+
+func (o *testOutput) Send(pts []*Point) {
+	o.SendRoute(pts, Route{})
+}
+
+func (o *testOutput) TrySend(pts []*Point) bool {
+	return o.TrySendRoute(pts, Route{})
+}
+
+func (o *testOutput) SendRoute(pts []*Point, r Route) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.sent = append(o.sent, pts...)
+	for range pts {
+		o.routes = append(o.routes, r)
+	}
+}
+
+func (o *testOutput) TrySendRoute(pts []*Point, r Route) bool {
+	if o.full {
+		return false
+	}
+	o.SendRoute(pts, r)
+	return true
+}
+
+// names returns the ifName tag of the sent points
+func (o *testOutput) names() []string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	var res []string
+	for _, p := range o.sent {
+		res = append(res, p.Tags["ifName"])
+	}
+	return res
+}
+
+func testProcPoint(name string, ifName string) *Point {
+	return &Point{
+		Name:   name,
+		Tags:   map[string]string{"device": "Router 1", "ifName": ifName, "ifType": "6"},
+		Fields: map[string]interface{}{"in": int64(10), "out": int64(20), "status": "Up"},
+		Time:   time.Unix(1000, 0),
+		Meta: PointMeta{
+			DeviceTag:     "device",
+			IndexTags:     []string{"ifName"},
+			DeviceTags:    []string{"device"},
+			CounterFields: map[string]bool{"in": true, "out": true},
+		},
+	}
+}
+
+func Test_Processors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.ProcessorCfg
+		point *Point
+		want  *Point
+		route bool
+	}{
+		{
+			name:  "rename tag",
+			cfg:   config.ProcessorCfg{Type: "rename_tag", Key: "ifName", Value: "interface"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "")
+				delete(p.Tags, "ifName")
+				p.Tags["interface"] = "Gi0/1"
+				p.Meta.IndexTags = []string{"interface"}
+				return p
+			}(),
+		},
+		{
+			name:  "rename device tag",
+			cfg:   config.ProcessorCfg{Type: "rename_tag", Key: "device", Value: "host"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				delete(p.Tags, "device")
+				p.Tags["host"] = "Router 1"
+				p.Meta.DeviceTag = "host"
+				p.Meta.DeviceTags = []string{"host"}
+				return p
+			}(),
+		},
+		{
+			name:  "rename missing tag",
+			cfg:   config.ProcessorCfg{Type: "rename_tag", Key: "vlan", Value: "vid"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want:  testProcPoint("ifstats", "Gi0/1"),
+		},
+		{
+			name:  "rename counter field",
+			cfg:   config.ProcessorCfg{Type: "rename_field", Key: "in", Value: "in_octets"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				delete(p.Fields, "in")
+				p.Fields["in_octets"] = int64(10)
+				p.Meta.CounterFields = map[string]bool{"in_octets": true, "out": true}
+				return p
+			}(),
+		},
+		{
+			name:  "rename gauge field",
+			cfg:   config.ProcessorCfg{Type: "rename_field", Key: "status", Value: "oper"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				delete(p.Fields, "status")
+				p.Fields["oper"] = "Up"
+				return p
+			}(),
+		},
+		{
+			name:  "drop fields",
+			cfg:   config.ProcessorCfg{Type: "drop_field", Key: "^(in|status)$"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				p.Fields = map[string]interface{}{"out": int64(20)}
+				p.Meta.CounterFields = map[string]bool{"out": true}
+				return p
+			}(),
+		},
+		{
+			name:  "add tag",
+			cfg:   config.ProcessorCfg{Type: "add_tag", Key: "site", Value: "bcn"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				p.Tags["site"] = "bcn"
+				return p
+			}(),
+		},
+		{
+			name:  "add existing tag",
+			cfg:   config.ProcessorCfg{Type: "add_tag", Key: "ifType", Value: "ethernet"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				p.Tags["ifType"] = "ethernet"
+				return p
+			}(),
+		},
+		{
+			name:  "lowercase tags",
+			cfg:   config.ProcessorCfg{Type: "lowercase_tag", Key: "^if"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "gi0/1")
+				p.Tags["device"] = "Router 1"
+				return p
+			}(),
+		},
+		{
+			name:  "sanitize tags",
+			cfg:   config.ProcessorCfg{Type: "sanitize_tag", Key: ".*"},
+			point: testProcPoint("ifstats", "Gi0/1.100"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0_1.100")
+				p.Tags["device"] = "Router_1"
+				return p
+			}(),
+		},
+		{
+			name:  "sanitize tags with replacement",
+			cfg:   config.ProcessorCfg{Type: "sanitize_tag", Key: "ifName", Value: "-"},
+			point: testProcPoint("ifstats", "Gi0/1 (uplink)"),
+			want:  testProcPoint("ifstats", "Gi0-1--uplink-"),
+		},
+		{
+			name:  "route matching value",
+			cfg:   config.ProcessorCfg{Type: "route", Key: "ifType", Value: "^(6|117)$"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want:  testProcPoint("ifstats", "Gi0/1"),
+			route: true,
+		},
+		{
+			name:  "route not matching value",
+			cfg:   config.ProcessorCfg{Type: "route", Key: "ifType", Value: "^24$"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want:  testProcPoint("ifstats", "Gi0/1"),
+		},
+		{
+			name:  "route missing tag",
+			cfg:   config.ProcessorCfg{Type: "route", Key: "vlan", Value: ".*"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want:  testProcPoint("ifstats", "Gi0/1"),
+		},
+		{
+			name:  "measurement not matching",
+			cfg:   config.ProcessorCfg{Type: "add_tag", Measurement: "^cpu", Key: "site", Value: "bcn"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want:  testProcPoint("ifstats", "Gi0/1"),
+		},
+		{
+			name:  "measurement matching",
+			cfg:   config.ProcessorCfg{Type: "drop_field", Measurement: "^if", Key: "status"},
+			point: testProcPoint("ifstats", "Gi0/1"),
+			want: func() *Point {
+				p := testProcPoint("ifstats", "Gi0/1")
+				delete(p.Fields, "status")
+				return p
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		p, err := newProcessor(&tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if route := p.apply(tt.point); route != tt.route {
+			t.Errorf("%s: route %t, expected %t", tt.name, route, tt.route)
+		}
+		if diff := cmp.Diff(tt.want, tt.point); diff != "" {
+			t.Errorf("%s: point (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func Test_NewProcessorInvalid(t *testing.T) {
+	tests := []config.ProcessorCfg{
+		{ID: "meas", Type: "add_tag", Measurement: "(", Key: "k"},
+		{ID: "key", Type: "drop_field", Key: "["},
+		{ID: "value", Type: "route", Key: "k", Value: "*"},
+		{ID: "type", Type: "unknown"},
+	}
+	for _, c := range tests {
+		if _, err := newProcessor(&c); err == nil {
+			t.Errorf("processor %s: expected error", c.ID)
+		}
+	}
+
+	// invalid processors are skipped, without valid ones the output is returned as is
+	o := &testOutput{id: "out"}
+	if po := NewProcessedOutput(o, []*config.ProcessorCfg{&tests[0], &tests[3]}, nil); po != o {
+		t.Errorf("output wrapped without valid processors: %#v", po)
+	}
+}
+
+func Test_ProcessedOutputRoute(t *testing.T) {
+	out := &testOutput{id: "out"}
+	fast := &testOutput{id: "fast"}
+	procs := []*config.ProcessorCfg{
+		{ID: "p1", Type: "add_tag", Key: "site", Value: "bcn", Order: 1},
+		{ID: "p2", Type: "route", Key: "ifType", Value: "^6$", Target: "fast", Order: 2},
+		{ID: "p3", Type: "route", Key: "ifType", Value: "^24$", Target: "missing", Order: 3},
+		{ID: "p4", Type: "drop_field", Key: ".*", Measurement: "^empty$", Order: 4},
+		{ID: "p5", Type: "lowercase_tag", Key: "ifName", Order: 5},
+	}
+	for _, c := range procs {
+		c.Outputs = []string{"out"}
+	}
+	outs := map[string]Output{"out": out, "fast": fast}
+	res := applyProcessors(outs, map[string]*config.ProcessorCfg{"p5": procs[4], "p3": procs[2], "p1": procs[0], "p4": procs[3], "p2": procs[1]})
+	po, ok := res["out"].(*ProcessedOutput)
+	if !ok {
+		t.Fatalf("output not wrapped with processors: %#v", res["out"])
+	}
+	// without processors configured the outputs are not wrapped
+	if res["fast"] != fast {
+		t.Errorf("output fast wrapped without processors: %#v", res["fast"])
+	}
+
+	build := func() []*Point {
+		loop := testProcPoint("ifstats", "LO0")
+		loop.Tags["ifType"] = "24"
+		empty := testProcPoint("empty", "EMPTY")
+		empty.Tags["ifType"] = "1"
+		other := testProcPoint("ifstats", "TU0")
+		other.Tags["ifType"] = "131"
+		return []*Point{testProcPoint("ifstats", "GI0"), loop, empty, other, testProcPoint("ifstats", "GI1")}
+	}
+	pts := build()
+	po.Send(pts)
+
+	// ethernet points are routed to fast once processed up to the route processor, loopback
+	// ones are dropped (target not found), the empty point is dropped and the others processed
+	if got := fast.names(); !cmp.Equal(got, []string{"GI0", "GI1"}) {
+		t.Errorf("routed points %v, expected [GI0 GI1]", got)
+	}
+	if got := out.names(); !cmp.Equal(got, []string{"tu0"}) {
+		t.Errorf("processed points %v, expected [tu0]", got)
+	}
+	if got := fast.sent[0].Tags["site"]; got != "bcn" {
+		t.Errorf("routed point site tag %q, expected bcn", got)
+	}
+	if got := out.sent[0].Tags["site"]; got != "bcn" {
+		t.Errorf("processed point site tag %q, expected bcn", got)
+	}
+	// the original points are not modified
+	if diff := cmp.Diff(build(), pts); diff != "" {
+		t.Errorf("original points modified (-want +got):\n%s", diff)
+	}
+
+	// routed destinations are kept for the output but not for the route targets
+	out.sent, fast.sent, out.routes, fast.routes = nil, nil, nil, nil
+	r := Route{Database: "db2", Retention: "rp2"}
+	NewRoutedOutput(po, r).Send(build())
+	if !cmp.Equal(out.routes, []Route{r}) || !cmp.Equal(fast.routes, []Route{{}, {}}) {
+		t.Errorf("routes out %v fast %v", out.routes, fast.routes)
+	}
+
+	// non blocking sends discard routed points on full targets without affecting the output
+	out.sent, fast.sent = nil, nil
+	fast.full = true
+	if !po.TrySend(build()) {
+		t.Errorf("TrySend failed with the output queue available")
+	}
+	if len(fast.sent) != 0 || !cmp.Equal(out.names(), []string{"tu0"}) {
+		t.Errorf("TrySend sent %v to fast and %v to out", fast.names(), out.names())
+	}
+	out.full = true
+	if po.TrySend(build()) {
+		t.Errorf("TrySend succeeded with the output queue full")
+	}
+	// all points routed or dropped, nothing to enqueue on the output
+	only := build()[:2]
+	if !po.TrySend(only) {
+		t.Errorf("TrySend failed without points for the output")
+	}
+}
+
+func Test_ClonePoint(t *testing.T) {
+	p := testProcPoint("ifstats", "Gi0/1")
+	cp := clonePoint(p)
+	if diff := cmp.Diff(p, cp, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("clone (-want +got):\n%s", diff)
+	}
+	cp.Tags["ifName"] = "x"
+	cp.Fields["in"] = int64(0)
+	cp.Meta.IndexTags[0] = "x"
+	cp.Meta.DeviceTags[0] = "x"
+	cp.Meta.CounterFields["status"] = true
+	if diff := cmp.Diff(testProcPoint("ifstats", "Gi0/1"), p); diff != "" {
+		t.Errorf("original modified by clone changes (-want +got):\n%s", diff)
+	}
+}
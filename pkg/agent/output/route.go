@@ -0,0 +1,85 @@
+package output
+
+import (
+	"sync"
+)
+
+// Route overrides the configured destination (database/bucket and retention policy) of an output
+type Route struct {
+	Database  string
+	Retention string
+}
+
+// IsEmpty returns true if no override has been set
+func (r Route) IsEmpty() bool {
+	return len(r.Database) == 0 && len(r.Retention) == 0
+}
+
+// RouteSender could be implemented by outputs able to write in other destinations than the configured one
+type RouteSender interface {
+	// SendRoute enqueues points to be written in the route destination
+	SendRoute(pts []*Point, r Route)
+	// TrySendRoute enqueues points without blocking, returns false if they have been discarded
+	TrySendRoute(pts []*Point, r Route) bool
+}
+
+// RoutedOutput sends all data to a shared output overriding its destination
+type RoutedOutput struct {
+	out   Output
+	route Route
+}
+
+// NewRoutedOutput creates an output that writes through o to the route destination, if the
+// route is empty or o does not support routing o is returned as is
+func NewRoutedOutput(o Output, r Route) Output {
+	if r.IsEmpty() {
+		return o
+	}
+	target := o
+	if po, ok := o.(*ProcessedOutput); ok {
+		target = po.out
+	}
+	if _, ok := target.(RouteSender); !ok {
+		log.Warnf("Output %s does not support database/retention overrides, ignoring route %+v", o.ID(), r)
+		return o
+	}
+	return &RoutedOutput{out: o, route: r}
+}
+
+// ID returns the output ID with the route destination
+func (ro *RoutedOutput) ID() string {
+	return ro.out.ID() + "@" + ro.route.Database + "." + ro.route.Retention
+}
+
+// Init initializes the shared output
+func (ro *RoutedOutput) Init() {
+	ro.out.Init()
+}
+
+// End does nothing, the output is shared and released from the runtime output map
+func (ro *RoutedOutput) End() {
+}
+
+// StartSender begins the sender goroutine of the shared output
+func (ro *RoutedOutput) StartSender(wg *sync.WaitGroup) {
+	ro.out.StartSender(wg)
+}
+
+// StopSender does nothing, the output is shared and stopped from the runtime output map
+func (ro *RoutedOutput) StopSender() {
+}
+
+// Send enqueues points to the route destination
+func (ro *RoutedOutput) Send(pts []*Point) {
+	ro.out.(RouteSender).SendRoute(pts, ro.route)
+}
+
+// TrySend enqueues points to the route destination without blocking
+func (ro *RoutedOutput) TrySend(pts []*Point) bool {
+	return ro.out.(RouteSender).TrySendRoute(pts, ro.route)
+}
+
+// GetResetStats returns empty stats, the shared output stats are reported by itself
+func (ro *RoutedOutput) GetResetStats() *Stats {
+	return &Stats{}
+}
@@ -0,0 +1,214 @@
+package output
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var dataDir string
+
+// SetDataDir set the base directory where output spool files will be stored
+func SetDataDir(dir string) {
+	dataDir = dir
+}
+
+const spoolSegmentExt = ".seg"
+
+// spoolSegment one batch of data stored on disk
+type spoolSegment struct {
+	seq   uint64
+	size  int64
+	mtime time.Time
+}
+
+// Spool is a disk backed FIFO queue for output batches that could not be written
+// to the backend. Each batch is stored in its own segment file named by a growing
+// sequence number, so it will be replayed in the same order it was stored.
+type Spool struct {
+	dir      string
+	maxSize  int64
+	maxAge   time.Duration
+	segments []*spoolSegment
+	size     int64
+	nextSeq  uint64
+	mutex    sync.Mutex
+	// counters since last reset
+	written  int64
+	replayed int64
+	dropped  int64
+}
+
+// SpoolStats spool counters and current state
+type SpoolStats struct {
+	Segments int64
+	Bytes    int64
+	Written  int64
+	Replayed int64
+	Dropped  int64
+}
+
+// NewSpool opens (or creates) the spool directory and loads pending segments from previous runs
+// maxSize in bytes and maxAge limits are not applied if set to 0
+func NewSpool(dir string, maxSize int64, maxAge time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Spool{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		nextSeq: 1,
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), spoolSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(f.Name(), spoolSegmentExt), 10, 64)
+		if err != nil || filepath.Base(s.segmentPath(seq)) != f.Name() {
+			log.Warnf("SPOOL [%s] skipping unknown file %s", dir, f.Name())
+			continue
+		}
+		s.segments = append(s.segments, &spoolSegment{seq: seq, size: f.Size(), mtime: f.ModTime()})
+		s.size += f.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	if len(s.segments) > 0 {
+		log.Infof("SPOOL [%s] found %d pending segments (%d bytes) from previous runs", dir, len(s.segments), s.size)
+	}
+	return s, nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentExt))
+}
+
+// removeFirst removes the oldest segment, should be called with the mutex locked
+func (s *Spool) removeFirst() {
+	seg := s.segments[0]
+	if err := os.Remove(s.segmentPath(seg.seq)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("SPOOL [%s] error on remove segment %d: %s", s.dir, seg.seq, err)
+	}
+	s.segments = s.segments[1:]
+	s.size -= seg.size
+}
+
+// purge drops the oldest segments until size and age limits are satisfied, should be called with the mutex locked
+func (s *Spool) purge() {
+	now := time.Now()
+	for len(s.segments) > 0 {
+		seg := s.segments[0]
+		switch {
+		case s.maxSize > 0 && s.size > s.maxSize:
+			log.Warnf("SPOOL [%s] size limit exceeded (%d > %d bytes) dropping segment %d", s.dir, s.size, s.maxSize, seg.seq)
+		case s.maxAge > 0 && now.Sub(seg.mtime) > s.maxAge:
+			log.Warnf("SPOOL [%s] age limit exceeded (%s) dropping segment %d", s.dir, s.maxAge, seg.seq)
+		default:
+			return
+		}
+		s.removeFirst()
+		s.dropped++
+	}
+}
+
+// Push stores a new batch at the end of the queue
+func (s *Spool) Push(data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	seq := s.nextSeq
+	fname := s.segmentPath(seq)
+	// write to a temporary file first to avoid half written segments on crash
+	tmp := fname + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	s.nextSeq++
+	s.segments = append(s.segments, &spoolSegment{seq: seq, size: int64(len(data)), mtime: time.Now()})
+	s.size += int64(len(data))
+	s.written++
+	s.purge()
+	return nil
+}
+
+// Peek returns the oldest batch in the queue, or nil if empty
+func (s *Spool) Peek() ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.purge()
+	if len(s.segments) == 0 {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(s.segmentPath(s.segments[0].seq))
+	if err != nil {
+		// unreadable segment, drop it to avoid blocking the queue forever
+		log.Errorf("SPOOL [%s] error on read segment %d , dropping it: %s", s.dir, s.segments[0].seq, err)
+		s.removeFirst()
+		s.dropped++
+		return nil, err
+	}
+	return data, nil
+}
+
+// Pop removes the oldest batch once it has been successfully replayed
+func (s *Spool) Pop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.segments) == 0 {
+		return
+	}
+	s.removeFirst()
+	s.replayed++
+}
+
+// Discard removes the oldest batch when it can not be replayed
+func (s *Spool) Discard() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.segments) == 0 {
+		return
+	}
+	s.removeFirst()
+	s.dropped++
+}
+
+// Len returns the number of pending batches
+func (s *Spool) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.segments)
+}
+
+// GetResetStats returns current spool state and counters and reset them
+func (s *Spool) GetResetStats() SpoolStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	st := SpoolStats{
+		Segments: int64(len(s.segments)),
+		Bytes:    s.size,
+		Written:  s.written,
+		Replayed: s.replayed,
+		Dropped:  s.dropped,
+	}
+	s.written = 0
+	s.replayed = 0
+	s.dropped = 0
+	return st
+}
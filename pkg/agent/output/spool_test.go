@@ -0,0 +1,222 @@
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testSpoolDrain returns all pending batches in order, removing them from the spool
+func testSpoolDrain(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var res []string
+	for s.Len() > 0 {
+		data, err := s.Peek()
+		if err != nil {
+			t.Fatalf("peek: %s", err)
+		}
+		if data == nil {
+			break
+		}
+		res = append(res, string(data))
+		s.Pop()
+	}
+	return res
+}
+
+func Test_SpoolOrder(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("new spool: %s", err)
+	}
+	var want []string
+	for i := 0; i < 12; i++ {
+		data := "batch " + string(rune('a'+i))
+		want = append(want, data)
+		if err := s.Push([]byte(data)); err != nil {
+			t.Fatalf("push: %s", err)
+		}
+	}
+	if s.Len() != 12 {
+		t.Fatalf("%d segments, expected 12", s.Len())
+	}
+	if got := testSpoolDrain(t, s); !cmp.Equal(want, got) {
+		t.Errorf("replay order %v, expected %v", got, want)
+	}
+	st := s.GetResetStats()
+	if st != (SpoolStats{Written: 12, Replayed: 12}) {
+		t.Errorf("stats %+v", st)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("%d files left after replay", len(files))
+	}
+	if data, err := s.Peek(); data != nil || err != nil {
+		t.Errorf("peek on empty spool returned %q, %v", data, err)
+	}
+}
+
+func Test_SpoolReload(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+		next  string // name of the next pushed segment
+	}{
+		{
+			name:  "empty",
+			files: nil,
+			next:  "00000000000000000001.seg",
+		},
+		{
+			name: "numeric order",
+			files: map[string]string{
+				"00000000000000000010.seg": "ten",
+				"00000000000000000002.seg": "two",
+				"00000000000000000007.seg": "seven",
+			},
+			want: []string{"two", "seven", "ten"},
+			next: "00000000000000000011.seg",
+		},
+		{
+			name: "unknown files skipped",
+			files: map[string]string{
+				"00000000000000000003.seg":     "three",
+				"00000000000000000004.seg.tmp": "half written",
+				"notaseq.seg":                  "unknown",
+				"5.seg":                        "not padded",
+				"README":                       "unknown",
+			},
+			want: []string{"three"},
+			next: "00000000000000000004.seg",
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for name, data := range tt.files {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+				t.Fatalf("%s: %s", tt.name, err)
+			}
+		}
+		s, err := NewSpool(dir, 0, 0)
+		if err != nil {
+			t.Fatalf("%s: new spool: %s", tt.name, err)
+		}
+		if err := s.Push([]byte("new")); err != nil {
+			t.Fatalf("%s: push: %s", tt.name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, tt.next)); err != nil {
+			t.Errorf("%s: next segment: %s", tt.name, err)
+		}
+		if got := testSpoolDrain(t, s); !cmp.Equal(append(tt.want, "new"), got) {
+			t.Errorf("%s: replay %v, expected %v", tt.name, got, append(tt.want, "new"))
+		}
+	}
+}
+
+func Test_SpoolRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("new spool: %s", err)
+	}
+	for _, data := range []string{"first", "second", "third"} {
+		s.Push([]byte(data))
+	}
+	s.Pop()
+
+	// pending segments are kept across restarts
+	s, err = NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("reopen spool: %s", err)
+	}
+	if st := s.GetResetStats(); st.Segments != 2 || st.Bytes != int64(len("second")+len("third")) {
+		t.Errorf("reloaded %d segments with %d bytes", st.Segments, st.Bytes)
+	}
+	s.Push([]byte("fourth"))
+	want := []string{"second", "third", "fourth"}
+	if got := testSpoolDrain(t, s); !cmp.Equal(want, got) {
+		t.Errorf("replay %v, expected %v", got, want)
+	}
+}
+
+func Test_SpoolPurge(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int64
+		maxAge  time.Duration
+		age     time.Duration // age of the first two segments
+		want    []string
+		dropped int64
+	}{
+		{name: "no limits", age: 48 * time.Hour, want: []string{"aaaa", "bbbb", "cccc"}},
+		{name: "size", maxSize: 10, want: []string{"bbbb", "cccc"}, dropped: 1},
+		{name: "size exact", maxSize: 12, want: []string{"aaaa", "bbbb", "cccc"}},
+		{name: "age", maxAge: time.Hour, age: 2 * time.Hour, want: []string{"cccc"}, dropped: 2},
+		{name: "age not reached", maxAge: time.Hour, age: 30 * time.Minute, want: []string{"aaaa", "bbbb", "cccc"}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		s, err := NewSpool(dir, 0, 0)
+		if err != nil {
+			t.Fatalf("%s: new spool: %s", tt.name, err)
+		}
+		for _, data := range []string{"aaaa", "bbbb", "cccc"} {
+			s.Push([]byte(data))
+		}
+		old := time.Now().Add(-tt.age)
+		for seq := uint64(1); seq <= 2; seq++ {
+			os.Chtimes(s.segmentPath(seq), old, old)
+		}
+		// limits are applied to the segments loaded from disk
+		s, err = NewSpool(dir, tt.maxSize, tt.maxAge)
+		if err != nil {
+			t.Fatalf("%s: reopen spool: %s", tt.name, err)
+		}
+		if got := testSpoolDrain(t, s); !cmp.Equal(tt.want, got) {
+			t.Errorf("%s: replay %v, expected %v", tt.name, got, tt.want)
+		}
+		if st := s.GetResetStats(); st.Dropped != tt.dropped || st.Segments != 0 || st.Bytes != 0 {
+			t.Errorf("%s: stats %+v, expected %d dropped", tt.name, st, tt.dropped)
+		}
+	}
+}
+
+func Test_SpoolPushSizeLimit(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), 10, 0)
+	if err != nil {
+		t.Fatalf("new spool: %s", err)
+	}
+	for _, data := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+		s.Push([]byte(data))
+	}
+	if st := s.GetResetStats(); st.Segments != 2 || st.Bytes != 8 || st.Written != 4 || st.Dropped != 2 {
+		t.Errorf("stats %+v, expected 2 segments, 8 bytes, 4 written and 2 dropped", st)
+	}
+}
+
+func Test_SpoolUnreadableSegment(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("new spool: %s", err)
+	}
+	s.Push([]byte("lost"))
+	s.Push([]byte("kept"))
+	os.Remove(s.segmentPath(1))
+
+	// the unreadable segment is dropped so it does not block the queue
+	if data, err := s.Peek(); err == nil || data != nil {
+		t.Errorf("peek returned %q, %v, expected error", data, err)
+	}
+	if got := testSpoolDrain(t, s); !cmp.Equal([]string{"kept"}, got) {
+		t.Errorf("replay %v, expected [kept]", got)
+	}
+	if st := s.GetResetStats(); st.Dropped != 1 || st.Replayed != 1 || st.Bytes != 0 {
+		t.Errorf("stats %+v", st)
+	}
+}
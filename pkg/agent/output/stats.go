@@ -5,8 +5,8 @@ import (
 	"time"
 )
 
-// InfluxStats  get stats
-type InfluxStats struct {
+// Stats output backend write statistics
+type Stats struct {
 	// Fields Sent
 	FieldSent int64
 	// Field Sent the max
@@ -15,6 +15,8 @@ type InfluxStats struct {
 	PSent int64
 	// PSentMax the max
 	PSentMax int64
+	// PDropped points discarded without blocking because the sender queue was full
+	PDropped int64
 	// WriteSent BatchPoints sent
 	WriteSent int64
 	// WriteErrors BatchPoints with  errors
@@ -25,38 +27,60 @@ type InfluxStats struct {
 	WriteTimeMax time.Duration
 	// BufferPercentUsed
 	BufferPercentUsed float32
-	mutex             sync.Mutex
+	// Flushes merged batches flushed (only if batching is enabled)
+	Flushes int64
+	// FlushBatches gathered batches merged on all flushes
+	FlushBatches int64
+	// FlushLatency time since the first batch was merged until flush
+	FlushLatency time.Duration
+	// FlushLatencyMax the max
+	FlushLatencyMax time.Duration
+	// Spool disk buffer stats (only if enabled)
+	Spool SpoolStats
+	// Breaker failover circuit breaker stats (only if a standby has been configured)
+	Breaker BreakerStats
+	mutex   sync.Mutex
 }
 
-// GetResetStats get stats for this InfluxStats Output
-func (is *InfluxStats) GetResetStats() *InfluxStats {
+// GetResetStats get stats for this Output
+func (is *Stats) GetResetStats() *Stats {
 	is.mutex.Lock()
 	defer is.mutex.Unlock()
-	retstat := &InfluxStats{
+	retstat := &Stats{
 		FieldSent:         is.FieldSent,
 		FieldSentMax:      is.FieldSentMax,
 		PSent:             is.PSent,
 		PSentMax:          is.PSentMax,
+		PDropped:          is.PDropped,
 		WriteSent:         is.WriteSent,
 		WriteErrors:       is.WriteErrors,
 		WriteTime:         is.WriteTime,
 		WriteTimeMax:      is.WriteTimeMax,
 		BufferPercentUsed: is.BufferPercentUsed,
+		Flushes:           is.Flushes,
+		FlushBatches:      is.FlushBatches,
+		FlushLatency:      is.FlushLatency,
+		FlushLatencyMax:   is.FlushLatencyMax,
 	}
 	is.FieldSent = 0
 	is.FieldSentMax = 0
 	is.PSent = 0
 	is.PSentMax = 0
+	is.PDropped = 0
 	is.WriteSent = 0
 	is.WriteErrors = 0
 	is.WriteTime = 0
 	is.WriteTimeMax = 0
 	is.BufferPercentUsed = 0
+	is.Flushes = 0
+	is.FlushBatches = 0
+	is.FlushLatency = 0
+	is.FlushLatencyMax = 0
 	return retstat
 }
 
 // WriteOkUpdate update stats on write ok
-func (is *InfluxStats) WriteOkUpdate(ps int64, fs int64, wt time.Duration, bufferPercent float32) {
+func (is *Stats) WriteOkUpdate(ps int64, fs int64, wt time.Duration, bufferPercent float32) {
 	is.mutex.Lock()
 	defer is.mutex.Unlock()
 	if is.PSentMax < ps {
@@ -76,7 +100,7 @@ func (is *InfluxStats) WriteOkUpdate(ps int64, fs int64, wt time.Duration, buffe
 }
 
 // WriteErrUpdate update stats on write error
-func (is *InfluxStats) WriteErrUpdate(wt time.Duration, bufferPercent float32) {
+func (is *Stats) WriteErrUpdate(wt time.Duration, bufferPercent float32) {
 	is.mutex.Lock()
 	defer is.mutex.Unlock()
 
@@ -87,3 +111,22 @@ func (is *InfluxStats) WriteErrUpdate(wt time.Duration, bufferPercent float32) {
 	is.WriteTime += wt
 	is.BufferPercentUsed = bufferPercent
 }
+
+// DropUpdate update stats on discarded points
+func (is *Stats) DropUpdate(ps int64) {
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+	is.PDropped += ps
+}
+
+// FlushUpdate update stats on merged batches flush
+func (is *Stats) FlushUpdate(batches int64, latency time.Duration) {
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+	if is.FlushLatencyMax < latency {
+		is.FlushLatencyMax = latency
+	}
+	is.Flushes++
+	is.FlushBatches += batches
+	is.FlushLatency += latency
+}
@@ -7,7 +7,6 @@ import (
 	"sync"
 	"time"
 
-	client "github.com/influxdata/influxdb1-client/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
@@ -23,11 +22,11 @@ func SetLogger(l *logrus.Logger) {
 // SelfMon configuration for self monitoring
 type SelfMon struct {
 	cfg                 *config.SelfMonConfig
-	Influx              *output.InfluxDB
-	OutDBs              map[string]*output.InfluxDB // needed to get statistics
+	Output              output.Output
+	OutDBs              map[string]output.Output // needed to get statistics
 	runtimeStatsRunning bool
 	TagMap              map[string]string
-	bps                 *client.BatchPoints
+	points              []*output.Point
 	chExit              chan bool
 	mutex               sync.Mutex
 	RtMeasName          string // devices measurement name
@@ -52,7 +51,7 @@ func (sm *SelfMon) Init() {
 		log.Info("Self monitoring thread  already Initialized (skipping Initialization)")
 		return
 	}
-	sm.OutDBs = make(map[string]*output.InfluxDB)
+	sm.OutDBs = make(map[string]output.Output)
 
 	// Init extra tags
 	if len(sm.cfg.ExtraTags) > 0 {
@@ -85,7 +84,7 @@ func (sm *SelfMon) Init() {
 }
 
 // SetOutDB set the output devices for query its statistics
-func (sm *SelfMon) SetOutDB(odb map[string]*output.InfluxDB) {
+func (sm *SelfMon) SetOutDB(odb map[string]output.Output) {
 	sm.OutDBs = odb
 }
 
@@ -115,28 +114,26 @@ func (sm *SelfMon) IsInitialized() bool {
 }
 
 // SetOutput set out data
-func (sm *SelfMon) SetOutput(val *output.InfluxDB) {
+func (sm *SelfMon) SetOutput(val output.Output) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
-	sm.Influx = val
-	// Creating a bachpoint to begin writing data
-	sm.bps, _ = sm.Influx.BP()
+	sm.Output = val
+	// Creating a point array to begin writing data
+	sm.points = nil
 }
 
 func (sm *SelfMon) sendData() {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
-	sm.Influx.Send(sm.bps)
-	// BatchPoint Init again
-	sm.bps, _ = sm.Influx.BP()
+	sm.Output.Send(sm.points)
+	// Point array Init again
+	sm.points = nil
 }
 
-func (sm *SelfMon) addDataPoint(pt *client.Point) {
+func (sm *SelfMon) addDataPoint(pt *output.Point) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
-	if sm.bps != nil {
-		(*sm.bps).AddPoint(pt)
-	}
+	sm.points = append(sm.points, pt)
 }
 
 // AddDeviceMetrics add data from devices
@@ -172,7 +169,7 @@ func (sm *SelfMon) AddMetrics(t string, id string, fields map[string]interface{}
 	tagMap["type"] = t
 
 	now := time.Now()
-	pt, err := client.NewPoint(
+	pt, err := output.NewPoint(
 		sm.RtMeasName,
 		tagMap,
 		fields,
@@ -182,7 +179,7 @@ func (sm *SelfMon) AddMetrics(t string, id string, fields map[string]interface{}
 		return
 	}
 
-	(*sm.bps).AddPoint(pt)
+	sm.points = append(sm.points, pt)
 }
 
 // End Release the SelMon Object
@@ -234,6 +231,7 @@ func (sm *SelfMon) getOutDBStats() {
 
 		fields["points_sent"] = stats.PSent
 		fields["points_sent_max"] = stats.PSentMax
+		fields["points_dropped"] = stats.PDropped
 
 		fields["write_sent"] = stats.WriteSent
 		fields["write_error"] = stats.WriteErrors
@@ -243,18 +241,47 @@ func (sm *SelfMon) getOutDBStats() {
 
 		fields["buffer_percent_used"] = stats.BufferPercentUsed
 
+		if st := stats.Spool; st.Segments > 0 || st.Written > 0 || st.Replayed > 0 || st.Dropped > 0 {
+			fields["spool_segments"] = st.Segments
+			fields["spool_bytes"] = st.Bytes
+			fields["spool_written"] = st.Written
+			fields["spool_replayed"] = st.Replayed
+			fields["spool_dropped"] = st.Dropped
+		}
+
+		if stats.Flushes > 0 {
+			fields["flush_count"] = stats.Flushes
+			fields["flush_batches_avg"] = float64(stats.FlushBatches) / float64(stats.Flushes)
+			fields["flush_latency_avg"] = stats.FlushLatency.Seconds() / float64(stats.Flushes)
+			fields["flush_latency_max"] = stats.FlushLatencyMax.Seconds()
+		}
+
+		if st := stats.Breaker; st.Enabled {
+			state := 0
+			switch st.State {
+			case output.BreakerOpen:
+				state = 1
+			case output.BreakerHalfOpen:
+				state = 2
+			}
+			fields["failover_state"] = state
+			fields["failover_count"] = st.Failovers
+			fields["failback_count"] = st.Failbacks
+			fields["failover_writes"] = st.StandbyWrites
+		}
+
 		if stats.WriteSent > 0 {
 			fields["points_sent_avg"] = float64(stats.PSent) / float64(stats.WriteSent)
 			fields["write_time_avg"] = sec / float64(stats.WriteSent)
 		}
 
-		pt, err := client.NewPoint(sm.OutMeasName, tm, fields, now)
+		pt, err := output.NewPoint(sm.OutMeasName, tm, fields, now)
 		if err != nil {
 			log.Warnf("Error on compute Stats data Point %+v for database %s: Error:%s", fields, dbname, err)
 			return
 		}
 
-		// add data to the batchpoint
+		// add data to the point array
 		sm.addDataPoint(pt)
 	}
 }
@@ -313,7 +340,7 @@ func (sm *SelfMon) getRuntimeStats() {
 
 	sm.lastSampleTime = now
 
-	pt, err := client.NewPoint(
+	pt, err := output.NewPoint(
 		sm.GvmMeasName,
 		sm.TagMap,
 		fields,
@@ -324,7 +351,7 @@ func (sm *SelfMon) getRuntimeStats() {
 		return
 	}
 
-	// add data to the batchpoint
+	// add data to the point array
 	sm.addDataPoint(pt)
 }
 
@@ -340,7 +367,7 @@ func (sm *SelfMon) reportStats(wg *sync.WaitGroup) {
 		sm.getRuntimeStats()
 		//
 		sm.getOutDBStats()
-		// BatchPoint Send
+		// Points Send
 		sm.sendData()
 
 	LOOP:
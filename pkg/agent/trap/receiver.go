@@ -0,0 +1,315 @@
+package trap
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/utils"
+)
+
+// resolveInterval min time between device source address resolutions, done when traps are received from unknown addresses
+const resolveInterval = 60 * time.Second
+
+var log utils.Logger
+
+// SetLogger sets the current log output
+func SetLogger(l utils.Logger) {
+	log = l
+}
+
+// Stats trap receiver counters since it was started
+// swagger:model TrapStats
+type Stats struct {
+	Listen        string
+	Received      int64 // traps and informs received
+	Informs       int64 // informs acknowledged
+	DecodeErrors  int64 // dropped, malformed packets
+	UnknownSource int64 // dropped, no device configured with the source address
+	AuthErrors    int64 // dropped, community or v3 credentials do not match the device ones
+	Unmatched     int64 // dropped, no rule for the notification OID
+	V3Informs     int64 // dropped, SNMPv3 informs are not supported
+	Points        int64 // points sent to the device outputs
+	PointsDropped int64 // points discarded because the device output queue was full
+}
+
+// source is a device which could send traps from an address
+type source struct {
+	dev     *device.SnmpDevice
+	decoder *gosnmp.GoSNMP
+}
+
+// Receiver listens for SNMP v1/v2c/v3 traps and v1/v2c informs, the sender address and credentials
+// are matched with the runtime devices and the traps are converted to points with the trap rules and
+// sent through the device outputs.
+// SNMPv3 informs are dropped: the receiver should be their authoritative engine, with its own engine
+// ID, boots and time reported to the senders on discovery, and it has no local engine.
+type Receiver struct {
+	cfg     config.TrapConfig
+	rules   []*rule
+	unknown *rule
+	devices func() []*device.SnmpDevice
+	conn    net.PacketConn
+	wg      sync.WaitGroup
+
+	// only accessed from the receiver goroutine
+	sources  map[string][]*source
+	resolved time.Time
+
+	smutex sync.Mutex
+	stats  Stats
+}
+
+// NewReceiver creates a trap receiver, devices returns the current runtime devices
+func NewReceiver(cfg config.TrapConfig, rules map[string]*config.TrapRuleCfg, devices func() []*device.SnmpDevice) *Receiver {
+	if len(cfg.Listen) == 0 {
+		cfg.Listen = "0.0.0.0:162"
+	}
+	r := &Receiver{cfg: cfg, rules: newRules(rules), devices: devices}
+	if len(cfg.UnknownMeasurement) > 0 {
+		r.unknown = unknownRule(cfg.UnknownMeasurement)
+	}
+	return r
+}
+
+// Start begins listening for traps
+func (r *Receiver) Start() error {
+	var err error
+	r.conn, err = net.ListenPacket("udp", r.cfg.Listen)
+	if err != nil {
+		return err
+	}
+	listen := r.conn.LocalAddr().String()
+	r.smutex.Lock()
+	r.stats.Listen = listen
+	r.smutex.Unlock()
+	log.Infof("TRAP: listening for traps and informs on %s with %d rules", listen, len(r.rules))
+	r.resolve()
+	r.wg.Add(1)
+	go r.receive()
+	return nil
+}
+
+// Stop closes the listener and waits until the received traps are processed
+func (r *Receiver) Stop() {
+	if r.conn == nil {
+		return
+	}
+	r.conn.Close()
+	r.wg.Wait()
+	log.Infof("TRAP: receiver on %s stopped", r.cfg.Listen)
+}
+
+// GetStats return the receiver counters
+func (r *Receiver) GetStats() Stats {
+	r.smutex.Lock()
+	defer r.smutex.Unlock()
+	return r.stats
+}
+
+func (r *Receiver) count(c *int64) {
+	r.smutex.Lock()
+	*c++
+	r.smutex.Unlock()
+}
+
+// resolve rebuilds the source address map from the runtime devices
+func (r *Receiver) resolve() {
+	r.sources = make(map[string][]*source)
+	r.resolved = time.Now()
+	for _, dev := range r.devices() {
+		addrs, decoder, err := dev.GetTrapSource()
+		if err != nil {
+			log.Warnf("TRAP: traps from device %s will be dropped: %s", dev.GetID(), err)
+			continue
+		}
+		for _, a := range addrs {
+			r.sources[a] = append(r.sources[a], &source{dev: dev, decoder: decoder})
+		}
+	}
+}
+
+// lookup returns the devices configured with the address, sources are resolved again if not found
+func (r *Receiver) lookup(addr string) []*source {
+	if srcs, ok := r.sources[addr]; ok {
+		return srcs
+	}
+	if time.Since(r.resolved) > resolveInterval {
+		r.resolve()
+	}
+	return r.sources[addr]
+}
+
+func (r *Receiver) receive() {
+	defer r.wg.Done()
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			// closed
+			return
+		}
+		ua, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		r.handle(buf[:n], ua)
+	}
+}
+
+// handle decodes and authenticates the trap, sends its point and acknowledges informs
+func (r *Receiver) handle(buf []byte, addr *net.UDPAddr) {
+	r.count(&r.stats.Received)
+	version, err := packetVersion(buf)
+	if err != nil {
+		r.count(&r.stats.DecodeErrors)
+		log.Warnf("TRAP: error on decode packet from %s: %s", addr, err)
+		return
+	}
+	ip := addr.IP.String()
+	srcs := r.lookup(ip)
+
+	var pkt *gosnmp.SnmpPacket
+	var src *source
+	if version == gosnmp.Version3 {
+		pkt, src = r.decodeV3(buf, srcs)
+	} else {
+		pkt = (&gosnmp.GoSNMP{Version: version}).UnmarshalTrap(buf, false)
+		if pkt == nil {
+			r.count(&r.stats.DecodeErrors)
+			log.Warnf("TRAP: error on decode v%s packet from %s", version, addr)
+			return
+		}
+		if len(srcs) == 0 {
+			// sent from a proxy or NAT device
+			if agent := agentAddress(pkt); len(agent) > 0 && agent != ip {
+				srcs = r.lookup(agent)
+			}
+		}
+		for _, s := range srcs {
+			if s.decoder.Version != gosnmp.Version3 && s.decoder.Community == pkt.Community {
+				src = s
+				break
+			}
+		}
+	}
+	if len(srcs) == 0 {
+		r.count(&r.stats.UnknownSource)
+		log.Warnf("TRAP: dropped trap from %s, no device configured with this address", ip)
+		return
+	}
+	if src == nil {
+		r.count(&r.stats.AuthErrors)
+		log.Warnf("TRAP: dropped %s trap from %s, the credentials do not match any device configured with this address", version, ip)
+		return
+	}
+	if pkt.PDUType != gosnmp.Trap && pkt.PDUType != gosnmp.SNMPv2Trap && pkt.PDUType != gosnmp.InformRequest {
+		r.count(&r.stats.DecodeErrors)
+		log.Warnf("TRAP: dropped unexpected %s PDU from %s", pkt.PDUType, ip)
+		return
+	}
+	if version == gosnmp.Version3 && pkt.PDUType == gosnmp.InformRequest {
+		r.count(&r.stats.V3Informs)
+		log.Warnf("TRAP: dropped SNMPv3 inform from device %s, only v3 traps are supported", src.dev.GetID())
+		return
+	}
+
+	r.process(pkt, ip, src.dev)
+	if pkt.PDUType == gosnmp.InformRequest {
+		if err := r.ack(pkt, addr); err != nil {
+			log.Warnf("TRAP: error on acknowledge inform from device %s: %s", src.dev.GetID(), err)
+			return
+		}
+		r.count(&r.stats.Informs)
+	}
+}
+
+// decodeV3 decodes and authenticates the packet with the credentials of the devices configured with the source address
+func (r *Receiver) decodeV3(buf []byte, srcs []*source) (*gosnmp.SnmpPacket, *source) {
+	for _, s := range srcs {
+		if s.decoder.Version != gosnmp.Version3 {
+			continue
+		}
+		pkt := s.decoder.UnmarshalTrap(buf, true)
+		if pkt == nil {
+			continue
+		}
+		usm, ok := pkt.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+		if !ok || usm.UserName != s.decoder.SecurityParameters.(*gosnmp.UsmSecurityParameters).UserName {
+			continue
+		}
+		// the packet security level should not be lower than the configured one
+		if pkt.MsgFlags&gosnmp.AuthPriv < s.decoder.MsgFlags&gosnmp.AuthPriv {
+			continue
+		}
+		return pkt, s
+	}
+	return nil, nil
+}
+
+// process converts the trap with its rule and sends the point through the device outputs
+func (r *Receiver) process(pkt *gosnmp.SnmpPacket, ip string, dev *device.SnmpDevice) {
+	t := &trapData{oid: notificationOID(pkt), source: ip, device: dev.GetID(), pkt: pkt}
+	rl := matchRule(r.rules, t.oid)
+	if rl == nil {
+		rl = r.unknown
+	}
+	if rl == nil {
+		r.count(&r.stats.Unmatched)
+		log.Debugf("TRAP: no rule for trap %s from device %s", t.oid, t.device)
+		return
+	}
+	name, tags, fields := rl.convert(t)
+	sent, err := dev.SendTrapPoint(name, tags, fields, time.Now())
+	if err != nil {
+		log.Warnf("TRAP: error on send trap %s from device %s with rule %s: %s", t.oid, t.device, rl.cfg.ID, err)
+		return
+	}
+	if !sent {
+		r.count(&r.stats.PointsDropped)
+		return
+	}
+	r.count(&r.stats.Points)
+}
+
+// ack sends the inform response with the same request id and varbinds
+func (r *Receiver) ack(pkt *gosnmp.SnmpPacket, addr net.Addr) error {
+	pkt.PDUType = gosnmp.GetResponse
+	pkt.Error = gosnmp.NoError
+	pkt.ErrorIndex = 0
+	// the response is not reportable
+	pkt.MsgFlags &^= gosnmp.Reportable
+	out, err := pkt.MarshalMsg()
+	if err != nil {
+		return err
+	}
+	_, err = r.conn.WriteTo(out, addr)
+	return err
+}
+
+// packetVersion returns the SNMP version of a message, SEQUENCE { version INTEGER, ... }
+func packetVersion(buf []byte) (gosnmp.SnmpVersion, error) {
+	if len(buf) < 2 || buf[0] != 0x30 {
+		return 0, fmt.Errorf("invalid message header")
+	}
+	i := 2
+	if buf[1]&0x80 != 0 {
+		i += int(buf[1] & 0x7f)
+	}
+	if len(buf) < i+3 || buf[i] != 0x02 || buf[i+1] != 0x01 {
+		return 0, fmt.Errorf("invalid message version")
+	}
+	v := gosnmp.SnmpVersion(buf[i+2])
+	switch v {
+	case gosnmp.Version1, gosnmp.Version2c, gosnmp.Version3:
+		return v, nil
+	}
+	return 0, fmt.Errorf("unsupported version %d", buf[i+2])
+}
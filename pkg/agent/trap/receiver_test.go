@@ -0,0 +1,164 @@
+package trap
+
+import (
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+// testOutput records the points sent, with full set all points are discarded
+type testOutput struct {
+	mutex sync.Mutex
+	full  bool
+	pts   []*output.Point
+}
+
+func (o *testOutput) ID() string                     { return "test" }
+func (o *testOutput) Init()                          {}
+func (o *testOutput) End()                           {}
+func (o *testOutput) StartSender(wg *sync.WaitGroup) {}
+func (o *testOutput) StopSender()                    {}
+func (o *testOutput) GetResetStats() *output.Stats   { return &output.Stats{} }
+
+func (o *testOutput) Send(pts []*output.Point) {
+	o.TrySend(pts)
+}
+
+func (o *testOutput) TrySend(pts []*output.Point) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.full {
+		return false
+	}
+	o.pts = append(o.pts, pts...)
+	return true
+}
+
+func (o *testOutput) points() []*output.Point {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return append([]*output.Point(nil), o.pts...)
+}
+
+func Test_packetVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+		want gosnmp.SnmpVersion
+		ok   bool
+	}{
+		{"v1", []byte{0x30, 0x03, 0x02, 0x01, 0x00}, gosnmp.Version1, true},
+		{"v2c", []byte{0x30, 0x03, 0x02, 0x01, 0x01}, gosnmp.Version2c, true},
+		{"v3", []byte{0x30, 0x03, 0x02, 0x01, 0x03}, gosnmp.Version3, true},
+		{"long length", []byte{0x30, 0x82, 0x01, 0x00, 0x02, 0x01, 0x01}, gosnmp.Version2c, true},
+		{"empty", nil, 0, false},
+		{"not a sequence", []byte{0x31, 0x03, 0x02, 0x01, 0x01}, 0, false},
+		{"truncated", []byte{0x30, 0x82, 0x01, 0x00, 0x02, 0x01}, 0, false},
+		{"version not integer", []byte{0x30, 0x03, 0x04, 0x01, 0x01}, 0, false},
+		{"long version", []byte{0x30, 0x04, 0x02, 0x02, 0x00, 0x01}, 0, false},
+		{"unknown version", []byte{0x30, 0x03, 0x02, 0x01, 0x02}, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := packetVersion(tt.buf)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("%s: version %s error %v, expected %s", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func Test_ReceiverV2c(t *testing.T) {
+	dev := device.New(&config.SnmpDeviceCfg{
+		ID:             "sw1",
+		Host:           "127.0.0.1",
+		Port:           161,
+		SnmpVersion:    "2c",
+		Community:      "public",
+		Timeout:        5,
+		DeviceTagValue: "id",
+		LogFile:        filepath.Join(t.TempDir(), "sw1.log"),
+	})
+	out := &testOutput{}
+	dev.Output = out
+	r := NewReceiver(
+		config.TrapConfig{Listen: "127.0.0.1:0"},
+		map[string]*config.TrapRuleCfg{"linkdown": {ID: "linkdown", TrapOID: ".1.3.6.1.6.3.1.1.5.3", Mode: "event", Measurement: "events"}},
+		func() []*device.SnmpDevice { return []*device.SnmpDevice{dev} },
+	)
+	if err := r.Start(); err != nil {
+		t.Fatalf("start: %s", err)
+	}
+	defer r.Stop()
+	_, port, _ := net.SplitHostPort(r.GetStats().Listen)
+
+	send := func(community string, inform bool) error {
+		g := &gosnmp.GoSNMP{Target: "127.0.0.1", Transport: "udp", Version: gosnmp.Version2c, Community: community, Timeout: time.Second, Retries: 0, MaxOids: gosnmp.MaxOids}
+		p, _ := net.LookupPort("udp", port)
+		g.Port = uint16(p)
+		if err := g.Connect(); err != nil {
+			t.Fatalf("connect: %s", err)
+		}
+		defer g.Conn.Close()
+		_, err := g.SendTrap(gosnmp.SnmpTrap{IsInform: inform, Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.3", Type: gosnmp.Integer, Value: 3},
+		}})
+		return err
+	}
+	waitFor := func(what string, cond func(st Stats) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond(r.GetStats()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout waiting %s: %+v", what, r.GetStats())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if err := send("public", false); err != nil {
+		t.Fatalf("send trap: %s", err)
+	}
+	waitFor("trap point", func(st Stats) bool { return st.Points == 1 })
+
+	// informs are acknowledged with the same request
+	if err := send("public", true); err != nil {
+		t.Fatalf("send inform: %s", err)
+	}
+	waitFor("inform ack", func(st Stats) bool { return st.Informs == 1 && st.Points == 2 })
+
+	// wrong credentials are not acknowledged
+	if err := send("private", true); err == nil {
+		t.Errorf("inform with wrong community acknowledged")
+	}
+	waitFor("auth error", func(st Stats) bool { return st.AuthErrors == 1 })
+
+	// the receiver is not blocked when the output queue is full
+	out.mutex.Lock()
+	out.full = true
+	out.mutex.Unlock()
+	if err := send("public", false); err != nil {
+		t.Fatalf("send trap: %s", err)
+	}
+	waitFor("dropped point", func(st Stats) bool { return st.PointsDropped == 1 })
+
+	if st := r.GetStats(); st.Received != 4 || st.Points != 2 || st.DecodeErrors != 0 || st.UnknownSource != 0 {
+		t.Errorf("receiver stats %+v", st)
+	}
+	pts := out.points()
+	if len(pts) != 2 {
+		t.Fatalf("%d points, expected 2", len(pts))
+	}
+	for _, p := range pts {
+		if p.Name != "events" || p.Tags["device"] != "sw1" || p.Tags["trap"] != "linkdown" || p.Fields["source"] != "127.0.0.1" {
+			t.Errorf("point %s tags %v fields %v", p.Name, p.Tags, p.Fields)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package trap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
+)
+
+const (
+	oidSysUpTime    = ".1.3.6.1.2.1.1.3.0"
+	oidTrapOID      = ".1.3.6.1.6.3.1.1.4.1.0"
+	oidTrapAddress  = ".1.3.6.1.6.3.18.1.3.0"
+	oidGenericTraps = ".1.3.6.1.6.3.1.1.5"
+)
+
+// rule is a trap rule ready to convert traps to points
+type rule struct {
+	cfg      *config.TrapRuleCfg
+	oid      string
+	prefix   bool
+	varbinds []config.TrapVarBind
+	tags     map[string]string
+}
+
+// newRule checks and prepares the trap rule config
+func newRule(c *config.TrapRuleCfg) (*rule, error) {
+	vbs, err := c.GetVarBinds()
+	if err != nil {
+		return nil, err
+	}
+	r := &rule{cfg: c, oid: c.TrapOID, varbinds: vbs, tags: make(map[string]string)}
+	if strings.HasSuffix(r.oid, ".*") {
+		r.oid = strings.TrimSuffix(r.oid, ".*")
+		r.prefix = true
+	}
+	if !strings.HasPrefix(r.oid, ".") {
+		r.oid = "." + r.oid
+	}
+	for _, tag := range c.ExtraTags {
+		s := strings.Split(tag, "=")
+		if len(s) != 2 {
+			return nil, fmt.Errorf("Error on tag definition TAG=VALUE [ %s ] on trap rule %s", tag, c.ID)
+		}
+		r.tags[s[0]] = s[1]
+	}
+	return r, nil
+}
+
+// newRules returns the valid trap rules sorted to match first the exact OIDs and then the longest prefixes
+func newRules(cfgs map[string]*config.TrapRuleCfg) []*rule {
+	rules := make([]*rule, 0, len(cfgs))
+	for _, c := range cfgs {
+		r, err := newRule(c)
+		if err != nil {
+			log.Errorf("Trap rule %s disabled: %s", c.ID, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].prefix != rules[j].prefix {
+			return !rules[i].prefix
+		}
+		if len(rules[i].oid) != len(rules[j].oid) {
+			return len(rules[i].oid) > len(rules[j].oid)
+		}
+		return rules[i].cfg.ID < rules[j].cfg.ID
+	})
+	return rules
+}
+
+// matchRule returns the rule for the notification OID, nil if no rule matches
+func matchRule(rules []*rule, oid string) *rule {
+	for _, r := range rules {
+		if r.oid == oid || (r.prefix && strings.HasPrefix(oid, r.oid+".")) {
+			return r
+		}
+	}
+	return nil
+}
+
+// notificationOID returns the trap OID, v1 traps are converted as defined on RFC3584 section 3.1
+func notificationOID(pkt *gosnmp.SnmpPacket) string {
+	if pkt.PDUType == gosnmp.Trap {
+		if pkt.GenericTrap == 6 {
+			return normalizeOID(pkt.Enterprise) + ".0." + fmt.Sprint(pkt.SpecificTrap)
+		}
+		return oidGenericTraps + "." + fmt.Sprint(pkt.GenericTrap+1)
+	}
+	for _, v := range pkt.Variables {
+		if normalizeOID(v.Name) == oidTrapOID {
+			return normalizeOID(snmp.PduVal2OID(v))
+		}
+	}
+	return ""
+}
+
+// agentAddress returns the address of the trap originator if sent from a proxy or NAT device
+func agentAddress(pkt *gosnmp.SnmpPacket) string {
+	if pkt.PDUType == gosnmp.Trap {
+		if pkt.AgentAddress != "0.0.0.0" {
+			return pkt.AgentAddress
+		}
+		return ""
+	}
+	for _, v := range pkt.Variables {
+		if normalizeOID(v.Name) == oidTrapAddress {
+			return snmp.PduVal2str(v)
+		}
+	}
+	return ""
+}
+
+func normalizeOID(oid string) string {
+	if len(oid) > 0 && !strings.HasPrefix(oid, ".") {
+		return "." + oid
+	}
+	return oid
+}
+
+// varBindValue returns the varbind value as point field value
+func varBindValue(v gosnmp.SnmpPDU) interface{} {
+	switch v.Type {
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return nil
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		return snmp.PduVal2Int64(v)
+	case gosnmp.OctetString, gosnmp.IPAddress, gosnmp.ObjectIdentifier:
+		return snmp.PduVal2str(v)
+	default:
+		return fmt.Sprint(v.Value)
+	}
+}
+
+// trapData is a received trap ready to be converted to a point
+type trapData struct {
+	oid    string
+	source string
+	device string
+	pkt    *gosnmp.SnmpPacket
+}
+
+// convert returns the point name, tags and fields for the trap
+func (r *rule) convert(t *trapData) (string, map[string]string, map[string]interface{}) {
+	tags := map[string]string{"trap": r.cfg.ID}
+	for k, v := range r.tags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+	for _, v := range t.pkt.Variables {
+		name := normalizeOID(v.Name)
+		if len(r.varbinds) == 0 {
+			if r.cfg.Mode == "event" && name != oidSysUpTime && name != oidTrapOID {
+				if val := varBindValue(v); val != nil {
+					fields[name] = val
+				}
+			}
+			continue
+		}
+		for _, vb := range r.varbinds {
+			if name != vb.OID && !strings.HasPrefix(name, vb.OID+".") {
+				continue
+			}
+			val := varBindValue(v)
+			if val == nil {
+				break
+			}
+			fields[vb.Name] = val
+			if idx := strings.TrimPrefix(name, vb.OID+"."); idx != name {
+				if _, ok := tags["index"]; !ok {
+					tags["index"] = idx
+				}
+			}
+			break
+		}
+	}
+	if r.cfg.Mode == "event" {
+		if len(r.cfg.Severity) > 0 {
+			tags["severity"] = r.cfg.Severity
+		}
+		fields["message"] = r.message(t, fields)
+		fields["trap_oid"] = t.oid
+		fields["source"] = t.source
+	}
+	return r.cfg.Measurement, tags, fields
+}
+
+// message renders the event text replacing the placeholders
+func (r *rule) message(t *trapData, fields map[string]interface{}) string {
+	if len(r.cfg.Message) == 0 {
+		return fmt.Sprintf("%s trap %s from %s", r.cfg.ID, t.oid, t.device)
+	}
+	repl := []string{"{trapoid}", t.oid, "{source}", t.source, "{device}", t.device}
+	for _, vb := range r.varbinds {
+		if v, ok := fields[vb.Name]; ok {
+			repl = append(repl, "{"+vb.Name+"}", fmt.Sprint(v))
+		}
+	}
+	return strings.NewReplacer(repl...).Replace(r.cfg.Message)
+}
+
+// unknownRule is used to send as events the traps without any matching rule
+func unknownRule(measurement string) *rule {
+	return &rule{cfg: &config.TrapRuleCfg{ID: "unknown", Mode: "event", Measurement: measurement}, tags: map[string]string{}}
+}
@@ -0,0 +1,174 @@
+package trap
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+)
+
+func init() {
+	SetLogger(logrus.New())
+}
+
+func Test_notificationOID(t *testing.T) {
+	tests := []struct {
+		name string
+		pkt  *gosnmp.SnmpPacket
+		want string
+	}{
+		{"v1 coldStart", &gosnmp.SnmpPacket{PDUType: gosnmp.Trap, SnmpTrap: gosnmp.SnmpTrap{GenericTrap: 0}}, ".1.3.6.1.6.3.1.1.5.1"},
+		{"v1 linkDown", &gosnmp.SnmpPacket{PDUType: gosnmp.Trap, SnmpTrap: gosnmp.SnmpTrap{GenericTrap: 2, Enterprise: ".1.3.6.1.4.1.9"}}, ".1.3.6.1.6.3.1.1.5.3"},
+		{
+			"v1 enterprise specific",
+			&gosnmp.SnmpPacket{PDUType: gosnmp.Trap, SnmpTrap: gosnmp.SnmpTrap{GenericTrap: 6, SpecificTrap: 12, Enterprise: "1.3.6.1.4.1.9.9.41.2"}},
+			".1.3.6.1.4.1.9.9.41.2.0.12",
+		},
+		{
+			"v2c",
+			&gosnmp.SnmpPacket{PDUType: gosnmp.SNMPv2Trap, Variables: []gosnmp.SnmpPDU{
+				{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+				{Name: "1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.6.3.1.1.5.3"},
+			}},
+			".1.3.6.1.6.3.1.1.5.3",
+		},
+		{
+			"inform",
+			&gosnmp.SnmpPacket{PDUType: gosnmp.InformRequest, Variables: []gosnmp.SnmpPDU{
+				{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9.0.1"},
+			}},
+			".1.3.6.1.4.1.9.0.1",
+		},
+		{"v2c without trap OID", &gosnmp.SnmpPacket{PDUType: gosnmp.SNMPv2Trap}, ""},
+	}
+	for _, tt := range tests {
+		if got := notificationOID(tt.pkt); got != tt.want {
+			t.Errorf("%s: notification OID %q, expected %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_matchRule(t *testing.T) {
+	rules := newRules(map[string]*config.TrapRuleCfg{
+		"linkdown":   {ID: "linkdown", TrapOID: ".1.3.6.1.6.3.1.1.5.3"},
+		"generic":    {ID: "generic", TrapOID: ".1.3.6.1.6.3.1.1.5.*"},
+		"cisco":      {ID: "cisco", TrapOID: "1.3.6.1.4.1.9.*"},
+		"enterprise": {ID: "enterprise", TrapOID: ".1.3.6.1.4.1.*"},
+		"ciscoconf":  {ID: "ciscoconf", TrapOID: ".1.3.6.1.4.1.9.9.43.*"},
+		// disabled rules
+		"badtag":     {ID: "badtag", TrapOID: ".1.3.6.1.2.1.*", ExtraTags: []string{"site"}},
+		"badvarbind": {ID: "badvarbind", TrapOID: ".1.3.6.1.2.1.*", VarBinds: []string{".1.3.6.1.2.1.2.2.1.1"}},
+	})
+	if len(rules) != 5 {
+		t.Errorf("%d valid rules, expected 5", len(rules))
+	}
+	tests := []struct {
+		oid  string
+		want string
+	}{
+		// exact OIDs are matched first
+		{".1.3.6.1.6.3.1.1.5.3", "linkdown"},
+		{".1.3.6.1.6.3.1.1.5.4", "generic"},
+		// then the longest prefix
+		{".1.3.6.1.4.1.9.9.43.2.0.1", "ciscoconf"},
+		{".1.3.6.1.4.1.9.0.1", "cisco"},
+		// prefixes match the OID itself and the OIDs below it
+		{".1.3.6.1.4.1.9", "cisco"},
+		{".1.3.6.1.4.1.90.1", "enterprise"},
+		{".1.3.6.1.6.3.1.1", ""},
+		{".1.3.6.1.2.1.1", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if r := matchRule(rules, tt.oid); r != nil {
+			got = r.cfg.ID
+		}
+		if got != tt.want {
+			t.Errorf("rule for %q: %q, expected %q", tt.oid, got, tt.want)
+		}
+	}
+}
+
+func Test_ruleConvert(t *testing.T) {
+	linkDown := &trapData{
+		oid:    ".1.3.6.1.6.3.1.1.5.3",
+		source: "10.0.0.1",
+		device: "sw1",
+		pkt: &gosnmp.SnmpPacket{PDUType: gosnmp.SNMPv2Trap, Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.3", Type: gosnmp.Integer, Value: 3},
+			{Name: "1.3.6.1.2.1.2.2.1.2.3", Type: gosnmp.OctetString, Value: []byte("Gi0/3")},
+			{Name: ".1.3.6.1.2.1.2.2.1.8.3", Type: gosnmp.Integer, Value: 2},
+			{Name: ".1.3.6.1.2.1.2.2.1.7.3", Type: gosnmp.NoSuchInstance},
+		}},
+	}
+	tests := []struct {
+		name   string
+		cfg    config.TrapRuleCfg
+		tags   map[string]string
+		fields map[string]interface{}
+	}{
+		{
+			name: "event with all varbinds",
+			cfg:  config.TrapRuleCfg{ID: "linkdown", Mode: "event", Measurement: "events", Severity: "major", ExtraTags: []string{"team=net"}},
+			tags: map[string]string{"trap": "linkdown", "severity": "major", "team": "net"},
+			fields: map[string]interface{}{
+				".1.3.6.1.2.1.2.2.1.1.3": int64(3),
+				".1.3.6.1.2.1.2.2.1.2.3": "Gi0/3",
+				".1.3.6.1.2.1.2.2.1.8.3": int64(2),
+				"message":                "linkdown trap .1.3.6.1.6.3.1.1.5.3 from sw1",
+				"trap_oid":               ".1.3.6.1.6.3.1.1.5.3",
+				"source":                 "10.0.0.1",
+			},
+		},
+		{
+			name: "event with mapped varbinds",
+			cfg: config.TrapRuleCfg{
+				ID:          "linkdown",
+				Mode:        "event",
+				Measurement: "events",
+				VarBinds:    []string{".1.3.6.1.2.1.2.2.1.2=ifDescr", "1.3.6.1.2.1.2.2.1.7=ifAdminStatus"},
+				Message:     "{ifDescr} down on {device} ({trapoid} from {source}) {ifAdminStatus}",
+			},
+			tags: map[string]string{"trap": "linkdown", "index": "3"},
+			fields: map[string]interface{}{
+				"ifDescr":  "Gi0/3",
+				"message":  "Gi0/3 down on sw1 (.1.3.6.1.6.3.1.1.5.3 from 10.0.0.1) {ifAdminStatus}",
+				"trap_oid": ".1.3.6.1.6.3.1.1.5.3",
+				"source":   "10.0.0.1",
+			},
+		},
+		{
+			name: "metric",
+			cfg: config.TrapRuleCfg{
+				ID:          "linkdown",
+				Mode:        "metric",
+				Measurement: "ifstatus",
+				VarBinds:    []string{".1.3.6.1.2.1.2.2.1.8=ifOperStatus", ".1.3.6.1.2.1.2.2.1.1=ifIndex"},
+				Severity:    "major",
+			},
+			tags:   map[string]string{"trap": "linkdown", "index": "3"},
+			fields: map[string]interface{}{"ifIndex": int64(3), "ifOperStatus": int64(2)},
+		},
+	}
+	for _, tt := range tests {
+		r, err := newRule(&tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		name, tags, fields := r.convert(linkDown)
+		if name != tt.cfg.Measurement {
+			t.Errorf("%s: measurement %s", tt.name, name)
+		}
+		if !cmp.Equal(tt.tags, tags) {
+			t.Errorf("%s: tags %v", tt.name, cmp.Diff(tt.tags, tags))
+		}
+		if !cmp.Equal(tt.fields, fields) {
+			t.Errorf("%s: fields %v", tt.name, cmp.Diff(tt.fields, fields))
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package config
+
+import "fmt"
+
+/***************************
+	SNMP credential profiles
+	-GetCredentialCfgByID(struct)
+	-GetCredentialCfgMap (map - for interna config use
+	-GetCredentialCfgArray(Array - for web ui use )
+	-AddCredentialCfg
+	-DelCredentialCfg
+	-UpdateCredentialCfg
+	-GetCredentialCfgAffectOnDel
+***********************************/
+
+// Apply sets the profile SNMP version and credentials on the device config
+func (c *CredentialCfg) Apply(dev *SnmpDeviceCfg) {
+	dev.SnmpVersion = c.SnmpVersion
+	dev.Community = c.Community
+	dev.V3SecLevel = c.V3SecLevel
+	dev.V3AuthUser = c.V3AuthUser
+	dev.V3AuthPass = c.V3AuthPass
+	dev.V3AuthProt = c.V3AuthProt
+	dev.V3PrivPass = c.V3PrivPass
+	dev.V3PrivProt = c.V3PrivProt
+	dev.V3ContextEngineID = c.V3ContextEngineID
+	dev.V3ContextName = c.V3ContextName
+}
+
+// ResolveCredential returns a copy of the device config with the SNMP version and credentials of
+// its credential profile, the device config is returned as is if it has no profile
+func (dev *SnmpDeviceCfg) ResolveCredential(creds map[string]*CredentialCfg) (*SnmpDeviceCfg, error) {
+	if len(dev.Credential) == 0 {
+		return dev, nil
+	}
+	c, ok := creds[dev.Credential]
+	if !ok {
+		return nil, fmt.Errorf("credential profile %s not found", dev.Credential)
+	}
+	res := *dev
+	c.Apply(&res)
+	return &res, nil
+}
+
+// GetFallbackCredentials returns the device fallback credential profiles in the order they should be tried
+func (dev *SnmpDeviceCfg) GetFallbackCredentials(creds map[string]*CredentialCfg) ([]*CredentialCfg, error) {
+	res := make([]*CredentialCfg, 0, len(dev.FallbackCredentials))
+	for _, id := range dev.FallbackCredentials {
+		c, ok := creds[id]
+		if !ok {
+			return nil, fmt.Errorf("fallback credential profile %s not found", id)
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// ResolveSnmpDeviceCredential sets on the device config the SNMP version and credentials of its credential profile
+func (dbc *DatabaseCfg) ResolveSnmpDeviceCredential(dev *SnmpDeviceCfg) error {
+	if len(dev.Credential) == 0 {
+		return nil
+	}
+	c, err := dbc.GetCredentialCfgByID(dev.Credential)
+	if err != nil {
+		return err
+	}
+	c.Apply(dev)
+	return nil
+}
+
+// checkCredentialCfg checks the fields needed for each SNMP version
+func checkCredentialCfg(c *CredentialCfg) error {
+	switch c.SnmpVersion {
+	case "1", "2c":
+		if len(c.Community) == 0 {
+			return fmt.Errorf("community is mandatory for SNMP v%s credential profiles", c.SnmpVersion)
+		}
+	case "3":
+		if len(c.V3AuthUser) == 0 {
+			return fmt.Errorf("V3AuthUser is mandatory for SNMP v3 credential profiles")
+		}
+		switch c.V3SecLevel {
+		case "NoAuthNoPriv":
+		case "AuthPriv":
+			if len(c.V3PrivPass) == 0 || len(c.V3PrivProt) == 0 {
+				return fmt.Errorf("V3PrivPass and V3PrivProt are mandatory with V3SecLevel AuthPriv")
+			}
+			fallthrough
+		case "AuthNoPriv":
+			if len(c.V3AuthPass) == 0 || len(c.V3AuthProt) == 0 {
+				return fmt.Errorf("V3AuthPass and V3AuthProt are mandatory with V3SecLevel %s", c.V3SecLevel)
+			}
+		default:
+			return fmt.Errorf("unknown V3SecLevel %s", c.V3SecLevel)
+		}
+	default:
+		return fmt.Errorf("unknown SNMP version %s", c.SnmpVersion)
+	}
+	return nil
+}
+
+// checkSnmpDeviceCredential checks the device has its own SNMP version or an existing credential profile
+// and all its fallback credential profiles exist
+func (dbc *DatabaseCfg) checkSnmpDeviceCredential(dev *SnmpDeviceCfg) error {
+	if len(dev.Credential) == 0 {
+		if len(dev.SnmpVersion) == 0 {
+			return fmt.Errorf("SnmpVersion is mandatory on devices without credential profile")
+		}
+	} else if _, err := dbc.GetCredentialCfgByID(dev.Credential); err != nil {
+		return fmt.Errorf("invalid credential profile on device %s: %s", dev.ID, err)
+	}
+	seen := make(map[string]bool)
+	for _, id := range dev.FallbackCredentials {
+		if id == dev.Credential || seen[id] {
+			return fmt.Errorf("duplicated fallback credential profile %s on device %s", id, dev.ID)
+		}
+		seen[id] = true
+		if _, err := dbc.GetCredentialCfgByID(id); err != nil {
+			return fmt.Errorf("invalid fallback credential profile on device %s: %s", dev.ID, err)
+		}
+	}
+	return nil
+}
+
+/*GetCredentialCfgByID get credential profile data by id*/
+func (dbc *DatabaseCfg) GetCredentialCfgByID(id string) (CredentialCfg, error) {
+	cfgarray, err := dbc.GetCredentialCfgArray("id='" + id + "'")
+	if err != nil {
+		return CredentialCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return CredentialCfg{}, fmt.Errorf("Error %d results on get CredentialCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return CredentialCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the credential profile config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetCredentialCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetCredentialCfgMap(filter string) (map[string]*CredentialCfg, error) {
+	cfgarray, err := dbc.GetCredentialCfgArray(filter)
+	cfgmap := make(map[string]*CredentialCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetCredentialCfgArray generate an array of credential profiles with all its information */
+func (dbc *DatabaseCfg) GetCredentialCfgArray(filter string) ([]*CredentialCfg, error) {
+	var err error
+	var creds []*CredentialCfg
+	// Get Only data for selected profiles
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&creds); err != nil {
+			log.Warnf("Fail to get CredentialCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&creds); err != nil {
+			log.Warnf("Fail to get CredentialCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return creds, nil
+}
+
+/*AddCredentialCfg for adding new credential profiles*/
+func (dbc *DatabaseCfg) AddCredentialCfg(dev CredentialCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = checkCredentialCfg(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new credential profile Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelCredentialCfg for deleting credential profiles from ID*/
+func (dbc *DatabaseCfg) DelCredentialCfg(id string) (int64, error) {
+	var affecteddev, affectedfb, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// devices with this profile will use their own credentials
+	affecteddev, err = session.Where("credential='" + id + "'").Cols("credential").Update(&SnmpDeviceCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete credential profile references on SnmpDeviceCfg with id: %s, error: %s", id, err)
+	}
+	// and the profile is removed from the fallback lists
+	affectedfb, err = session.Where("id_credential='" + id + "'").Delete(&SnmpDevCredentials{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete credential profile references on SnmpDevCredentials with id: %s, error: %s", id, err)
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&CredentialCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully credential profile with ID %s [ %d Devices Affected / %d Fallbacks Affected ]", id, affecteddev, affectedfb)
+	dbc.addChanges(affected + affecteddev + affectedfb)
+	return affected, nil
+}
+
+/*UpdateCredentialCfg for updating credential profiles*/
+func (dbc *DatabaseCfg) UpdateCredentialCfg(id string, dev CredentialCfg) (int64, error) {
+	var affecteddev, affectedfb, affected int64
+	var err error
+	if err = checkCredentialCfg(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	if id != dev.ID { // ID has been changed
+		affecteddev, err = session.Where("credential='" + id + "'").Cols("credential").Update(&SnmpDeviceCfg{Credential: dev.ID})
+		if err != nil {
+			session.Rollback()
+			return 0, fmt.Errorf("Error on Update credential profile on update id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
+		}
+		affectedfb, err = session.Where("id_credential='" + id + "'").Cols("id_credential").Update(&SnmpDevCredentials{IDCredential: dev.ID})
+		if err != nil {
+			session.Rollback()
+			return 0, fmt.Errorf("Error on Update SnmpDevCredentials on update id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
+		}
+		log.Infof("Updated credential profile to %d devices and %d fallbacks", affecteddev, affectedfb)
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated credential profile Successfully with id %s", id)
+	dbc.addChanges(affected + affecteddev + affectedfb)
+	return affected, nil
+}
+
+/*GetCredentialCfgAffectOnDel for deleting credential profiles from ID*/
+func (dbc *DatabaseCfg) GetCredentialCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	var devices []*SnmpDeviceCfg
+	var fallbacks []*SnmpDevCredentials
+	var obj []*DbObjAction
+	if err := dbc.x.Where("credential='" + id + "'").Find(&devices); err != nil {
+		log.Warnf("Error on Get credential profile id %s for devices , error: %s", id, err)
+		return nil, err
+	}
+
+	for _, val := range devices {
+		obj = append(obj, &DbObjAction{
+			Type:     "snmpdevicecfg",
+			TypeDesc: "SNMP Devices",
+			ObID:     val.ID,
+			Action:   "Reset Credential profile from SNMPDevice (its own SNMP credentials will be used)",
+		})
+	}
+
+	if err := dbc.x.Where("id_credential='" + id + "'").Find(&fallbacks); err != nil {
+		log.Warnf("Error on Get fallback credential profile id %s for devices , error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range fallbacks {
+		obj = append(obj, &DbObjAction{
+			Type:     "snmpdevicecfg",
+			TypeDesc: "SNMP Devices",
+			ObID:     val.IDSnmpDev,
+			Action:   "Delete Credential profile from SNMPDevice fallback credentials",
+		})
+	}
+	return obj, nil
+}
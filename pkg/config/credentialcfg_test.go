@@ -0,0 +1,141 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testCredentials = map[string]*CredentialCfg{
+	"v2c": {ID: "v2c", SnmpVersion: "2c", Community: "private"},
+	"v3": {
+		ID: "v3", SnmpVersion: "3", V3SecLevel: "AuthPriv", V3AuthUser: "snmpuser", V3AuthPass: "authpass", V3AuthProt: "SHA",
+		V3PrivPass: "privpass", V3PrivProt: "AES", V3ContextEngineID: "80001f8880", V3ContextName: "ctx",
+	},
+	"v1": {ID: "v1", SnmpVersion: "1", Community: "public"},
+}
+
+func Test_CredentialCfgApply(t *testing.T) {
+	dev := &SnmpDeviceCfg{ID: "sw1", Host: "10.0.0.1", SnmpVersion: "2c", Community: "public", Credential: "v3"}
+	testCredentials["v3"].Apply(dev)
+	want := &SnmpDeviceCfg{
+		ID: "sw1", Host: "10.0.0.1", Credential: "v3", SnmpVersion: "3",
+		V3SecLevel: "AuthPriv", V3AuthUser: "snmpuser", V3AuthPass: "authpass", V3AuthProt: "SHA",
+		V3PrivPass: "privpass", V3PrivProt: "AES", V3ContextEngineID: "80001f8880", V3ContextName: "ctx",
+	}
+	// the v2c community is cleared
+	if diff := cmp.Diff(want, dev); diff != "" {
+		t.Errorf("v3 profile applied on a v2c device (-want +got):\n%s", diff)
+	}
+
+	// and the v3 credentials when a v2c profile is applied
+	testCredentials["v2c"].Apply(dev)
+	want = &SnmpDeviceCfg{ID: "sw1", Host: "10.0.0.1", Credential: "v3", SnmpVersion: "2c", Community: "private"}
+	if diff := cmp.Diff(want, dev); diff != "" {
+		t.Errorf("v2c profile applied on a v3 device (-want +got):\n%s", diff)
+	}
+}
+
+func Test_ResolveCredential(t *testing.T) {
+	tests := []struct {
+		name string
+		dev  SnmpDeviceCfg
+		want *SnmpDeviceCfg
+		ok   bool
+	}{
+		{
+			name: "without profile",
+			dev:  SnmpDeviceCfg{ID: "sw1", SnmpVersion: "2c", Community: "public"},
+			want: &SnmpDeviceCfg{ID: "sw1", SnmpVersion: "2c", Community: "public"},
+			ok:   true,
+		},
+		{
+			name: "v3 profile over v2c",
+			dev:  SnmpDeviceCfg{ID: "sw1", SnmpVersion: "2c", Community: "public", Credential: "v3", FallbackCredentials: []string{"v1"}},
+			want: &SnmpDeviceCfg{
+				ID: "sw1", Credential: "v3", FallbackCredentials: []string{"v1"}, SnmpVersion: "3",
+				V3SecLevel: "AuthPriv", V3AuthUser: "snmpuser", V3AuthPass: "authpass", V3AuthProt: "SHA",
+				V3PrivPass: "privpass", V3PrivProt: "AES", V3ContextEngineID: "80001f8880", V3ContextName: "ctx",
+			},
+			ok: true,
+		},
+		{
+			name: "unknown profile",
+			dev:  SnmpDeviceCfg{ID: "sw1", SnmpVersion: "2c", Community: "public", Credential: "v4"},
+		},
+	}
+	for _, tt := range tests {
+		dev := tt.dev
+		got, err := dev.ResolveCredential(testCredentials)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error %v", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: resolved device (-want +got):\n%s", tt.name, diff)
+		}
+		// the device config is not modified
+		if diff := cmp.Diff(tt.dev, dev); diff != "" {
+			t.Errorf("%s: device modified (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func Test_GetFallbackCredentials(t *testing.T) {
+	tests := []struct {
+		fallbacks []string
+		want      []string
+		ok        bool
+	}{
+		{nil, []string{}, true},
+		// in the configured order
+		{[]string{"v3", "v1", "v2c"}, []string{"v3", "v1", "v2c"}, true},
+		{[]string{"v1", "v3"}, []string{"v1", "v3"}, true},
+		{[]string{"v1", "v4", "v3"}, nil, false},
+	}
+	for _, tt := range tests {
+		dev := &SnmpDeviceCfg{ID: "sw1", Credential: "v2c", FallbackCredentials: tt.fallbacks}
+		creds, err := dev.GetFallbackCredentials(testCredentials)
+		if (err == nil) != tt.ok {
+			t.Errorf("fallbacks %v: error %v", tt.fallbacks, err)
+			continue
+		}
+		var got []string
+		if creds != nil {
+			got = []string{}
+		}
+		for _, c := range creds {
+			got = append(got, c.ID)
+			if c != testCredentials[c.ID] {
+				t.Errorf("fallbacks %v: profile %s is not the configured one", tt.fallbacks, c.ID)
+			}
+		}
+		if !cmp.Equal(tt.want, got) {
+			t.Errorf("fallbacks %v: got %v, expected %v", tt.fallbacks, got, tt.want)
+		}
+	}
+}
+
+func Test_checkCredentialCfg(t *testing.T) {
+	tests := []struct {
+		cfg CredentialCfg
+		ok  bool
+	}{
+		{CredentialCfg{ID: "v1", SnmpVersion: "1", Community: "public"}, true},
+		{CredentialCfg{ID: "v2c no community", SnmpVersion: "2c"}, false},
+		{CredentialCfg{ID: "v3 noauth", SnmpVersion: "3", V3SecLevel: "NoAuthNoPriv", V3AuthUser: "u"}, true},
+		{CredentialCfg{ID: "v3 no user", SnmpVersion: "3", V3SecLevel: "NoAuthNoPriv"}, false},
+		{CredentialCfg{ID: "v3 auth", SnmpVersion: "3", V3SecLevel: "AuthNoPriv", V3AuthUser: "u", V3AuthPass: "p", V3AuthProt: "MD5"}, true},
+		{CredentialCfg{ID: "v3 auth no prot", SnmpVersion: "3", V3SecLevel: "AuthNoPriv", V3AuthUser: "u", V3AuthPass: "p"}, false},
+		{CredentialCfg{ID: "v3 priv", SnmpVersion: "3", V3SecLevel: "AuthPriv", V3AuthUser: "u", V3AuthPass: "p", V3AuthProt: "SHA", V3PrivPass: "p", V3PrivProt: "AES"}, true},
+		{CredentialCfg{ID: "v3 priv no auth", SnmpVersion: "3", V3SecLevel: "AuthPriv", V3AuthUser: "u", V3PrivPass: "p", V3PrivProt: "AES"}, false},
+		{CredentialCfg{ID: "v3 bad level", SnmpVersion: "3", V3SecLevel: "Auth", V3AuthUser: "u"}, false},
+		{CredentialCfg{ID: "bad version", SnmpVersion: "4", Community: "public"}, false},
+	}
+	for _, tt := range tests {
+		err := checkCredentialCfg(&tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, expected ok %t", tt.cfg.ID, err, tt.ok)
+		}
+	}
+}
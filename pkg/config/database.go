@@ -123,6 +123,33 @@ func (dbc *DatabaseCfg) InitDB() error {
 	if err = dbc.x.Sync(new(InfluxCfg)); err != nil {
 		log.Fatalf("Fail to sync database InfluxCfg: %v\n", err)
 	}
+	if err = dbc.x.Sync(new(GraphiteCfg)); err != nil {
+		log.Fatalf("Fail to sync database GraphiteCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(OtlpCfg)); err != nil {
+		log.Fatalf("Fail to sync database OtlpCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(FileCfg)); err != nil {
+		log.Fatalf("Fail to sync database FileCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(MqttCfg)); err != nil {
+		log.Fatalf("Fail to sync database MqttCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(KafkaCfg)); err != nil {
+		log.Fatalf("Fail to sync database KafkaCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(ProcessorCfg)); err != nil {
+		log.Fatalf("Fail to sync database ProcessorCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(OutputProcessors)); err != nil {
+		log.Fatalf("Fail to sync database OutputProcessors: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(TrapRuleCfg)); err != nil {
+		log.Fatalf("Fail to sync database TrapRuleCfg: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(CredentialCfg)); err != nil {
+		log.Fatalf("Fail to sync database CredentialCfg: %v\n", err)
+	}
 	if err = dbc.x.Sync(new(SnmpDeviceCfg)); err != nil {
 		log.Fatalf("Fail to sync database SnmpDeviceCfg: %v\n", err)
 	}
@@ -150,6 +177,15 @@ func (dbc *DatabaseCfg) InitDB() error {
 	if err = dbc.x.Sync(new(SnmpDevFilters)); err != nil {
 		log.Fatalf("Fail to sync database SnmpDevFilters: %v\n", err)
 	}
+	if err = dbc.x.Sync(new(SnmpDevOutDBs)); err != nil {
+		log.Fatalf("Fail to sync database SnmpDevOutDBs: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(SnmpDevCredentials)); err != nil {
+		log.Fatalf("Fail to sync database SnmpDevCredentials: %v\n", err)
+	}
+	if err = dbc.x.Sync(new(MGroupsOutDBs)); err != nil {
+		log.Fatalf("Fail to sync database MGroupsOutDBs: %v\n", err)
+	}
 	if err = dbc.x.Sync(new(CustomFilterCfg)); err != nil {
 		log.Fatalf("Fail to sync database CustomFilterCfg: %v\n", err)
 	}
@@ -200,12 +236,60 @@ func (dbc *DatabaseCfg) LoadDbConfig(cfg *DBConfig) {
 	cfg.VarCatalog = make(map[string]interface{}, len(VarCatalog))
 	cfg.VarCatalog = CatalogVar2Map(VarCatalog)
 
+	// Load credential profiles
+	cfg.Credentials, err = dbc.GetCredentialCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get credential profiles :%v", err)
+	}
+
 	// Load Influxdb databases
 	cfg.Influxdb, err = dbc.GetInfluxCfgMap("")
 	if err != nil {
 		log.Warningf("Some errors on get Influx db's :%v", err)
 	}
 
+	// Load Graphite servers
+	cfg.Graphite, err = dbc.GetGraphiteCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get Graphite servers :%v", err)
+	}
+
+	// Load OTLP receivers
+	cfg.Otlp, err = dbc.GetOtlpCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get OTLP receivers :%v", err)
+	}
+
+	// Load file outputs
+	cfg.File, err = dbc.GetFileCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get file outputs :%v", err)
+	}
+
+	// Load MQTT brokers
+	cfg.Mqtt, err = dbc.GetMqttCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get MQTT brokers :%v", err)
+	}
+
+	// Load kafka outputs
+	cfg.Kafka, err = dbc.GetKafkaCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get kafka outputs :%v", err)
+	}
+
+	// Load output processors
+	cfg.Processors, err = dbc.GetProcessorCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get output processors :%v", err)
+	}
+
+	// Load trap rules
+	cfg.TrapRules, err = dbc.GetTrapRuleCfgMap("")
+	if err != nil {
+		log.Warningf("Some errors on get trap rules :%v", err)
+	}
+
 	// Load metrics
 	cfg.Metrics, err = dbc.GetSnmpMetricCfgMap("")
 	if err != nil {
@@ -5,15 +5,17 @@ package config
 type SnmpDeviceCfg struct {
 	ID string `xorm:"'id' unique" binding:"Required"`
 	// snmp connection config
-	Host       string   `xorm:"host" binding:"Required;AlphaDashDot"`
+	Host       string   `xorm:"host" binding:"Required;HostAddress"` // hostname, IPv4 or IPv6 address (brackets allowed)
 	Port       int      `xorm:"port" binding:"Required"`
+	Transport  string   `xorm:"'transport' default 'udp'" binding:"Default(udp);OmitEmpty;In(udp,udp6,tcp,tcp6)"`
 	SystemOIDs []string `xorm:"systemoids"` // for non MIB-2 based devices
 	Retries    int      `xorm:"retries"`
 	Timeout    int      `xorm:"timeout"`
 	Repeat     int      `xorm:"repeat"`
 	Active     bool     `xorm:"'active' default TRUE"`
-	// snmp auth  config
-	SnmpVersion       string `xorm:"snmpversion" binding:"Required;In(1,2c,3)"`
+	// snmp auth  config (not needed if a credential profile is set)
+	Credential        string `xorm:"credential"`
+	SnmpVersion       string `xorm:"snmpversion" binding:"OmitEmpty;In(1,2c,3)"`
 	Community         string `xorm:"community"`
 	V3SecLevel        string `xorm:"v3seclevel"`
 	V3AuthUser        string `xorm:"v3authuser"`
@@ -23,10 +25,19 @@ type SnmpDeviceCfg struct {
 	V3PrivProt        string `xorm:"v3privprot"`
 	V3ContextEngineID string `xorm:"v3contextengineid"`
 	V3ContextName     string `xorm:"v3contextname"`
+	// credential profiles tried in order when the device ones fail with auth errors or timeouts
+	FallbackCredentials []string `xorm:"-"`
 	// snmp workarround for some devices
 	DisableBulk    bool  `xorm:"'disablebulk' default 0"`
 	MaxRepetitions uint8 `xorm:"'maxrepetitions' default 50" binding:"Default(50);IntegerNotZero"`
 	MaxOids        int   `xorm:"'maxoids' default 60"`
+	// halve MaxRepetitions/MaxOids on timeouts or tooBig errors and grow them again up to the configured ones
+	AdaptivePDUSize bool `xorm:"'adaptive_pdu_size' default 0"`
+	// snmp request limits shared by all the device measurements
+	RateLimit   int `xorm:"'rate_limit' default 0"`   // max PDUs per second, 0 => unlimited
+	MaxInFlight int `xorm:"'max_inflight' default 0"` // max concurrent requests, 0 => unlimited
+	// each measurement uses its own connection instead of the device shared one
+	DisableSharedSession bool `xorm:"'disable_shared_session' default 0"`
 	// snmp runtime config
 	Freq             int  `xorm:"'freq' default 60" binding:"Default(60);IntegerNotZero"`
 	UpdateFltFreq    int  `xorm:"'update_flt_freq' default 60" binding:"Default(60);UIntegerAndLessOne"`
@@ -46,6 +57,26 @@ type SnmpDeviceCfg struct {
 	// Filters for measurements
 	MeasurementGroups []string `xorm:"-"`
 	MeasFilters       []string `xorm:"-"`
+	// Additional output databases, data will be sent to all of them besides OutDB
+	ExtraOutDBs []string `xorm:"-"`
+}
+
+// CredentialCfg is a reusable SNMP credential profile, devices referencing it are polled with its
+// version and community or v3 USM credentials instead of their own ones
+// swagger:model CredentialCfg
+type CredentialCfg struct {
+	ID                string `xorm:"'id' unique" binding:"Required"`
+	SnmpVersion       string `xorm:"snmpversion" binding:"Required;In(1,2c,3)"`
+	Community         string `xorm:"community"`
+	V3SecLevel        string `xorm:"v3seclevel"`
+	V3AuthUser        string `xorm:"v3authuser"`
+	V3AuthPass        string `xorm:"v3authpass"`
+	V3AuthProt        string `xorm:"v3authprot"`
+	V3PrivPass        string `xorm:"v3privpass"`
+	V3PrivProt        string `xorm:"v3privprot"`
+	V3ContextEngineID string `xorm:"v3contextengineid"`
+	V3ContextName     string `xorm:"v3contextname"`
+	Description       string `xorm:"description"`
 }
 
 // InfluxCfg is the main configuration for any InfluxDB TSDB
@@ -54,10 +85,21 @@ type InfluxCfg struct {
 	ID                 string `xorm:"'id' unique" binding:"Required"`
 	Host               string `xorm:"host" binding:"Required"`
 	Port               int    `xorm:"port" binding:"Required;IntegerNotZero"`
-	DB                 string `xorm:"db" binding:"Required"`
-	User               string `xorm:"user" binding:"Required"`
-	Password           string `xorm:"password" binding:"Required"`
-	Retention          string `xorm:"'retention' default 'autogen'" binding:"Required"`
+	APIVersion         string `xorm:"'api_version' default 'v1'" binding:"Default(v1);OmitEmpty;In(v1,v2)"`      // v1 => InfluxDB 1.x (db/rp) , v2 => InfluxDB 2.x/3.x (org/bucket/token)
+	Transport          string `xorm:"'transport' default 'http'" binding:"Default(http);OmitEmpty;In(http,udp)"` // http => HTTP(S) write API, udp => line protocol to an UDP listener (database set on the listener)
+	UDPPayloadSize     int    `xorm:"'udp_payload_size' default 1400"`                                           // max bytes on each UDP packet (should fit the path MTU)
+	UDPRateLimit       int    `xorm:"'udp_rate_limit' default 0"`                                                // max UDP packets per second (0 no limit)
+	DB                 string `xorm:"db"`
+	User               string `xorm:"user"`
+	Password           string `xorm:"password"`
+	Retention          string `xorm:"'retention' default 'autogen'"`
+	AutoProvision      bool   `xorm:"auto_provision"`                     // create the database and create/alter the retention policy on connect (v1 HTTP API only)
+	RetentionDuration  string `xorm:"'retention_duration' default 'INF'"` // provisioned retention policy duration (30d, 52w, INF => keep data forever)
+	ShardDuration      string `xorm:"shard_duration"`                     // provisioned retention policy shard group duration (empty => server default)
+	ContinuousQueries  string `xorm:"continuous_queries"`                 // continuous queries created if not exist, one per line as <name>: <SELECT ... INTO ... GROUP BY time(...)>
+	Org                string `xorm:"org"`
+	Bucket             string `xorm:"bucket"`
+	Token              string `xorm:"token"`
 	Precision          string `xorm:"'precision' default 's'" binding:"Default(s);OmitEmpty;In(h,m,s,ms,u,ns)"` // posible values [h,m,s,ms,u,ns] default seconds for the nature of data
 	Timeout            int    `xorm:"'timeout' default 30" binding:"Default(30);IntegerNotZero"`
 	UserAgent          string `xorm:"useragent" binding:"Default(snmpcollector)"`
@@ -67,9 +109,143 @@ type InfluxCfg struct {
 	SSLKey             string `xorm:"ssl_key"`
 	InsecureSkipVerify bool   `xorm:"insecure_skip_verify"`
 	BufferSize         int    `xorm:"'buffer_size' default 65535"`
+	BatchSize          int    `xorm:"'batch_size' default 0" binding:"Default(0)"` // gathered batches are merged until this number of points before write (0 => write each batch as is)
+	FlushInterval      int    `xorm:"'flush_interval' default 5"`                  // max seconds merged batches wait before write
+	Gzip               bool   `xorm:"gzip"`                                        // gzip compress write requests
+	SpoolEnabled       bool   `xorm:"spool_enabled"`                               // store on disk batches with write errors to replay later
+	SpoolMaxSize       int    `xorm:"'spool_max_size' default 1024"`               // max spool size in MB (0 no limit)
+	SpoolMaxAge        int    `xorm:"'spool_max_age' default 24"`                  // max age in hours for spooled data (0 no limit)
+	StandbyID          string `xorm:"standby_id"`                                  // other influx server where data is sent while this one is failing (active/passive HA)
+	FailoverThreshold  int    `xorm:"'failover_threshold' default 3"`              // consecutive write errors before fail over to the standby server
+	FailbackInterval   int    `xorm:"'failback_interval' default 30"`              // seconds between health checks (ping) of this server while failed over
+	Description        string `xorm:"description"`
+}
+
+// GraphiteCfg is the configuration for a Graphite server (carbon plaintext protocol over TCP)
+// swagger:model GraphiteCfg
+type GraphiteCfg struct {
+	ID            string `xorm:"'id' unique" binding:"Required"`
+	Host          string `xorm:"host" binding:"Required"`
+	Port          int    `xorm:"'port' default 2003" binding:"Default(2003);IntegerNotZero"`
+	PathTemplate  string `xorm:"path_template"`                                                    // metric path template, placeholders: {device} {measurement} {index} {field} {tag:<name>}
+	Timeout       int    `xorm:"'timeout' default 10" binding:"Default(10);IntegerNotZero"`        // connect and write timeout in seconds
+	BatchSize     int    `xorm:"'batch_size' default 1000" binding:"Default(1000);IntegerNotZero"` // max metric lines on each write
+	FlushInterval int    `xorm:"'flush_interval' default 10" binding:"Default(10);IntegerNotZero"` // max seconds to wait before write pending lines
+	BufferSize    int    `xorm:"'buffer_size' default 65535"`
+	Description   string `xorm:"description"`
+}
+
+// OtlpCfg is the configuration for an OpenTelemetry OTLP/HTTP (protobuf) metrics receiver
+// swagger:model OtlpCfg
+type OtlpCfg struct {
+	ID                 string `xorm:"'id' unique" binding:"Required"`
+	URL                string `xorm:"url" binding:"Required"`                                           // metrics endpoint, ex: http://localhost:4318/v1/metrics
+	Headers            string `xorm:"headers"`                                                          // extra HTTP headers as key1=value1,key2=value2
+	Timeout            int    `xorm:"'timeout' default 10" binding:"Default(10);IntegerNotZero"`        // request timeout in seconds
+	BatchSize          int    `xorm:"'batch_size' default 1000" binding:"Default(1000);IntegerNotZero"` // max points on each request
+	FlushInterval      int    `xorm:"'flush_interval' default 10" binding:"Default(10);IntegerNotZero"` // max seconds to wait before send pending points
+	BufferSize         int    `xorm:"'buffer_size' default 65535"`
+	InsecureSkipVerify bool   `xorm:"insecure_skip_verify"`
+	Description        string `xorm:"description"`
+}
+
+// FileCfg is the configuration for a local file output with rotating segments
+// swagger:model FileCfg
+type FileCfg struct {
+	ID             string `xorm:"'id' unique" binding:"Required"`
+	Directory      string `xorm:"directory"`                                                     // relative paths are taken from the data directory, default <data_dir>/files/<id>
+	Format         string `xorm:"'format' default 'line'" binding:"Default(line);In(line,json)"` // line => influx line protocol, json => JSON lines
+	MaxSize        int    `xorm:"'max_size' default 100"`                                        // rotate when the segment reaches this size in MB (0 => no size rotation)
+	RotateInterval int    `xorm:"'rotate_interval' default 60"`                                  // rotate every RotateInterval minutes (0 => no time rotation)
+	Compress       bool   `xorm:"compress"`                                                      // gzip closed segments
+	MaxFiles       int    `xorm:"'max_files' default 24"`                                        // max closed segments kept, older are removed (0 => no limit)
+	BufferSize     int    `xorm:"'buffer_size' default 65535"`
+	Description    string `xorm:"description"`
+}
+
+// ProcessorCfg is a transformation applied to the points before being sent to its related outputs
+// swagger:model ProcessorCfg
+type ProcessorCfg struct {
+	ID          string   `xorm:"'id' unique" binding:"Required"`
+	Type        string   `xorm:"type" binding:"Required;In(rename_tag,rename_field,drop_field,add_tag,lowercase_tag,sanitize_tag,route)"`
+	Measurement string   `xorm:"measurement"`            // regex on the measurement name, only matching points are processed (empty => all)
+	Key         string   `xorm:"proc_key"`               // tag/field name, regex on field names (drop_field) or on tag names (lowercase_tag, sanitize_tag, .* => all)
+	Value       string   `xorm:"proc_value"`             // new name (rename_*), tag value (add_tag), replacement (sanitize_tag) or regex on the Key tag value (route)
+	Target      string   `xorm:"target"`                 // route: output where matching points are sent instead (empty => drop)
+	Order       int      `xorm:"'proc_order' default 0"` // processors are applied on ascending order
+	Outputs     []string `xorm:"-"`
+	Description string   `xorm:"description"`
+}
+
+// OutputProcessors processors applied on each output
+type OutputProcessors struct {
+	IDOutput    string `xorm:"id_output"`
+	IDProcessor string `xorm:"id_processor"`
+}
+
+// MqttCfg is the configuration for a MQTT broker where each measurement row is published as JSON
+// swagger:model MqttCfg
+type MqttCfg struct {
+	ID                 string `xorm:"'id' unique" binding:"Required"`
+	Host               string `xorm:"host" binding:"Required"`
+	Port               int    `xorm:"'port' default 1883" binding:"Default(1883);IntegerNotZero"`
+	ProtocolVersion    string `xorm:"'protocol_version' default '3.1.1'" binding:"Default(3.1.1);In(3.1.1,5)"`
+	ClientID           string `xorm:"client_id"` // default snmpcollector-<instance>-<id>
+	User               string `xorm:"user"`
+	Password           string `xorm:"password"`
+	TopicTemplate      string `xorm:"topic_template"`                                            // topic template, placeholders: {device} {measurement} {index} {tag:<name>}
+	QoS                int    `xorm:"'qos' default 0" binding:"In(0,1,2)"`                       // 0 => at most once, 1 => at least once, 2 => exactly once
+	Retain             bool   `xorm:"retain"`                                                    // broker keeps the last message on each topic for new subscribers
+	KeepAlive          int    `xorm:"'keep_alive' default 60"`                                   // seconds between pings on idle connections (0 disabled)
+	Timeout            int    `xorm:"'timeout' default 10" binding:"Default(10);IntegerNotZero"` // connect and publish acknowledge timeout in seconds
+	BufferSize         int    `xorm:"'buffer_size' default 65535"`
+	EnableSSL          bool   `xorm:"enable_ssl"`
+	SSLCA              string `xorm:"ssl_ca"`
+	SSLCert            string `xorm:"ssl_cert"`
+	SSLKey             string `xorm:"ssl_key"`
+	InsecureSkipVerify bool   `xorm:"insecure_skip_verify"`
+	Description        string `xorm:"description"`
+}
+
+// KafkaCfg is the configuration for a Kafka cluster where measurement points are produced keyed by device
+// swagger:model KafkaCfg
+type KafkaCfg struct {
+	ID                 string `xorm:"'id' unique" binding:"Required"`
+	Brokers            string `xorm:"brokers" binding:"Required"`                                                                         // comma separated bootstrap brokers list host:port
+	Topic              string `xorm:"topic" binding:"Required"`                                                                           // could contain the {measurement} placeholder
+	Format             string `xorm:"'format' default 'line'" binding:"Default(line);In(line,json)"`                                      // line => influx line protocol, json => JSON object
+	Compression        string `xorm:"'compression' default 'none'" binding:"Default(none);In(none,gzip,snappy)"`                          // record batch compression
+	RequiredAcks       int    `xorm:"'required_acks' default 1" binding:"In(-1,0,1)"`                                                     // 0 => no ack, 1 => leader ack, -1 => all in sync replicas ack
+	ClientID           string `xorm:"client_id"`                                                                                          // default snmpcollector-<hostname>
+	SASLMechanism      string `xorm:"'sasl_mechanism' default 'none'" binding:"Default(none);In(none,PLAIN,SCRAM-SHA-256,SCRAM-SHA-512)"` // SASL authentication
+	User               string `xorm:"user"`
+	Password           string `xorm:"password"`
+	Timeout            int    `xorm:"'timeout' default 10" binding:"Default(10);IntegerNotZero"`        // connect and produce acknowledge timeout in seconds
+	BatchSize          int    `xorm:"'batch_size' default 1000" binding:"Default(1000);IntegerNotZero"` // max messages on each produce request
+	FlushInterval      int    `xorm:"'flush_interval' default 1" binding:"Default(1);IntegerNotZero"`   // max seconds to wait before produce pending messages
+	BufferSize         int    `xorm:"'buffer_size' default 65535"`
+	EnableSSL          bool   `xorm:"enable_ssl"`
+	SSLCA              string `xorm:"ssl_ca"`
+	SSLCert            string `xorm:"ssl_cert"`
+	SSLKey             string `xorm:"ssl_key"`
+	InsecureSkipVerify bool   `xorm:"insecure_skip_verify"`
 	Description        string `xorm:"description"`
 }
 
+// TrapRuleCfg defines how the traps and informs with a notification OID are converted to points
+// swagger:model TrapRuleCfg
+type TrapRuleCfg struct {
+	ID          string   `xorm:"'id' unique" binding:"Required"`
+	TrapOID     string   `xorm:"trap_oid" binding:"Required"`                                      // notification OID (v1 traps as enterprise.0.specific or the RFC3584 generic OIDs), ending with .* matches all OIDs below
+	Mode        string   `xorm:"'mode' default 'event'" binding:"Default(event);In(event,metric)"` // event => point with the Message text, metric => point with the mapped varbinds as fields
+	Measurement string   `xorm:"measurement" binding:"Required"`
+	VarBinds    []string `xorm:"varbinds"`   // mapped varbinds as <OID>=<field name>, the OID suffix (if any) is added as the index tag (event mode without mappings adds all varbinds)
+	Message     string   `xorm:"message"`    // event text, placeholders: {<field name>} {trapoid} {source} {device}
+	Severity    string   `xorm:"severity"`   // event severity tag
+	ExtraTags   []string `xorm:"extra-tags"` // tags added to the points as tag=value
+	Description string   `xorm:"description"`
+}
+
 // MeasFilterCfg the filter configuration
 // swagger:model MeasFilterCfg
 type MeasFilterCfg struct {
@@ -121,6 +297,7 @@ type SnmpDevFilters struct {
 type MGroupsCfg struct {
 	ID           string   `xorm:"'id' unique" binding:"Required"`
 	Measurements []string `xorm:"-"`
+	OutDBs       []string `xorm:"-"`
 	Description  string   `xorm:"description"`
 }
 
@@ -130,6 +307,25 @@ type MGroupsMeasurements struct {
 	IDMeasurementCfg string `xorm:"id_measurement_cfg"`
 }
 
+// MGroupsOutDBs additional output databases where all group measurements will be sent
+type MGroupsOutDBs struct {
+	IDMGroupCfg string `xorm:"id_mgroup_cfg"`
+	IDOutDB     string `xorm:"id_outdb"`
+}
+
+// SnmpDevOutDBs additional output databases defined on each SnmpDevice
+type SnmpDevOutDBs struct {
+	IDSnmpDev string `xorm:"id_snmpdev"`
+	IDOutDB   string `xorm:"id_outdb"`
+}
+
+// SnmpDevCredentials fallback credential profiles defined on each SnmpDevice
+type SnmpDevCredentials struct {
+	IDSnmpDev    string `xorm:"id_snmpdev"`
+	IDCredential string `xorm:"id_credential"`
+	Priority     int    `xorm:"priority"`
+}
+
 // SnmpDevMGroups Mgroups defined on each SnmpDevice
 type SnmpDevMGroups struct {
 	IDSnmpDev   string `xorm:"id_snmpdev"`
@@ -143,7 +339,15 @@ type DBConfig struct {
 	MFilters     map[string]*MeasFilterCfg
 	GetGroups    map[string]*MGroupsCfg
 	SnmpDevice   map[string]*SnmpDeviceCfg
+	Credentials  map[string]*CredentialCfg
 	Influxdb     map[string]*InfluxCfg
+	Graphite     map[string]*GraphiteCfg
+	Otlp         map[string]*OtlpCfg
+	File         map[string]*FileCfg
+	Mqtt         map[string]*MqttCfg
+	Kafka        map[string]*KafkaCfg
+	Processors   map[string]*ProcessorCfg
+	TrapRules    map[string]*TrapRuleCfg
 	VarCatalog   map[string]interface{}
 }
 
@@ -0,0 +1,163 @@
+package config
+
+import "fmt"
+
+/***************************
+	File outputs
+	-GetFileCfgByID(struct)
+	-GetFileCfgMap (map - for interna config use
+	-GetFileCfgArray(Array - for web ui use )
+	-AddFileCfg
+	-DelFileCfg
+	-UpdateFileCfg
+	-GetFileCfgAffectOnDel
+***********************************/
+
+/*GetFileCfgByID get file output data by id*/
+func (dbc *DatabaseCfg) GetFileCfgByID(id string) (FileCfg, error) {
+	cfgarray, err := dbc.GetFileCfgArray("id='" + id + "'")
+	if err != nil {
+		return FileCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return FileCfg{}, fmt.Errorf("Error %d results on get FileCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return FileCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the file output config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetFileCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetFileCfgMap(filter string) (map[string]*FileCfg, error) {
+	cfgarray, err := dbc.GetFileCfgArray(filter)
+	cfgmap := make(map[string]*FileCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetFileCfgArray generate an array of file outputs with all its information */
+func (dbc *DatabaseCfg) GetFileCfgArray(filter string) ([]*FileCfg, error) {
+	var err error
+	var outs []*FileCfg
+	// Get Only data for selected outputs
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&outs); err != nil {
+			log.Warnf("Fail to get FileCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&outs); err != nil {
+			log.Warnf("Fail to get FileCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return outs, nil
+}
+
+/*AddFileCfg for adding new file outputs*/
+func (dbc *DatabaseCfg) AddFileCfg(dev FileCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = dbc.checkOutputID(dev.ID, "file"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new file output Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelFileCfg for deleting file outputs from ID*/
+func (dbc *DatabaseCfg) DelFileCfg(id string) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// deleting references in SnmpDevCfg, measurement groups and measurements
+	affecteddev, err = delOutputRefs(session, id)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&FileCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully file output with ID %s [ %d Devices Affected  ]", id, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*UpdateFileCfg for updating file outputs*/
+func (dbc *DatabaseCfg) UpdateFileCfg(id string, dev FileCfg) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+	if err = dbc.checkOutputID(dev.ID, "file"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	if id != dev.ID { // ID has been changed
+		affecteddev, err = updateOutputRefs(session, id, dev.ID)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+		log.Infof("Updated File Config to %d devices ", affecteddev)
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated File Config Successfully with id %s and data:%+v, affected", id, dev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*GetFileCfgAffectOnDel for deleting file outputs from ID*/
+func (dbc *DatabaseCfg) GetFileCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	return dbc.getOutputAffectOnDel(id)
+}
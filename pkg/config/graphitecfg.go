@@ -0,0 +1,163 @@
+package config
+
+import "fmt"
+
+/***************************
+	Graphite backends
+	-GetGraphiteCfgByID(struct)
+	-GetGraphiteCfgMap (map - for interna config use
+	-GetGraphiteCfgArray(Array - for web ui use )
+	-AddGraphiteCfg
+	-DelGraphiteCfg
+	-UpdateGraphiteCfg
+	-GetGraphiteCfgAffectOnDel
+***********************************/
+
+/*GetGraphiteCfgByID get graphite server data by id*/
+func (dbc *DatabaseCfg) GetGraphiteCfgByID(id string) (GraphiteCfg, error) {
+	cfgarray, err := dbc.GetGraphiteCfgArray("id='" + id + "'")
+	if err != nil {
+		return GraphiteCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return GraphiteCfg{}, fmt.Errorf("Error %d results on get GraphiteCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return GraphiteCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the graphite config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetGraphiteCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetGraphiteCfgMap(filter string) (map[string]*GraphiteCfg, error) {
+	cfgarray, err := dbc.GetGraphiteCfgArray(filter)
+	cfgmap := make(map[string]*GraphiteCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetGraphiteCfgArray generate an array of graphite servers with all its information */
+func (dbc *DatabaseCfg) GetGraphiteCfgArray(filter string) ([]*GraphiteCfg, error) {
+	var err error
+	var servers []*GraphiteCfg
+	// Get Only data for selected servers
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&servers); err != nil {
+			log.Warnf("Fail to get GraphiteCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&servers); err != nil {
+			log.Warnf("Fail to get GraphiteCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return servers, nil
+}
+
+/*AddGraphiteCfg for adding new graphite servers*/
+func (dbc *DatabaseCfg) AddGraphiteCfg(dev GraphiteCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = dbc.checkOutputID(dev.ID, "graphite"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new graphite backend Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelGraphiteCfg for deleting graphite servers from ID*/
+func (dbc *DatabaseCfg) DelGraphiteCfg(id string) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// deleting references in SnmpDevCfg, measurement groups and measurements
+	affecteddev, err = delOutputRefs(session, id)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&GraphiteCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully graphite server with ID %s [ %d Devices Affected  ]", id, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*UpdateGraphiteCfg for updating graphite servers*/
+func (dbc *DatabaseCfg) UpdateGraphiteCfg(id string, dev GraphiteCfg) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+	if err = dbc.checkOutputID(dev.ID, "graphite"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	if id != dev.ID { // ID has been changed
+		affecteddev, err = updateOutputRefs(session, id, dev.ID)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+		log.Infof("Updated Graphite Config to %d devices ", affecteddev)
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated Graphite Config Successfully with id %s and data:%+v, affected", id, dev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*GetGraphiteCfgAffectOnDel for deleting graphite servers from ID*/
+func (dbc *DatabaseCfg) GetGraphiteCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	return dbc.getOutputAffectOnDel(id)
+}
@@ -1,6 +1,12 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
 
 /***************************
 	Influx DB backends
@@ -58,10 +64,152 @@ func (dbc *DatabaseCfg) GetInfluxCfgArray(filter string) ([]*InfluxCfg, error) {
 	return devices, nil
 }
 
+// IsAPIv2 returns true if the backend should be accessed through the InfluxDB 2.x/3.x API
+func (c *InfluxCfg) IsAPIv2() bool {
+	return c.APIVersion == "v2"
+}
+
+// IsUDP returns true if data should be sent in line protocol to an UDP listener
+func (c *InfluxCfg) IsUDP() bool {
+	return c.Transport == "udp"
+}
+
+// CheckAPIParams checks all needed parameters for the configured API version have been set
+func (c *InfluxCfg) CheckAPIParams() error {
+	if c.IsUDP() {
+		// database and credentials are set on the UDP listener side
+		return c.checkProvisionParams()
+	}
+	switch c.APIVersion {
+	case "", "v1":
+		if len(c.DB) == 0 || len(c.User) == 0 || len(c.Password) == 0 {
+			return fmt.Errorf("InfluxDB v1 API needs DB, User and Password parameters on influx config %s", c.ID)
+		}
+	case "v2":
+		if len(c.Org) == 0 || len(c.Bucket) == 0 || len(c.Token) == 0 {
+			return fmt.Errorf("InfluxDB v2 API needs Org, Bucket and Token parameters on influx config %s", c.ID)
+		}
+	default:
+		return fmt.Errorf("Unknown InfluxDB API version %s on influx config %s", c.APIVersion, c.ID)
+	}
+	return c.checkProvisionParams()
+}
+
+var influxDurationUnit = regexp.MustCompile(`^([0-9]+)(ns|us|u|µ|ms|s|m|h|d|w)`)
+
+var influxDurationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"u":  time.Microsecond,
+	"µ":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// InfluxDuration parses an InfluxQL duration literal (30d, 1w, 1h30m, INF ...), INF is returned as 0
+func InfluxDuration(s string) (time.Duration, error) {
+	if strings.EqualFold(s, "INF") {
+		return 0, nil
+	}
+	if len(s) == 0 {
+		return 0, fmt.Errorf("empty duration")
+	}
+	var d time.Duration
+	for rest := s; len(rest) > 0; {
+		m := influxDurationUnit.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %s", s, err)
+		}
+		d += time.Duration(n) * influxDurationUnits[m[2]]
+		rest = rest[len(m[0]):]
+	}
+	return d, nil
+}
+
+// InfluxCQ is a continuous query to be provisioned on the configured database
+type InfluxCQ struct {
+	Name  string
+	Query string
+}
+
+// GetContinuousQueries parses the configured continuous queries, one per line as <name>: <SELECT statement>
+func (c *InfluxCfg) GetContinuousQueries() ([]InfluxCQ, error) {
+	var cqs []InfluxCQ
+	for _, line := range strings.Split(c.ContinuousQueries, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid continuous query %q, expected <name>: <SELECT statement>", line)
+		}
+		cq := InfluxCQ{Name: strings.TrimSpace(line[:i]), Query: strings.TrimSpace(line[i+1:])}
+		if !strings.HasPrefix(strings.ToUpper(cq.Query), "SELECT ") {
+			return nil, fmt.Errorf("invalid continuous query %s, it should be a SELECT statement", cq.Name)
+		}
+		cqs = append(cqs, cq)
+	}
+	return cqs, nil
+}
+
+// checkProvisionParams checks the auto provisioning parameters
+func (c *InfluxCfg) checkProvisionParams() error {
+	if !c.AutoProvision {
+		return nil
+	}
+	if c.IsUDP() || c.IsAPIv2() {
+		return fmt.Errorf("Auto provisioning is only supported with the InfluxDB v1 HTTP API on influx config %s", c.ID)
+	}
+	if _, err := InfluxDuration(c.RetentionDuration); err != nil {
+		return fmt.Errorf("Invalid retention duration on influx config %s: %s", c.ID, err)
+	}
+	if len(c.ShardDuration) > 0 {
+		if _, err := InfluxDuration(c.ShardDuration); err != nil {
+			return fmt.Errorf("Invalid shard duration on influx config %s: %s", c.ID, err)
+		}
+	}
+	if _, err := c.GetContinuousQueries(); err != nil {
+		return fmt.Errorf("%s on influx config %s", err, c.ID)
+	}
+	return nil
+}
+
+// checkStandby returns error if the standby server is the server itself or does not exist
+func (dbc *DatabaseCfg) checkStandby(dev *InfluxCfg) error {
+	if len(dev.StandbyID) == 0 {
+		return nil
+	}
+	if dev.StandbyID == dev.ID {
+		return fmt.Errorf("Influx server %s can not be its own standby server", dev.ID)
+	}
+	if _, err := dbc.GetInfluxCfgByID(dev.StandbyID); err != nil {
+		return fmt.Errorf("Standby influx server %s for %s does not exist", dev.StandbyID, dev.ID)
+	}
+	return nil
+}
+
 /*AddInfluxCfg for adding new devices*/
 func (dbc *DatabaseCfg) AddInfluxCfg(dev InfluxCfg) (int64, error) {
 	var err error
 	var affected int64
+	if err = dev.CheckAPIParams(); err != nil {
+		return 0, err
+	}
+	if err = dbc.checkOutputID(dev.ID, "influxdb"); err != nil {
+		return 0, err
+	}
+	if err = dbc.checkStandby(&dev); err != nil {
+		return 0, err
+	}
 	session := dbc.x.NewSession()
 	if err := session.Begin(); err != nil {
 		// if returned then will rollback automatically
@@ -95,13 +243,19 @@ func (dbc *DatabaseCfg) DelInfluxCfg(id string) (int64, error) {
 		return 0, err
 	}
 	defer session.Close()
-	// deleting references in SnmpDevCfg
-
-	affecteddev, err = session.Where("outdb='" + id + "'").Cols("outdb").Update(&SnmpDeviceCfg{})
+	// deleting references in SnmpDevCfg, measurement groups and measurements
+	affecteddev, err = delOutputRefs(session, id)
 	if err != nil {
 		session.Rollback()
-		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevCfg with id: %s, error: %s", id, err)
+		return 0, err
 	}
+	// servers with this one as standby will not fail over anymore
+	affectedsb, err := session.Where("standby_id='" + id + "'").Cols("standby_id").Update(&InfluxCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete standby server references on InfluxCfg with id: %s, error: %s", id, err)
+	}
+	affecteddev += affectedsb
 
 	affected, err = session.Where("id='" + id + "'").Delete(&InfluxCfg{})
 	if err != nil {
@@ -122,6 +276,15 @@ func (dbc *DatabaseCfg) DelInfluxCfg(id string) (int64, error) {
 func (dbc *DatabaseCfg) UpdateInfluxCfg(id string, dev InfluxCfg) (int64, error) {
 	var affecteddev, affected int64
 	var err error
+	if err = dev.CheckAPIParams(); err != nil {
+		return 0, err
+	}
+	if err = dbc.checkOutputID(dev.ID, "influxdb"); err != nil {
+		return 0, err
+	}
+	if err = dbc.checkStandby(&dev); err != nil {
+		return 0, err
+	}
 	session := dbc.x.NewSession()
 	if err := session.Begin(); err != nil {
 		// if returned then will rollback automatically
@@ -129,11 +292,17 @@ func (dbc *DatabaseCfg) UpdateInfluxCfg(id string, dev InfluxCfg) (int64, error)
 	}
 	defer session.Close()
 	if id != dev.ID { // ID has been changed
-		affecteddev, err = session.Where("outdb='" + id + "'").Cols("outdb").Update(&SnmpDeviceCfg{OutDB: dev.ID})
+		affecteddev, err = updateOutputRefs(session, id, dev.ID)
 		if err != nil {
 			session.Rollback()
 			return 0, fmt.Errorf("Error on Update InfluxConfig on update id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
 		}
+		affectedsb, err := session.Where("standby_id='" + id + "'").Cols("standby_id").Update(&InfluxCfg{StandbyID: dev.ID})
+		if err != nil {
+			session.Rollback()
+			return 0, fmt.Errorf("Error on Update standby server references on InfluxCfg id(old)  %s with (new): %s, error: %s", id, dev.ID, err)
+		}
+		affecteddev += affectedsb
 		log.Infof("Updated Influx Config to %d devices ", affecteddev)
 	}
 
@@ -154,19 +323,21 @@ func (dbc *DatabaseCfg) UpdateInfluxCfg(id string, dev InfluxCfg) (int64, error)
 
 /*GetInfluxCfgAffectOnDel for deleting devices from ID*/
 func (dbc *DatabaseCfg) GetInfluxCfgAffectOnDel(id string) ([]*DbObjAction, error) {
-	var devices []*SnmpDeviceCfg
-	var obj []*DbObjAction
-	if err := dbc.x.Where("outdb='" + id + "'").Find(&devices); err != nil {
-		log.Warnf("Error on Get Outout db id %s for devices , error: %s", id, err)
+	obj, err := dbc.getOutputAffectOnDel(id)
+	if err != nil {
 		return nil, err
 	}
-
-	for _, val := range devices {
+	var influx []*InfluxCfg
+	if err := dbc.x.Where("standby_id='" + id + "'").Find(&influx); err != nil {
+		log.Warnf("Error on Get Influx server id %s as standby server, error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range influx {
 		obj = append(obj, &DbObjAction{
-			Type:     "snmpdevicecfg",
-			TypeDesc: "SNMP Devices",
+			Type:     "influxcfg",
+			TypeDesc: "Influx Servers",
 			ObID:     val.ID,
-			Action:   "Reset InfluxDB Server from SNMPDevice to 'default' InfluxDB Server",
+			Action:   "Reset Standby server from Influx server (no fail over)",
 		})
 	}
 	return obj, nil
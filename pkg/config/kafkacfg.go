@@ -0,0 +1,163 @@
+package config
+
+import "fmt"
+
+/***************************
+	kafka outputs
+	-GetKafkaCfgByID(struct)
+	-GetKafkaCfgMap (map - for interna config use
+	-GetKafkaCfgArray(Array - for web ui use )
+	-AddKafkaCfg
+	-DelKafkaCfg
+	-UpdateKafkaCfg
+	-GetKafkaCfgAffectOnDel
+***********************************/
+
+/*GetKafkaCfgByID get kafka output data by id*/
+func (dbc *DatabaseCfg) GetKafkaCfgByID(id string) (KafkaCfg, error) {
+	cfgarray, err := dbc.GetKafkaCfgArray("id='" + id + "'")
+	if err != nil {
+		return KafkaCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return KafkaCfg{}, fmt.Errorf("Error %d results on get KafkaCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return KafkaCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the kafka output config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetKafkaCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetKafkaCfgMap(filter string) (map[string]*KafkaCfg, error) {
+	cfgarray, err := dbc.GetKafkaCfgArray(filter)
+	cfgmap := make(map[string]*KafkaCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetKafkaCfgArray generate an array of kafka outputs with all its information */
+func (dbc *DatabaseCfg) GetKafkaCfgArray(filter string) ([]*KafkaCfg, error) {
+	var err error
+	var outs []*KafkaCfg
+	// Get Only data for selected outputs
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&outs); err != nil {
+			log.Warnf("Fail to get KafkaCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&outs); err != nil {
+			log.Warnf("Fail to get KafkaCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return outs, nil
+}
+
+/*AddKafkaCfg for adding new kafka outputs*/
+func (dbc *DatabaseCfg) AddKafkaCfg(dev KafkaCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = dbc.checkOutputID(dev.ID, "kafka"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new kafka output Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelKafkaCfg for deleting kafka outputs from ID*/
+func (dbc *DatabaseCfg) DelKafkaCfg(id string) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// deleting references in SnmpDevCfg, measurement groups and measurements
+	affecteddev, err = delOutputRefs(session, id)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&KafkaCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully kafka output with ID %s [ %d Devices Affected  ]", id, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*UpdateKafkaCfg for updating kafka outputs*/
+func (dbc *DatabaseCfg) UpdateKafkaCfg(id string, dev KafkaCfg) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+	if err = dbc.checkOutputID(dev.ID, "kafka"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	if id != dev.ID { // ID has been changed
+		affecteddev, err = updateOutputRefs(session, id, dev.ID)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+		log.Infof("Updated kafka Config to %d devices ", affecteddev)
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated kafka Config Successfully with id %s and data:%+v, affected", id, dev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*GetKafkaCfgAffectOnDel for deleting kafka outputs from ID*/
+func (dbc *DatabaseCfg) GetKafkaCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	return dbc.getOutputAffectOnDel(id)
+}
@@ -41,6 +41,13 @@ type SelfMonConfig struct {
 	ExtraTags         []string `mapstructure:"extratags" envconfig:"SNMPCOL_SELFMON_EXTRATAGS"`
 }
 
+// TrapConfig has the SNMP trap/inform receiver config options
+type TrapConfig struct {
+	Enabled            bool   `mapstructure:"enabled" envconfig:"SNMPCOL_TRAP_ENABLED"`
+	Listen             string `mapstructure:"listen" envconfig:"SNMPCOL_TRAP_LISTEN"`
+	UnknownMeasurement string `mapstructure:"unknown_measurement" envconfig:"SNMPCOL_TRAP_UNKNOWN_MEASUREMENT"`
+}
+
 // HTTPConfig has webserver config options
 // Port should be deprecated from version >= 0.8.1
 type HTTPConfig struct {
@@ -59,6 +66,7 @@ type Config struct {
 	General  GeneralConfig `mapstructure:"general"`
 	Database DatabaseCfg   `mapstructure:"database"`
 	Selfmon  SelfMonConfig `mapstructure:"selfmon"`
+	Trap     TrapConfig    `mapstructure:"trap"`
 	HTTP     HTTPConfig    `mapstructure:"http"`
 }
 
@@ -37,7 +37,39 @@ type MeasurementCfg struct {
 	OidCondMetric     []*SnmpMetricCfg         `xorm:"-" json:"-"`
 	Freq              int                      `xorm:"'freq'" binding:"IntegerNotZero"`
 	UpdateFltFreq     int                      `xorm:"'update_flt_freq'" binding:"UIntegerAndLessOne"`
-	Description       string                   `xorm:"description"`
+	// Output routing overrides, empty values will use the device ones
+	OutDB        string `xorm:"outdb"`
+	OutDatabase  string `xorm:"out_database"`
+	OutRetention string `xorm:"out_retention"`
+	// SNMP context overrides, empty values will use the device ones. {context} is replaced by each context
+	// the measurement is gathered from and {community} by the device community
+	Community   string `xorm:"community"`    // v1/v2c community, defaults to {community}@{context} with contexts
+	ContextName string `xorm:"context_name"` // v3 context name, defaults to {context} with contexts
+	Contexts    string `xorm:"contexts"`     // comma separated list of contexts
+	ContextOID  string `xorm:"context_oid"`  // OID walked to discover the contexts, from the string values or the last index
+	ContextTag  string `xorm:"context_tag"`  // tag set with the context, defaults to context
+	Description string `xorm:"description"`
+}
+
+// DefaultContextTag is the tag set with the SNMP context if the measurement has no context tag configured
+const DefaultContextTag = "context"
+
+// GetContexts returns the static list of SNMP contexts, in the configured order
+func (mc *MeasurementCfg) GetContexts() []string {
+	var ctxs []string
+	seen := make(map[string]bool)
+	for _, c := range strings.Split(mc.Contexts, ",") {
+		if c = strings.TrimSpace(c); len(c) > 0 && !seen[c] {
+			seen[c] = true
+			ctxs = append(ctxs, c)
+		}
+	}
+	return ctxs
+}
+
+// HasContexts returns true if the measurement is gathered from a list of SNMP contexts
+func (mc *MeasurementCfg) HasContexts() bool {
+	return len(mc.GetContexts()) > 0 || len(mc.ContextOID) > 0
 }
 
 // MultipleTagOID defines TagOID to iterate over multiple tables to retrieve tag
@@ -290,6 +322,13 @@ func (mc *MeasurementCfg) Init(MetricCfg *map[string]*SnmpMetricCfg, varmap map[
 		return errors.New("Unknown GetMode" + mc.GetMode + " in measurement Config " + mc.ID)
 	}
 
+	if len(mc.ContextOID) > 0 && !strings.HasPrefix(mc.ContextOID, ".") {
+		return errors.New("Bad BaseOid format:" + mc.ContextOID + " for context OID in measurement Config " + mc.ID)
+	}
+	if len(mc.ContextTag) == 0 {
+		mc.ContextTag = DefaultContextTag
+	}
+
 	log.Infof("processing measurement key: %s ", mc.ID)
 	log.Debugf("%+v", mc)
 
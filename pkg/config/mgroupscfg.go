@@ -69,13 +69,27 @@ func (dbc *DatabaseCfg) GetMGroupsCfgArray(filter string) ([]*MGroupsCfg, error)
 			}
 		}
 	}
+
+	// Load extra output databases for each group
+	var mgroupsoutdbs []*MGroupsOutDBs
+	if err = dbc.x.Find(&mgroupsoutdbs); err != nil {
+		log.Warnf("Fail to get MGroup Output DB relationship  data: %v\n", err)
+	}
+
+	for _, mVal := range devices {
+		for _, mgo := range mgroupsoutdbs {
+			if mgo.IDMGroupCfg == mVal.ID {
+				mVal.OutDBs = append(mVal.OutDBs, mgo.IDOutDB)
+			}
+		}
+	}
 	return devices, nil
 }
 
 /*AddMGroupsCfg for adding new Metric*/
 func (dbc *DatabaseCfg) AddMGroupsCfg(dev MGroupsCfg) (int64, error) {
 	var err error
-	var affected, newmf int64
+	var affected, newmf, newod int64
 	session := dbc.x.NewSession()
 	if err := session.Begin(); err != nil {
 		// if returned then will rollback automatically
@@ -101,19 +115,30 @@ func (dbc *DatabaseCfg) AddMGroupsCfg(dev MGroupsCfg) (int64, error) {
 			return 0, err
 		}
 	}
-	// no other relation
+	// Output DBs
+	for _, od := range dev.OutDBs {
+		odstruct := MGroupsOutDBs{
+			IDMGroupCfg: dev.ID,
+			IDOutDB:     od,
+		}
+		newod, err = session.Insert(&odstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
 	err = session.Commit()
 	if err != nil {
 		return 0, err
 	}
-	log.Infof("Added new Measurement Group Successfully with id %s  [%d Measurements]", dev.ID, newmf)
-	dbc.addChanges(affected + newmf)
+	log.Infof("Added new Measurement Group Successfully with id %s  [%d Measurements | %d Output DBs]", dev.ID, newmf, newod)
+	dbc.addChanges(affected + newmf + newod)
 	return affected, nil
 }
 
 /*DelMGroupsCfg for deleting influx databases from ID*/
 func (dbc *DatabaseCfg) DelMGroupsCfg(id string) (int64, error) {
-	var affecteddev, affected int64
+	var affecteddev, affectedod, affected int64
 	var err error
 
 	session := dbc.x.NewSession()
@@ -136,6 +161,13 @@ func (dbc *DatabaseCfg) DelMGroupsCfg(id string) (int64, error) {
 		return 0, fmt.Errorf("Error on Delete Filter on SnmpDeviceFilter table with id: %s, error: %s", id, err)
 	}
 
+	// deleting output db relations
+	affectedod, err = session.Where("id_mgroup_cfg='" + id + "'").Delete(&MGroupsOutDBs{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Measurement Group on MGroupsOutDBs table with id: %s, error: %s", id, err)
+	}
+
 	affected, err = session.Where("id='" + id + "'").Delete(&MGroupsCfg{})
 	if err != nil {
 		session.Rollback()
@@ -147,13 +179,13 @@ func (dbc *DatabaseCfg) DelMGroupsCfg(id string) (int64, error) {
 		return 0, err
 	}
 	log.Infof("Deleted Successfully Measurment Group with ID %s [ %d Devices Affected  ]", id, affecteddev)
-	dbc.addChanges(affected + affecteddev)
+	dbc.addChanges(affected + affecteddev + affectedod)
 	return affected, nil
 }
 
 /*UpdateMGroupsCfg for adding new influxdb*/
 func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, error) {
-	var affecteddev, newmg, affected int64
+	var affecteddev, newmg, newod, affected int64
 	var err error
 	session := dbc.x.NewSession()
 	if err := session.Begin(); err != nil {
@@ -189,6 +221,23 @@ func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, erro
 			return 0, err
 		}
 	}
+	// Remove all output dbs in group and adding again
+	_, err = session.Where("id_mgroup_cfg='" + id + "'").Delete(&MGroupsOutDBs{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Measurement Group output dbs on MGroupsOutDBs with id: %s, error: %s", id, err)
+	}
+	for _, od := range dev.OutDBs {
+		odstruct := MGroupsOutDBs{
+			IDMGroupCfg: dev.ID,
+			IDOutDB:     od,
+		}
+		newod, err = session.Insert(&odstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
 
 	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
 	if err != nil {
@@ -200,8 +249,8 @@ func (dbc *DatabaseCfg) UpdateMGroupsCfg(id string, dev MGroupsCfg) (int64, erro
 		return 0, err
 	}
 
-	log.Infof("Updated Measurement Group Successfully with id %s [%d measurements], affected", dev.ID, newmg)
-	dbc.addChanges(affected + newmg)
+	log.Infof("Updated Measurement Group Successfully with id %s [%d measurements | %d output dbs], affected", dev.ID, newmg, newod)
+	dbc.addChanges(affected + newmg + newod)
 	return affected, nil
 }
 
@@ -0,0 +1,163 @@
+package config
+
+import "fmt"
+
+/***************************
+	MQTT brokers
+	-GetMqttCfgByID(struct)
+	-GetMqttCfgMap (map - for interna config use
+	-GetMqttCfgArray(Array - for web ui use )
+	-AddMqttCfg
+	-DelMqttCfg
+	-UpdateMqttCfg
+	-GetMqttCfgAffectOnDel
+***********************************/
+
+/*GetMqttCfgByID get MQTT broker data by id*/
+func (dbc *DatabaseCfg) GetMqttCfgByID(id string) (MqttCfg, error) {
+	cfgarray, err := dbc.GetMqttCfgArray("id='" + id + "'")
+	if err != nil {
+		return MqttCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return MqttCfg{}, fmt.Errorf("Error %d results on get MqttCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return MqttCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the MQTT broker config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetMqttCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetMqttCfgMap(filter string) (map[string]*MqttCfg, error) {
+	cfgarray, err := dbc.GetMqttCfgArray(filter)
+	cfgmap := make(map[string]*MqttCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetMqttCfgArray generate an array of MQTT brokers with all its information */
+func (dbc *DatabaseCfg) GetMqttCfgArray(filter string) ([]*MqttCfg, error) {
+	var err error
+	var outs []*MqttCfg
+	// Get Only data for selected brokers
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&outs); err != nil {
+			log.Warnf("Fail to get MqttCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&outs); err != nil {
+			log.Warnf("Fail to get MqttCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return outs, nil
+}
+
+/*AddMqttCfg for adding new MQTT brokers*/
+func (dbc *DatabaseCfg) AddMqttCfg(dev MqttCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = dbc.checkOutputID(dev.ID, "mqtt"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new MQTT broker Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelMqttCfg for deleting MQTT brokers from ID*/
+func (dbc *DatabaseCfg) DelMqttCfg(id string) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// deleting references in SnmpDevCfg, measurement groups and measurements
+	affecteddev, err = delOutputRefs(session, id)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&MqttCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully MQTT broker with ID %s [ %d Devices Affected  ]", id, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*UpdateMqttCfg for updating MQTT brokers*/
+func (dbc *DatabaseCfg) UpdateMqttCfg(id string, dev MqttCfg) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+	if err = dbc.checkOutputID(dev.ID, "mqtt"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	if id != dev.ID { // ID has been changed
+		affecteddev, err = updateOutputRefs(session, id, dev.ID)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+		log.Infof("Updated MQTT Config to %d devices ", affecteddev)
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated MQTT Config Successfully with id %s and data:%+v, affected", id, dev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*GetMqttCfgAffectOnDel for deleting MQTT brokers from ID*/
+func (dbc *DatabaseCfg) GetMqttCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	return dbc.getOutputAffectOnDel(id)
+}
@@ -0,0 +1,163 @@
+package config
+
+import "fmt"
+
+/***************************
+	OTLP backends
+	-GetOtlpCfgByID(struct)
+	-GetOtlpCfgMap (map - for interna config use
+	-GetOtlpCfgArray(Array - for web ui use )
+	-AddOtlpCfg
+	-DelOtlpCfg
+	-UpdateOtlpCfg
+	-GetOtlpCfgAffectOnDel
+***********************************/
+
+/*GetOtlpCfgByID get OTLP receiver data by id*/
+func (dbc *DatabaseCfg) GetOtlpCfgByID(id string) (OtlpCfg, error) {
+	cfgarray, err := dbc.GetOtlpCfgArray("id='" + id + "'")
+	if err != nil {
+		return OtlpCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return OtlpCfg{}, fmt.Errorf("Error %d results on get OtlpCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return OtlpCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the OTLP config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetOtlpCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetOtlpCfgMap(filter string) (map[string]*OtlpCfg, error) {
+	cfgarray, err := dbc.GetOtlpCfgArray(filter)
+	cfgmap := make(map[string]*OtlpCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetOtlpCfgArray generate an array of OTLP receivers with all its information */
+func (dbc *DatabaseCfg) GetOtlpCfgArray(filter string) ([]*OtlpCfg, error) {
+	var err error
+	var servers []*OtlpCfg
+	// Get Only data for selected servers
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&servers); err != nil {
+			log.Warnf("Fail to get OtlpCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&servers); err != nil {
+			log.Warnf("Fail to get OtlpCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return servers, nil
+}
+
+/*AddOtlpCfg for adding new OTLP receivers*/
+func (dbc *DatabaseCfg) AddOtlpCfg(dev OtlpCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = dbc.checkOutputID(dev.ID, "otlp"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new OTLP backend Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelOtlpCfg for deleting OTLP receivers from ID*/
+func (dbc *DatabaseCfg) DelOtlpCfg(id string) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// deleting references in SnmpDevCfg, measurement groups and measurements
+	affecteddev, err = delOutputRefs(session, id)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&OtlpCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully OTLP receiver with ID %s [ %d Devices Affected  ]", id, affecteddev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*UpdateOtlpCfg for updating OTLP receivers*/
+func (dbc *DatabaseCfg) UpdateOtlpCfg(id string, dev OtlpCfg) (int64, error) {
+	var affecteddev, affected int64
+	var err error
+	if err = dbc.checkOutputID(dev.ID, "otlp"); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	if id != dev.ID { // ID has been changed
+		affecteddev, err = updateOutputRefs(session, id, dev.ID)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+		log.Infof("Updated OTLP Config to %d devices ", affecteddev)
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated OTLP Config Successfully with id %s and data:%+v, affected", id, dev)
+	dbc.addChanges(affected + affecteddev)
+	return affected, nil
+}
+
+/*GetOtlpCfgAffectOnDel for deleting OTLP receivers from ID*/
+func (dbc *DatabaseCfg) GetOtlpCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	return dbc.getOutputAffectOnDel(id)
+}
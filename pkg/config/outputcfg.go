@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+/***************************
+	Output backends references
+	All output backends (influx, graphite, otlp, file, mqtt, kafka, ...) share the same ID namespace
+	and can be referenced from devices (OutDB, ExtraOutDBs), measurement
+	groups (OutDBs) and measurements (OutDB)
+	-GetOutputArray(Array - for web ui use )
+	-checkOutputID
+	-delOutputRefs
+	-updateOutputRefs
+	-getOutputAffectOnDel
+	Outputs are also referenced from processors (OutputProcessors and route Target)
+***********************************/
+
+// OutputInfo basic info of any configured output backend
+// swagger:model OutputInfo
+type OutputInfo struct {
+	ID          string
+	Type        string
+	Description string
+}
+
+// outputTables returns the ID and Description of each configured output by backend type
+func (dbc *DatabaseCfg) outputTables() (map[string][]*OutputInfo, error) {
+	outs := make(map[string][]*OutputInfo)
+	influx, err := dbc.GetInfluxCfgArray("")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range influx {
+		outs["influxdb"] = append(outs["influxdb"], &OutputInfo{ID: v.ID, Type: "influxdb", Description: v.Description})
+	}
+	graphite, err := dbc.GetGraphiteCfgArray("")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range graphite {
+		outs["graphite"] = append(outs["graphite"], &OutputInfo{ID: v.ID, Type: "graphite", Description: v.Description})
+	}
+	otlp, err := dbc.GetOtlpCfgArray("")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range otlp {
+		outs["otlp"] = append(outs["otlp"], &OutputInfo{ID: v.ID, Type: "otlp", Description: v.Description})
+	}
+	files, err := dbc.GetFileCfgArray("")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range files {
+		outs["file"] = append(outs["file"], &OutputInfo{ID: v.ID, Type: "file", Description: v.Description})
+	}
+	mqtt, err := dbc.GetMqttCfgArray("")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range mqtt {
+		outs["mqtt"] = append(outs["mqtt"], &OutputInfo{ID: v.ID, Type: "mqtt", Description: v.Description})
+	}
+	kafka, err := dbc.GetKafkaCfgArray("")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range kafka {
+		outs["kafka"] = append(outs["kafka"], &OutputInfo{ID: v.ID, Type: "kafka", Description: v.Description})
+	}
+	return outs, nil
+}
+
+/*GetOutputArray generate an array with all configured output backends */
+func (dbc *DatabaseCfg) GetOutputArray() ([]*OutputInfo, error) {
+	tables, err := dbc.outputTables()
+	if err != nil {
+		return nil, err
+	}
+	var outs []*OutputInfo
+	for _, t := range tables {
+		outs = append(outs, t...)
+	}
+	return outs, nil
+}
+
+// checkOutputID returns error if the id is already used by an output of other backend type
+func (dbc *DatabaseCfg) checkOutputID(id string, otype string) error {
+	tables, err := dbc.outputTables()
+	if err != nil {
+		return err
+	}
+	for t, outs := range tables {
+		if t == otype {
+			continue
+		}
+		for _, o := range outs {
+			if o.ID == id {
+				return fmt.Errorf("Output ID %s is already used by a %s output", id, t)
+			}
+		}
+	}
+	return nil
+}
+
+// delOutputRefs removes all references to the output id on devices, measurement groups, measurements and processors
+func delOutputRefs(session *xorm.Session, id string) (int64, error) {
+	// deleting references in SnmpDevCfg
+	affecteddev, err := session.Where("outdb='" + id + "'").Cols("outdb").Update(&SnmpDeviceCfg{})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevCfg with id: %s, error: %s", id, err)
+	}
+	// deleting references in extra output dbs relations
+	affecteddevod, err := session.Where("id_outdb='" + id + "'").Delete(&SnmpDevOutDBs{})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Delete output on SnmpDevOutDBs with id: %s, error: %s", id, err)
+	}
+	affectedmgod, err := session.Where("id_outdb='" + id + "'").Delete(&MGroupsOutDBs{})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Delete output on MGroupsOutDBs with id: %s, error: %s", id, err)
+	}
+	// measurements with this output db override will use the device one
+	affectedmeas, err := session.Where("outdb='" + id + "'").Cols("outdb").Update(&MeasurementCfg{})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Delete output on MeasurementCfg with id: %s, error: %s", id, err)
+	}
+	// deleting processors relations and route processors with this output as target
+	affectedproc, err := session.Where("id_output='" + id + "'").Delete(&OutputProcessors{})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Delete output on OutputProcessors with id: %s, error: %s", id, err)
+	}
+	var routes []*ProcessorCfg
+	if err = session.Where("type='route' and target='" + id + "'").Find(&routes); err != nil {
+		return 0, fmt.Errorf("Error on get route processors with target output %s, error: %s", id, err)
+	}
+	for _, r := range routes {
+		if _, err = session.Where("id_processor='" + r.ID + "'").Delete(&OutputProcessors{}); err != nil {
+			return 0, fmt.Errorf("Error on Delete processor %s on OutputProcessors, error: %s", r.ID, err)
+		}
+		if _, err = session.Where("id='" + r.ID + "'").Delete(&ProcessorCfg{}); err != nil {
+			return 0, fmt.Errorf("Error on Delete route processor %s, error: %s", r.ID, err)
+		}
+		affectedproc++
+	}
+	log.Infof("Deleted output %s references [ %d Devices Affected | %d Device Extra Output DBs Affected | %d Measurement Groups Affected | %d Measurements Affected | %d Processors Affected ]", id, affecteddev, affecteddevod, affectedmgod, affectedmeas, affectedproc)
+	return affecteddev + affecteddevod + affectedmgod + affectedmeas + affectedproc, nil
+}
+
+// updateOutputRefs renames all references to the output id on devices, measurement groups, measurements and processors
+func updateOutputRefs(session *xorm.Session, id string, newid string) (int64, error) {
+	affecteddev, err := session.Where("outdb='" + id + "'").Cols("outdb").Update(&SnmpDeviceCfg{OutDB: newid})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Update SnmpDeviceCfg on update id(old)  %s with (new): %s, error: %s", id, newid, err)
+	}
+	affecteddevod, err := session.Where("id_outdb='" + id + "'").Cols("id_outdb").Update(&SnmpDevOutDBs{IDOutDB: newid})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Update SnmpDevOutDBs on update id(old)  %s with (new): %s, error: %s", id, newid, err)
+	}
+	affectedmgod, err := session.Where("id_outdb='" + id + "'").Cols("id_outdb").Update(&MGroupsOutDBs{IDOutDB: newid})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Update MGroupsOutDBs on update id(old)  %s with (new): %s, error: %s", id, newid, err)
+	}
+	affectedmeas, err := session.Where("outdb='" + id + "'").Cols("outdb").Update(&MeasurementCfg{OutDB: newid})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Update MeasurementCfg on update id(old)  %s with (new): %s, error: %s", id, newid, err)
+	}
+	affectedproc, err := session.Where("id_output='" + id + "'").Cols("id_output").Update(&OutputProcessors{IDOutput: newid})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Update OutputProcessors on update id(old)  %s with (new): %s, error: %s", id, newid, err)
+	}
+	affectedroute, err := session.Where("target='" + id + "'").Cols("target").Update(&ProcessorCfg{Target: newid})
+	if err != nil {
+		return 0, fmt.Errorf("Error on Update ProcessorCfg route target on update id(old)  %s with (new): %s, error: %s", id, newid, err)
+	}
+	log.Infof("Updated output %s references to %s [ %d devices | %d device extra output dbs | %d measurement groups | %d measurements | %d processors ]", id, newid, affecteddev, affecteddevod, affectedmgod, affectedmeas, affectedproc+affectedroute)
+	return affecteddev + affecteddevod + affectedmgod + affectedmeas + affectedproc + affectedroute, nil
+}
+
+// getOutputAffectOnDel get all objects referencing the output id
+func (dbc *DatabaseCfg) getOutputAffectOnDel(id string) ([]*DbObjAction, error) {
+	var devices []*SnmpDeviceCfg
+	var obj []*DbObjAction
+	if err := dbc.x.Where("outdb='" + id + "'").Find(&devices); err != nil {
+		log.Warnf("Error on Get Outout db id %s for devices , error: %s", id, err)
+		return nil, err
+	}
+
+	for _, val := range devices {
+		obj = append(obj, &DbObjAction{
+			Type:     "snmpdevicecfg",
+			TypeDesc: "SNMP Devices",
+			ObID:     val.ID,
+			Action:   "Reset Output from SNMPDevice to 'default' Output",
+		})
+	}
+
+	var devoutdbs []*SnmpDevOutDBs
+	if err := dbc.x.Where("id_outdb='" + id + "'").Find(&devoutdbs); err != nil {
+		log.Warnf("Error on Get Outout db id %s for devices extra output dbs, error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range devoutdbs {
+		obj = append(obj, &DbObjAction{
+			Type:     "snmpdevicecfg",
+			TypeDesc: "SNMP Devices",
+			ObID:     val.IDSnmpDev,
+			Action:   "Delete Output from SNMPDevice extra Outputs",
+		})
+	}
+
+	var mgoutdbs []*MGroupsOutDBs
+	if err := dbc.x.Where("id_outdb='" + id + "'").Find(&mgoutdbs); err != nil {
+		log.Warnf("Error on Get Outout db id %s for measurement groups, error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range mgoutdbs {
+		obj = append(obj, &DbObjAction{
+			Type:     "measgroupcfg",
+			TypeDesc: "Measurement Groups",
+			ObID:     val.IDMGroupCfg,
+			Action:   "Delete Output from Measurement Group relation",
+		})
+	}
+
+	var meas []*MeasurementCfg
+	if err := dbc.x.Where("outdb='" + id + "'").Find(&meas); err != nil {
+		log.Warnf("Error on Get Outout db id %s for measurements, error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range meas {
+		obj = append(obj, &DbObjAction{
+			Type:     "measurementcfg",
+			TypeDesc: "Measurements",
+			ObID:     val.ID,
+			Action:   "Reset Output override from Measurement to the device Output",
+		})
+	}
+
+	var outprocs []*OutputProcessors
+	if err := dbc.x.Where("id_output='" + id + "'").Find(&outprocs); err != nil {
+		log.Warnf("Error on Get Outout db id %s for processors, error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range outprocs {
+		obj = append(obj, &DbObjAction{
+			Type:     "processorcfg",
+			TypeDesc: "Processors",
+			ObID:     val.IDProcessor,
+			Action:   "Delete Output from Processor relation",
+		})
+	}
+
+	var routes []*ProcessorCfg
+	if err := dbc.x.Where("type='route' and target='" + id + "'").Find(&routes); err != nil {
+		log.Warnf("Error on Get Outout db id %s for route processors, error: %s", id, err)
+		return nil, err
+	}
+	for _, val := range routes {
+		obj = append(obj, &DbObjAction{
+			Type:     "processorcfg",
+			TypeDesc: "Processors",
+			ObID:     val.ID,
+			Action:   "Delete route Processor with this Output as target",
+		})
+	}
+	return obj, nil
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+/***************************
+	Output Processors
+	-GetProcessorCfgByID(struct)
+	-GetProcessorCfgMap (map - for interna config use
+	-GetProcessorCfgArray(Array - for web ui use )
+	-AddProcessorCfg
+	-DelProcessorCfg
+	-UpdateProcessorCfg
+	-GetProcessorCfgAffectOnDel
+***********************************/
+
+// checkProcessorCfg returns error if the processor parameters are not valid for its type
+func (dbc *DatabaseCfg) checkProcessorCfg(dev *ProcessorCfg) error {
+	if _, err := regexp.Compile(dev.Measurement); err != nil {
+		return fmt.Errorf("Invalid measurement regex %q on processor %s: %s", dev.Measurement, dev.ID, err)
+	}
+	switch dev.Type {
+	case "rename_tag", "rename_field":
+		if len(dev.Key) == 0 || len(dev.Value) == 0 {
+			return fmt.Errorf("Processor %s of type %s needs the old (Key) and new (Value) names", dev.ID, dev.Type)
+		}
+	case "add_tag":
+		if len(dev.Key) == 0 {
+			return fmt.Errorf("Processor %s of type %s needs the tag name (Key)", dev.ID, dev.Type)
+		}
+	case "drop_field", "lowercase_tag", "sanitize_tag":
+		if len(dev.Key) == 0 {
+			return fmt.Errorf("Processor %s of type %s needs the name regex (Key), use .* to match all", dev.ID, dev.Type)
+		}
+		if _, err := regexp.Compile(dev.Key); err != nil {
+			return fmt.Errorf("Invalid key regex %q on processor %s: %s", dev.Key, dev.ID, err)
+		}
+	case "route":
+		if len(dev.Key) == 0 {
+			return fmt.Errorf("Processor %s of type %s needs the tag name (Key)", dev.ID, dev.Type)
+		}
+		if _, err := regexp.Compile(dev.Value); err != nil {
+			return fmt.Errorf("Invalid value regex %q on processor %s: %s", dev.Value, dev.ID, err)
+		}
+		if len(dev.Target) == 0 {
+			return nil
+		}
+		outs, err := dbc.GetOutputArray()
+		if err != nil {
+			return err
+		}
+		for _, o := range outs {
+			if o.ID == dev.Target {
+				return nil
+			}
+		}
+		return fmt.Errorf("Route target output %s on processor %s does not exist", dev.Target, dev.ID)
+	}
+	return nil
+}
+
+/*GetProcessorCfgByID get processor data by id*/
+func (dbc *DatabaseCfg) GetProcessorCfgByID(id string) (ProcessorCfg, error) {
+	cfgarray, err := dbc.GetProcessorCfgArray("id='" + id + "'")
+	if err != nil {
+		return ProcessorCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return ProcessorCfg{}, fmt.Errorf("Error %d results on get ProcessorCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return ProcessorCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the processor config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetProcessorCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetProcessorCfgMap(filter string) (map[string]*ProcessorCfg, error) {
+	cfgarray, err := dbc.GetProcessorCfgArray(filter)
+	cfgmap := make(map[string]*ProcessorCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetProcessorCfgArray generate an array of processors with all its information */
+func (dbc *DatabaseCfg) GetProcessorCfgArray(filter string) ([]*ProcessorCfg, error) {
+	var err error
+	var procs []*ProcessorCfg
+	// Get Only data for selected processors
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&procs); err != nil {
+			log.Warnf("Fail to get ProcessorCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&procs); err != nil {
+			log.Warnf("Fail to get ProcessorCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+
+	// Load outputs for each processor
+	var outprocs []*OutputProcessors
+	if err = dbc.x.Find(&outprocs); err != nil {
+		log.Warnf("Fail to get Output Processors relationship  data: %v\n", err)
+	}
+
+	for _, pVal := range procs {
+		for _, op := range outprocs {
+			if op.IDProcessor == pVal.ID {
+				pVal.Outputs = append(pVal.Outputs, op.IDOutput)
+			}
+		}
+	}
+	return procs, nil
+}
+
+/*AddProcessorCfg for adding new processors*/
+func (dbc *DatabaseCfg) AddProcessorCfg(dev ProcessorCfg) (int64, error) {
+	var err error
+	var affected, newop int64
+	if err = dbc.checkProcessorCfg(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// Outputs
+	for _, out := range dev.Outputs {
+		opstruct := OutputProcessors{
+			IDOutput:    out,
+			IDProcessor: dev.ID,
+		}
+		newop, err = session.Insert(&opstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new Processor Successfully with id %s [%d Outputs]", dev.ID, len(dev.Outputs))
+	dbc.addChanges(affected + newop)
+	return affected, nil
+}
+
+/*DelProcessorCfg for deleting processors from ID*/
+func (dbc *DatabaseCfg) DelProcessorCfg(id string) (int64, error) {
+	var affectedop, affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// deleting output relations
+	affectedop, err = session.Where("id_processor='" + id + "'").Delete(&OutputProcessors{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Processor on OutputProcessors table with id: %s, error: %s", id, err)
+	}
+
+	affected, err = session.Where("id='" + id + "'").Delete(&ProcessorCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully Processor with ID %s [ %d Outputs Affected  ]", id, affectedop)
+	dbc.addChanges(affected + affectedop)
+	return affected, nil
+}
+
+/*UpdateProcessorCfg for updating processors*/
+func (dbc *DatabaseCfg) UpdateProcessorCfg(id string, dev ProcessorCfg) (int64, error) {
+	var newop, affected int64
+	var err error
+	if err = dbc.checkProcessorCfg(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	// Remove all outputs and adding again
+	_, err = session.Where("id_processor='" + id + "'").Delete(&OutputProcessors{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Processor outputs on OutputProcessors with id: %s, error: %s", id, err)
+	}
+	for _, out := range dev.Outputs {
+		opstruct := OutputProcessors{
+			IDOutput:    out,
+			IDProcessor: dev.ID,
+		}
+		newop, err = session.Insert(&opstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated Processor Successfully with id %s [%d outputs] and data:%+v", dev.ID, len(dev.Outputs), dev)
+	dbc.addChanges(affected + newop)
+	return affected, nil
+}
+
+/*GetProcessorCfgAffectOnDel for deleting processors from ID*/
+func (dbc *DatabaseCfg) GetProcessorCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	var outprocs []*OutputProcessors
+	var obj []*DbObjAction
+	if err := dbc.x.Where("id_processor='" + id + "'").Find(&outprocs); err != nil {
+		log.Warnf("Error on Get Processor id %s for outputs , error: %s", id, err)
+		return nil, err
+	}
+
+	for _, val := range outprocs {
+		obj = append(obj, &DbObjAction{
+			Type:     "outputcfg",
+			TypeDesc: "Outputs",
+			ObID:     val.IDOutput,
+			Action:   "Delete Processor from Output relation",
+		})
+	}
+	return obj, nil
+}
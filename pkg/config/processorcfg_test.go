@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+)
+
+func Test_checkProcessorCfg(t *testing.T) {
+	tests := []struct {
+		cfg ProcessorCfg
+		ok  bool
+	}{
+		{ProcessorCfg{ID: "rename", Type: "rename_tag", Key: "a", Value: "b"}, true},
+		{ProcessorCfg{ID: "rename no value", Type: "rename_field", Key: "a"}, false},
+		{ProcessorCfg{ID: "add", Type: "add_tag", Key: "site"}, true},
+		{ProcessorCfg{ID: "add no key", Type: "add_tag", Value: "bcn"}, false},
+		{ProcessorCfg{ID: "drop", Type: "drop_field", Key: "^in"}, true},
+		{ProcessorCfg{ID: "drop no key", Type: "drop_field"}, false},
+		{ProcessorCfg{ID: "drop bad key", Type: "drop_field", Key: "["}, false},
+		{ProcessorCfg{ID: "lowercase all", Type: "lowercase_tag", Key: ".*"}, true},
+		{ProcessorCfg{ID: "lowercase no key", Type: "lowercase_tag"}, false},
+		{ProcessorCfg{ID: "sanitize no key", Type: "sanitize_tag", Value: "-"}, false},
+		{ProcessorCfg{ID: "route drop", Type: "route", Key: "ifType", Value: "^24$"}, true},
+		{ProcessorCfg{ID: "route no key", Type: "route", Value: ".*"}, false},
+		{ProcessorCfg{ID: "bad measurement", Type: "add_tag", Key: "a", Measurement: "("}, false},
+	}
+	dbc := &DatabaseCfg{}
+	for _, tt := range tests {
+		err := dbc.checkProcessorCfg(&tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, expected ok %t", tt.cfg.ID, err, tt.ok)
+		}
+	}
+}
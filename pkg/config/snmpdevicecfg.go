@@ -89,13 +89,46 @@ func (dbc *DatabaseCfg) GetSnmpDeviceCfgArray(filter string) ([]*SnmpDeviceCfg,
 			}
 		}
 	}
+
+	// Asign extra output databases to devices.
+	var snmpdevoutdbs []*SnmpDevOutDBs
+	if err = dbc.x.Find(&snmpdevoutdbs); err != nil {
+		log.Warnf("Fail to get SnmpDevices and Output DB relationship data: %v\n", err)
+		return devices, err
+	}
+
+	for _, mVal := range devices {
+		for _, od := range snmpdevoutdbs {
+			if od.IDSnmpDev == mVal.ID {
+				mVal.ExtraOutDBs = append(mVal.ExtraOutDBs, od.IDOutDB)
+			}
+		}
+	}
+
+	// Asign fallback credential profiles to devices in priority order.
+	var snmpdevcreds []*SnmpDevCredentials
+	if err = dbc.x.Asc("priority").Find(&snmpdevcreds); err != nil {
+		log.Warnf("Fail to get SnmpDevices and Credential relationship data: %v\n", err)
+		return devices, err
+	}
+
+	for _, mVal := range devices {
+		for _, dc := range snmpdevcreds {
+			if dc.IDSnmpDev == mVal.ID {
+				mVal.FallbackCredentials = append(mVal.FallbackCredentials, dc.IDCredential)
+			}
+		}
+	}
 	return devices, nil
 }
 
 /*AddSnmpDeviceCfg for adding new devices*/
 func (dbc *DatabaseCfg) AddSnmpDeviceCfg(dev SnmpDeviceCfg) (int64, error) {
 	var err error
-	var affected, newmg, newft int64
+	var affected, newmg, newft, newod, newcr int64
+	if err = dbc.checkSnmpDeviceCredential(&dev); err != nil {
+		return 0, err
+	}
 	session := dbc.x.NewSession()
 	if err := session.Begin(); err != nil {
 		// if returned then will rollback automatically
@@ -133,18 +166,43 @@ func (dbc *DatabaseCfg) AddSnmpDeviceCfg(dev SnmpDeviceCfg) (int64, error) {
 			return 0, err
 		}
 	}
+	// Extra Output DBs
+	for _, od := range dev.ExtraOutDBs {
+		odstruct := SnmpDevOutDBs{
+			IDSnmpDev: dev.ID,
+			IDOutDB:   od,
+		}
+		newod, err = session.Insert(&odstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
+	// Fallback Credentials
+	for i, cr := range dev.FallbackCredentials {
+		crstruct := SnmpDevCredentials{
+			IDSnmpDev:    dev.ID,
+			IDCredential: cr,
+			Priority:     i,
+		}
+		newcr, err = session.Insert(&crstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
 	err = session.Commit()
 	if err != nil {
 		return 0, err
 	}
-	log.Infof("Added new Device Successfully with id %s [%d Measurment Groups | %d filters]", dev.ID, newmg, newft)
-	dbc.addChanges(affected + newmg + newft)
+	log.Infof("Added new Device Successfully with id %s [%d Measurment Groups | %d filters | %d extra output dbs | %d fallback credentials]", dev.ID, newmg, newft, newod, newcr)
+	dbc.addChanges(affected + newmg + newft + newod + newcr)
 	return affected, nil
 }
 
 /*DelSnmpDeviceCfg for deleting devices from ID*/
 func (dbc *DatabaseCfg) DelSnmpDeviceCfg(id string) (int64, error) {
-	var affectedmg, affectedft, affectedcf, affected int64
+	var affectedmg, affectedft, affectedod, affectedcr, affectedcf, affected int64
 	var err error
 
 	session := dbc.x.NewSession()
@@ -166,6 +224,18 @@ func (dbc *DatabaseCfg) DelSnmpDeviceCfg(id string) (int64, error) {
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevFilters with id: %s, error: %s", id, err)
 	}
+	// Extra Output DBs
+	affectedod, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevOutDBs{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevOutDBs with id: %s, error: %s", id, err)
+	}
+	// Fallback Credentials
+	affectedcr, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevCredentials{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevCredentials with id: %s, error: %s", id, err)
+	}
 	// CustomFilter Reladed Dev
 	affectedcf, err = session.Where("related_dev='" + id + "'").Cols("related_dev").Update(&CustomFilterCfg{})
 	if err != nil {
@@ -183,15 +253,18 @@ func (dbc *DatabaseCfg) DelSnmpDeviceCfg(id string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	log.Infof("Deleted Successfully device with ID %s [] %d Measurement Groups affected , %d Filters affected ,%d Custom filter  affected]", id, affectedmg, affectedft, affectedcf)
-	dbc.addChanges(affected + affectedmg + affectedft + affectedcf)
+	log.Infof("Deleted Successfully device with ID %s [] %d Measurement Groups affected , %d Filters affected , %d Output DBs affected , %d Fallback Credentials affected ,%d Custom filter  affected]", id, affectedmg, affectedft, affectedod, affectedcr, affectedcf)
+	dbc.addChanges(affected + affectedmg + affectedft + affectedod + affectedcr + affectedcf)
 	return affected, nil
 }
 
 /*UpdateSnmpDeviceCfg for adding new devices*/
 func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64, error) {
-	var deletemg, newmg, deleteft, newft, affectedcf, affected int64
+	var deletemg, newmg, deleteft, newft, deleteod, newod, deletecr, newcr, affectedcf, affected int64
 	var err error
+	if err = dbc.checkSnmpDeviceCredential(&dev); err != nil {
+		return 0, err
+	}
 	session := dbc.x.NewSession()
 	if err := session.Begin(); err != nil {
 		// if returned then will rollback automatically
@@ -210,6 +283,18 @@ func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64
 		session.Rollback()
 		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevFilters with id: %s, error: %s", id, err)
 	}
+	// Extra Output DBs
+	deleteod, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevOutDBs{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevOutDBs with id: %s, error: %s", id, err)
+	}
+	// Fallback Credentials
+	deletecr, err = session.Where("id_snmpdev='" + id + "'").Delete(&SnmpDevCredentials{})
+	if err != nil {
+		session.Rollback()
+		return 0, fmt.Errorf("Error on Delete Device with id on delete SnmpDevCredentials with id: %s, error: %s", id, err)
+	}
 
 	affectedcf, err = session.Where("related_dev='" + id + "'").Cols("related_dev").Update(&CustomFilterCfg{RelatedDev: dev.ID})
 	if err != nil {
@@ -233,6 +318,31 @@ func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64
 		}
 		newft, err = session.Insert(&mfstruct)
 	}
+	// Extra Output DBs
+	for _, od := range dev.ExtraOutDBs {
+		odstruct := SnmpDevOutDBs{
+			IDSnmpDev: dev.ID,
+			IDOutDB:   od,
+		}
+		newod, err = session.Insert(&odstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
+	// Fallback Credentials
+	for i, cr := range dev.FallbackCredentials {
+		crstruct := SnmpDevCredentials{
+			IDSnmpDev:    dev.ID,
+			IDCredential: cr,
+			Priority:     i,
+		}
+		newcr, err = session.Insert(&crstruct)
+		if err != nil {
+			session.Rollback()
+			return 0, err
+		}
+	}
 	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
 
 	if err != nil {
@@ -245,8 +355,10 @@ func (dbc *DatabaseCfg) UpdateSnmpDeviceCfg(id string, dev SnmpDeviceCfg) (int64
 	}
 	log.Infof("Updated device constrains (old %d / new %d ) Measurement Groups", deletemg, newmg)
 	log.Infof("Updated device constrains (old %d / new %d ) MFilters", deleteft, newft)
+	log.Infof("Updated device constrains (old %d / new %d ) Extra Output DBs", deleteod, newod)
+	log.Infof("Updated device constrains (old %d / new %d ) Fallback Credentials", deletecr, newcr)
 	log.Infof("Updated new Device Successfully with id %s and data:%+v", id, dev)
-	dbc.addChanges(affected + deletemg + newmg + deleteft + newft + affectedcf)
+	dbc.addChanges(affected + deletemg + newmg + deleteft + newft + deleteod + newod + deletecr + newcr + affectedcf)
 	return affected, nil
 }
 
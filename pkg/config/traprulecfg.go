@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/***************************
+	SNMP Trap rules
+	-GetTrapRuleCfgByID(struct)
+	-GetTrapRuleCfgMap (map - for interna config use
+	-GetTrapRuleCfgArray(Array - for web ui use )
+	-AddTrapRuleCfg
+	-DelTrapRuleCfg
+	-UpdateTrapRuleCfg
+	-GetTrapRuleCfgAffectOnDel
+***********************************/
+
+var trapOIDRegexp = regexp.MustCompile(`^\.?[0-9]+(\.[0-9]+)*(\.\*)?$`)
+
+// TrapVarBind is a varbind mapped to a point field
+type TrapVarBind struct {
+	OID  string
+	Name string
+}
+
+// GetVarBinds parses the mapped varbinds configured as <OID>=<field name>
+func (r *TrapRuleCfg) GetVarBinds() ([]TrapVarBind, error) {
+	var vbs []TrapVarBind
+	for _, v := range r.VarBinds {
+		s := strings.SplitN(v, "=", 2)
+		if len(s) != 2 || len(strings.TrimSpace(s[1])) == 0 {
+			return nil, fmt.Errorf("Invalid varbind %q on trap rule %s, expected <OID>=<field name>", v, r.ID)
+		}
+		oid := strings.TrimSpace(s[0])
+		if !trapOIDRegexp.MatchString(oid) || strings.HasSuffix(oid, "*") {
+			return nil, fmt.Errorf("Invalid varbind OID %q on trap rule %s", oid, r.ID)
+		}
+		if !strings.HasPrefix(oid, ".") {
+			oid = "." + oid
+		}
+		vbs = append(vbs, TrapVarBind{OID: oid, Name: strings.TrimSpace(s[1])})
+	}
+	return vbs, nil
+}
+
+// checkTrapRuleCfg returns error if the trap rule parameters are not valid
+func (dbc *DatabaseCfg) checkTrapRuleCfg(dev *TrapRuleCfg) error {
+	if !trapOIDRegexp.MatchString(dev.TrapOID) {
+		return fmt.Errorf("Invalid trap OID %q on trap rule %s", dev.TrapOID, dev.ID)
+	}
+	vbs, err := dev.GetVarBinds()
+	if err != nil {
+		return err
+	}
+	if dev.Mode == "metric" && len(vbs) == 0 {
+		return fmt.Errorf("Trap rule %s on metric mode needs at least one mapped varbind", dev.ID)
+	}
+	for _, tag := range dev.ExtraTags {
+		if len(strings.Split(tag, "=")) != 2 {
+			return fmt.Errorf("Error on tag definition TAG=VALUE [ %s ] on trap rule %s", tag, dev.ID)
+		}
+	}
+	return nil
+}
+
+/*GetTrapRuleCfgByID get trap rule data by id*/
+func (dbc *DatabaseCfg) GetTrapRuleCfgByID(id string) (TrapRuleCfg, error) {
+	cfgarray, err := dbc.GetTrapRuleCfgArray("id='" + id + "'")
+	if err != nil {
+		return TrapRuleCfg{}, err
+	}
+	if len(cfgarray) > 1 {
+		return TrapRuleCfg{}, fmt.Errorf("Error %d results on get TrapRuleCfg by id %s", len(cfgarray), id)
+	}
+	if len(cfgarray) == 0 {
+		return TrapRuleCfg{}, fmt.Errorf("Error no values have been returned with this id %s in the trap rule config table", id)
+	}
+	return *cfgarray[0], nil
+}
+
+/*GetTrapRuleCfgMap  return data in map format*/
+func (dbc *DatabaseCfg) GetTrapRuleCfgMap(filter string) (map[string]*TrapRuleCfg, error) {
+	cfgarray, err := dbc.GetTrapRuleCfgArray(filter)
+	cfgmap := make(map[string]*TrapRuleCfg)
+	for _, val := range cfgarray {
+		cfgmap[val.ID] = val
+		log.Debugf("%+v", *val)
+	}
+	return cfgmap, err
+}
+
+/*GetTrapRuleCfgArray generate an array of trap rules with all its information */
+func (dbc *DatabaseCfg) GetTrapRuleCfgArray(filter string) ([]*TrapRuleCfg, error) {
+	var err error
+	var rules []*TrapRuleCfg
+	// Get Only data for selected rules
+	if len(filter) > 0 {
+		if err = dbc.x.Where(filter).Find(&rules); err != nil {
+			log.Warnf("Fail to get TrapRuleCfg  data filteter with %s : %v\n", filter, err)
+			return nil, err
+		}
+	} else {
+		if err = dbc.x.Find(&rules); err != nil {
+			log.Warnf("Fail to get TrapRuleCfg   data: %v\n", err)
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+/*AddTrapRuleCfg for adding new trap rules*/
+func (dbc *DatabaseCfg) AddTrapRuleCfg(dev TrapRuleCfg) (int64, error) {
+	var err error
+	var affected int64
+	if err = dbc.checkTrapRuleCfg(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Insert(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	// no other relation
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Added new trap rule Successfully with id %s ", dev.ID)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*DelTrapRuleCfg for deleting trap rules from ID*/
+func (dbc *DatabaseCfg) DelTrapRuleCfg(id string) (int64, error) {
+	var affected int64
+	var err error
+
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+	// no other relation
+
+	affected, err = session.Where("id='" + id + "'").Delete(&TrapRuleCfg{})
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Deleted Successfully trap rule with ID %s", id)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*UpdateTrapRuleCfg for updating trap rules*/
+func (dbc *DatabaseCfg) UpdateTrapRuleCfg(id string, dev TrapRuleCfg) (int64, error) {
+	var affected int64
+	var err error
+	if err = dbc.checkTrapRuleCfg(&dev); err != nil {
+		return 0, err
+	}
+	session := dbc.x.NewSession()
+	if err := session.Begin(); err != nil {
+		// if returned then will rollback automatically
+		return 0, err
+	}
+	defer session.Close()
+
+	affected, err = session.Where("id='" + id + "'").UseBool().AllCols().Update(dev)
+	if err != nil {
+		session.Rollback()
+		return 0, err
+	}
+	err = session.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof("Updated trap rule Successfully with id %s and data:%+v", id, dev)
+	dbc.addChanges(affected)
+	return affected, nil
+}
+
+/*GetTrapRuleCfgAffectOnDel for deleting trap rules from ID*/
+func (dbc *DatabaseCfg) GetTrapRuleCfgAffectOnDel(id string) ([]*DbObjAction, error) {
+	// trap rules are not referenced by other objects
+	var obj []*DbObjAction
+	return obj, nil
+}
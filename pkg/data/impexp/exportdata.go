@@ -111,6 +111,26 @@ func (e *ExportData) UpdateTmpObject() {
 	e.tmpObjects = nil
 }
 
+// outputObjType returns the export object type of the output with this id
+func outputObjType(id string) string {
+	if _, err := dbc.GetGraphiteCfgByID(id); err == nil {
+		return "graphitecfg"
+	}
+	if _, err := dbc.GetOtlpCfgByID(id); err == nil {
+		return "otlpcfg"
+	}
+	if _, err := dbc.GetFileCfgByID(id); err == nil {
+		return "filecfg"
+	}
+	if _, err := dbc.GetMqttCfgByID(id); err == nil {
+		return "mqttcfg"
+	}
+	if _, err := dbc.GetKafkaCfgByID(id); err == nil {
+		return "kafkacfg"
+	}
+	return "influxcfg"
+}
+
 // Export  exports data
 func (e *ExportData) Export(ObjType string, id string, recursive bool, level int) error {
 	switch ObjType {
@@ -130,7 +150,23 @@ func (e *ExportData) Export(ObjType string, id string, recursive bool, level int
 		for _, val := range v.MeasFilters {
 			e.Export("measfiltercfg", val, recursive, level+1)
 		}
-		e.Export("influxcfg", v.OutDB, recursive, level+1)
+		e.Export(outputObjType(v.OutDB), v.OutDB, recursive, level+1)
+		for _, val := range v.ExtraOutDBs {
+			e.Export(outputObjType(val), val, recursive, level+1)
+		}
+		if len(v.Credential) > 0 {
+			e.Export("credentialcfg", v.Credential, recursive, level+1)
+		}
+		for _, val := range v.FallbackCredentials {
+			e.Export("credentialcfg", val, recursive, level+1)
+		}
+	case "credentialcfg":
+		// contains sensible data
+		v, err := dbc.GetCredentialCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "credentialcfg", ObjectID: id, ObjectCfg: v})
 	case "influxcfg":
 		// contains sensible probable
 		v, err := dbc.GetInfluxCfgByID(id)
@@ -138,6 +174,58 @@ func (e *ExportData) Export(ObjType string, id string, recursive bool, level int
 			return err
 		}
 		e.PrependObject(&ExportObject{ObjectTypeID: "influxcfg", ObjectID: id, ObjectCfg: v})
+	case "graphitecfg":
+		v, err := dbc.GetGraphiteCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "graphitecfg", ObjectID: id, ObjectCfg: v})
+	case "otlpcfg":
+		// contains sensible probable (headers)
+		v, err := dbc.GetOtlpCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "otlpcfg", ObjectID: id, ObjectCfg: v})
+	case "filecfg":
+		v, err := dbc.GetFileCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "filecfg", ObjectID: id, ObjectCfg: v})
+	case "mqttcfg":
+		v, err := dbc.GetMqttCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "mqttcfg", ObjectID: id, ObjectCfg: v})
+	case "kafkacfg":
+		v, err := dbc.GetKafkaCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "kafkacfg", ObjectID: id, ObjectCfg: v})
+	case "processorcfg":
+		v, err := dbc.GetProcessorCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "processorcfg", ObjectID: id, ObjectCfg: v})
+		if !recursive {
+			break
+		}
+		for _, val := range v.Outputs {
+			e.Export(outputObjType(val), val, recursive, level+1)
+		}
+		if len(v.Target) > 0 {
+			e.Export(outputObjType(v.Target), v.Target, recursive, level+1)
+		}
+	case "traprulecfg":
+		v, err := dbc.GetTrapRuleCfgByID(id)
+		if err != nil {
+			return err
+		}
+		e.PrependObject(&ExportObject{ObjectTypeID: "traprulecfg", ObjectID: id, ObjectCfg: v})
 	case "measfiltercfg":
 		v, err := dbc.GetMeasFilterCfgByID(id)
 		if err != nil {
@@ -194,6 +282,9 @@ func (e *ExportData) Export(ObjType string, id string, recursive bool, level int
 		if !recursive {
 			break
 		}
+		if len(v.OutDB) > 0 {
+			e.Export(outputObjType(v.OutDB), v.OutDB, recursive, level+1)
+		}
 		//--------------------
 		// metric objects
 		//--------------------
@@ -255,6 +346,9 @@ func (e *ExportData) Export(ObjType string, id string, recursive bool, level int
 		for _, val := range v.Measurements {
 			e.Export("measurementcfg", val, recursive, level+1)
 		}
+		for _, val := range v.OutDBs {
+			e.Export(outputObjType(val), val, recursive, level+1)
+		}
 	case "varcatalogcfg":
 		v, err := dbc.GetVarCatalogCfgByID(id)
 		if err != nil {
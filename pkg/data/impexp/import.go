@@ -43,6 +43,21 @@ func (e *ExportData) ImportCheck() (*ExportData, error) {
 				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
 				duplicated = append(duplicated, o)
 			}
+		case "credentialcfg":
+			data := config.CredentialCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetCredentialCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
 		case "influxcfg":
 			data := config.InfluxCfg{}
 			json.Unmarshal(raw, &data)
@@ -58,6 +73,111 @@ func (e *ExportData) ImportCheck() (*ExportData, error) {
 				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
 				duplicated = append(duplicated, o)
 			}
+		case "graphitecfg":
+			data := config.GraphiteCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetGraphiteCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "otlpcfg":
+			data := config.OtlpCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetOtlpCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "filecfg":
+			data := config.FileCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetFileCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "mqttcfg":
+			data := config.MqttCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetMqttCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "kafkacfg":
+			data := config.KafkaCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetKafkaCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "processorcfg":
+			data := config.ProcessorCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetProcessorCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
+		case "traprulecfg":
+			data := config.TrapRuleCfg{}
+			json.Unmarshal(raw, &data)
+			ers := binding.RawValidate(data)
+			if ers.Len() > 0 {
+				e, _ := json.Marshal(ers)
+				o.Error = string(e)
+				duplicated = append(duplicated, o)
+				break
+			}
+			_, err := dbc.GetTrapRuleCfgByID(o.ObjectID)
+			if err == nil {
+				o.Error = fmt.Sprintf("Duplicated object %s in the database", o.ObjectID)
+				duplicated = append(duplicated, o)
+			}
 		case "measfiltercfg":
 			data := config.MeasFilterCfg{}
 			json.Unmarshal(raw, &data)
@@ -220,6 +340,28 @@ func (e *ExportData) Import(overwrite bool, autorename bool) error {
 				return err
 			}
 
+		case "credentialcfg":
+			log.Debugf("Importing credentialcfg : %+v", o.ObjectCfg)
+			data := config.CredentialCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetCredentialCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateCredentialCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddCredentialCfg(data)
+			if err != nil {
+				return err
+			}
 		case "influxcfg":
 			log.Debugf("Importing influxcfg : %+v", o.ObjectCfg)
 			data := config.InfluxCfg{}
@@ -242,6 +384,160 @@ func (e *ExportData) Import(overwrite bool, autorename bool) error {
 			if err != nil {
 				return err
 			}
+		case "graphitecfg":
+			log.Debugf("Importing graphitecfg : %+v", o.ObjectCfg)
+			data := config.GraphiteCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetGraphiteCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateGraphiteCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddGraphiteCfg(data)
+			if err != nil {
+				return err
+			}
+		case "otlpcfg":
+			log.Debugf("Importing otlpcfg : %+v", o.ObjectCfg)
+			data := config.OtlpCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetOtlpCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateOtlpCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddOtlpCfg(data)
+			if err != nil {
+				return err
+			}
+		case "filecfg":
+			log.Debugf("Importing filecfg : %+v", o.ObjectCfg)
+			data := config.FileCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetFileCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateFileCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddFileCfg(data)
+			if err != nil {
+				return err
+			}
+		case "mqttcfg":
+			log.Debugf("Importing mqttcfg : %+v", o.ObjectCfg)
+			data := config.MqttCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetMqttCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateMqttCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddMqttCfg(data)
+			if err != nil {
+				return err
+			}
+		case "kafkacfg":
+			log.Debugf("Importing kafkacfg : %+v", o.ObjectCfg)
+			data := config.KafkaCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetKafkaCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateKafkaCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddKafkaCfg(data)
+			if err != nil {
+				return err
+			}
+		case "processorcfg":
+			log.Debugf("Importing processorcfg : %+v", o.ObjectCfg)
+			data := config.ProcessorCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetProcessorCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateProcessorCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddProcessorCfg(data)
+			if err != nil {
+				return err
+			}
+		case "traprulecfg":
+			log.Debugf("Importing traprulecfg : %+v", o.ObjectCfg)
+			data := config.TrapRuleCfg{}
+			json.Unmarshal(raw, &data)
+			var err error
+			_, err = dbc.GetTrapRuleCfgByID(o.ObjectID)
+			if err == nil { // value exist already in the database
+				if overwrite == true {
+					_, err2 := dbc.UpdateTrapRuleCfg(o.ObjectID, data)
+					if err2 != nil {
+						return fmt.Errorf("Error on overwrite object [%s] %s : %s", o.ObjectTypeID, o.ObjectID, err2)
+					}
+					break
+				}
+			}
+			if autorename == true {
+				data.ID = data.ID + suffix
+			}
+			_, err = dbc.AddTrapRuleCfg(data)
+			if err != nil {
+				return err
+			}
 		case "measfiltercfg":
 			log.Debugf("Importing measfiltercfg : %+v", o.ObjectCfg)
 			data := config.MeasFilterCfg{}
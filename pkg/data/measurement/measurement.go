@@ -54,9 +54,19 @@ type Measurement struct {
 	ID      string
 	MName   string
 	TagName []string
+	// deviceTag is the tag key set by the device with its identifier
+	deviceTag string
+	// Context is the SNMP context queried by the measurement, empty if it is the device one
+	Context string
 	// MetricTable data from OidSnmpMap structured to be passed to the UI (with ToJSON).
 	// We use pointers, so the data is the same here and OidSnmpMap.
 	MetricTable *metric.MetricTable
+	// lastData protects lastPoints and counterTotals, it is not held while gathering so scrapes are never blocked
+	lastData sync.Mutex
+	// lastPoints points built from the MetricTable on the last gather cycle (used by scrape endpoints)
+	lastPoints []*output.Point
+	// counterTotals cumulative value of the counter fields by series since the measurement start
+	counterTotals map[string]float64
 	// OidSnmpMap store values returned from the snmp queries
 	OidSnmpMap       map[string]*metric.SnmpMetric `json:"-"` // snmpMetric mapped with real OID's
 	AllIndexedLabels map[string]string             //`json:"-"` //all available values on the remote device
@@ -111,6 +121,35 @@ func New(c *config.MeasurementCfg, measFilters []string, mFilters map[string]*co
 	}
 }
 
+// SetDeviceTag sets the tag key used by the device to identify itself on the generated points
+func (m *Measurement) SetDeviceTag(tag string) {
+	m.deviceTag = tag
+}
+
+// SetContext sets the SNMP context queried by this measurement, it is added to its ID to
+// distinguish it from the measurements of the other contexts
+func (m *Measurement) SetContext(context string) {
+	m.ID = m.cfg.ID + "@" + context
+	m.Context = context
+}
+
+// ContextParams returns the community and v3 context name overrides configured for the measurement context
+func (m *Measurement) ContextParams() snmp.ContextParams {
+	community, contextName := m.cfg.Community, m.cfg.ContextName
+	if len(m.Context) > 0 {
+		if len(community) == 0 {
+			community = "{community}@{context}"
+		}
+		if len(contextName) == 0 {
+			contextName = "{context}"
+		}
+	}
+	return snmp.ContextParams{
+		Community:   strings.ReplaceAll(community, "{context}", m.Context),
+		ContextName: strings.ReplaceAll(contextName, "{context}", m.Context),
+	}
+}
+
 // InvalidateMetrics mark as old (Valid=False) all the metrics in the table
 func (m *Measurement) InvalidateMetrics() {
 	m.MetricTable.InvalidateTable()
@@ -181,7 +220,7 @@ func (m *Measurement) InitMultiIndex() error {
 	for _, v := range m.cfg.MultiIndexCfg {
 		// Create a new measurement cfg from multiindex fields...
 		mcfg := config.MeasurementCfg{
-			ID:             m.ID + ".." + v.Label,
+			ID:             m.cfg.ID + ".." + v.Label,
 			Name:           v.Label,
 			GetMode:        v.GetMode,
 			IndexOID:       v.IndexOID,
@@ -411,7 +450,7 @@ func (m *Measurement) InitBuildRuntime() {
 // CheckInitFilter loads measurement filter on measurement if name/label is matched
 func (m *Measurement) CheckInitFilter(f *config.MeasFilterCfg) (bool, bool) {
 	// check if filter must be applied on base measurement
-	if m.cfg.GetMode != "value" && f.IDMeasurementCfg == m.ID {
+	if m.cfg.GetMode != "value" && f.IDMeasurementCfg == m.cfg.ID {
 		m.FilterCfg = f
 		return true, false
 	}
@@ -943,7 +982,7 @@ func (m *Measurement) GatherLoop(
 	varMap map[string]interface{},
 	tagMap map[string]string,
 	systemOIDs []string,
-	influxClient *output.InfluxDB,
+	out output.Output,
 	gatherLock *sync.Mutex,
 ) {
 	m.Log.Info("MeasurementLoop Fist Check....")
@@ -964,7 +1003,7 @@ func (m *Measurement) GatherLoop(
 				m.Log.Errorf("Not able to initialize at the start of the measurement: %v", errInit)
 			} else {
 				// If connection and initialization are correct, mark the measurement as initiliazed and gather data for the first time
-				// Set influxClient as nil, the first time it shouldn't send metrics
+				// Set output as nil, the first time it shouldn't send metrics
 				m.initialized = true
 				m.stats.ResetCounters()
 				err := m.GatherOnce(gatherLock, varMap, tagMap, nil)
@@ -1037,7 +1076,7 @@ func (m *Measurement) GatherLoop(
 			} else {
 				// If connection and initialization are correct, mark the measurement as initialized and gather data for the first time
 				m.initialized = true
-				// Set influxClient as nil, the first time it shouldn't send metrics
+				// Set output as nil, the first time it shouldn't send metrics
 				m.stats.ResetCounters()
 				err := m.GatherOnce(gatherLock, varMap, tagMap, nil)
 				if err != nil {
@@ -1066,7 +1105,7 @@ func (m *Measurement) GatherLoop(
 			// compute next gather time ( needed to show in the UI )
 			m.stats.SetGatherNextTime(time.Now().Add(time.Duration(gatherFreq) * time.Second).Unix())
 			// Gather
-			err := m.GatherOnce(gatherLock, varMap, tagMap, influxClient)
+			err := m.GatherOnce(gatherLock, varMap, tagMap, out)
 			if err != nil {
 				// if error is because of no response from any metric
 				// we can suppose the connection has been dropped
@@ -1088,7 +1127,7 @@ func (m *Measurement) GatherLoop(
 				m.filterUpdate()
 			case bus.ForceGather:
 				m.stats.ResetCounters()
-				m.GatherOnce(gatherLock, varMap, tagMap, influxClient)
+				m.GatherOnce(gatherLock, varMap, tagMap, out)
 			case bus.Enabled:
 				active, ok := val.Data.(bool)
 				if !ok {
@@ -1137,6 +1176,10 @@ func (m *Measurement) GatherLoop(
 				m.snmpClient.SetMaxRep(maxrep)
 			case bus.Exit, bus.SyncExit:
 				m.Log.Info("exit measurement")
+				// the client is a copy of the device one, release here the connection (or shared session)
+				if err := m.snmpClient.Release(); err != nil {
+					m.Log.Errorf("releasing snmp client on exit: %v", err)
+				}
 				return
 			default:
 				m.Log.Errorf("unknown command: %v", val)
@@ -1190,6 +1233,7 @@ func (m *Measurement) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		ID               string
 		MName            string
+		Context          string
 		TagName          []string
 		MetricTable      *metric.MetricTable
 		AllIndexedLabels map[string]string
@@ -1201,6 +1245,7 @@ func (m *Measurement) MarshalJSON() ([]byte, error) {
 	}{
 		ID:               m.ID,
 		MName:            m.MName,
+		Context:          m.Context,
 		TagName:          m.TagName,
 		MetricTable:      m.MetricTable,
 		AllIndexedLabels: m.AllIndexedLabels,
@@ -1212,6 +1257,14 @@ func (m *Measurement) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// GetLastPoints returns the points built on the last gather cycle
+// with counter increments replaced by their cumulative value (see setLastPoints)
+func (m *Measurement) GetLastPoints() []*output.Point {
+	m.lastData.Lock()
+	defer m.lastData.Unlock()
+	return m.lastPoints
+}
+
 // GatherOnce metrics from device, process them and send values to the backend.
 // It also checks if it should run based on the measurement state.
 // At the end of the function it could change the status of the connection (to not
@@ -1220,7 +1273,7 @@ func (m *Measurement) GatherOnce(
 	gatherLock *sync.Mutex,
 	varMap map[string]interface{},
 	tagMap map[string]string,
-	influxClient *output.InfluxDB,
+	out output.Output,
 ) error {
 	start := time.Now()
 	// Do not gather data if measurement is disabled or it doesn't have a connection or measurement is not initialized
@@ -1263,27 +1316,23 @@ func (m *Measurement) GatherOnce(
 
 	m.ComputeEvaluatedMetrics(varMap)
 
-	// prepare batchpoint
-	metSent, metError, measSent, measError, points := m.GetInfluxPoint(tagMap)
+	// prepare points
+	metSent, metError, measSent, measError, points := m.GetPoints(tagMap)
 	m.stats.AddMeasStats(metSent, metError, measSent, measError)
+	m.setLastPoints(points)
 
 	sentStats := time.Now()
-	// check if the influxClient is nil and skip the send process
-	if influxClient != nil {
-		bpts, _ := influxClient.BP()
-		if bpts != nil {
-			(*bpts).AddPoints(points)
-			// send data
-			influxClient.Send(bpts)
-		} else {
-			m.Log.Warnf("Can not send data to the output DB because of batchpoint creation error")
-		}
+	// check if the output is nil and skip the send process
+	if out != nil {
+		out.Send(points)
 	}
 	elapsedSentStats := time.Since(sentStats)
 	m.stats.AddSentDuration(sentStats, elapsedSentStats)
 
 	end := time.Since(start)
 	m.stats.SetGatherDuration(start, end)
+	m.stats.AddRateLimitWait(m.snmpClient.RateLimitWait())
+	m.stats.AddRequestStats(m.snmpClient.RequestStats())
 	// updating public query stats
 	m.statsData.Lock()
 	m.Stats = m.getBasicStats()
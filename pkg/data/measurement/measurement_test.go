@@ -8,6 +8,7 @@ import (
 
 	"github.com/gosnmp/gosnmp"
 	"github.com/sirupsen/logrus"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/metric"
 	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
@@ -62,11 +63,12 @@ func OrderMapByKey(m map[string]string) string {
 func GetOutputInfluxMetrics(m *Measurement) {
 	m.Log.Infof("GOT MEAS --> %+v", m)
 
-	metSent, metError, measSent, measError, ptarray := m.GetInfluxPoint(map[string]string{})
+	metSent, metError, measSent, measError, points := m.GetPoints(map[string]string{})
 
 	m.Log.Infof("METRIC SENT[%d],METRIC ERROR[%d],MEAS SENT[%d], MEAS ERROR[%d]", metSent, metError, measSent, measError)
 
-	for _, v := range ptarray {
+	for _, p := range points {
+		v, _ := output.ToInfluxPoint(p)
 		m.Log.Infof("GOT V %+v", v)
 		fields, _ := v.Fields()
 		tags := OrderMapByKey(v.Tags())
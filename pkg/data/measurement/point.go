@@ -0,0 +1,212 @@
+package measurement
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/data/metric"
+)
+
+// addCounterField marks the metric field as a counter increment if it has been reported
+func addCounterField(counters map[string]bool, m *metric.SnmpMetric, fields map[string]interface{}) {
+	if !m.IsCounterIncrement() {
+		return
+	}
+	if _, ok := fields[m.GetFieldName()]; ok {
+		counters[m.GetFieldName()] = true
+	}
+}
+
+// GetPoints get backend neutral points from measurements
+func (m *Measurement) GetPoints(hostTags map[string]string) (int64, int64, int64, int64, []*output.Point) {
+	var metSent int64
+	var metError int64
+	var measSent int64
+	var measError int64
+	var ptarray []*output.Point
+
+	deviceTags := make([]string, 0, len(hostTags))
+	for k := range hostTags {
+		deviceTags = append(deviceTags, k)
+	}
+	// the context tag is not a device one
+	if len(m.Context) > 0 {
+		tags := make(map[string]string, len(hostTags)+1)
+		for k, v := range hostTags {
+			tags[k] = v
+		}
+		tags[m.cfg.ContextTag] = m.Context
+		hostTags = tags
+	}
+
+	switch m.cfg.GetMode {
+	case "value":
+		k := m.MetricTable.Row["0"]
+		var t time.Time
+		// copy tags and add index tag
+		Tags := make(map[string]string)
+		for kT, vT := range hostTags {
+			Tags[kT] = vT
+		}
+		Fields := make(map[string]interface{})
+		counters := make(map[string]bool)
+		for _, vMtr := range k.Data {
+			me := vMtr.ImportFieldsAndTags(m.cfg.ID, Fields, Tags)
+			metError += me
+			addCounterField(counters, vMtr, Fields)
+			// check again if metric is valid
+			if vMtr.Valid == true {
+				t = vMtr.CurTime
+			} else {
+				m.Log.Debugf("SKIPPING TS due to invalid %s metric %s", m.cfg.ID, vMtr.CurTime)
+			}
+		}
+		metSent += int64(len(Fields))
+		m.Log.Debugf("FIELDS:%+v", Fields)
+
+		pt, err := output.NewPoint(m.cfg.Name, Tags, Fields, t)
+		if err != nil {
+			m.Log.Warnf("error in point building:%s", err)
+			measError++
+		} else {
+			pt.Meta.DeviceTag = m.deviceTag
+			pt.Meta.DeviceTags = deviceTags
+			pt.Meta.CounterFields = counters
+			m.Log.Debugf("GENERATED POINT[%s] value: %+v", m.cfg.Name, pt)
+			ptarray = append(ptarray, pt)
+			measSent++
+			k.Valid = true
+		}
+
+	case "indexed", "indexed_it", "indexed_mit", "indexed_multiple":
+		var t time.Time
+		for idx, vIdx := range m.MetricTable.Row {
+			m.Log.Debugf("generating point for indexed %s", idx)
+			// copy tags and add index tag
+			Tags := make(map[string]string)
+			for kT, vT := range hostTags {
+				Tags[kT] = vT
+			}
+			// Need to check that the lengt of stags is the same as m.tagName
+			// The split must be only applied on indexed_multiple measurements
+			stags := []string{idx}
+
+			if m.cfg.GetMode == "indexed_multiple" {
+				stags = strings.Split(idx, "|")
+				if len(stags) != len(m.TagName) {
+					m.Log.Errorf("Tags %+v - doesn't match with generated tags %+v. Error in generating point", m.TagName, stags)
+					return metSent, metError, measSent, measError, ptarray
+				}
+			}
+			for k, v := range m.TagName {
+				Tags[v] = stags[k]
+			}
+			m.Log.Debugf("IDX :%+v", vIdx)
+			Fields := make(map[string]interface{})
+			counters := make(map[string]bool)
+			for _, vMtr := range vIdx.Data {
+				me := vMtr.ImportFieldsAndTags(m.cfg.ID, Fields, Tags)
+				metError += me
+				addCounterField(counters, vMtr, Fields)
+				// check again if metric is valid
+				if vMtr.Valid == true {
+					t = vMtr.CurTime
+				} else {
+					m.Log.Debugf("SKIPPING TS due to invalid %s metric %s", m.cfg.ID, vMtr.CurTime)
+				}
+			}
+			metSent += int64(len(Fields))
+			// here we can chek Fields names prior to send data
+			m.Log.Debugf("FIELDS:%+v TAGS:%+v", Fields, Tags)
+			pt, err := output.NewPoint(m.cfg.Name, Tags, Fields, t)
+			if err != nil {
+				m.Log.Warnf("error in point creation :%s", err)
+				measError++
+			} else {
+				pt.Meta.DeviceTag = m.deviceTag
+				pt.Meta.IndexTags = m.TagName
+				pt.Meta.DeviceTags = deviceTags
+				pt.Meta.CounterFields = counters
+				m.Log.Debugf("GENERATED POINT[%s] index [%s]: %+v", m.cfg.Name, idx, pt)
+				ptarray = append(ptarray, pt)
+				measSent++
+				vIdx.Valid = true
+			}
+		}
+
+	}
+
+	return metSent, metError, measSent, measError, ptarray
+}
+
+// counterValue converts a counter increment to float
+func counterValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case uint:
+		return float64(val), true
+	case uint32:
+		return float64(val), true
+	}
+	return 0, false
+}
+
+// counterSeriesID identifies the point field series: name, sorted tags and field
+func counterSeriesID(p *output.Point, field string) string {
+	keys := make([]string, 0, len(p.Tags))
+	for k := range p.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(p.Name)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + p.Tags[k])
+	}
+	b.WriteString("|" + field)
+	return b.String()
+}
+
+// setLastPoints stores the gathered points for the scrape endpoints. Counter increments are
+// replaced by the cumulative value of the series so they can be exported as monotonic counters,
+// series not gathered on this cycle start again from zero. Sent points are not modified.
+func (m *Measurement) setLastPoints(points []*output.Point) {
+	m.lastData.Lock()
+	defer m.lastData.Unlock()
+	totals := make(map[string]float64)
+	last := make([]*output.Point, 0, len(points))
+	for _, p := range points {
+		if len(p.Meta.CounterFields) == 0 {
+			last = append(last, p)
+			continue
+		}
+		cp := *p
+		cp.Fields = make(map[string]interface{}, len(p.Fields))
+		for f, v := range p.Fields {
+			inc, ok := counterValue(v)
+			if !ok || !p.Meta.CounterFields[f] {
+				cp.Fields[f] = v
+				continue
+			}
+			id := counterSeriesID(p, f)
+			totals[id] = m.counterTotals[id] + inc
+			cp.Fields[f] = totals[id]
+		}
+		last = append(last, &cp)
+	}
+	m.lastPoints = last
+	m.counterTotals = totals
+}
@@ -0,0 +1,71 @@
+package measurement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+)
+
+func testCounterPoint(t *testing.T, ifName string, in interface{}, status int64) *output.Point {
+	t.Helper()
+	pt, err := output.NewPoint("ifstats", map[string]string{"device": "dev1", "ifName": ifName}, map[string]interface{}{"in": in, "status": status}, time.Now())
+	if err != nil {
+		t.Fatalf("new point: %s", err)
+	}
+	pt.Meta.CounterFields = map[string]bool{"in": true}
+	return pt
+}
+
+func Test_SetLastPoints(t *testing.T) {
+	m := &Measurement{}
+	cycles := []struct {
+		points []*output.Point
+		want   map[string]map[string]interface{}
+	}{
+		{
+			points: []*output.Point{testCounterPoint(t, "eth0", int64(100), 1), testCounterPoint(t, "eth1", uint64(10), 2)},
+			want: map[string]map[string]interface{}{
+				"eth0": {"in": float64(100), "status": int64(1)},
+				"eth1": {"in": float64(10), "status": int64(2)},
+			},
+		},
+		{
+			points: []*output.Point{testCounterPoint(t, "eth0", int64(50), 1), testCounterPoint(t, "eth1", 2.5, 2)},
+			want: map[string]map[string]interface{}{
+				"eth0": {"in": float64(150), "status": int64(1)},
+				"eth1": {"in": 12.5, "status": int64(2)},
+			},
+		},
+		{
+			// eth1 is not gathered, it starts again from zero on the next cycle
+			points: []*output.Point{testCounterPoint(t, "eth0", int64(0), 3)},
+			want: map[string]map[string]interface{}{
+				"eth0": {"in": float64(150), "status": int64(3)},
+			},
+		},
+		{
+			points: []*output.Point{testCounterPoint(t, "eth0", int64(1), 3), testCounterPoint(t, "eth1", uint64(7), 2)},
+			want: map[string]map[string]interface{}{
+				"eth0": {"in": float64(151), "status": int64(3)},
+				"eth1": {"in": float64(7), "status": int64(2)},
+			},
+		},
+	}
+	for i, c := range cycles {
+		sent := c.points[0].Fields["in"]
+		m.setLastPoints(c.points)
+		got := make(map[string]map[string]interface{})
+		for _, p := range m.GetLastPoints() {
+			got[p.Tags["ifName"]] = p.Fields
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("cycle %d: last points (-want +got):\n%s", i, diff)
+		}
+		// the sent points keep the increments
+		if v := c.points[0].Fields["in"]; v != sent {
+			t.Errorf("cycle %d: sent point modified to %v", i, v)
+		}
+	}
+}
@@ -74,6 +74,12 @@ func (s *SnmpMetric) GetDataSrcType() string {
 	return s.cfg.DataSrcType
 }
 
+// IsCounterIncrement returns true if the reported value is the increment of a COUNTER32/COUNTER64
+// since the previous gather (counter without rate computation)
+func (s *SnmpMetric) IsCounterIncrement() bool {
+	return (s.cfg.DataSrcType == "COUNTER32" || s.cfg.DataSrcType == "COUNTER64") && !s.cfg.GetRate && !s.cfg.IsTag
+}
+
 // IsTag needed to generate Influx measurements
 func (s *SnmpMetric) IsTag() bool {
 	return s.cfg.IsTag
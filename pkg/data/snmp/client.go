@@ -1,10 +1,15 @@
 package snmp
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
@@ -23,12 +28,23 @@ type V3Params struct {
 	ContextEngineID string
 }
 
+// ContextParams overrides the community and the v3 context name of the credentials, used to query other
+// SNMP contexts of the device (as the Cisco per VLAN BRIDGE-MIB instances)
+type ContextParams struct {
+	// Community if set, replaces the v1/v2c community, {community} is replaced by the credential community
+	Community string
+	// ContextName if set, replaces the v3 context name
+	ContextName string
+}
+
 // ConnectionParams store all needed information to create a new snmpGo client
 type ConnectionParams struct {
 	// Host is the hostname or IP of the device
 	Host string
 	// Port where the SNMP connection should be established
 	Port int
+	// Transport is the network transport: udp, udp6, tcp or tcp6. Empty means udp
+	Transport string
 	// Timeout is the timeout for one SNMP request/response, in seconds
 	Timeout int
 	// Retries is the number of retries to attempt.
@@ -45,10 +61,109 @@ type ConnectionParams struct {
 	Debug bool
 	// V3Params store specific values only for SNMP v3
 	V3Params V3Params
+	// Context overrides the community or the v3 context name of the credential used
+	Context ContextParams
+	// Credentials if not nil, the credential sets tried in order on connect instead of SnmpVersion, Community and V3Params
+	Credentials *CredentialSet
+	// Limiter if not nil, limits the rate and concurrency of the requests sent to the device
+	Limiter *Limiter
+	// Session if not nil, the requests are sent through the device shared connection
+	Session *Session
+	// PDUSize if not nil, the GETBULK max repetitions and OIDs per GET are adapted to the device, MaxRepetitions
+	// and MaxOids are the max values
+	PDUSize *PDUSize
+}
+
+// Credential is a named SNMP version with its community or v3 credentials
+type Credential struct {
+	Name        string
+	SnmpVersion string
+	Community   string
+	V3Params    V3Params
+}
+
+// CredentialSet is an ordered list of credentials to try on connect. It is shared between all
+// the clients of a device to remember the credential which works and use it first.
+type CredentialSet struct {
+	mutex   sync.RWMutex
+	list    []Credential
+	current int
+	working bool
+}
+
+// NewCredentialSet creates a credential set, the first credential is tried first
+func NewCredentialSet(list []Credential) *CredentialSet {
+	return &CredentialSet{list: list}
+}
+
+// List returns the credentials in the configured order
+func (s *CredentialSet) List() []Credential {
+	return s.list
+}
+
+// Working returns the name of the last credential which worked on connect, empty if none has worked yet
+func (s *CredentialSet) Working() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if !s.working {
+		return ""
+	}
+	return s.list[s.current].Name
+}
+
+// Current returns the last credential which worked or the first one if none has worked yet
+func (s *CredentialSet) Current() Credential {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.list[s.current]
+}
+
+// order returns the credential indexes to try, the current one first and then the others in the configured order
+func (s *CredentialSet) order() []int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	res := []int{s.current}
+	for i := range s.list {
+		if i != s.current {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func (s *CredentialSet) setWorking(i int) {
+	s.mutex.Lock()
+	s.current = i
+	s.working = true
+	s.mutex.Unlock()
+}
+
+// SetCredential sets the SNMP version and credentials to use on the next connections
+func (c *ConnectionParams) SetCredential(cr Credential) {
+	c.SnmpVersion = cr.SnmpVersion
+	c.Community = cr.Community
+	c.V3Params = cr.V3Params
+}
+
+// community returns the v1/v2c community to use, the context one if set
+func (c ConnectionParams) community() string {
+	if len(c.Context.Community) == 0 {
+		return c.Community
+	}
+	return strings.ReplaceAll(c.Context.Community, "{community}", c.Community)
 }
 
 type Client struct {
+	// time waited for the device limiter in nanoseconds since the last RateLimitWait call,
+	// first field to keep the 64 bit alignment needed by the atomic operations
+	wait       int64
 	snmpClient *gosnmp.GoSNMP
+	// request stats since the last RequestStats call, updated by the goSNMP hooks
+	requests RequestStats
+	kind     int       // kind of the current request
+	sent     time.Time // when the last PDU was sent
+	retrying bool      // the last PDU sent got no response and it will be sent again
+	conn     *readErrConn
 	// Log is the logger used to trace this client
 	Log utils.Logger
 	// ID used to generate the debug file name
@@ -61,6 +176,26 @@ type Client struct {
 
 // Validation check if SNMP parameters are valid to establish a SNMP connection.
 func (c ConnectionParams) Validation() error {
+	if c.Credentials != nil {
+		for _, cr := range c.Credentials.List() {
+			p := c
+			p.Credentials = nil
+			p.SetCredential(cr)
+			if err := p.Validation(); err != nil {
+				return fmt.Errorf("credential %s: %v", cr.Name, err)
+			}
+		}
+		return nil
+	}
+
+	if _, _, err := SplitHost(c.Host, c.Port); err != nil {
+		return fmt.Errorf("invalid host: %v", err)
+	}
+
+	if c.Transport != "" && !transports[c.Transport] {
+		return fmt.Errorf("invalid transport: %s", c.Transport)
+	}
+
 	if c.SnmpVersion != "1" && c.SnmpVersion != "2c" && c.SnmpVersion != "3" {
 		return fmt.Errorf("invalid snmp version: %s", c.SnmpVersion)
 	}
@@ -124,6 +259,9 @@ func (c *Client) SetMaxRep(rep uint8) {
 	c.Log.Debugf("client.SetMaxRep %v", rep)
 	c.snmpClient.MaxRepetitions = uint32(rep)
 	c.ConnectionParams.MaxRepetitions = rep
+	if c.ConnectionParams.PDUSize != nil {
+		c.ConnectionParams.PDUSize.setMaxRepetitions(rep)
+	}
 }
 
 // SetDebug configure a new logger for the goSNMP client to log everything to a different file
@@ -142,20 +280,84 @@ func (c *Client) SetDebug(debug bool) {
 // Connect using the info stored in the struct, generate the goSNMP client and make the first connection to the
 // device to check if it works.
 // It also try to obtain some basic OIDs to check if everything works.
+// If there are credential sets, they are tried in order until one of them works, starting with the last one which worked.
 func (c *Client) Connect(systemOIDs []string) (*SysInfo, error) {
+	creds := c.ConnectionParams.Credentials
+	if creds == nil {
+		return c.connect(systemOIDs, true)
+	}
+	var err error
+	for n, i := range creds.order() {
+		cr := creds.List()[i]
+		c.ConnectionParams.SetCredential(cr)
+		var sysinfo *SysInfo
+		sysinfo, err = c.connect(systemOIDs, true)
+		if err != nil && c.ConnectionParams.SnmpVersion != "3" && c.timedOut() {
+			// agents drop wrong communities silently, check again with all the retries to tell them from lost packets
+			c.Log.Infof("no response with credential %s, checking it again with %d retries", cr.Name, c.ConnectionParams.Retries)
+			sysinfo, err = c.connect(systemOIDs, false)
+		}
+		if err == nil {
+			if n > 0 {
+				c.Log.Infof("connected with credential %s", cr.Name)
+			}
+			creds.setWorking(i)
+			return sysinfo, nil
+		}
+		if !c.isCredentialError(err) {
+			return nil, err
+		}
+		c.Log.Warnf("unable to connect with credential %s: %v", cr.Name, err)
+	}
+	return nil, err
+}
+
+// isCredentialError returns true if the connection error could be caused by wrong credentials: the agent
+// reported an authentication or USM error, or on v1/v2c, where wrong communities are silently dropped, the
+// request timed out after all the retries. Other errors (unreachable hosts, v3 timeouts) do not try other credentials.
+func (c *Client) isCredentialError(err error) bool {
+	switch {
+	case errors.Is(err, gosnmp.ErrUnknownUsername),
+		errors.Is(err, gosnmp.ErrWrongDigest),
+		errors.Is(err, gosnmp.ErrDecryption),
+		errors.Is(err, gosnmp.ErrUnknownSecurityLevel),
+		errors.Is(err, gosnmp.ErrUnknownEngineID):
+		return true
+	}
+	return c.ConnectionParams.SnmpVersion != "3" && c.timedOut()
+}
+
+// connectCheckTimeout is the timeout in seconds of the quick connection check
+var connectCheckTimeout = 5
+
+// connect creates the goSNMP client and checks the device answers. With quick the check is done without
+// retries and with connectCheckTimeout, else with the configured retries and timeout.
+func (c *Client) connect(systemOIDs []string, quick bool) (*SysInfo, error) {
 	c.Log.Debug("client.Connect")
 	retries := c.ConnectionParams.Retries
 	timeout := c.ConnectionParams.Timeout
 
 	// change values only to check connection
-	c.ConnectionParams.Retries = 0
-	c.ConnectionParams.Timeout = 5
+	if quick {
+		c.ConnectionParams.Retries = 0
+		c.ConnectionParams.Timeout = connectCheckTimeout
+	}
+	// timedOut should only report the requests of this connection
+	c.conn = nil
 	goSNMPClient, err := GetClient(c.ConnectionParams, c.Log)
 	if err != nil {
 		c.ConnectionParams.Retries = retries
 		c.ConnectionParams.Timeout = timeout
 		return nil, fmt.Errorf("initializing the goSNMP client: %v", err)
 	}
+	if c.ConnectionParams.Session != nil {
+		if err = c.ConnectionParams.Session.attach(goSNMPClient); err != nil {
+			goSNMPClient.Conn.Close()
+			c.ConnectionParams.Retries = retries
+			c.ConnectionParams.Timeout = timeout
+			return nil, fmt.Errorf("sharing the device session: %v", err)
+		}
+	}
 
 	// Close previous client if it exists
 	if c.snmpClient != nil {
@@ -165,6 +367,9 @@ func (c *Client) Connect(systemOIDs []string) (*SysInfo, error) {
 		}
 	}
 
+	goSNMPClient.PreSend = c.preSend
+	goSNMPClient.OnRetry = c.onRetry
+	goSNMPClient.OnFinish = c.onFinish
 	c.snmpClient = goSNMPClient
 
 	sysinfo, err := c.SysInfoQuery(systemOIDs)
@@ -172,7 +377,10 @@ func (c *Client) Connect(systemOIDs []string) (*SysInfo, error) {
 	c.ConnectionParams.Retries = retries
 	c.ConnectionParams.Timeout = timeout
 	if err != nil {
-		return nil, fmt.Errorf("obtaining the sysInfo: %v", err)
+		return nil, fmt.Errorf("obtaining the sysInfo: %w", err)
+	}
+	if c.ConnectionParams.Session != nil && c.snmpClient.Version == gosnmp.Version3 {
+		c.ConnectionParams.Session.storeEngine(c.snmpClient)
 	}
 	c.snmpClient.Retries = retries
 	c.snmpClient.Timeout = time.Duration(timeout) * time.Second
@@ -187,6 +395,8 @@ func (c *Client) Connect(systemOIDs []string) (*SysInfo, error) {
 // is not empty and its first element is not the string "null"
 func (c *Client) SysInfoQuery(systemOIDs []string) (*SysInfo, error) {
 	c.Log.Debug("client.SysInfoQuery")
+	c.acquire(RequestGet)
+	defer c.release()
 
 	if len(systemOIDs) > 0 && systemOIDs[0] != "" && systemOIDs[0] != "null" {
 		c.Log.Infof("Detected alternate %d SystemOID's ", len(systemOIDs))
@@ -210,6 +420,133 @@ func (c *Client) SysInfoQuery(systemOIDs []string) (*SysInfo, error) {
 	return &si, err
 }
 
+// preSend is called by the goSNMP client before sending each PDU (retries included) to wait for the device rate limit,
+// the latency of the PDU starts after the wait
+func (c *Client) preSend(x *gosnmp.GoSNMP) {
+	if c.retrying {
+		c.requests.Retries++
+		c.retrying = false
+	}
+	// the connection could be replaced by the goSNMP client (TCP reconnections)
+	if c.conn == nil || !c.conn.wraps(x.Conn) {
+		c.conn = wrapReadErrConn(x)
+	}
+	c.conn.err = nil
+	if c.ConnectionParams.Limiter != nil {
+		if d := c.ConnectionParams.Limiter.wait(); d > 0 {
+			atomic.AddInt64(&c.wait, int64(d))
+			// the response timeout should start when the request is sent
+			if err := x.Conn.SetDeadline(time.Now().Add(x.Timeout)); err != nil {
+				c.Log.Warnf("setting SNMP request deadline: %v", err)
+			}
+		}
+	}
+	c.sent = time.Now()
+}
+
+// onRetry is called by the goSNMP client when the last PDU sent failed, also after the last retry. It is
+// called on any error (write, read, decoding or authentication), only the timeouts are counted
+func (c *Client) onRetry(x *gosnmp.GoSNMP) {
+	c.retrying = c.conn != nil && isReadTimeout(c.conn.err)
+}
+
+// timedOut returns true if the last request got no response after all the retries
+func (c *Client) timedOut() bool {
+	return c.conn != nil && isReadTimeout(c.conn.err)
+}
+
+// isReadTimeout returns true if the connection read error is a deadline timeout
+func isReadTimeout(err error) bool {
+	var ne net.Error
+	return errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout())
+}
+
+// readErrConn keeps the last read error of the goSNMP client connection, the goSNMP hooks do not get it
+type readErrConn struct {
+	net.Conn
+	err error
+}
+
+func (c *readErrConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.err = err
+	return n, err
+}
+
+// readErrPacketConn is a readErrConn for packet connections, goSNMP reads them with ReadFrom
+type readErrPacketConn struct {
+	*readErrConn
+	pc net.PacketConn
+}
+
+func (c *readErrPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.pc.ReadFrom(b)
+	c.err = err
+	return n, addr, err
+}
+
+func (c *readErrPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.pc.WriteTo(b, addr)
+}
+
+// wraps returns true if conn is the wrapped connection
+func (c *readErrConn) wraps(conn net.Conn) bool {
+	if pc, ok := conn.(*readErrPacketConn); ok {
+		return pc.readErrConn == c
+	}
+	return conn == net.Conn(c)
+}
+
+// wrapReadErrConn replaces the goSNMP client connection with one keeping the last read error
+func wrapReadErrConn(x *gosnmp.GoSNMP) *readErrConn {
+	rc := &readErrConn{Conn: x.Conn}
+	if pc, ok := x.Conn.(net.PacketConn); ok {
+		x.Conn = &readErrPacketConn{readErrConn: rc, pc: pc}
+	} else {
+		x.Conn = rc
+	}
+	return rc
+}
+
+// onFinish is called by the goSNMP client when the response to the last PDU sent is received
+func (c *Client) onFinish(x *gosnmp.GoSNMP) {
+	c.requests.Latency[c.kind].Observe(time.Since(c.sent))
+}
+
+// acquire starts a request of the kind, waiting for a free in flight request slot on the device limiter
+func (c *Client) acquire(kind int) {
+	c.kind = kind
+	if c.ConnectionParams.Limiter == nil {
+		return
+	}
+	atomic.AddInt64(&c.wait, int64(c.ConnectionParams.Limiter.acquire()))
+}
+
+// release ends the request and frees the in flight request slot taken with acquire
+func (c *Client) release() {
+	// the last PDU timed out and it has not been retried
+	if c.retrying {
+		c.requests.Timeouts++
+		c.retrying = false
+	}
+	if c.ConnectionParams.Limiter == nil {
+		return
+	}
+	c.ConnectionParams.Limiter.release()
+}
+
+// RateLimitWait returns the time waited for the device request limits since the last call
+func (c *Client) RateLimitWait() time.Duration {
+	return time.Duration(atomic.SwapInt64(&c.wait, 0))
+}
+
+// RequestStats returns the latencies of the responses, the retries and the timeouts since the last call
+func (c *Client) RequestStats() RequestStats {
+	r := c.requests
+	c.requests = RequestStats{}
+	return r
+}
+
 func (c *Client) Target() string {
 	return c.snmpClient.Target
 }
@@ -217,25 +554,133 @@ func (c *Client) Target() string {
 // Walk selects how to gather data based on the SNMP version and a custom flag
 func (c *Client) Walk(rootOid string, walkFn gosnmp.WalkFunc) error {
 	if c.snmpClient.Version == gosnmp.Version1 || c.DisableBulk {
+		c.acquire(RequestWalk)
+		defer c.release()
 		return c.snmpClient.Walk(rootOid, walkFn)
 	}
+	c.acquire(RequestBulk)
+	defer c.release()
+	if c.ConnectionParams.PDUSize != nil {
+		return c.bulkWalk(rootOid, walkFn)
+	}
 	return c.snmpClient.BulkWalk(rootOid, walkFn)
 }
 
+// bulkWalk walks the OID tree like the goSNMP BulkWalk, but with the device adaptive max repetitions.
+// Requests which fail with tooBig are retried with half the max repetitions, and also the ones which
+// time out, only once to not wait a lot on devices that are down.
+func (c *Client) bulkWalk(rootOid string, walkFn gosnmp.WalkFunc) error {
+	if !strings.HasPrefix(rootOid, ".") {
+		rootOid = "." + rootOid
+	}
+	pduSize := c.ConnectionParams.PDUSize
+	oid := rootOid
+	retried := false
+	for {
+		maxRep := pduSize.MaxRepetitions()
+		response, err := c.snmpClient.GetBulk([]string{oid}, uint8(c.snmpClient.NonRepeaters), uint32(maxRep))
+		tooBig := err == nil && response.Error == gosnmp.TooBig
+		if tooBig || (err != nil && c.timedOut() && !retried) {
+			if pduSize.shrinkMaxRepetitions(maxRep) {
+				c.Log.Warnf("GETBULK for %s failed with max repetitions %d (%s), retrying with %d", oid, maxRep, failReason(err), pduSize.MaxRepetitions())
+				retried = !tooBig
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		retried = false
+		if tooBig || response.Error != gosnmp.NoError || len(response.Variables) == 0 {
+			// the goSNMP walk also ends on any error status
+			return nil
+		}
+		pduSize.success()
+
+		for i, pdu := range response.Variables {
+			if pdu.Type == gosnmp.EndOfMibView || pdu.Type == gosnmp.NoSuchObject || pdu.Type == gosnmp.NoSuchInstance {
+				return nil
+			}
+			if !strings.HasPrefix(pdu.Name, rootOid+".") {
+				// the first result out of the root means that the root could be a leaf OID
+				if oid == rootOid && i == 0 {
+					return c.getLeaf(rootOid, walkFn)
+				}
+				return nil
+			}
+			if pdu.Name == oid {
+				return fmt.Errorf("OID not increasing: %s", pdu.Name)
+			}
+			if err := walkFn(pdu); err != nil {
+				return err
+			}
+		}
+		oid = response.Variables[len(response.Variables)-1].Name
+	}
+}
+
+// failReason returns the error or tooBig if there is no error
+func failReason(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return gosnmp.TooBig.String()
+}
+
+// getLeaf sends the leaf OID to the walkFn if it exists on the device
+func (c *Client) getLeaf(oid string, walkFn gosnmp.WalkFunc) error {
+	c.kind = RequestGet
+	response, err := c.snmpClient.Get([]string{oid})
+	if err != nil {
+		return err
+	}
+	if response.Error != gosnmp.NoError || len(response.Variables) == 0 {
+		return nil
+	}
+	pdu := response.Variables[0]
+	if pdu.Name != oid || pdu.Type == gosnmp.EndOfMibView || pdu.Type == gosnmp.NoSuchObject || pdu.Type == gosnmp.NoSuchInstance {
+		return nil
+	}
+	return walkFn(pdu)
+}
+
 // SetSnmpClient get the values of the list of OIDs in groups of c.MaxOids.
 // Send each value to the walkfunc (second parameter).
 func (c *Client) Get(oids []string, walkFunc gosnmp.WalkFunc) error {
 	l := len(oids)
 	c.Log.Debugf("LEN %d : %+v | client : %+v", l, oids, c)
 
-	// Get values in groups of c.MaxOids
-	for i := 0; i < l; i += c.snmpClient.MaxOids {
-		end := i + c.snmpClient.MaxOids
+	pduSize := c.ConnectionParams.PDUSize
+	retried := false
+
+	// Get values in groups of c.MaxOids (or the adaptive size)
+	for i, end := 0, 0; i < l; i = end {
+		maxOids := c.snmpClient.MaxOids
+		if pduSize != nil {
+			maxOids = pduSize.MaxOids()
+		}
+		end = i + maxOids
 		if end > l {
 			end = len(oids)
 		}
 		c.Log.Debugf("Getting snmp data from %d to %d", i, end)
+		c.acquire(RequestGet)
 		pkt, err := c.snmpClient.Get(oids[i:end])
+		c.release()
+		if pduSize != nil {
+			// requests which fail with tooBig are retried with half the OIDs, and also the ones which time out, only once
+			tooBig := err == nil && pkt.Error == gosnmp.TooBig
+			if (tooBig || (err != nil && c.timedOut() && !retried)) && pduSize.shrinkMaxOids(end-i) {
+				c.Log.Warnf("SNMP (%s) GET failed with %d OIDs (%s), retrying with %d", c.snmpClient.Target, end-i, failReason(err), pduSize.MaxOids())
+				retried = !tooBig
+				end = i
+				continue
+			}
+			retried = false
+			if err == nil && !tooBig {
+				pduSize.success()
+			}
+		}
 		if err != nil {
 			c.Log.Debugf("selected OIDS %+v", oids[i:end])
 			c.Log.Errorf("SNMP (%s) for OIDs (%d/%d) get error: %s\n", c.snmpClient.Target, i, end, err)
@@ -257,5 +702,11 @@ func (c *Client) Get(oids []string, walkFunc gosnmp.WalkFunc) error {
 }
 
 func (c *Client) Query(mode string, oid string) ([]EasyPDU, error) {
+	kind := RequestGet
+	if mode == "walk" {
+		kind = RequestWalk
+	}
+	c.acquire(kind)
+	defer c.release()
 	return Query(c.snmpClient, mode, oid)
 }
@@ -0,0 +1,224 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// testStatsClient returns a client with its request stats hooks connected to the port
+func testStatsClient(t *testing.T, port int, s *Session) *Client {
+	t.Helper()
+	x := &gosnmp.GoSNMP{
+		Target:    "127.0.0.1",
+		Port:      uint16(port),
+		Transport: "udp",
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		Timeout:   100 * time.Millisecond,
+		Retries:   2,
+		MaxOids:   gosnmp.MaxOids,
+	}
+	if err := x.Connect(); err != nil {
+		t.Fatalf("connect: %s", err)
+	}
+	if s != nil {
+		if err := s.attach(x); err != nil {
+			t.Fatalf("attach: %s", err)
+		}
+	}
+	c := &Client{snmpClient: x, Log: logrus.New()}
+	x.PreSend = c.preSend
+	x.OnRetry = c.onRetry
+	x.OnFinish = c.onFinish
+	t.Cleanup(func() { x.Conn.Close() })
+	return c
+}
+
+// testGarbageAgent answers all requests with an undecodable message
+func testGarbageAgent(t *testing.T) int {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo([]byte{0x30, 0x03, 0x02, 0x01, 0x09}, addr)
+		}
+	}()
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// testClosedPort returns a local UDP port without listener, requests get an ICMP port unreachable
+func testClosedPort(t *testing.T) int {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+	return port
+}
+
+func Test_ClientRequestStats(t *testing.T) {
+	agentPort := func(t *testing.T, respond bool) int {
+		return newTestAgent(t, respond).conn.LocalAddr().(*net.UDPAddr).Port
+	}
+	tests := []struct {
+		name     string
+		port     func(t *testing.T) int
+		session  bool
+		ok       bool
+		retries  int
+		timeouts int
+	}{
+		{name: "response", port: func(t *testing.T) int { return agentPort(t, true) }, ok: true},
+		{name: "response on session", port: func(t *testing.T) int { return agentPort(t, true) }, session: true, ok: true},
+		{name: "no response", port: func(t *testing.T) int { return agentPort(t, false) }, retries: 2, timeouts: 1},
+		{name: "no response on session", port: func(t *testing.T) int { return agentPort(t, false) }, session: true, retries: 2, timeouts: 1},
+		// errors other than timeouts are not counted
+		{name: "undecodable responses", port: testGarbageAgent},
+		{name: "port unreachable", port: testClosedPort},
+	}
+	for _, tt := range tests {
+		var s *Session
+		if tt.session {
+			s = NewSession(logrus.New())
+		}
+		c := testStatsClient(t, tt.port(t), s)
+		c.acquire(RequestGet)
+		_, err := c.snmpClient.Get([]string{".1.3.6.1.2.1.1.1.0"})
+		c.release()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: get error %v", tt.name, err)
+		}
+		st := c.RequestStats()
+		if st.Retries != tt.retries || st.Timeouts != tt.timeouts {
+			t.Errorf("%s: %d retries and %d timeouts, expected %d and %d", tt.name, st.Retries, st.Timeouts, tt.retries, tt.timeouts)
+		}
+		if n := st.Latency[RequestGet].Count(); (n == 1) != tt.ok {
+			t.Errorf("%s: %d latencies observed", tt.name, n)
+		}
+		if st = c.RequestStats(); st.Retries != 0 || st.Timeouts != 0 || st.Latency[RequestGet].Count() != 0 {
+			t.Errorf("%s: stats not reset: %+v", tt.name, st)
+		}
+	}
+}
+
+func Test_isCredentialError(t *testing.T) {
+	timeout := &readErrConn{err: os.ErrDeadlineExceeded}
+	refused := &readErrConn{err: errors.New("read: connection refused")}
+	tests := []struct {
+		name    string
+		version string
+		conn    *readErrConn
+		err     error
+		want    bool
+	}{
+		{"unknown user", "3", nil, fmt.Errorf("obtaining the sysInfo: %w", gosnmp.ErrUnknownUsername), true},
+		{"wrong digest", "3", nil, gosnmp.ErrWrongDigest, true},
+		{"decryption", "3", nil, gosnmp.ErrDecryption, true},
+		{"security level", "3", nil, gosnmp.ErrUnknownSecurityLevel, true},
+		{"unknown engine", "3", nil, gosnmp.ErrUnknownEngineID, true},
+		{"v2c no response", "2c", timeout, errors.New("request timeout (after 1 retries)"), true},
+		{"v1 no response", "1", timeout, errors.New("request timeout (after 1 retries)"), true},
+		{"v3 no response", "3", timeout, errors.New("request timeout (after 1 retries)"), false},
+		{"v2c unreachable", "2c", refused, errors.New("error reading from socket: read: connection refused"), false},
+		{"timeout text without timeout", "2c", nil, errors.New("dial udp: lookup host: i/o timeout"), false},
+		{"timeout text with other error", "2c", refused, errors.New("request timeout (after 0 retries)"), false},
+	}
+	for _, tt := range tests {
+		c := &Client{conn: tt.conn, ConnectionParams: ConnectionParams{SnmpVersion: tt.version}}
+		if got := c.isCredentialError(tt.err); got != tt.want {
+			t.Errorf("%s: credential error %t, expected %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_ClientConnectCredentials(t *testing.T) {
+	connectCheckTimeout = 1
+	defer func() { connectCheckTimeout = 5 }()
+	creds := []Credential{
+		{Name: "v3", SnmpVersion: "3", V3Params: V3Params{SecLevel: "NoAuthNoPriv", AuthUser: "user"}},
+		{Name: "old", SnmpVersion: "2c", Community: "old"},
+		{Name: "new", SnmpVersion: "2c", Community: "new"},
+	}
+	tests := []struct {
+		name    string
+		port    func(t *testing.T) int
+		creds   []Credential
+		working string
+		tried   int // credentials which failed
+	}{
+		{
+			name:    "wrong community",
+			port:    func(t *testing.T) int { return newTestAgent(t, true, "new").conn.LocalAddr().(*net.UDPAddr).Port },
+			creds:   creds[1:],
+			working: "new",
+			tried:   1,
+		},
+		{
+			// unreachable devices do not try the other credentials
+			name:  "port unreachable",
+			port:  testClosedPort,
+			creds: creds[1:],
+			tried: 0,
+		},
+		{
+			// v3 agents answer wrong credentials with report PDUs, timeouts are not caused by them
+			name:  "v3 no response",
+			port:  func(t *testing.T) int { return newTestAgent(t, false).conn.LocalAddr().(*net.UDPAddr).Port },
+			creds: creds,
+			tried: 0,
+		},
+	}
+	for _, tt := range tests {
+		l, hook := logtest.NewNullLogger()
+		set := NewCredentialSet(tt.creds)
+		c := &Client{
+			Log: l,
+			ConnectionParams: ConnectionParams{
+				Host:           "127.0.0.1",
+				Port:           tt.port(t),
+				Timeout:        1,
+				Retries:        0,
+				MaxRepetitions: 10,
+				MaxOids:        60,
+				Credentials:    set,
+			},
+		}
+		_, err := c.Connect(nil)
+		if (err == nil) != (len(tt.working) > 0) {
+			t.Errorf("%s: connect error %v", tt.name, err)
+		}
+		if set.Working() != tt.working {
+			t.Errorf("%s: working credential %q, expected %q", tt.name, set.Working(), tt.working)
+		}
+		tried := 0
+		for _, e := range hook.AllEntries() {
+			if strings.HasPrefix(e.Message, "unable to connect with credential") {
+				tried++
+			}
+		}
+		if tried != tt.tried {
+			t.Errorf("%s: %d credentials failed, expected %d", tt.name, tried, tt.tried)
+		}
+		if err == nil {
+			c.Release()
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package snmp
+
+import (
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the request latency histogram buckets
+var latencyBuckets = [...]time.Duration{
+	1 * time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// Histogram counts the request latencies in buckets, the last one counts the latencies over the max bound
+type Histogram [len(latencyBuckets) + 1]int
+
+// Observe adds a latency to the histogram
+func (h *Histogram) Observe(d time.Duration) {
+	for i, b := range latencyBuckets {
+		if d <= b {
+			h[i]++
+			return
+		}
+	}
+	h[len(latencyBuckets)]++
+}
+
+// Add adds the counts of other histogram
+func (h *Histogram) Add(o *Histogram) {
+	for i := range h {
+		h[i] += o[i]
+	}
+}
+
+// Count returns the number of latencies observed
+func (h *Histogram) Count() int {
+	n := 0
+	for _, c := range h {
+		n += c
+	}
+	return n
+}
+
+// Percentile returns the latency below which are the p (0-1) of the observed ones, interpolated linearly
+// inside the bucket. It returns 0 if there are no latencies and the max bound if it is over it.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	count := h.Count()
+	if count == 0 {
+		return 0
+	}
+	rank := p * float64(count)
+	cum := 0
+	for i, c := range h {
+		if c == 0 || float64(cum+c) < rank {
+			cum += c
+			continue
+		}
+		if i == len(latencyBuckets) {
+			break
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = latencyBuckets[i-1]
+		}
+		return lower + time.Duration(float64(latencyBuckets[i]-lower)*(rank-float64(cum))/float64(c))
+	}
+	return latencyBuckets[len(latencyBuckets)-1]
+}
+
+// Request kinds with their own latency histogram
+const (
+	// RequestGet are GET requests
+	RequestGet = iota
+	// RequestWalk are GETNEXT requests done on walks
+	RequestWalk
+	// RequestBulk are GETBULK requests done on walks
+	RequestBulk
+	requestKinds
+)
+
+// RequestStats are the latencies of the responses received, the retries and the timeouts of the requests sent
+type RequestStats struct {
+	Latency  [requestKinds]Histogram
+	Retries  int
+	Timeouts int
+}
+
+// Add adds other request stats
+func (r *RequestStats) Add(o *RequestStats) {
+	for i := range r.Latency {
+		r.Latency[i].Add(&o.Latency[i])
+	}
+	r.Retries += o.Retries
+	r.Timeouts += o.Timeouts
+}
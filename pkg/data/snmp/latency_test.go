@@ -0,0 +1,77 @@
+package snmp
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_HistogramObserve(t *testing.T) {
+	var h Histogram
+	for _, d := range []time.Duration{0, time.Millisecond, time.Millisecond + 1, 7 * time.Millisecond, 30 * time.Second, time.Minute} {
+		h.Observe(d)
+	}
+	want := Histogram{2, 1, 0, 1}
+	want[len(latencyBuckets)-1] = 1
+	want[len(latencyBuckets)] = 1
+	if h != want {
+		t.Errorf("histogram %v, expected %v", h, want)
+	}
+	if h.Count() != 6 {
+		t.Errorf("count %d, expected 6", h.Count())
+	}
+	h.Add(&want)
+	if h.Count() != 12 || h[0] != 4 {
+		t.Errorf("histogram %v after adding", h)
+	}
+}
+
+func Test_HistogramPercentile(t *testing.T) {
+	// observe returns a histogram with n latencies of each duration
+	observe := func(n int, ds ...time.Duration) *Histogram {
+		var h Histogram
+		for _, d := range ds {
+			for i := 0; i < n; i++ {
+				h.Observe(d)
+			}
+		}
+		return &h
+	}
+	tests := []struct {
+		name string
+		h    *Histogram
+		p    float64
+		want time.Duration
+	}{
+		{"empty", observe(0), 0.5, 0},
+		{"first bucket p50", observe(10, 500*time.Microsecond), 0.5, 500 * time.Microsecond},
+		{"first bucket p100", observe(10, 500*time.Microsecond), 1, time.Millisecond},
+		{"first bucket p0", observe(10, 500*time.Microsecond), 0, 0},
+		// 4 latencies in (5ms, 10ms], p50 is the 2nd one: 5ms + 5ms*2/4
+		{"interpolated", observe(4, 8*time.Millisecond), 0.5, 7500 * time.Microsecond},
+		// 50 in (1ms, 2ms] and 50 in (100ms, 200ms]
+		{"two buckets p50", observe(50, 2*time.Millisecond, 150*time.Millisecond), 0.5, 2 * time.Millisecond},
+		{"two buckets p51", observe(50, 2*time.Millisecond, 150*time.Millisecond), 0.51, 102 * time.Millisecond},
+		{"two buckets p99", observe(50, 2*time.Millisecond, 150*time.Millisecond), 0.99, 198 * time.Millisecond},
+		// the empty buckets between them are skipped
+		{"two buckets p25", observe(50, 2*time.Millisecond, 150*time.Millisecond), 0.25, 1500 * time.Microsecond},
+		{"over max bound", observe(10, time.Minute), 0.5, 30 * time.Second},
+		{"over max bound p99", observe(99, 3*time.Millisecond, time.Minute), 0.99, 30 * time.Second},
+		{"last bucket", observe(10, 20*time.Second), 0.5, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := tt.h.Percentile(tt.p); got != tt.want {
+			t.Errorf("%s: p%v %s, expected %s", tt.name, tt.p*100, got, tt.want)
+		}
+	}
+}
+
+func Test_RequestStatsAdd(t *testing.T) {
+	var r RequestStats
+	o := RequestStats{Retries: 2, Timeouts: 1}
+	o.Latency[RequestBulk].Observe(time.Millisecond)
+	r.Add(&o)
+	r.Add(&o)
+	if r.Retries != 4 || r.Timeouts != 2 || r.Latency[RequestBulk].Count() != 2 || r.Latency[RequestGet].Count() != 0 {
+		t.Errorf("request stats %+v", r)
+	}
+}
@@ -0,0 +1,75 @@
+package snmp
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter limits the SNMP requests sent to a device. It is shared between all the device clients
+// so the limits apply to the device whatever the number of measurements gathered concurrently.
+type Limiter struct {
+	// interval between PDUs, 0 if the rate is not limited
+	interval time.Duration
+	mutex    sync.Mutex
+	next     time.Time
+	// in flight request slots, nil if not limited
+	slots chan struct{}
+}
+
+// NewLimiter creates a device limiter with a max rate of PDUs per second and a max number of
+// requests in flight at the same time, 0 means no limit. It returns nil if there are no limits.
+func NewLimiter(rate int, maxInFlight int) *Limiter {
+	if rate <= 0 && maxInFlight <= 0 {
+		return nil
+	}
+	l := &Limiter{}
+	if rate > 0 {
+		l.interval = time.Second / time.Duration(rate)
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// wait blocks until the next PDU could be sent with the configured rate, returns the time waited
+func (l *Limiter) wait() time.Duration {
+	if l.interval == 0 {
+		return 0
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	d := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mutex.Unlock()
+	if d > 0 {
+		time.Sleep(d)
+	}
+	return d
+}
+
+// acquire blocks until there is a free in flight request slot, returns the time waited
+func (l *Limiter) acquire() time.Duration {
+	if l.slots == nil {
+		return 0
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return 0
+	default:
+	}
+	start := time.Now()
+	l.slots <- struct{}{}
+	return time.Since(start)
+}
+
+// release frees an in flight request slot
+func (l *Limiter) release() {
+	if l.slots == nil {
+		return
+	}
+	<-l.slots
+}
@@ -0,0 +1,113 @@
+package snmp
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_NewLimiter(t *testing.T) {
+	if l := NewLimiter(0, 0); l != nil {
+		t.Errorf("limiter without limits %+v, expected nil", l)
+	}
+	l := NewLimiter(100, 0)
+	if l.interval != 10*time.Millisecond || l.slots != nil {
+		t.Errorf("rate limiter interval %s slots %v", l.interval, l.slots)
+	}
+	l = NewLimiter(0, 4)
+	if l.interval != 0 || cap(l.slots) != 4 {
+		t.Errorf("in flight limiter interval %s slots %d", l.interval, cap(l.slots))
+	}
+	// no limits do not block
+	if d := l.wait(); d != 0 {
+		t.Errorf("wait %s without rate limit", d)
+	}
+	l = NewLimiter(100, 0)
+	if d := l.acquire(); d != 0 {
+		t.Errorf("acquire %s without in flight limit", d)
+	}
+	l.release()
+}
+
+func Test_LimiterWait(t *testing.T) {
+	const n = 10
+	interval := 20 * time.Millisecond
+	l := NewLimiter(int(time.Second/interval), 0)
+
+	// concurrent callers are spaced interval by the rate
+	start := time.Now()
+	sent := make([]time.Duration, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l.wait()
+			sent[i] = time.Since(start)
+		}(i)
+	}
+	wg.Wait()
+	sort.Slice(sent, func(i, j int) bool { return sent[i] < sent[j] })
+	for i, s := range sent {
+		if s < time.Duration(i)*interval-time.Millisecond {
+			t.Errorf("request %d sent after %s, expected at least %s", i, s, time.Duration(i)*interval)
+		}
+	}
+	if sent[n-1] > time.Duration(n)*interval+time.Second {
+		t.Errorf("last request sent after %s", sent[n-1])
+	}
+
+	// waits are not accumulated while idle
+	time.Sleep(3 * interval)
+	if d := l.wait(); d != 0 {
+		t.Errorf("wait %s after idle", d)
+	}
+	if d := l.wait(); d < interval/2 || d > interval {
+		t.Errorf("wait %s after a request, expected %s", d, interval)
+	}
+}
+
+func Test_LimiterInFlight(t *testing.T) {
+	const max = 3
+	l := NewLimiter(0, max)
+	var inFlight, maxSeen int32
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.acquire()
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxSeen)
+				if n <= m || atomic.CompareAndSwapInt32(&maxSeen, m, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			l.release()
+		}()
+	}
+	wg.Wait()
+	if maxSeen > max || maxSeen == 0 {
+		t.Errorf("%d requests in flight, expected up to %d", maxSeen, max)
+	}
+	if len(l.slots) != 0 {
+		t.Errorf("%d slots not released", len(l.slots))
+	}
+
+	// the time waited for a free slot is returned
+	for i := 0; i < max; i++ {
+		l.acquire()
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		l.release()
+	}()
+	if d := l.acquire(); d < 15*time.Millisecond {
+		t.Errorf("acquire waited %s, expected 20ms", d)
+	}
+}
@@ -0,0 +1,144 @@
+package snmp
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const (
+	// pduGrowAfter is the number of consecutive successful requests needed to double the sizes again
+	pduGrowAfter = 20
+	// pduRetryFailedAfter is the number of consecutive successful requests needed to try again the sizes which failed
+	pduRetryFailedAfter = 500
+)
+
+// PDUSize learns the GETBULK max repetitions and the OIDs per GET supported by a device. The sizes are halved
+// when the requests time out or fail with tooBig and doubled again after a run of successful requests, up
+// to the configured ones. It is shared between all the device clients, so all of them use the learned sizes.
+type PDUSize struct {
+	mutex sync.Mutex
+	// current, configured (ceiling) and last failed sizes, failed is 0 if there is no failure to remember
+	maxRep, maxRepCeil, maxRepFailed    int
+	maxOids, maxOidsCeil, maxOidsFailed int
+	successes                           int
+}
+
+// NewPDUSize creates an adaptive PDU size starting with the configured max repetitions and OIDs per GET
+func NewPDUSize(maxRep uint8, maxOids int) *PDUSize {
+	if maxRep == 0 {
+		maxRep = 1
+	}
+	if maxOids <= 0 {
+		maxOids = 1
+	}
+	return &PDUSize{
+		maxRep:      int(maxRep),
+		maxRepCeil:  int(maxRep),
+		maxOids:     maxOids,
+		maxOidsCeil: maxOids,
+	}
+}
+
+// MaxRepetitions returns the current GETBULK max repetitions
+func (p *PDUSize) MaxRepetitions() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.maxRep
+}
+
+// MaxOids returns the current number of OIDs per GET
+func (p *PDUSize) MaxOids() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.maxOids
+}
+
+// MarshalJSON outputs the current and configured sizes
+func (p *PDUSize) MarshalJSON() ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return json.Marshal(&struct {
+		MaxRepetitions    int
+		MaxRepetitionsCfg int
+		MaxOids           int
+		MaxOidsCfg        int
+	}{
+		MaxRepetitions:    p.maxRep,
+		MaxRepetitionsCfg: p.maxRepCeil,
+		MaxOids:           p.maxOids,
+		MaxOidsCfg:        p.maxOidsCeil,
+	})
+}
+
+// setMaxRepetitions sets the configured max repetitions, used also as the current one
+func (p *PDUSize) setMaxRepetitions(maxRep uint8) {
+	if maxRep == 0 {
+		maxRep = 1
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.maxRep = int(maxRep)
+	p.maxRepCeil = int(maxRep)
+	p.maxRepFailed = 0
+}
+
+// shrinkMaxRepetitions halves the max repetitions after a failed request sent with used max repetitions,
+// returns false if it could not be smaller
+func (p *PDUSize) shrinkMaxRepetitions(used int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.shrink(&p.maxRep, &p.maxRepFailed, used)
+}
+
+// shrinkMaxOids halves the OIDs per GET after a failed request sent with used OIDs, returns false if
+// it could not be smaller
+func (p *PDUSize) shrinkMaxOids(used int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.shrink(&p.maxOids, &p.maxOidsFailed, used)
+}
+
+// shrink must be called with the lock held
+func (p *PDUSize) shrink(cur *int, failed *int, used int) bool {
+	if used <= 1 {
+		return false
+	}
+	p.successes = 0
+	*failed = used
+	// other clients could have shrunk it already
+	if *cur > used/2 {
+		*cur = used / 2
+	}
+	return true
+}
+
+// success counts a successful request, the sizes are doubled after a run of them
+func (p *PDUSize) success() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.successes++
+	if p.successes%pduGrowAfter != 0 {
+		return
+	}
+	if p.successes >= pduRetryFailedAfter {
+		p.maxRepFailed = 0
+		p.maxOidsFailed = 0
+	}
+	p.maxRep = grow(p.maxRep, p.maxRepCeil, p.maxRepFailed)
+	p.maxOids = grow(p.maxOids, p.maxOidsCeil, p.maxOidsFailed)
+}
+
+// grow doubles the size up to the ceiling, or goes halfway to the last failed one to find the max size which works
+func grow(cur, ceil, failed int) int {
+	n := cur * 2
+	if failed > 0 && n >= failed {
+		n = (cur + failed) / 2
+	}
+	if n > ceil {
+		n = ceil
+	}
+	if n < cur {
+		return cur
+	}
+	return n
+}
@@ -0,0 +1,286 @@
+package snmp
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gosnmp/gosnmp"
+)
+
+func Test_NewPDUSize(t *testing.T) {
+	p := NewPDUSize(0, 0)
+	if p.MaxRepetitions() != 1 || p.MaxOids() != 1 {
+		t.Errorf("max repetitions %d and oids %d, expected 1", p.MaxRepetitions(), p.MaxOids())
+	}
+	p = NewPDUSize(50, 60)
+	p.shrinkMaxRepetitions(50)
+	p.setMaxRepetitions(30)
+	if p.MaxRepetitions() != 30 || p.maxRepCeil != 30 || p.maxRepFailed != 0 || p.MaxOids() != 60 {
+		t.Errorf("after set max repetitions: %d (ceil %d failed %d) oids %d", p.MaxRepetitions(), p.maxRepCeil, p.maxRepFailed, p.MaxOids())
+	}
+}
+
+func Test_PDUSize(t *testing.T) {
+	// step is a shrink after a failed request sent with used max repetitions,
+	// or successes consecutive successful requests
+	type step struct {
+		used      int
+		successes int
+		shrunk    bool
+		maxRep    int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "halved on failures",
+			steps: []step{
+				{used: 50, shrunk: true, maxRep: 25},
+				{used: 25, shrunk: true, maxRep: 12},
+				{used: 12, shrunk: true, maxRep: 6},
+			},
+		},
+		{
+			// other clients failing with bigger sizes do not grow it
+			name: "concurrent failures",
+			steps: []step{
+				{used: 50, shrunk: true, maxRep: 25},
+				{used: 25, shrunk: true, maxRep: 12},
+				{used: 50, shrunk: true, maxRep: 12},
+			},
+		},
+		{
+			name: "not smaller than 1",
+			steps: []step{
+				{used: 2, shrunk: true, maxRep: 1},
+				{used: 1, shrunk: false, maxRep: 1},
+			},
+		},
+		{
+			name: "doubled after successes up to the ceiling",
+			steps: []step{
+				{used: 50, shrunk: true, maxRep: 25},
+				{used: 25, shrunk: true, maxRep: 12},
+				{used: 12, shrunk: true, maxRep: 6},
+				// the last failure was 12, it goes halfway to it
+				{successes: pduGrowAfter - 1, maxRep: 6},
+				{successes: 1, maxRep: 9},
+				{successes: pduGrowAfter, maxRep: 10},
+				{successes: pduGrowAfter, maxRep: 11},
+				// stuck below the failed size
+				{successes: 4 * pduGrowAfter, maxRep: 11},
+				// until the failed size is tried again, and doubled up to the ceiling
+				{successes: pduRetryFailedAfter - 7*pduGrowAfter, maxRep: 22},
+				{successes: pduGrowAfter, maxRep: 44},
+				{successes: pduGrowAfter, maxRep: 50},
+				{successes: pduGrowAfter, maxRep: 50},
+			},
+		},
+		{
+			// a failure resets the successes count
+			name: "failure between successes",
+			steps: []step{
+				{used: 50, shrunk: true, maxRep: 25},
+				{successes: pduGrowAfter - 1, maxRep: 25},
+				{used: 25, shrunk: true, maxRep: 12},
+				{successes: pduGrowAfter - 1, maxRep: 12},
+				{successes: 1, maxRep: 24},
+			},
+		},
+	}
+	for _, tt := range tests {
+		p := NewPDUSize(50, 60)
+		for i, s := range tt.steps {
+			if s.used > 0 {
+				if got := p.shrinkMaxRepetitions(s.used); got != s.shrunk {
+					t.Errorf("%s: step %d shrink %t, expected %t", tt.name, i, got, s.shrunk)
+				}
+			}
+			for j := 0; j < s.successes; j++ {
+				p.success()
+			}
+			if got := p.MaxRepetitions(); got != s.maxRep {
+				t.Errorf("%s: step %d max repetitions %d, expected %d", tt.name, i, got, s.maxRep)
+			}
+		}
+		// the OIDs per GET are learned apart
+		if p.MaxOids() != 60 {
+			t.Errorf("%s: max oids %d, expected 60", tt.name, p.MaxOids())
+		}
+	}
+}
+
+// testBulkAgent answers GETBULK requests walking n OIDs below root, requests with more max repetitions than
+// tooBig get a tooBig error and the ones with more than timeout are not answered (0 no limit)
+type testBulkAgent struct {
+	mutex   sync.Mutex
+	tooBig  int
+	timeout int
+	reps    []int
+}
+
+const testBulkRoot = ".1.3.6.1.2.1.2.2.1.2"
+
+// testBER returns the content of the first BER TLV and the data after it
+func testBER(b []byte) ([]byte, []byte) {
+	if len(b) < 2 {
+		return nil, nil
+	}
+	n, hdr := int(b[1]), 2
+	if b[1]&0x80 != 0 {
+		n, hdr = 0, 2+int(b[1]&0x7f)
+		for _, c := range b[2:hdr] {
+			n = n<<8 | int(c)
+		}
+	}
+	return b[hdr : hdr+n], b[hdr+n:]
+}
+
+// testMaxRepetitions returns the max repetitions of a v2c GETBULK message, goSNMP does not decode it
+func testMaxRepetitions(msg []byte) int {
+	seq, _ := testBER(msg)
+	_, rest := testBER(seq) // version
+	_, rest = testBER(rest) // community
+	pdu, _ := testBER(rest) // GetBulkRequest-PDU
+	_, rest = testBER(pdu)  // request id
+	_, rest = testBER(rest) // non repeaters
+	v, _ := testBER(rest)   // max repetitions
+	var reps int
+	for _, c := range v {
+		reps = reps<<8 | int(c)
+	}
+	return reps
+}
+
+func newTestBulkAgent(t *testing.T, n int, a *testBulkAgent) int {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	dec := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public", Logger: gosnmp.NewLogger(nil)}
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			m, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req, err := dec.SnmpDecodePacket(buf[:m])
+			if err != nil || req.PDUType != gosnmp.GetBulkRequest || len(req.Variables) != 1 {
+				continue
+			}
+			reps := testMaxRepetitions(buf[:m])
+			a.mutex.Lock()
+			a.reps = append(a.reps, reps)
+			a.mutex.Unlock()
+			if a.timeout != 0 && reps > a.timeout {
+				continue
+			}
+			req.PDUType = gosnmp.GetResponse
+			if a.tooBig != 0 && reps > a.tooBig {
+				req.Error = gosnmp.TooBig
+			} else {
+				// next OIDs after the requested one
+				next := 1
+				if name := req.Variables[0].Name; len(name) > len(testBulkRoot) {
+					next, _ = strconv.Atoi(name[len(testBulkRoot)+1:])
+					next++
+				}
+				req.Variables = nil
+				for i := next; i < next+reps; i++ {
+					if i > n {
+						req.Variables = append(req.Variables, gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.3.1", Type: gosnmp.Integer, Value: 6})
+						break
+					}
+					req.Variables = append(req.Variables, gosnmp.SnmpPDU{Name: testBulkRoot + "." + strconv.Itoa(i), Type: gosnmp.OctetString, Value: []byte("eth" + strconv.Itoa(i))})
+				}
+			}
+			if resp, err := req.MarshalMsg(); err == nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func Test_ClientBulkWalkPDUSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		agent  *testBulkAgent
+		maxRep uint8
+		ok     []bool // walks done and its result
+		reps   []int
+		learn  int
+		walked int
+	}{
+		{
+			name:   "no errors",
+			agent:  &testBulkAgent{},
+			maxRep: 20,
+			ok:     []bool{true},
+			reps:   []int{20, 20},
+			learn:  20,
+			walked: 25,
+		},
+		{
+			name:   "too big",
+			agent:  &testBulkAgent{tooBig: 10},
+			maxRep: 40,
+			ok:     []bool{true},
+			reps:   []int{40, 20, 10, 10, 10},
+			learn:  10,
+			walked: 25,
+		},
+		{
+			// too big with max repetitions 1 ends the walk as the goSNMP walk does
+			name:   "too big always",
+			agent:  &testBulkAgent{tooBig: -1},
+			maxRep: 2,
+			ok:     []bool{true},
+			reps:   []int{2, 1},
+			learn:  1,
+		},
+		{
+			// timeouts are retried once on each walk
+			name:   "timeout",
+			agent:  &testBulkAgent{timeout: 10},
+			maxRep: 40,
+			ok:     []bool{false, true},
+			reps:   []int{40, 40, 40, 20, 20, 20, 20, 20, 20, 10, 10, 10},
+			learn:  10,
+			walked: 25,
+		},
+	}
+	for _, tt := range tests {
+		port := newTestBulkAgent(t, 25, tt.agent)
+		c := testStatsClient(t, port, nil)
+		c.ConnectionParams.PDUSize = NewPDUSize(tt.maxRep, 60)
+		var walked int
+		for i, ok := range tt.ok {
+			walked = 0
+			err := c.Walk(testBulkRoot, func(pdu gosnmp.SnmpPDU) error {
+				walked++
+				return nil
+			})
+			if (err == nil) != ok {
+				t.Errorf("%s: walk %d error %v", tt.name, i, err)
+			}
+		}
+		if walked != tt.walked {
+			t.Errorf("%s: %d OIDs walked, expected %d", tt.name, walked, tt.walked)
+		}
+		if got := c.ConnectionParams.PDUSize.MaxRepetitions(); got != tt.learn {
+			t.Errorf("%s: learned max repetitions %d, expected %d", tt.name, got, tt.learn)
+		}
+		tt.agent.mutex.Lock()
+		if !cmp.Equal(tt.reps, tt.agent.reps) {
+			t.Errorf("%s: requests with max repetitions %v, expected %v", tt.name, tt.agent.reps, tt.reps)
+		}
+		tt.agent.mutex.Unlock()
+	}
+}
@@ -0,0 +1,328 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/toni-moreno/snmpcollector/pkg/data/utils"
+)
+
+// maxSessionRoutes is the number of last request ids routed to each client, older responses are dropped
+const maxSessionRoutes = 16
+
+// Session is a SNMP connection shared by all the clients of a device. The requests of all the clients
+// are sent through a single UDP socket and each response is routed back to the client which sent the
+// request by its request id (message id on v3). Each client keeps its own goSNMP object, so debug,
+// max repetitions and resets still work by client. The v3 engine discovered by the first client is
+// also shared, so the next ones do not need to discover it again.
+type Session struct {
+	log    utils.Logger
+	mutex  sync.Mutex
+	conn   *net.UDPConn
+	conns  map[*sessionConn]bool
+	routes map[uint32]*sessionConn
+	// v3 authoritative engine
+	engineID     string
+	engineBoots  uint32
+	engineTime   uint32
+	engineStored time.Time
+}
+
+// NewSession creates a device session, the socket is opened when the first client connects and
+// closed when the last one is released
+func NewSession(l utils.Logger) *Session {
+	return &Session{
+		log:    l,
+		conns:  make(map[*sessionConn]bool),
+		routes: make(map[uint32]*sessionConn),
+	}
+}
+
+// attach replaces the goSNMP client socket with a connection multiplexed over the session socket
+func (s *Session) attach(x *gosnmp.GoSNMP) error {
+	if !strings.HasPrefix(x.Transport, "udp") {
+		return nil
+	}
+	raddr, ok := x.Conn.RemoteAddr().(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conn == nil {
+		conn, err := net.DialUDP(x.Transport, nil, raddr)
+		if err != nil {
+			return fmt.Errorf("opening session socket: %v", err)
+		}
+		s.conn = conn
+		go s.receive(conn)
+	} else if s.conn.RemoteAddr().String() != raddr.String() {
+		s.log.Warnf("session: device address changed from %s to %s, the connection will not be shared", s.conn.RemoteAddr(), raddr)
+		return nil
+	}
+	if err := x.Conn.Close(); err != nil {
+		s.log.Warnf("session: closing SNMP connection: %v", err)
+	}
+	sc := &sessionConn{s: s, conn: s.conn, in: make(chan []byte, 32), closed: make(chan struct{})}
+	s.conns[sc] = true
+	x.Conn = sc
+	if x.Version == gosnmp.Version3 {
+		s.setEngine(x)
+	}
+	return nil
+}
+
+// detach removes the client connection, the socket is closed with the last one
+func (s *Session) detach(sc *sessionConn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, sc)
+	for _, id := range sc.ids {
+		if s.routes[id] == sc {
+			delete(s.routes, id)
+		}
+	}
+	if len(s.conns) == 0 && s.conn != nil && s.conn == sc.conn {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// route sends the responses with the id to the client connection
+func (s *Session) route(id uint32, sc *sessionConn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.routes[id] = sc
+	sc.ids = append(sc.ids, id)
+	if len(sc.ids) > maxSessionRoutes {
+		if s.routes[sc.ids[0]] == sc {
+			delete(s.routes, sc.ids[0])
+		}
+		sc.ids = sc.ids[1:]
+	}
+}
+
+// receive reads the responses from the socket and delivers them to the client connections
+func (s *Session) receive(conn *net.UDPConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			// ICMP errors (port unreachable) are reported by the socket, the requests will time out
+			s.log.Debugf("session: error reading from socket: %v", err)
+			continue
+		}
+		id, err := messageID(buf[:n])
+		if err != nil {
+			s.log.Debugf("session: dropped response: %v", err)
+			continue
+		}
+		s.mutex.Lock()
+		sc := s.routes[id]
+		s.mutex.Unlock()
+		if sc == nil {
+			s.log.Debugf("session: dropped response with unknown id %d", id)
+			continue
+		}
+		pkt := make([]byte, n)
+		copy(pkt, buf[:n])
+		select {
+		case sc.in <- pkt:
+		default:
+			s.log.Debugf("session: dropped response with id %d, client queue full", id)
+		}
+	}
+}
+
+// setEngine sets the v3 engine discovered by other clients, must be called with the lock held
+func (s *Session) setEngine(x *gosnmp.GoSNMP) {
+	if len(s.engineID) == 0 {
+		return
+	}
+	usm, ok := x.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok || len(usm.AuthoritativeEngineID) > 0 {
+		return
+	}
+	usm.AuthoritativeEngineID = s.engineID
+	usm.AuthoritativeEngineBoots = s.engineBoots
+	usm.AuthoritativeEngineTime = s.engineTime + uint32(time.Since(s.engineStored).Seconds())
+	if len(x.ContextEngineID) == 0 {
+		x.ContextEngineID = s.engineID
+	}
+}
+
+// storeEngine saves the v3 engine discovered by the client to share it with the next ones
+func (s *Session) storeEngine(x *gosnmp.GoSNMP) {
+	usm, ok := x.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok || len(usm.AuthoritativeEngineID) == 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.engineID = usm.AuthoritativeEngineID
+	s.engineBoots = usm.AuthoritativeEngineBoots
+	s.engineTime = usm.AuthoritativeEngineTime
+	s.engineStored = time.Now()
+}
+
+// sessionConn is the connection of a client on the session socket
+type sessionConn struct {
+	s         *Session
+	conn      *net.UDPConn
+	in        chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+	mutex     sync.Mutex
+	deadline  time.Time
+	// last request ids sent by the client, protected by the session lock
+	ids []uint32
+}
+
+func (c *sessionConn) Read(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.mutex.Lock()
+	deadline := c.deadline
+	c.mutex.Unlock()
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		t := time.NewTimer(time.Until(deadline))
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case pkt := <-c.in:
+		return copy(b, pkt), nil
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	case <-c.closed:
+		return 0, net.ErrClosed
+	}
+}
+
+func (c *sessionConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	if id, err := messageID(b); err == nil {
+		c.s.route(id, c)
+	}
+	return c.conn.Write(b)
+}
+
+func (c *sessionConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.s.detach(c)
+	})
+	return nil
+}
+
+func (c *sessionConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *sessionConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *sessionConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *sessionConn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.deadline = t
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *sessionConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// messageID returns the request id of v1/v2c messages or the message id of v3 ones
+func messageID(buf []byte) (uint32, error) {
+	// message SEQUENCE
+	_, i, err := berHeader(buf, 0, 0x30)
+	if err != nil {
+		return 0, err
+	}
+	// version INTEGER
+	l, i, err := berHeader(buf, i, 0x02)
+	if err != nil {
+		return 0, err
+	}
+	version := berUint(buf[i : i+l])
+	i += l
+	if version == uint32(gosnmp.Version3) {
+		// msgGlobalData SEQUENCE { msgID INTEGER, ... }
+		_, i, err = berHeader(buf, i, 0x30)
+	} else {
+		// community OCTET STRING
+		l, i, err = berHeader(buf, i, 0x04)
+		if err != nil {
+			return 0, err
+		}
+		i += l
+		// PDU { request-id INTEGER, ... }
+		if i >= len(buf) || buf[i]&0xe0 != 0xa0 {
+			return 0, fmt.Errorf("invalid PDU type")
+		}
+		_, i, err = berHeader(buf, i, buf[i])
+	}
+	if err != nil {
+		return 0, err
+	}
+	l, i, err = berHeader(buf, i, 0x02)
+	if err != nil {
+		return 0, err
+	}
+	return berUint(buf[i : i+l]), nil
+}
+
+// berHeader checks the tag at position i and returns the content length and position
+func berHeader(buf []byte, i int, tag byte) (int, int, error) {
+	if i+2 > len(buf) || buf[i] != tag {
+		return 0, 0, fmt.Errorf("invalid message, expected tag %#x at %d", tag, i)
+	}
+	l := int(buf[i+1])
+	i += 2
+	if l&0x80 != 0 {
+		n := l & 0x7f
+		if n == 0 || n > 4 || i+n > len(buf) {
+			return 0, 0, fmt.Errorf("invalid message, bad length at %d", i)
+		}
+		l = 0
+		for _, b := range buf[i : i+n] {
+			l = l<<8 | int(b)
+		}
+		i += n
+	}
+	if l < 0 || i+l > len(buf) {
+		return 0, 0, fmt.Errorf("invalid message, truncated at %d", i)
+	}
+	return l, i, nil
+}
+
+func berUint(b []byte) uint32 {
+	var v uint32
+	for _, x := range b {
+		v = v<<8 | uint32(x)
+	}
+	return v
+}
@@ -0,0 +1,326 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+)
+
+// testSnmpPacket encodes a GetRequest, on v3 the message id is set to msgID and the request id
+// to other value to check the right one is used
+func testSnmpPacket(t *testing.T, version gosnmp.SnmpVersion, community string, msgID uint32, vars int) []byte {
+	t.Helper()
+	p := &gosnmp.SnmpPacket{
+		Version:   version,
+		Community: community,
+		PDUType:   gosnmp.GetRequest,
+		RequestID: msgID,
+	}
+	if version == gosnmp.Version3 {
+		p.RequestID = msgID + 1000
+		p.MsgID = msgID
+		p.MsgFlags = gosnmp.NoAuthNoPriv | gosnmp.Reportable
+		p.SecurityModel = gosnmp.UserSecurityModel
+		p.SecurityParameters = &gosnmp.UsmSecurityParameters{UserName: "user"}
+	}
+	for i := 0; i < vars; i++ {
+		p.Variables = append(p.Variables, gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.10." + strconv.Itoa(i+1), Type: gosnmp.Null})
+	}
+	b, err := p.MarshalMsg()
+	if err != nil {
+		t.Fatalf("marshal packet: %s", err)
+	}
+	return b
+}
+
+func Test_messageID(t *testing.T) {
+	ids := []uint32{0, 1, 127, 128, 255, 256, 65535, 1 << 24, 0x7fffffff}
+	for _, version := range []gosnmp.SnmpVersion{gosnmp.Version1, gosnmp.Version2c, gosnmp.Version3} {
+		for _, id := range ids {
+			for _, vars := range []int{1, 200} {
+				// short and long form lengths on the message and PDU (gosnmp only encodes
+				// communities up to 127 bytes)
+				pkt := testSnmpPacket(t, version, strings.Repeat("c", 127), id, vars)
+				if vars > 1 && pkt[1] != 0x82 {
+					t.Fatalf("version %s: message length %#x, expected long form", version, pkt[1])
+				}
+				got, err := messageID(pkt)
+				if err != nil {
+					t.Errorf("version %s id %d vars %d: %s", version, id, vars, err)
+					continue
+				}
+				if got != id {
+					t.Errorf("version %s vars %d: message id %d, expected %d", version, vars, got, id)
+				}
+			}
+		}
+	}
+}
+
+func Test_messageIDInvalid(t *testing.T) {
+	v2 := testSnmpPacket(t, gosnmp.Version2c, "public", 1234, 1)
+	tests := []struct {
+		name string
+		pkt  []byte
+	}{
+		{"empty", nil},
+		{"one byte", []byte{0x30}},
+		{"not a sequence", append([]byte{0x04}, v2[1:]...)},
+		{"indefinite length", []byte{0x30, 0x80, 0x02, 0x01, 0x01, 0x00, 0x00}},
+		{"length of length too big", []byte{0x30, 0x85, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{"length bigger than packet", []byte{0x30, 0x82, 0xff, 0xff, 0x02, 0x01, 0x01}},
+		{"version not integer", []byte{0x30, 0x03, 0x04, 0x01, 0x01}},
+		{"community not string", []byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x00}},
+		{"invalid pdu type", []byte{0x30, 0x0d, 0x02, 0x01, 0x01, 0x04, 0x01, 'p', 0x30, 0x05, 0x02, 0x03, 0x01, 0x02, 0x03}},
+		{"missing request id", []byte{0x30, 0x0a, 0x02, 0x01, 0x01, 0x04, 0x01, 'p', 0xa0, 0x02, 0x05, 0x00}},
+		{"v3 without global data", []byte{0x30, 0x05, 0x02, 0x01, 0x03, 0x04, 0x00}},
+	}
+	for _, tt := range tests {
+		if id, err := messageID(tt.pkt); err == nil {
+			t.Errorf("%s: got id %d, expected error", tt.name, id)
+		}
+	}
+
+	// truncated packets
+	for _, version := range []gosnmp.SnmpVersion{gosnmp.Version1, gosnmp.Version2c, gosnmp.Version3} {
+		pkt := testSnmpPacket(t, version, "public", 1234, 3)
+		for n := 0; n < len(pkt); n++ {
+			if id, err := messageID(pkt[:n]); err == nil {
+				t.Errorf("version %s truncated at %d: got id %d, expected error", version, n, id)
+			}
+		}
+	}
+
+	// garbage should never panic
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		pkt := make([]byte, r.Intn(64))
+		r.Read(pkt)
+		if len(pkt) > 0 && i%2 == 0 {
+			pkt[0] = 0x30
+		}
+		messageID(pkt)
+	}
+}
+
+//--------------------------------------------------------------------
+// Routing
+//--------------------------------------------------------------------
+
+// testAgent is an UDP SNMP agent stand-in, if respond is set it answers v2c GetRequests with
+// the OID as value after a random delay, so responses are not received in the request order.
+// If communities are given, requests with other communities are dropped.
+type testAgent struct {
+	conn *net.UDPConn
+	// last client address
+	mutex sync.Mutex
+	addr  net.Addr
+}
+
+func newTestAgent(t *testing.T, respond bool, communities ...string) *testAgent {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	a := &testAgent{conn: conn}
+	dec := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public", Logger: gosnmp.NewLogger(nil)}
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			a.mutex.Lock()
+			a.addr = addr
+			a.mutex.Unlock()
+			if !respond {
+				continue
+			}
+			req, err := dec.SnmpDecodePacket(buf[:n])
+			if err != nil || (len(communities) > 0 && !testContains(communities, req.Community)) {
+				continue
+			}
+			go func(req *gosnmp.SnmpPacket, addr net.Addr) {
+				time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+				req.PDUType = gosnmp.GetResponse
+				for i := range req.Variables {
+					req.Variables[i].Type = gosnmp.OctetString
+					req.Variables[i].Value = []byte(req.Variables[i].Name)
+				}
+				if resp, err := req.MarshalMsg(); err == nil {
+					conn.WriteTo(resp, addr)
+				}
+			}(req, addr)
+		}
+	}()
+	t.Cleanup(func() { conn.Close() })
+	return a
+}
+
+func testContains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// send writes a raw packet to the last client address
+func (a *testAgent) send(pkt []byte) {
+	a.mutex.Lock()
+	addr := a.addr
+	a.mutex.Unlock()
+	a.conn.WriteTo(pkt, addr)
+}
+
+func testSessionClient(t *testing.T, s *Session, a *testAgent) *gosnmp.GoSNMP {
+	t.Helper()
+	x := &gosnmp.GoSNMP{
+		Target:    "127.0.0.1",
+		Port:      uint16(a.conn.LocalAddr().(*net.UDPAddr).Port),
+		Transport: "udp",
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		Timeout:   2 * time.Second,
+		MaxOids:   gosnmp.MaxOids,
+	}
+	if err := x.Connect(); err != nil {
+		t.Fatalf("connect: %s", err)
+	}
+	if err := s.attach(x); err != nil {
+		t.Fatalf("attach: %s", err)
+	}
+	if _, ok := x.Conn.(*sessionConn); !ok {
+		t.Fatalf("client connection not attached to the session")
+	}
+	return x
+}
+
+func Test_SessionRouting(t *testing.T) {
+	a := newTestAgent(t, true)
+	s := NewSession(logrus.New())
+	clients := []*gosnmp.GoSNMP{testSessionClient(t, s, a), testSessionClient(t, s, a), testSessionClient(t, s, a)}
+
+	// concurrent requests from all clients, each one should get its own responses
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for c, x := range clients {
+		wg.Add(1)
+		go func(c int, x *gosnmp.GoSNMP) {
+			defer wg.Done()
+			for i := 0; i < 30; i++ {
+				oid := fmt.Sprintf(".1.3.6.1.4.1.%d.%d", c, i)
+				res, err := x.Get([]string{oid})
+				if err != nil {
+					errs <- fmt.Errorf("client %d get %s: %v", c, oid, err)
+					return
+				}
+				if v, _ := res.Variables[0].Value.([]byte); string(v) != oid {
+					errs <- fmt.Errorf("client %d get %s: got %q", c, oid, v)
+				}
+			}
+		}(c, x)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// the session socket is closed with the last client
+	for i, x := range clients {
+		x.Conn.Close()
+		s.mutex.Lock()
+		open := s.conn != nil
+		routes := len(s.routes)
+		s.mutex.Unlock()
+		if last := i == len(clients)-1; open == last {
+			t.Errorf("socket open %t after closing %d of %d clients", open, i+1, len(clients))
+		}
+		if want := maxSessionRoutes * (len(clients) - i - 1); routes != want {
+			t.Errorf("%d routes after closing %d clients, expected %d", routes, i+1, want)
+		}
+	}
+}
+
+// testSessionRead returns the message ids received by the connection until the deadline
+func testSessionRead(sc *sessionConn, wait time.Duration) ([]uint32, error) {
+	var ids []uint32
+	buf := make([]byte, 65535)
+	sc.SetReadDeadline(time.Now().Add(wait))
+	for {
+		n, err := sc.Read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return ids, nil
+			}
+			return ids, err
+		}
+		id, err := messageID(buf[:n])
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+}
+
+func Test_SessionStaleResponses(t *testing.T) {
+	a := newTestAgent(t, false)
+	s := NewSession(logrus.New())
+	c1 := testSessionClient(t, s, a).Conn.(*sessionConn)
+	c2 := testSessionClient(t, s, a).Conn.(*sessionConn)
+
+	for id := uint32(1); id <= 20; id++ {
+		c1.Write(testSnmpPacket(t, gosnmp.Version2c, "public", id, 1))
+	}
+	c2.Write(testSnmpPacket(t, gosnmp.Version2c, "public", 100, 1))
+	time.Sleep(50 * time.Millisecond)
+
+	// only the last maxSessionRoutes ids of each client are routed
+	for _, id := range []uint32{1, 4, 5, 20, 100, 999} {
+		a.send(testSnmpPacket(t, gosnmp.Version2c, "public", id, 1))
+	}
+	a.send([]byte("garbage"))
+	a.send(testSnmpPacket(t, gosnmp.Version2c, "public", 5, 1)[:20])
+
+	ids1, err := testSessionRead(c1, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("client 1 read: %s", err)
+	}
+	if fmt.Sprint(ids1) != "[5 20]" {
+		t.Errorf("client 1 received %v, expected [5 20]", ids1)
+	}
+	ids2, err := testSessionRead(c2, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("client 2 read: %s", err)
+	}
+	if fmt.Sprint(ids2) != "[100]" {
+		t.Errorf("client 2 received %v, expected [100]", ids2)
+	}
+
+	// responses to closed clients are dropped
+	c1.Close()
+	a.send(testSnmpPacket(t, gosnmp.Version2c, "public", 20, 1))
+	if _, err := c1.Read(make([]byte, 100)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("read on closed connection: %v", err)
+	}
+	if _, err := c1.Write([]byte{0x30, 0x00}); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("write on closed connection: %v", err)
+	}
+	if ids, _ := testSessionRead(c2, 50*time.Millisecond); len(ids) != 0 {
+		t.Errorf("client 2 received %v", ids)
+	}
+	c2.Close()
+}
@@ -40,6 +40,13 @@ var (
 		"AES256":  gosnmp.AES256,
 		"AES256C": gosnmp.AES256C,
 	}
+	// transports define the allowed network transports, the ones ending in 6 only use IPv6 addresses
+	transports = map[string]bool{
+		"udp":  true,
+		"udp6": true,
+		"tcp":  true,
+		"tcp6": true,
+	}
 )
 
 // SetLogger xx
@@ -296,7 +303,7 @@ func GetSysInfo(client *gosnmp.GoSNMP, l utils.Logger) (SysInfo, error) {
 	info := SysInfo{SysDescr: "", SysUptime: time.Duration(0), SysContact: "", SysName: "", SysLocation: ""}
 	pkt, err := client.Get(sysOids)
 	if err != nil {
-		return info, fmt.Errorf("client get: %s", err)
+		return info, fmt.Errorf("client get: %w", err)
 	}
 
 	for idx, pdu := range pkt.Variables {
@@ -514,15 +521,63 @@ func Release(client *gosnmp.GoSNMP) {
 	}
 }
 
-// GetClient return the gosnmp client configured.
-// To connect, the host is resolved and the first IP is used.
-func GetClient(connectionParams ConnectionParams, l utils.Logger) (*gosnmp.GoSNMP, error) {
-	hostIPs, err := net.LookupHost(connectionParams.Host)
+// SplitHost returns the host without the IPv6 square brackets and the port, which could be set
+// in the host as [address]:port or host:port. IPv6 addresses without brackets are returned as is.
+func SplitHost(host string, port int) (string, int, error) {
+	if strings.Count(host, ":") > 1 && !strings.HasPrefix(host, "[") {
+		return host, port, nil
+	}
+	addr, err := utils.SplitHostPortDefault(host, "", strconv.Itoa(port))
+	if err != nil {
+		return "", 0, err
+	}
+	if len(addr.Host) == 0 {
+		return "", 0, fmt.Errorf("empty host in '%s'", host)
+	}
+	p, err := strconv.Atoi(addr.Port)
+	if err != nil || p <= 0 || p > 65535 {
+		return "", 0, fmt.Errorf("invalid port '%s' in '%s'", addr.Port, host)
+	}
+	return addr.Host, p, nil
+}
+
+// LookupHost resolves the host, IPv4 addresses are discarded if the transport is IPv6 only
+// and IPv6 addresses if it is IPv4 only
+func LookupHost(host string, transport string) ([]string, error) {
+	hostIPs, err := net.LookupHost(host)
 	if err != nil {
 		return nil, fmt.Errorf("name lookup: %v", err)
 	}
+	ipv4 := strings.HasSuffix(transport, "4")
+	if ipv4 || strings.HasSuffix(transport, "6") {
+		valid := hostIPs[:0]
+		for _, ip := range hostIPs {
+			if addr := net.ParseIP(strings.SplitN(ip, "%", 2)[0]); addr != nil && (addr.To4() != nil) == ipv4 {
+				valid = append(valid, ip)
+			}
+		}
+		hostIPs = valid
+	}
 	if len(hostIPs) == 0 {
-		return nil, fmt.Errorf("empty name lookup response")
+		return nil, fmt.Errorf("empty name lookup response for transport %s", transport)
+	}
+	return hostIPs, nil
+}
+
+// GetClient return the gosnmp client configured.
+// To connect, the host is resolved and the first IP valid for the transport is used.
+func GetClient(connectionParams ConnectionParams, l utils.Logger) (*gosnmp.GoSNMP, error) {
+	host, port, err := SplitHost(connectionParams.Host, connectionParams.Port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host: %v", err)
+	}
+	transport := connectionParams.Transport
+	if len(transport) == 0 {
+		transport = "udp"
+	}
+	hostIPs, err := LookupHost(host, transport)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(hostIPs) > 1 {
@@ -531,57 +586,29 @@ func GetClient(connectionParams ConnectionParams, l utils.Logger) (*gosnmp.GoSNM
 
 	// Common options
 	client := &gosnmp.GoSNMP{
-		Target:  hostIPs[0],
-		Port:    uint16(connectionParams.Port),
-		Timeout: time.Duration(connectionParams.Timeout) * time.Second,
-		Retries: connectionParams.Retries,
-		MaxOids: connectionParams.MaxOids,
+		Target:    hostIPs[0],
+		Port:      uint16(port),
+		Transport: transport,
+		Timeout:   time.Duration(connectionParams.Timeout) * time.Second,
+		Retries:   connectionParams.Retries,
+		MaxOids:   connectionParams.MaxOids,
 	}
 
 	switch connectionParams.SnmpVersion {
 	case "1":
 		client.Version = gosnmp.Version1
-		client.Community = connectionParams.Community
+		client.Community = connectionParams.community()
 	case "2c":
 		client.Version = gosnmp.Version2c
-		client.Community = connectionParams.Community
+		client.Community = connectionParams.community()
 		client.MaxRepetitions = uint32(connectionParams.MaxRepetitions)
 	case "3":
 		client.Version = gosnmp.Version3
-		UsmParams := new(gosnmp.UsmSecurityParameters)
-
-		switch connectionParams.V3Params.SecLevel {
-		case "NoAuthNoPriv":
-			UsmParams = &gosnmp.UsmSecurityParameters{
-				UserName:               connectionParams.V3Params.AuthUser,
-				AuthenticationProtocol: gosnmp.NoAuth,
-				PrivacyProtocol:        gosnmp.NoPriv,
-			}
-		case "AuthNoPriv":
-			UsmParams = &gosnmp.UsmSecurityParameters{
-				UserName:                 connectionParams.V3Params.AuthUser,
-				AuthenticationProtocol:   authpmap[connectionParams.V3Params.AuthProt],
-				AuthenticationPassphrase: connectionParams.V3Params.AuthPass,
-				PrivacyProtocol:          gosnmp.NoPriv,
-			}
-		case "AuthPriv":
-			UsmParams = &gosnmp.UsmSecurityParameters{
-				UserName:                 connectionParams.V3Params.AuthUser,
-				AuthenticationProtocol:   authpmap[connectionParams.V3Params.AuthProt],
-				AuthenticationPassphrase: connectionParams.V3Params.AuthPass,
-				PrivacyProtocol:          privpmap[connectionParams.V3Params.PrivProt],
-				PrivacyPassphrase:        connectionParams.V3Params.PrivPass,
-			}
-		default:
-			panic("Invalid SNMP v3 SecLevel. Code should never reach here. Validation should control it")
+		setV3Params(client, connectionParams.V3Params)
+		if len(connectionParams.Context.ContextName) > 0 {
+			client.ContextName = connectionParams.Context.ContextName
 		}
-
 		client.MaxRepetitions = uint32(connectionParams.MaxRepetitions)
-		client.SecurityModel = gosnmp.UserSecurityModel
-		client.MsgFlags = seclpmap[connectionParams.V3Params.SecLevel]
-		client.SecurityParameters = UsmParams
-		client.ContextName = connectionParams.V3Params.ContextName
-		client.ContextEngineID = connectionParams.V3Params.ContextEngineID
 	default:
 		panic("Invalid SNMP version. Code should never reach here. Validation should control it")
 	}
@@ -595,3 +622,59 @@ func GetClient(connectionParams ConnectionParams, l utils.Logger) (*gosnmp.GoSNM
 
 	return client, nil
 }
+
+// setV3Params sets the USM security parameters on the gosnmp client
+func setV3Params(client *gosnmp.GoSNMP, v3 V3Params) {
+	UsmParams := new(gosnmp.UsmSecurityParameters)
+
+	switch v3.SecLevel {
+	case "NoAuthNoPriv":
+		UsmParams = &gosnmp.UsmSecurityParameters{
+			UserName:               v3.AuthUser,
+			AuthenticationProtocol: gosnmp.NoAuth,
+			PrivacyProtocol:        gosnmp.NoPriv,
+		}
+	case "AuthNoPriv":
+		UsmParams = &gosnmp.UsmSecurityParameters{
+			UserName:                 v3.AuthUser,
+			AuthenticationProtocol:   authpmap[v3.AuthProt],
+			AuthenticationPassphrase: v3.AuthPass,
+			PrivacyProtocol:          gosnmp.NoPriv,
+		}
+	case "AuthPriv":
+		UsmParams = &gosnmp.UsmSecurityParameters{
+			UserName:                 v3.AuthUser,
+			AuthenticationProtocol:   authpmap[v3.AuthProt],
+			AuthenticationPassphrase: v3.AuthPass,
+			PrivacyProtocol:          privpmap[v3.PrivProt],
+			PrivacyPassphrase:        v3.PrivPass,
+		}
+	default:
+		panic("Invalid SNMP v3 SecLevel. Code should never reach here. Validation should control it")
+	}
+
+	client.SecurityModel = gosnmp.UserSecurityModel
+	client.MsgFlags = seclpmap[v3.SecLevel]
+	client.SecurityParameters = UsmParams
+	client.ContextName = v3.ContextName
+	client.ContextEngineID = v3.ContextEngineID
+}
+
+// GetTrapDecoder returns a gosnmp object (not connected) able to decode the traps and informs
+// sent with the connection params version and credentials
+func GetTrapDecoder(connectionParams ConnectionParams) (*gosnmp.GoSNMP, error) {
+	if err := connectionParams.Validation(); err != nil {
+		return nil, err
+	}
+	client := &gosnmp.GoSNMP{Community: connectionParams.Community}
+	switch connectionParams.SnmpVersion {
+	case "1":
+		client.Version = gosnmp.Version1
+	case "2c":
+		client.Version = gosnmp.Version2c
+	case "3":
+		client.Version = gosnmp.Version3
+		setV3Params(client, connectionParams.V3Params)
+	}
+	return client, nil
+}
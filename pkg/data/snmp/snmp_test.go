@@ -0,0 +1,72 @@
+package snmp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_SplitHost(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+		wp   int
+		ok   bool
+	}{
+		{"10.0.0.1", 161, "10.0.0.1", 161, true},
+		{"10.0.0.1:1161", 161, "10.0.0.1", 1161, true},
+		{"router1", 161, "router1", 161, true},
+		{"router1.example.com:1161", 161, "router1.example.com", 1161, true},
+		// bare IPv6 addresses are returned as is, the port can not be set on them
+		{"2001:db8::1", 161, "2001:db8::1", 161, true},
+		{"fe80::1%eth0", 161, "fe80::1%eth0", 161, true},
+		{"[2001:db8::1]", 161, "2001:db8::1", 161, true},
+		{"[2001:db8::1]:1161", 161, "2001:db8::1", 1161, true},
+		{"[fe80::1%eth0]:1161", 161, "fe80::1%eth0", 1161, true},
+		{"router1:0", 161, "", 0, false},
+		{"router1:70000", 161, "", 0, false},
+		{"router1:snmp", 161, "", 0, false},
+		{":1161", 161, "", 0, false},
+		{"[2001:db8::1", 161, "", 0, false},
+		// an empty port is the default one
+		{"[2001:db8::1]:", 161, "2001:db8::1", 161, true},
+		{"router1", 0, "", 0, false},
+	}
+	for _, tt := range tests {
+		host, port, err := SplitHost(tt.host, tt.port)
+		if (err == nil) != tt.ok || host != tt.want || port != tt.wp {
+			t.Errorf("split %q: %q %d %v, expected %q %d", tt.host, host, port, err, tt.want, tt.wp)
+		}
+	}
+}
+
+func Test_LookupHost(t *testing.T) {
+	tests := []struct {
+		host      string
+		transport string
+		want      []string
+	}{
+		{"127.0.0.1", "udp", []string{"127.0.0.1"}},
+		{"127.0.0.1", "udp4", []string{"127.0.0.1"}},
+		{"127.0.0.1", "tcp4", []string{"127.0.0.1"}},
+		{"127.0.0.1", "udp6", nil},
+		{"::1", "udp", []string{"::1"}},
+		{"::1", "udp6", []string{"::1"}},
+		{"::1", "tcp6", []string{"::1"}},
+		{"::1", "udp4", nil},
+		// IPv4 mapped IPv6 addresses are IPv4 ones
+		{"::ffff:10.0.0.1", "udp6", nil},
+		{"fe80::1%lo", "udp6", []string{"fe80::1%lo"}},
+		{"localhost", "udp4", []string{"127.0.0.1"}},
+	}
+	for _, tt := range tests {
+		got, err := LookupHost(tt.host, tt.transport)
+		if (err == nil) != (tt.want != nil) {
+			t.Errorf("lookup %s %s: error %v", tt.host, tt.transport, err)
+		}
+		if tt.want != nil && !cmp.Equal(tt.want, got) {
+			t.Errorf("lookup %s %s: %v, expected %v", tt.host, tt.transport, got, tt.want)
+		}
+	}
+}
@@ -59,10 +59,39 @@ const (
 	DeviceActive = 21
 	// DeviceConnected 1 if connected 0 if not
 	DeviceConnected = 22
+	// SnmpRateLimitWait Time waited for the device SNMP request limits
+	SnmpRateLimitWait = 23
+	// SnmpRetries SNMP requests sent again after getting no response
+	SnmpRetries = 24
+	// SnmpGetLatencyP50 median latency of the SNMP GET requests
+	SnmpGetLatencyP50 = 25
+	// SnmpGetLatencyP95 95th percentile latency of the SNMP GET requests
+	SnmpGetLatencyP95 = 26
+	// SnmpGetLatencyP99 99th percentile latency of the SNMP GET requests
+	SnmpGetLatencyP99 = 27
+	// SnmpWalkLatencyP50 median latency of the SNMP GETNEXT requests done on walks
+	SnmpWalkLatencyP50 = 28
+	// SnmpWalkLatencyP95 95th percentile latency of the SNMP GETNEXT requests done on walks
+	SnmpWalkLatencyP95 = 29
+	// SnmpWalkLatencyP99 99th percentile latency of the SNMP GETNEXT requests done on walks
+	SnmpWalkLatencyP99 = 30
+	// SnmpBulkLatencyP50 median latency of the SNMP GETBULK requests done on walks
+	SnmpBulkLatencyP50 = 31
+	// SnmpBulkLatencyP95 95th percentile latency of the SNMP GETBULK requests done on walks
+	SnmpBulkLatencyP95 = 32
+	// SnmpBulkLatencyP99 99th percentile latency of the SNMP GETBULK requests done on walks
+	SnmpBulkLatencyP99 = 33
 	// DevStatTypeSize special value to set the last stat position
-	DevStatTypeSize = 23
+	DevStatTypeSize = 34
 )
 
+// latencyCounters are the first latency percentile counter (p50, p95 and p99 follow it) for each SNMP request kind
+var latencyCounters = map[int]GatherStatType{
+	snmp.RequestGet:  SnmpGetLatencyP50,
+	snmp.RequestWalk: SnmpWalkLatencyP50,
+	snmp.RequestBulk: SnmpBulkLatencyP50,
+}
+
 // GatherStats minimal info to show users
 type GatherStats struct {
 	// ID
@@ -76,6 +105,8 @@ type GatherStats struct {
 
 	// Counter Statistics
 	Counters []interface{}
+	// SNMP request latency histograms, used to compute the latency percentile counters
+	requests snmp.RequestStats
 
 	// Gather state
 	//
@@ -126,9 +157,18 @@ func (s *GatherStats) Init(t string, id string, tm map[string]string, l utils.Lo
 	s.Counters[BackEndSentDuration] = 0.0
 	s.Counters[DeviceActive] = 0
 	s.Counters[DeviceConnected] = 0
+	s.Counters[SnmpRateLimitWait] = 0.0
+	s.Counters[SnmpRetries] = 0
+	for _, c := range latencyCounters {
+		s.Counters[c] = 0.0
+		s.Counters[c+1] = 0.0
+		s.Counters[c+2] = 0.0
+	}
+	s.requests = snmp.RequestStats{}
 }
 
 func (s *GatherStats) reset() {
+	s.requests = snmp.RequestStats{}
 	for k, val := range s.Counters {
 		switch v := val.(type) {
 		case string:
@@ -207,7 +247,7 @@ func (s *GatherStats) getMetricFields() map[string]interface{} {
 		/*1*/ //"snmp_walk_queries": s.Counters[SnmpWalkQueries],
 		/*2*/ //"snmp_get_errors": s.Counters[SnmpGetErrors],
 		/*3*/ //"snmp_walk_errors": s.Counters[SnmpWalkErrors],
-		/*4*/ //"snmp_query_timeouts": s.Counters[SnmpQueryTimeouts],
+		/*4*/ "snmp_query_timeouts": s.Counters[SnmpQueryTimeouts],
 		/*5*/ "snmp_oid_get_all": s.Counters[SnmpOIDGetAll],
 		/*6*/ "snmp_oid_get_processed": s.Counters[SnmpOIDGetProcessed],
 		/*7*/ "snmp_oid_get_errors": s.Counters[SnmpOIDGetErrors],
@@ -226,10 +266,31 @@ func (s *GatherStats) getMetricFields() map[string]interface{} {
 		/*20*/ "backend_sent_duration": s.Counters[BackEndSentDuration],
 		/*21*/ "active_value": active,
 		/*22*/ "connected_value": connected,
+		/*23*/ "snmp_ratelimit_wait": s.Counters[SnmpRateLimitWait],
+		/*24*/ "snmp_retries": s.Counters[SnmpRetries],
+		/*25*/ "snmp_get_latency_p50": s.Counters[SnmpGetLatencyP50],
+		/*26*/ "snmp_get_latency_p95": s.Counters[SnmpGetLatencyP95],
+		/*27*/ "snmp_get_latency_p99": s.Counters[SnmpGetLatencyP99],
+		/*28*/ "snmp_walk_latency_p50": s.Counters[SnmpWalkLatencyP50],
+		/*29*/ "snmp_walk_latency_p95": s.Counters[SnmpWalkLatencyP95],
+		/*30*/ "snmp_walk_latency_p99": s.Counters[SnmpWalkLatencyP99],
+		/*31*/ "snmp_bulk_latency_p50": s.Counters[SnmpBulkLatencyP50],
+		/*32*/ "snmp_bulk_latency_p95": s.Counters[SnmpBulkLatencyP95],
+		/*33*/ "snmp_bulk_latency_p99": s.Counters[SnmpBulkLatencyP99],
 	}
 	return fields
 }
 
+// GetFields get the current stat values as the same field map sent to the selfmon output
+func (s *GatherStats) GetFields() map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.Active && s.Connected {
+		return s.getMetricFields()
+	}
+	return s.getStatusFields()
+}
+
 // SetSelfMonitoring set the output device where send monitoring metrics
 func (s *GatherStats) SetSelfMonitoring(cfg *selfmon.SelfMon) {
 	s.mutex.Lock()
@@ -246,6 +307,7 @@ func (s *GatherStats) ThSafeCopy() *GatherStats {
 	for k, v := range s.Counters {
 		st.Counters[k] = v
 	}
+	st.requests = s.requests
 	st.Active = s.Active
 	st.Connected = s.Connected
 	st.GatherNextTime = s.GatherNextTime
@@ -358,6 +420,10 @@ func (s *GatherStats) Combine(sc *GatherStats) {
 	// Filter Durations
 	s.Counters[FilterStartTime] = minI(s.Counters[FilterStartTime].(int64), sc.Counters[FilterStartTime].(int64))
 	s.Counters[FilterDuration] = maxf(s.Counters[FilterDuration].(float64), sc.Counters[FilterDuration].(float64))
+	// Rate Limit Wait
+	s.Counters[SnmpRateLimitWait] = s.Counters[SnmpRateLimitWait].(float64) + sc.Counters[SnmpRateLimitWait].(float64)
+	// SNMP requests
+	s.addRequestStats(&sc.requests)
 }
 
 // AddMeasStats add measurement stats to the device stats object
@@ -398,6 +464,33 @@ func (s *GatherStats) AddSentDuration(start time.Time, duration time.Duration) {
 	s.Counters[BackEndSentDuration] = s.Counters[BackEndSentDuration].(float64) + duration.Seconds()
 }
 
+// AddRateLimitWait Update the time waited for the device SNMP request limits
+func (s *GatherStats) AddRateLimitWait(wait time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Counters[SnmpRateLimitWait] = s.Counters[SnmpRateLimitWait].(float64) + wait.Seconds()
+}
+
+// AddRequestStats Update the SNMP request latencies, retries and timeouts
+func (s *GatherStats) AddRequestStats(r snmp.RequestStats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.addRequestStats(&r)
+}
+
+// addRequestStats must be called with the lock held
+func (s *GatherStats) addRequestStats(r *snmp.RequestStats) {
+	s.requests.Add(r)
+	s.Counters[SnmpQueryTimeouts] = s.Counters[SnmpQueryTimeouts].(int) + r.Timeouts
+	s.Counters[SnmpRetries] = s.Counters[SnmpRetries].(int) + r.Retries
+	for kind, c := range latencyCounters {
+		h := &s.requests.Latency[kind]
+		s.Counters[c] = h.Percentile(0.50).Seconds()
+		s.Counters[c+1] = h.Percentile(0.95).Seconds()
+		s.Counters[c+2] = h.Percentile(0.99).Seconds()
+	}
+}
+
 // SetFltUpdateStats Set Filter Stats
 func (s *GatherStats) SetFltUpdateStats(start time.Time, duration time.Duration) {
 	s.mutex.Lock()
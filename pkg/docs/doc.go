@@ -23,6 +23,8 @@ package docs
 import (
 	"time"
 
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/trap"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
 	"github.com/toni-moreno/snmpcollector/pkg/data/stats"
@@ -49,6 +51,18 @@ type rtAgentDurationResponseWrapper struct {
 	Body time.Duration
 }
 
+// swagger:response idOfOutputsFailoverResp
+type rtAgentOutputsFailoverResponseWrapper struct {
+	// in:body
+	Body map[string]*output.BreakerStatus
+}
+
+// swagger:response idOfTrapStatsResp
+type rtAgentTrapStatsResponseWrapper struct {
+	// in:body
+	Body trap.Stats
+}
+
 // swagger:response idOfDeviceStatResp
 type rtAgentDeviceStatResponseWrapper struct {
 	// in:body
@@ -67,6 +81,66 @@ type rtCfgArrayInfluxCfgResponseWrapper struct {
 	Body []*config.InfluxCfg
 }
 
+// swagger:response idOfArrayGraphiteCfgResp
+type rtCfgArrayGraphiteCfgResponseWrapper struct {
+	// in:body
+	Body []*config.GraphiteCfg
+}
+
+// swagger:response idOfArrayOtlpCfgResp
+type rtCfgArrayOtlpCfgResponseWrapper struct {
+	// in:body
+	Body []*config.OtlpCfg
+}
+
+// swagger:response idOfArrayFileCfgResp
+type rtCfgArrayFileCfgResponseWrapper struct {
+	// in:body
+	Body []*config.FileCfg
+}
+
+// swagger:response idOfArrayMqttCfgResp
+type rtCfgArrayMqttCfgResponseWrapper struct {
+	// in:body
+	Body []*config.MqttCfg
+}
+
+// swagger:response idOfArrayKafkaCfgResp
+type rtCfgArrayKafkaCfgResponseWrapper struct {
+	// in:body
+	Body []*config.KafkaCfg
+}
+
+// swagger:response idOfArrayProvisionActionResp
+type rtCfgArrayProvisionActionResponseWrapper struct {
+	// in:body
+	Body []*output.ProvisionAction
+}
+
+// swagger:response idOfArrayProcessorCfgResp
+type rtCfgArrayProcessorCfgResponseWrapper struct {
+	// in:body
+	Body []*config.ProcessorCfg
+}
+
+// swagger:response idOfArrayCredentialCfgResp
+type rtCfgArrayCredentialCfgResponseWrapper struct {
+	// in:body
+	Body []*config.CredentialCfg
+}
+
+// swagger:response idOfArrayTrapRuleCfgResp
+type rtCfgArrayTrapRuleCfgResponseWrapper struct {
+	// in:body
+	Body []*config.TrapRuleCfg
+}
+
+// swagger:response idOfArrayOutputInfoResp
+type rtCfgArrayOutputInfoResponseWrapper struct {
+	// in:body
+	Body []*config.OutputInfo
+}
+
 // swagger:response idOfArrayCustomFilterCfgResp
 type rtCfgArrayCustomFilterCfgResponseWrapper struct {
 	// in:body
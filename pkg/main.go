@@ -21,6 +21,7 @@ import (
 	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
 	"github.com/toni-moreno/snmpcollector/pkg/agent/selfmon"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/trap"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/impexp"
 	"github.com/toni-moreno/snmpcollector/pkg/data/measurement"
@@ -170,7 +171,9 @@ func init() {
 	snmp.SetLogDir(logDir)
 
 	output.SetLogger(log)
+	output.SetDataDir(dataDir)
 	selfmon.SetLogger(log)
+	trap.SetLogger(log)
 	// devices needs access to all db loaded data
 	device.SetDBConfig(&agent.DBConfig)
 	device.SetLogDir(logDir)
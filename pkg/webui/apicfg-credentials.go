@@ -0,0 +1,237 @@
+package webui
+
+import (
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgCredential Credential API REST creator
+func NewAPICfgCredential(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/credentials", func() {
+		m.Get("/", reqSignedIn, GetCredential)
+		m.Get("/:id", reqSignedIn, GetCredentialByID)
+		m.Post("/", reqSignedIn, bind(config.CredentialCfg{}), AddCredential)
+		m.Put("/:id", reqSignedIn, bind(config.CredentialCfg{}), UpdateCredential)
+		m.Delete("/:id", reqSignedIn, DeleteCredential)
+		m.Get("/checkondel/:id", reqSignedIn, GetCredentialAffectOnDel)
+	})
+
+	return nil
+}
+
+// GetCredential Return Credential Array
+func GetCredential(ctx *Context) {
+	// swagger:operation GET /cfg/credentials  Config_Credentials GetCredential
+	//---
+	// summary: Get All Credentials Config Items from DB
+	// description: Get All Credentials config Items as an array from DB
+	// tags:
+	// - "Credentials Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayCredentialCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetCredentialCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get credential profile :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting Credentials %+v", &cfgarray)
+}
+
+// GetCredentialByID --pending--
+func GetCredentialByID(ctx *Context) {
+	// swagger:operation GET /cfg/credentials/{id}  Config_Credentials GetCredentialByID
+	//---
+	// summary: Get Credential Config from DB
+	// description: Get Credentials config info by ID from DB
+	// tags:
+	// - "Credentials Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Credential to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/CredentialCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetCredentialCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get credential profile data for credential profile %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddCredential Insert new credentials to de internal BBDD --pending--
+func AddCredential(ctx *Context, dev config.CredentialCfg) {
+	// swagger:operation POST /cfg/credentials Config_Credentials AddCredential
+	//---
+	// summary: Add new Credential Config
+	// description: Add Credential from Data
+	// tags:
+	// - "Credentials Config"
+	//
+	// parameters:
+	// - name: CredentialCfg
+	//   in: body
+	//   description: CredentialConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/CredentialCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/CredentialCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING Credential %+v", dev)
+	affected, err := agent.MainConfig.Database.AddCredentialCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Credential %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateCredential --pending--
+func UpdateCredential(ctx *Context, dev config.CredentialCfg) {
+	// swagger:operation PUT /cfg/credentials/{id} Config_Credentials UpdateCredential
+	//---
+	// summary: Update Credential Config
+	// description: Update Credential from Data with specified ID
+	// tags:
+	// - "Credentials Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Credential Config ID to update
+	//   required: true
+	//   type: string
+	// - name: CredentialCfg
+	//   in: body
+	//   description: Credential to update
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/CredentialCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/CredentialCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateCredentialCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update credential profile %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteCredential --pending--
+func DeleteCredential(ctx *Context) {
+	// swagger:operation DELETE /cfg/credentials/{id} Config_Credentials DeleteCredential
+	//---
+	// summary: Delete Credential Config on DB
+	// description: Delete Credential on DB with specified ID
+	// tags:
+	// - "Credentials Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Credential ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelCredentialCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete credential profile %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetCredentialAffectOnDel --pending--
+func GetCredentialAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/credentials/checkondel/{id} Config_Credentials GetCredentialAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the Credential.
+	// tags:
+	// - "Credentials Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The Credential ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetCredentialCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for credential profile %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
@@ -0,0 +1,237 @@
+package webui
+
+import (
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgFileOutput FileOutput API REST creator
+func NewAPICfgFileOutput(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/fileoutputs", func() {
+		m.Get("/", reqSignedIn, GetFileOutput)
+		m.Get("/:id", reqSignedIn, GetFileOutputByID)
+		m.Post("/", reqSignedIn, bind(config.FileCfg{}), AddFileOutput)
+		m.Put("/:id", reqSignedIn, bind(config.FileCfg{}), UpdateFileOutput)
+		m.Delete("/:id", reqSignedIn, DeleteFileOutput)
+		m.Get("/checkondel/:id", reqSignedIn, GetFileAffectOnDel)
+	})
+
+	return nil
+}
+
+// GetFileOutput Return File Output Array
+func GetFileOutput(ctx *Context) {
+	// swagger:operation GET /cfg/fileoutputs  Config_FileOutputs GetFileOutput
+	//---
+	// summary: Get All File Outputs Config Items from DB
+	// description: Get All File Outputs config Items as an array from DB
+	// tags:
+	// - "File Outputs Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayFileCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetFileCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get file output :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting File Outputs %+v", &cfgarray)
+}
+
+// GetFileOutputByID --pending--
+func GetFileOutputByID(ctx *Context) {
+	// swagger:operation GET /cfg/fileoutputs/{id}  Config_FileOutputs GetFileOutputByID
+	//---
+	// summary: Get FileOutput Config from DB
+	// description: Get FileOutputs config info by ID from DB
+	// tags:
+	// - "File Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: FileOutput to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/FileCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetFileCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get file output data for device %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddFileOutput Insert new file outputs to de internal BBDD --pending--
+func AddFileOutput(ctx *Context, dev config.FileCfg) {
+	// swagger:operation POST /cfg/fileoutputs Config_FileOutputs AddFileOutput
+	//---
+	// summary: Add new File Output Config
+	// description: Add FileOutput from Data
+	// tags:
+	// - "File Outputs Config"
+	//
+	// parameters:
+	// - name: FileCfg
+	//   in: body
+	//   description: FileConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/FileCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/FileCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING File Output %+v", dev)
+	affected, err := agent.MainConfig.Database.AddFileCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Backend %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateFileOutput --pending--
+func UpdateFileOutput(ctx *Context, dev config.FileCfg) {
+	// swagger:operation PUT /cfg/fileoutputs/{id} Config_FileOutputs UpdateFileOutput
+	//---
+	// summary: Update File Output Config
+	// description: Update FileOutput from Data with specified ID
+	// tags:
+	// - "File Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: File Output Config ID to update
+	//   required: true
+	//   type: string
+	// - name: FileCfg
+	//   in: body
+	//   description: Metric to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/FileCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/FileCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateFileCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update file output %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteFileOutput --pending--
+func DeleteFileOutput(ctx *Context) {
+	// swagger:operation DELETE /cfg/fileoutputs/{id} Config_FileOutputs DeleteFileOutput
+	//---
+	// summary: Delete File Output Config on DB
+	// description: Delete File Output on DB with specified ID
+	// tags:
+	// - "File Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: File Output ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelFileCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete file output %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetFileAffectOnDel --pending--
+func GetFileAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/fileoutputs/checkondel/{id} Config_FileOutputs GetFileAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the FileOutput.
+	// tags:
+	// - "File Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The File Output ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetFileCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for file output %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
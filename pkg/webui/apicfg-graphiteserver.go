@@ -0,0 +1,286 @@
+package webui
+
+import (
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgGraphiteServer GraphiteServer API REST creator
+func NewAPICfgGraphiteServer(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/graphiteservers", func() {
+		m.Get("/", reqSignedIn, GetGraphiteServer)
+		m.Get("/:id", reqSignedIn, GetGraphiteServerByID)
+		m.Post("/", reqSignedIn, bind(config.GraphiteCfg{}), AddGraphiteServer)
+		m.Put("/:id", reqSignedIn, bind(config.GraphiteCfg{}), UpdateGraphiteServer)
+		m.Delete("/:id", reqSignedIn, DeleteGraphiteServer)
+		m.Get("/checkondel/:id", reqSignedIn, GetGraphiteAffectOnDel)
+		m.Post("/ping/", reqSignedIn, bind(config.GraphiteCfg{}), PingGraphiteServer)
+	})
+
+	return nil
+}
+
+// GetGraphiteServer Return Server Array
+func GetGraphiteServer(ctx *Context) {
+	// swagger:operation GET /cfg/graphiteservers  Config_GraphiteServers GetGraphiteServer
+	//---
+	// summary: Get All Graphite Servers Config Items from DB
+	// description: Get All Graphite Servers config Items as an array from DB
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayGraphiteCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetGraphiteCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get Graphite server :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting Graphite Servers %+v", &cfgarray)
+}
+
+// GetGraphiteServerByID --pending--
+func GetGraphiteServerByID(ctx *Context) {
+	// swagger:operation GET /cfg/graphiteservers/{id}  Config_GraphiteServers GetGraphiteServerByID
+	//---
+	// summary: Get GraphiteServer Config from DB
+	// description: Get GraphiteServers config info by ID from DB
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: GraphiteServer to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetGraphiteCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get Graphite server data for device %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddGraphiteServer Insert new graphite servers to de internal BBDD --pending--
+func AddGraphiteServer(ctx *Context, dev config.GraphiteCfg) {
+	// swagger:operation POST /cfg/graphiteservers Config_GraphiteServers AddGraphiteServer
+	//---
+	// summary: Add new Graphite Server Config
+	// description: Add GraphiteServer from Data
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// parameters:
+	// - name: GraphiteCfg
+	//   in: body
+	//   description: GraphiteConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING Graphite Backend %+v", dev)
+	affected, err := agent.MainConfig.Database.AddGraphiteCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Backend %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateGraphiteServer --pending--
+func UpdateGraphiteServer(ctx *Context, dev config.GraphiteCfg) {
+	// swagger:operation PUT /cfg/graphiteservers/{id} Config_GraphiteServers UpdateGraphiteServer
+	//---
+	// summary: Update Graphite Server Config
+	// description: Update GraphiteServer from Data with specified ID
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Graphite Config ID to update
+	//   required: true
+	//   type: string
+	// - name: GraphiteCfg
+	//   in: body
+	//   description: Metric to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateGraphiteCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update Graphite server %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteGraphiteServer --pending--
+func DeleteGraphiteServer(ctx *Context) {
+	// swagger:operation DELETE /cfg/graphiteservers/{id} Config_GraphiteServers DeleteGraphiteServer
+	//---
+	// summary: Delete Graphite Server Config on DB
+	// description: Delete Graphite Server on DB with specified ID
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Graphite Server ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelGraphiteCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete graphite server %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetGraphiteAffectOnDel --pending--
+func GetGraphiteAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/graphiteservers/checkondel/{id} Config_GraphiteServers GetGraphiteAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the GraphiteServer.
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The Graphite Server ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetGraphiteCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for graphite server %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
+
+// PingGraphiteServer Return ping result
+func PingGraphiteServer(ctx *Context, cfg config.GraphiteCfg) {
+	// swagger:operation POST /cfg/graphiteservers/ping Config_GraphiteServers PingGraphiteServer
+	//---
+	// summary: Connection Test (Ping) to the Graphite Server
+	// description: Performs a Test TCP Connection to the Graphite Server With specified Config in the Body
+	// tags:
+	// - "Graphite Servers Config"
+	//
+	// parameters:
+	// - name: GraphiteCfg
+	//   in: body
+	//   description: GraphiteConfig to ping
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/GraphiteCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+
+	log.Infof("trying to ping graphite server %s : %+v", cfg.ID, cfg)
+	elapsed, message, err := output.PingGraphite(&cfg)
+	type result struct {
+		Result  string
+		Elapsed time.Duration
+		Message string
+	}
+	if err != nil {
+		log.Debugf("ERROR on ping Graphite Server : %s", err)
+		res := result{Result: "NOOK", Elapsed: elapsed, Message: err.Error()}
+		ctx.JSON(400, res)
+	} else {
+		log.Debugf("OK on ping Graphite Server %+v, %+v", elapsed, message)
+		res := result{Result: "OK", Elapsed: elapsed, Message: message}
+		ctx.JSON(200, res)
+	}
+}
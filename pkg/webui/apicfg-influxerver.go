@@ -22,6 +22,7 @@ func NewAPICfgInfluxServer(m *macaron.Macaron) error {
 		m.Delete("/:id", reqSignedIn, DeleteInfluxServer)
 		m.Get("/checkondel/:id", reqSignedIn, GetInfluxAffectOnDel)
 		m.Post("/ping/", reqSignedIn, bind(config.InfluxCfg{}), PingInfluxServer)
+		m.Get("/provision/:id", reqSignedIn, GetInfluxProvisionPlan)
 	})
 
 	return nil
@@ -239,6 +240,56 @@ func GetInfluxAffectOnDel(ctx *Context) {
 	}
 }
 
+// GetInfluxProvisionPlan Return the statements needed to provision the server
+func GetInfluxProvisionPlan(ctx *Context) {
+	// swagger:operation GET /cfg/influxservers/provision/{id} Config_InfluxServers GetInfluxProvisionPlan
+	//---
+	// summary: Check the InfluxServer provisioning.
+	// description: Get the statements needed to create the database, retention policy and continuous queries of the InfluxServer (nothing is changed on the server).
+	// tags:
+	// - "Influx Servers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The InfluxServer ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Provision Action Array
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayProvisionActionResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	cfg, err := agent.MainConfig.Database.GetInfluxCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get Influx db data for device %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	cli, _, _, err := output.Ping(&cfg)
+	if cli != nil {
+		defer cli.Close()
+	}
+	if err != nil {
+		log.Warningf("Error on connect to influx server %s , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	actions, err := output.ProvisionPlan(cli, &cfg)
+	if err != nil {
+		log.Warningf("Error on get provision plan for influx server %s , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, &actions)
+}
+
 // PingInfluxServer Return ping result
 func PingInfluxServer(ctx *Context, cfg config.InfluxCfg) {
 	// swagger:operation POST /cfg/influxservers/ping Config_InfluxServers PingInfluxServer
@@ -267,7 +318,10 @@ func PingInfluxServer(ctx *Context, cfg config.InfluxCfg) {
 	//       "$ref": "#/responses/idOfStringResp"
 
 	log.Infof("trying to ping influx server %s : %+v", cfg.ID, cfg)
-	_, elapsed, message, err := output.Ping(&cfg)
+	cli, elapsed, message, err := output.Ping(&cfg)
+	if cli != nil {
+		cli.Close()
+	}
 	type result struct {
 		Result  string
 		Elapsed time.Duration
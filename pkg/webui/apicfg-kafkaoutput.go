@@ -0,0 +1,286 @@
+package webui
+
+import (
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgKafkaOutput KafkaOutput API REST creator
+func NewAPICfgKafkaOutput(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/kafkaoutputs", func() {
+		m.Get("/", reqSignedIn, GetKafkaOutput)
+		m.Get("/:id", reqSignedIn, GetKafkaOutputByID)
+		m.Post("/", reqSignedIn, bind(config.KafkaCfg{}), AddKafkaOutput)
+		m.Put("/:id", reqSignedIn, bind(config.KafkaCfg{}), UpdateKafkaOutput)
+		m.Delete("/:id", reqSignedIn, DeleteKafkaOutput)
+		m.Get("/checkondel/:id", reqSignedIn, GetKafkaAffectOnDel)
+		m.Post("/ping/", reqSignedIn, bind(config.KafkaCfg{}), PingKafkaOutput)
+	})
+
+	return nil
+}
+
+// GetKafkaOutput Return Server Array
+func GetKafkaOutput(ctx *Context) {
+	// swagger:operation GET /cfg/kafkaoutputs  Config_KafkaOutputs GetKafkaOutput
+	//---
+	// summary: Get All Kafka Outputs Config Items from DB
+	// description: Get All Kafka Outputs config Items as an array from DB
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayKafkaCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetKafkaCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get kafka output :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting Kafka Outputs %+v", &cfgarray)
+}
+
+// GetKafkaOutputByID --pending--
+func GetKafkaOutputByID(ctx *Context) {
+	// swagger:operation GET /cfg/kafkaoutputs/{id}  Config_KafkaOutputs GetKafkaOutputByID
+	//---
+	// summary: Get KafkaOutput Config from DB
+	// description: Get KafkaOutputs config info by ID from DB
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: KafkaOutput to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetKafkaCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get kafka output data for device %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddKafkaOutput Insert new kafka outputs to de internal BBDD --pending--
+func AddKafkaOutput(ctx *Context, dev config.KafkaCfg) {
+	// swagger:operation POST /cfg/kafkaoutputs Config_KafkaOutputs AddKafkaOutput
+	//---
+	// summary: Add new Kafka Output Config
+	// description: Add KafkaOutput from Data
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// parameters:
+	// - name: KafkaCfg
+	//   in: body
+	//   description: KafkaConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING Kafka Backend %+v", dev)
+	affected, err := agent.MainConfig.Database.AddKafkaCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Backend %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateKafkaOutput --pending--
+func UpdateKafkaOutput(ctx *Context, dev config.KafkaCfg) {
+	// swagger:operation PUT /cfg/kafkaoutputs/{id} Config_KafkaOutputs UpdateKafkaOutput
+	//---
+	// summary: Update Kafka Output Config
+	// description: Update KafkaOutput from Data with specified ID
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Kafka Config ID to update
+	//   required: true
+	//   type: string
+	// - name: KafkaCfg
+	//   in: body
+	//   description: Metric to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateKafkaCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update kafka output %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteKafkaOutput --pending--
+func DeleteKafkaOutput(ctx *Context) {
+	// swagger:operation DELETE /cfg/kafkaoutputs/{id} Config_KafkaOutputs DeleteKafkaOutput
+	//---
+	// summary: Delete Kafka Output Config on DB
+	// description: Delete Kafka Output on DB with specified ID
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Kafka Output ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelKafkaCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete kafka output %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetKafkaAffectOnDel --pending--
+func GetKafkaAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/kafkaoutputs/checkondel/{id} Config_KafkaOutputs GetKafkaAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the KafkaOutput.
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The Kafka Output ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetKafkaCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for kafka output %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
+
+// PingKafkaOutput Return ping result
+func PingKafkaOutput(ctx *Context, cfg config.KafkaCfg) {
+	// swagger:operation POST /cfg/kafkaoutputs/ping Config_KafkaOutputs PingKafkaOutput
+	//---
+	// summary: Connection Test (Ping) to the Kafka cluster
+	// description: Performs a Test Metadata Request to the Kafka bootstrap brokers With specified Config in the Body
+	// tags:
+	// - "Kafka Outputs Config"
+	//
+	// parameters:
+	// - name: KafkaCfg
+	//   in: body
+	//   description: KafkaConfig to ping
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/KafkaCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+
+	log.Infof("trying to ping kafka output %s : %+v", cfg.ID, cfg)
+	elapsed, message, err := output.PingKafka(&cfg)
+	type result struct {
+		Result  string
+		Elapsed time.Duration
+		Message string
+	}
+	if err != nil {
+		log.Debugf("ERROR on ping Kafka Output : %s", err)
+		res := result{Result: "NOOK", Elapsed: elapsed, Message: err.Error()}
+		ctx.JSON(400, res)
+	} else {
+		log.Debugf("OK on ping Kafka Output %+v, %+v", elapsed, message)
+		res := result{Result: "OK", Elapsed: elapsed, Message: message}
+		ctx.JSON(200, res)
+	}
+}
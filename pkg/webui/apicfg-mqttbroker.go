@@ -0,0 +1,286 @@
+package webui
+
+import (
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgMqttBroker MqttBroker API REST creator
+func NewAPICfgMqttBroker(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/mqttbrokers", func() {
+		m.Get("/", reqSignedIn, GetMqttBroker)
+		m.Get("/:id", reqSignedIn, GetMqttBrokerByID)
+		m.Post("/", reqSignedIn, bind(config.MqttCfg{}), AddMqttBroker)
+		m.Put("/:id", reqSignedIn, bind(config.MqttCfg{}), UpdateMqttBroker)
+		m.Delete("/:id", reqSignedIn, DeleteMqttBroker)
+		m.Get("/checkondel/:id", reqSignedIn, GetMqttAffectOnDel)
+		m.Post("/ping/", reqSignedIn, bind(config.MqttCfg{}), PingMqttBroker)
+	})
+
+	return nil
+}
+
+// GetMqttBroker Return Server Array
+func GetMqttBroker(ctx *Context) {
+	// swagger:operation GET /cfg/mqttbrokers  Config_MqttBrokers GetMqttBroker
+	//---
+	// summary: Get All MQTT Brokers Config Items from DB
+	// description: Get All MQTT Brokers config Items as an array from DB
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayMqttCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetMqttCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get MQTT broker :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting MQTT Brokers %+v", &cfgarray)
+}
+
+// GetMqttBrokerByID --pending--
+func GetMqttBrokerByID(ctx *Context) {
+	// swagger:operation GET /cfg/mqttbrokers/{id}  Config_MqttBrokers GetMqttBrokerByID
+	//---
+	// summary: Get MqttBroker Config from DB
+	// description: Get MqttBrokers config info by ID from DB
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: MqttBroker to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetMqttCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get MQTT broker data for device %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddMqttBroker Insert new MQTT brokers to de internal BBDD --pending--
+func AddMqttBroker(ctx *Context, dev config.MqttCfg) {
+	// swagger:operation POST /cfg/mqttbrokers Config_MqttBrokers AddMqttBroker
+	//---
+	// summary: Add new MQTT Broker Config
+	// description: Add MqttBroker from Data
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// parameters:
+	// - name: MqttCfg
+	//   in: body
+	//   description: MqttConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING MQTT Backend %+v", dev)
+	affected, err := agent.MainConfig.Database.AddMqttCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Backend %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateMqttBroker --pending--
+func UpdateMqttBroker(ctx *Context, dev config.MqttCfg) {
+	// swagger:operation PUT /cfg/mqttbrokers/{id} Config_MqttBrokers UpdateMqttBroker
+	//---
+	// summary: Update MQTT Broker Config
+	// description: Update MqttBroker from Data with specified ID
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: MQTT Config ID to update
+	//   required: true
+	//   type: string
+	// - name: MqttCfg
+	//   in: body
+	//   description: Metric to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateMqttCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update MQTT broker %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteMqttBroker --pending--
+func DeleteMqttBroker(ctx *Context) {
+	// swagger:operation DELETE /cfg/mqttbrokers/{id} Config_MqttBrokers DeleteMqttBroker
+	//---
+	// summary: Delete MQTT Broker Config on DB
+	// description: Delete MQTT Broker on DB with specified ID
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: MQTT Broker ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelMqttCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete MQTT broker %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetMqttAffectOnDel --pending--
+func GetMqttAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/mqttbrokers/checkondel/{id} Config_MqttBrokers GetMqttAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the MqttBroker.
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The MQTT Broker ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetMqttCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for MQTT broker %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
+
+// PingMqttBroker Return ping result
+func PingMqttBroker(ctx *Context, cfg config.MqttCfg) {
+	// swagger:operation POST /cfg/mqttbrokers/ping Config_MqttBrokers PingMqttBroker
+	//---
+	// summary: Connection Test (Ping) to the MQTT Broker
+	// description: Performs a Test MQTT Connection (CONNECT/CONNACK) to the MQTT Broker With specified Config in the Body
+	// tags:
+	// - "MQTT Brokers Config"
+	//
+	// parameters:
+	// - name: MqttCfg
+	//   in: body
+	//   description: MqttConfig to ping
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/MqttCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+
+	log.Infof("trying to ping MQTT broker %s : %+v", cfg.ID, cfg)
+	elapsed, message, err := output.PingMqtt(&cfg)
+	type result struct {
+		Result  string
+		Elapsed time.Duration
+		Message string
+	}
+	if err != nil {
+		log.Debugf("ERROR on ping MQTT Broker : %s", err)
+		res := result{Result: "NOOK", Elapsed: elapsed, Message: err.Error()}
+		ctx.JSON(400, res)
+	} else {
+		log.Debugf("OK on ping MQTT Broker %+v, %+v", elapsed, message)
+		res := result{Result: "OK", Elapsed: elapsed, Message: message}
+		ctx.JSON(200, res)
+	}
+}
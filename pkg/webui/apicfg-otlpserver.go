@@ -0,0 +1,286 @@
+package webui
+
+import (
+	"time"
+
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgOtlpServer OtlpServer API REST creator
+func NewAPICfgOtlpServer(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/otlpservers", func() {
+		m.Get("/", reqSignedIn, GetOtlpServer)
+		m.Get("/:id", reqSignedIn, GetOtlpServerByID)
+		m.Post("/", reqSignedIn, bind(config.OtlpCfg{}), AddOtlpServer)
+		m.Put("/:id", reqSignedIn, bind(config.OtlpCfg{}), UpdateOtlpServer)
+		m.Delete("/:id", reqSignedIn, DeleteOtlpServer)
+		m.Get("/checkondel/:id", reqSignedIn, GetOtlpAffectOnDel)
+		m.Post("/ping/", reqSignedIn, bind(config.OtlpCfg{}), PingOtlpServer)
+	})
+
+	return nil
+}
+
+// GetOtlpServer Return Server Array
+func GetOtlpServer(ctx *Context) {
+	// swagger:operation GET /cfg/otlpservers  Config_OtlpServers GetOtlpServer
+	//---
+	// summary: Get All OTLP Receivers Config Items from DB
+	// description: Get All OTLP Receivers config Items as an array from DB
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayOtlpCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetOtlpCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get OTLP receiver :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting OTLP Receivers %+v", &cfgarray)
+}
+
+// GetOtlpServerByID --pending--
+func GetOtlpServerByID(ctx *Context) {
+	// swagger:operation GET /cfg/otlpservers/{id}  Config_OtlpServers GetOtlpServerByID
+	//---
+	// summary: Get OtlpServer Config from DB
+	// description: Get OtlpServers config info by ID from DB
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: OtlpServer to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetOtlpCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get OTLP receiver data for device %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddOtlpServer Insert new OTLP receivers to de internal BBDD --pending--
+func AddOtlpServer(ctx *Context, dev config.OtlpCfg) {
+	// swagger:operation POST /cfg/otlpservers Config_OtlpServers AddOtlpServer
+	//---
+	// summary: Add new OTLP Receiver Config
+	// description: Add OtlpServer from Data
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// parameters:
+	// - name: OtlpCfg
+	//   in: body
+	//   description: OtlpConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING OTLP Backend %+v", dev)
+	affected, err := agent.MainConfig.Database.AddOtlpCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Backend %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateOtlpServer --pending--
+func UpdateOtlpServer(ctx *Context, dev config.OtlpCfg) {
+	// swagger:operation PUT /cfg/otlpservers/{id} Config_OtlpServers UpdateOtlpServer
+	//---
+	// summary: Update OTLP Receiver Config
+	// description: Update OtlpServer from Data with specified ID
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: OTLP Config ID to update
+	//   required: true
+	//   type: string
+	// - name: OtlpCfg
+	//   in: body
+	//   description: Metric to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateOtlpCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update OTLP receiver %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteOtlpServer --pending--
+func DeleteOtlpServer(ctx *Context) {
+	// swagger:operation DELETE /cfg/otlpservers/{id} Config_OtlpServers DeleteOtlpServer
+	//---
+	// summary: Delete OTLP Receiver Config on DB
+	// description: Delete OTLP Receiver on DB with specified ID
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: OTLP Receiver ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelOtlpCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete OTLP receiver %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetOtlpAffectOnDel --pending--
+func GetOtlpAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/otlpservers/checkondel/{id} Config_OtlpServers GetOtlpAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the OtlpServer.
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The OTLP Receiver ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetOtlpCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for OTLP receiver %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
+
+// PingOtlpServer Return ping result
+func PingOtlpServer(ctx *Context, cfg config.OtlpCfg) {
+	// swagger:operation POST /cfg/otlpservers/ping Config_OtlpServers PingOtlpServer
+	//---
+	// summary: Connection Test (Ping) to the OTLP Receiver
+	// description: Sends an empty metrics request to the OTLP Receiver With specified Config in the Body
+	// tags:
+	// - "OTLP Receivers Config"
+	//
+	// parameters:
+	// - name: OtlpCfg
+	//   in: body
+	//   description: OtlpConfig to ping
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/OtlpCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+
+	log.Infof("trying to ping OTLP receiver %s : %+v", cfg.ID, cfg)
+	elapsed, message, err := output.PingOtlp(&cfg)
+	type result struct {
+		Result  string
+		Elapsed time.Duration
+		Message string
+	}
+	if err != nil {
+		log.Debugf("ERROR on ping OTLP Receiver : %s", err)
+		res := result{Result: "NOOK", Elapsed: elapsed, Message: err.Error()}
+		ctx.JSON(400, res)
+	} else {
+		log.Debugf("OK on ping OTLP Receiver %+v, %+v", elapsed, message)
+		res := result{Result: "OK", Elapsed: elapsed, Message: message}
+		ctx.JSON(200, res)
+	}
+}
@@ -0,0 +1,43 @@
+package webui
+
+import (
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgOutputs Outputs API REST creator
+func NewAPICfgOutputs(m *macaron.Macaron) error {
+	m.Group("/api/cfg/outputs", func() {
+		m.Get("/", reqSignedIn, GetOutputs)
+	})
+
+	return nil
+}
+
+// GetOutputs Return all configured output backends
+func GetOutputs(ctx *Context) {
+	// swagger:operation GET /cfg/outputs  Config_Outputs GetOutputs
+	//---
+	// summary: Get All configured Outputs from DB
+	// description: Get ID, type and description of all configured output backends (influxdb, graphite, ...) from DB
+	// tags:
+	// - "Outputs Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayOutputInfoResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetOutputArray()
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get outputs :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting Outputs %+v", &cfgarray)
+}
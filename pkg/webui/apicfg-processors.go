@@ -0,0 +1,237 @@
+package webui
+
+import (
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgProcessor Processor API REST creator
+func NewAPICfgProcessor(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/processors", func() {
+		m.Get("/", reqSignedIn, GetProcessor)
+		m.Get("/:id", reqSignedIn, GetProcessorByID)
+		m.Post("/", reqSignedIn, bind(config.ProcessorCfg{}), AddProcessor)
+		m.Put("/:id", reqSignedIn, bind(config.ProcessorCfg{}), UpdateProcessor)
+		m.Delete("/:id", reqSignedIn, DeleteProcessor)
+		m.Get("/checkondel/:id", reqSignedIn, GetProcessorAffectOnDel)
+	})
+
+	return nil
+}
+
+// GetProcessor Return Processor Array
+func GetProcessor(ctx *Context) {
+	// swagger:operation GET /cfg/processors  Config_Processors GetProcessor
+	//---
+	// summary: Get All Processors Config Items from DB
+	// description: Get All Processors config Items as an array from DB
+	// tags:
+	// - "Processors Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayProcessorCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetProcessorCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get processor :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting Processors %+v", &cfgarray)
+}
+
+// GetProcessorByID --pending--
+func GetProcessorByID(ctx *Context) {
+	// swagger:operation GET /cfg/processors/{id}  Config_Processors GetProcessorByID
+	//---
+	// summary: Get Processor Config from DB
+	// description: Get Processors config info by ID from DB
+	// tags:
+	// - "Processors Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Processor to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/ProcessorCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetProcessorCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get processor data for processor %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddProcessor Insert new processors to de internal BBDD --pending--
+func AddProcessor(ctx *Context, dev config.ProcessorCfg) {
+	// swagger:operation POST /cfg/processors Config_Processors AddProcessor
+	//---
+	// summary: Add new Processor Config
+	// description: Add Processor from Data
+	// tags:
+	// - "Processors Config"
+	//
+	// parameters:
+	// - name: ProcessorCfg
+	//   in: body
+	//   description: ProcessorConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/ProcessorCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/ProcessorCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING Processor %+v", dev)
+	affected, err := agent.MainConfig.Database.AddProcessorCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new Processor %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateProcessor --pending--
+func UpdateProcessor(ctx *Context, dev config.ProcessorCfg) {
+	// swagger:operation PUT /cfg/processors/{id} Config_Processors UpdateProcessor
+	//---
+	// summary: Update Processor Config
+	// description: Update Processor from Data with specified ID
+	// tags:
+	// - "Processors Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Processor Config ID to update
+	//   required: true
+	//   type: string
+	// - name: ProcessorCfg
+	//   in: body
+	//   description: Processor to update
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/ProcessorCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/ProcessorCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateProcessorCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update processor %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteProcessor --pending--
+func DeleteProcessor(ctx *Context) {
+	// swagger:operation DELETE /cfg/processors/{id} Config_Processors DeleteProcessor
+	//---
+	// summary: Delete Processor Config on DB
+	// description: Delete Processor on DB with specified ID
+	// tags:
+	// - "Processors Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: Processor ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelProcessorCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete processor %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetProcessorAffectOnDel --pending--
+func GetProcessorAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/processors/checkondel/{id} Config_Processors GetProcessorAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the Processor.
+	// tags:
+	// - "Processors Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The Processor ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetProcessorCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for processor %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
@@ -116,28 +116,40 @@ func addDeviceOnline(mode string, id string, dev *config.SnmpDeviceCfg) error {
 	})
 	l.Infof("trying to ping device, config: %+v", dev)
 
+	// the device config is saved as is, without the profile credentials
+	cdev := *dev
+	credentials, err := getCredentialSet(dev)
+	if err != nil {
+		return fmt.Errorf("SNMP credentials: %v", err)
+	}
+	if err := agent.MainConfig.Database.ResolveSnmpDeviceCredential(&cdev); err != nil {
+		return fmt.Errorf("SNMP credentials: %v", err)
+	}
+
 	connectionParams := snmp.ConnectionParams{
 		Host:           dev.Host,
 		Port:           dev.Port,
+		Transport:      dev.Transport,
 		Timeout:        dev.Timeout,
 		Retries:        dev.Retries,
-		SnmpVersion:    dev.SnmpVersion,
-		Community:      dev.Community,
+		SnmpVersion:    cdev.SnmpVersion,
+		Community:      cdev.Community,
 		MaxRepetitions: dev.MaxRepetitions,
 		MaxOids:        dev.MaxOids,
 		Debug:          dev.SnmpDebug,
 		V3Params: snmp.V3Params{
-			SecLevel:        dev.V3SecLevel,
-			AuthUser:        dev.V3AuthUser,
-			AuthPass:        dev.V3AuthPass,
-			PrivPass:        dev.V3PrivPass,
-			PrivProt:        dev.V3PrivProt,
-			AuthProt:        dev.V3AuthProt,
-			ContextName:     dev.V3ContextName,
-			ContextEngineID: dev.V3ContextEngineID,
+			SecLevel:        cdev.V3SecLevel,
+			AuthUser:        cdev.V3AuthUser,
+			AuthPass:        cdev.V3AuthPass,
+			PrivPass:        cdev.V3PrivPass,
+			PrivProt:        cdev.V3PrivProt,
+			AuthProt:        cdev.V3AuthProt,
+			ContextName:     cdev.V3ContextName,
+			ContextEngineID: cdev.V3ContextEngineID,
 		},
+		Credentials: credentials,
 	}
-	err := connectionParams.Validation()
+	err = connectionParams.Validation()
 	if err != nil {
 		return fmt.Errorf("SNMP parameter validation: %v", err)
 	}
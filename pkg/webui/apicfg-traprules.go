@@ -0,0 +1,237 @@
+package webui
+
+import (
+	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/config"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPICfgTrapRule TrapRule API REST creator
+func NewAPICfgTrapRule(m *macaron.Macaron) error {
+	bind := binding.Bind
+
+	m.Group("/api/cfg/traprules", func() {
+		m.Get("/", reqSignedIn, GetTrapRule)
+		m.Get("/:id", reqSignedIn, GetTrapRuleByID)
+		m.Post("/", reqSignedIn, bind(config.TrapRuleCfg{}), AddTrapRule)
+		m.Put("/:id", reqSignedIn, bind(config.TrapRuleCfg{}), UpdateTrapRule)
+		m.Delete("/:id", reqSignedIn, DeleteTrapRule)
+		m.Get("/checkondel/:id", reqSignedIn, GetTrapRuleAffectOnDel)
+	})
+
+	return nil
+}
+
+// GetTrapRule Return TrapRule Array
+func GetTrapRule(ctx *Context) {
+	// swagger:operation GET /cfg/traprules  Config_TrapRules GetTrapRule
+	//---
+	// summary: Get All TrapRules Config Items from DB
+	// description: Get All TrapRules config Items as an array from DB
+	// tags:
+	// - "TrapRules Config"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfArrayTrapRuleCfgResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	cfgarray, err := agent.MainConfig.Database.GetTrapRuleCfgArray("")
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		log.Errorf("Error on get trap rule :%+s", err)
+		return
+	}
+	ctx.JSON(200, &cfgarray)
+	log.Debugf("Getting TrapRules %+v", &cfgarray)
+}
+
+// GetTrapRuleByID --pending--
+func GetTrapRuleByID(ctx *Context) {
+	// swagger:operation GET /cfg/traprules/{id}  Config_TrapRules GetTrapRuleByID
+	//---
+	// summary: Get TrapRule Config from DB
+	// description: Get TrapRules config info by ID from DB
+	// tags:
+	// - "TrapRules Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: TrapRule to get
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/TrapRuleCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	dev, err := agent.MainConfig.Database.GetTrapRuleCfgByID(id)
+	if err != nil {
+		log.Warningf("Error on get trap rule data for trap rule %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &dev)
+	}
+}
+
+// AddTrapRule Insert new traprules to de internal BBDD --pending--
+func AddTrapRule(ctx *Context, dev config.TrapRuleCfg) {
+	// swagger:operation POST /cfg/traprules Config_TrapRules AddTrapRule
+	//---
+	// summary: Add new TrapRule Config
+	// description: Add TrapRule from Data
+	// tags:
+	// - "TrapRules Config"
+	//
+	// parameters:
+	// - name: TrapRuleCfg
+	//   in: body
+	//   description: TrapRuleConfig to add
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/TrapRuleCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/TrapRuleCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	log.Printf("ADDING TrapRule %+v", dev)
+	affected, err := agent.MainConfig.Database.AddTrapRuleCfg(dev)
+	if err != nil {
+		log.Warningf("Error on insert new TrapRule %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return data  or affected
+		ctx.JSON(200, &dev)
+	}
+}
+
+// UpdateTrapRule --pending--
+func UpdateTrapRule(ctx *Context, dev config.TrapRuleCfg) {
+	// swagger:operation PUT /cfg/traprules/{id} Config_TrapRules UpdateTrapRule
+	//---
+	// summary: Update TrapRule Config
+	// description: Update TrapRule from Data with specified ID
+	// tags:
+	// - "TrapRules Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: TrapRule Config ID to update
+	//   required: true
+	//   type: string
+	// - name: TrapRuleCfg
+	//   in: body
+	//   description: TrapRule to update
+	//   required: true
+	//   schema:
+	//       "$ref": "#/definitions/TrapRuleCfg"
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/definitions/TrapRuleCfg"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to update: %+v", dev)
+	affected, err := agent.MainConfig.Database.UpdateTrapRuleCfg(id, dev)
+	if err != nil {
+		log.Warningf("Error on update trap rule %s  , affected : %+v , error: %s", dev.ID, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		// TODO: review if needed return device data
+		ctx.JSON(200, &dev)
+	}
+}
+
+// DeleteTrapRule --pending--
+func DeleteTrapRule(ctx *Context) {
+	// swagger:operation DELETE /cfg/traprules/{id} Config_TrapRules DeleteTrapRule
+	//---
+	// summary: Delete TrapRule Config on DB
+	// description: Delete TrapRule on DB with specified ID
+	// tags:
+	// - "TrapRules Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: TrapRule ID to delete
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: "OK"
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	log.Debugf("Tying to delete: %+v", id)
+	affected, err := agent.MainConfig.Database.DelTrapRuleCfg(id)
+	if err != nil {
+		log.Warningf("Error on delete trap rule %s  , affected : %+v , error: %s", id, affected, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, "deleted")
+	}
+}
+
+// GetTrapRuleAffectOnDel --pending--
+func GetTrapRuleAffectOnDel(ctx *Context) {
+	// swagger:operation GET /cfg/traprules/checkondel/{id} Config_TrapRules GetTrapRuleAffectOnDel
+	//---
+	// summary: Check affected sources.
+	// description: Get all existing Objects affected when deleted the TrapRule.
+	// tags:
+	// - "TrapRules Config"
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: The TrapRule ID to check
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: Object Array
+	//     schema:
+	//       "$ref": "#/responses/idOfCheckOnDelResp"
+	//   '404':
+	//     description: unexpected error
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	id := ctx.Params(":id")
+	obarray, err := agent.MainConfig.Database.GetTrapRuleCfgAffectOnDel(id)
+	if err != nil {
+		log.Warningf("Error on get object array for trap rule %s  , error: %s", id, err)
+		ctx.JSON(404, err.Error())
+	} else {
+		ctx.JSON(200, &obarray)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/go-macaron/binding"
 	"github.com/sirupsen/logrus"
 	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/device"
 	"github.com/toni-moreno/snmpcollector/pkg/config"
 	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
 	"gopkg.in/macaron.v1"
@@ -24,6 +25,8 @@ func NewAPIRtAgent(m *macaron.Macaron) error {
 		m.Post("/snmpconsole/ping/", reqSignedIn, bind(config.SnmpDeviceCfg{}), PingSNMPDevice)
 		m.Post("/snmpconsole/query/:getmode/:obtype/:data", reqSignedIn, bind(config.SnmpDeviceCfg{}), QuerySNMPDevice)
 		m.Get("/info/version/", RTGetVersion)
+		m.Get("/outputs/failover/", reqSignedIn, RTGetOutputsFailover)
+		m.Get("/traps/stats/", reqSignedIn, RTGetTrapStats)
 	})
 
 	return nil
@@ -56,6 +59,49 @@ func AgentReloadConf(ctx *Context) {
 	ctx.JSON(200, time)
 }
 
+// RTGetOutputsFailover returns the fail over status of all outputs with a standby backend
+func RTGetOutputsFailover(ctx *Context) {
+	// swagger:operation GET /rt/agent/outputs/failover Runtime_Agent RTGetOutputsFailover
+	//---
+	// summary: Get outputs fail over status
+	// description: Get the circuit breaker state and fail over counters of all outputs with a standby server
+	// tags:
+	// - "Runtime Agent"
+	//
+	// responses:
+	//   '200':
+	//     description: Fail over status by output ID
+	//     schema:
+	//       "$ref": "#/responses/idOfOutputsFailoverResp"
+	ctx.JSON(200, agent.GetOutputsFailover())
+}
+
+// RTGetTrapStats returns the trap receiver counters
+func RTGetTrapStats(ctx *Context) {
+	// swagger:operation GET /rt/agent/traps/stats Runtime_Agent RTGetTrapStats
+	//---
+	// summary: Get trap receiver stats
+	// description: Get the received, acknowledged and dropped traps counters since the trap receiver was started
+	// tags:
+	// - "Runtime Agent"
+	//
+	// responses:
+	//   '200':
+	//     description: Trap receiver counters
+	//     schema:
+	//       "$ref": "#/responses/idOfTrapStatsResp"
+	//   '404':
+	//     description: Trap receiver not enabled
+	//     schema:
+	//       "$ref": "#/responses/idOfStringResp"
+	st, err := agent.GetTrapStats()
+	if err != nil {
+		ctx.JSON(404, err.Error())
+		return
+	}
+	ctx.JSON(200, st)
+}
+
 // AgentShutdown xx
 func AgentShutdown(ctx *Context) {
 	// swagger:operation GET /rt/agent/shutdown Runtime_Agent AgentShutdown
@@ -109,9 +155,22 @@ func PingSNMPDevice(ctx *Context, cfg config.SnmpDeviceCfg) {
 	})
 	l.Infof("trying to ping device, config: %+v", cfg)
 
+	credentials, err := getCredentialSet(&cfg)
+	if err != nil {
+		l.Debugf("ERROR on query device : %s", err)
+		ctx.JSON(400, fmt.Errorf("SNMP credentials: %v", err))
+		return
+	}
+	if err := agent.MainConfig.Database.ResolveSnmpDeviceCredential(&cfg); err != nil {
+		l.Debugf("ERROR on query device : %s", err)
+		ctx.JSON(400, fmt.Errorf("SNMP credentials: %v", err))
+		return
+	}
+
 	connectionParams := snmp.ConnectionParams{
 		Host:           cfg.Host,
 		Port:           cfg.Port,
+		Transport:      cfg.Transport,
 		Timeout:        cfg.Timeout,
 		Retries:        cfg.Retries,
 		SnmpVersion:    cfg.SnmpVersion,
@@ -129,8 +188,9 @@ func PingSNMPDevice(ctx *Context, cfg config.SnmpDeviceCfg) {
 			ContextName:     cfg.V3ContextName,
 			ContextEngineID: cfg.V3ContextEngineID,
 		},
+		Credentials: credentials,
 	}
-	err := connectionParams.Validation()
+	err = connectionParams.Validation()
 	if err != nil {
 		l.Debugf("ERROR on query device : %s", err)
 		ctx.JSON(400, fmt.Errorf("SNMP parameter validation: %v", err))
@@ -154,6 +214,18 @@ func PingSNMPDevice(ctx *Context, cfg config.SnmpDeviceCfg) {
 	ctx.JSON(200, sysinfo)
 }
 
+// getCredentialSet returns the device credentials to try in order on connect, nil without fallback credentials
+func getCredentialSet(dev *config.SnmpDeviceCfg) (*snmp.CredentialSet, error) {
+	if len(dev.FallbackCredentials) == 0 {
+		return nil, nil
+	}
+	creds, err := agent.MainConfig.Database.GetCredentialCfgMap("")
+	if err != nil {
+		return nil, err
+	}
+	return device.NewCredentialSet(dev, creds)
+}
+
 // SnmpQueryResponse response for queries in the UI
 // swagger:model SnmpQueryResponse
 type SnmpQueryResponse struct {
@@ -223,9 +295,22 @@ func QuerySNMPDevice(ctx *Context, cfg config.SnmpDeviceCfg) {
 		return
 	}
 
+	credentials, err := getCredentialSet(&cfg)
+	if err != nil {
+		l.Debugf("ERROR on query device : %s", err)
+		ctx.JSON(400, fmt.Errorf("SNMP credentials: %v", err))
+		return
+	}
+	if err := agent.MainConfig.Database.ResolveSnmpDeviceCredential(&cfg); err != nil {
+		l.Debugf("ERROR on query device : %s", err)
+		ctx.JSON(400, fmt.Errorf("SNMP credentials: %v", err))
+		return
+	}
+
 	connectionParams := snmp.ConnectionParams{
 		Host:           cfg.Host,
 		Port:           cfg.Port,
+		Transport:      cfg.Transport,
 		Timeout:        cfg.Timeout,
 		Retries:        cfg.Retries,
 		SnmpVersion:    cfg.SnmpVersion,
@@ -243,8 +328,9 @@ func QuerySNMPDevice(ctx *Context, cfg config.SnmpDeviceCfg) {
 			ContextName:     cfg.V3ContextName,
 			ContextEngineID: cfg.V3ContextEngineID,
 		},
+		Credentials: credentials,
 	}
-	err := connectionParams.Validation()
+	err = connectionParams.Validation()
 	if err != nil {
 		l.Debugf("ERROR on query device : %s", err)
 		ctx.JSON(400, fmt.Errorf("SNMP parameter validation: %v", err))
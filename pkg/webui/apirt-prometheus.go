@@ -0,0 +1,207 @@
+package webui
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/toni-moreno/snmpcollector/pkg/agent"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+	"gopkg.in/macaron.v1"
+)
+
+// NewAPIRtPrometheus Prometheus scrape endpoint creator
+func NewAPIRtPrometheus(m *macaron.Macaron) error {
+	m.Get("/metrics", reqSignedIn, RTGetPrometheusMetrics)
+	return nil
+}
+
+// promSample one sample line in the prometheus exposition format
+type promSample struct {
+	labels string
+	value  float64
+}
+
+// promFamily a metric family with its type (counter or gauge) and samples, with unique label sets
+type promFamily struct {
+	typ     string
+	samples []promSample
+	labels  map[string]bool
+}
+
+// promMetrics metric families by name
+type promMetrics map[string]*promFamily
+
+// promName sanitizes a string to be a valid prometheus metric or label name
+func promName(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// promLabels builds the sorted label set string {k1="v1",k2="v2"}
+func promLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lbls := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lbls = append(lbls, fmt.Sprintf("%s=\"%s\"", promName(k), promLabelEscaper.Replace(tags[k])))
+	}
+	return "{" + strings.Join(lbls, ",") + "}"
+}
+
+// promValue converts field values to float, strings can not be exported as samples
+func promValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case uint:
+		return float64(val), true
+	case uint32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case bool:
+		if val {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (pm promMetrics) add(name string, typ string, tags map[string]string, v interface{}) {
+	value, ok := promValue(v)
+	if !ok {
+		return
+	}
+	fam, ok := pm[name]
+	if !ok {
+		fam = &promFamily{typ: typ, labels: make(map[string]bool)}
+		pm[name] = fam
+	}
+	labels := promLabels(tags)
+	// the same series could be built by several measurements, only the first one is exported
+	if fam.labels[labels] {
+		return
+	}
+	fam.labels[labels] = true
+	fam.samples = append(fam.samples, promSample{labels: labels, value: value})
+}
+
+// addPoints adds the point fields, counter fields hold cumulative values (see Measurement.GetLastPoints)
+func (pm promMetrics) addPoints(pts []*output.Point) {
+	for _, p := range pts {
+		for f, v := range p.Fields {
+			typ := "gauge"
+			if p.Meta.CounterFields[f] {
+				typ = "counter"
+			}
+			pm.add(promName(p.Name+"_"+f), typ, p.Tags, v)
+		}
+	}
+}
+
+func (pm promMetrics) write(b *bytes.Buffer) {
+	names := make([]string, 0, len(pm))
+	for n := range pm {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(b, "# TYPE %s %s\n", n, pm[n].typ)
+		for _, s := range pm[n].samples {
+			var value string
+			switch {
+			case math.IsNaN(s.value):
+				value = "NaN"
+			case math.IsInf(s.value, 1):
+				value = "+Inf"
+			case math.IsInf(s.value, -1):
+				value = "-Inf"
+			default:
+				value = strconv.FormatFloat(s.value, 'g', -1, 64)
+			}
+			fmt.Fprintf(b, "%s%s %s\n", n, s.labels, value)
+		}
+	}
+}
+
+// RTGetPrometheusMetrics renders last gathered values and device stats in prometheus format
+func RTGetPrometheusMetrics(ctx *Context) {
+	// swagger:operation GET /metrics Runtime_Prometheus RTGetPrometheusMetrics
+	//---
+	// summary: Get last gathered values in Prometheus format
+	// description: Get last gathered metrics for all running devices and its gather statistics in the Prometheus text exposition format
+	// tags:
+	// - "Runtime Prometheus"
+	//
+	// produces:
+	// - text/plain
+	//
+	// responses:
+	//   '200':
+	//     description: Prometheus text exposition format
+	//     schema:
+	//       type: string
+	pm := make(promMetrics)
+	// sorted devices, so duplicated series are always taken from the same one
+	devpoints := agent.GetDevLastPoints()
+	ids := make([]string, 0, len(devpoints))
+	for id := range devpoints {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		pm.addPoints(devpoints[id])
+	}
+	for id, st := range agent.GetDevStats() {
+		if st == nil {
+			continue
+		}
+		tags := make(map[string]string)
+		for k, v := range st.TagMap {
+			tags[k] = v
+		}
+		tags["device"] = id
+		for f, v := range st.GetFields() {
+			pm.add(promName("snmpcollector_device_"+f), "gauge", tags, v)
+		}
+	}
+	var b bytes.Buffer
+	pm.write(&b)
+	ctx.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	ctx.WriteHeader(200)
+	ctx.Write(b.Bytes())
+}
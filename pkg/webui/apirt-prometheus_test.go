@@ -0,0 +1,55 @@
+package webui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/toni-moreno/snmpcollector/pkg/agent/output"
+)
+
+func Test_PromMetrics(t *testing.T) {
+	tags := map[string]string{"device": "dev1", "ifName": "eth0"}
+	pts := []*output.Point{
+		{
+			Name:   "ifstats",
+			Tags:   tags,
+			Fields: map[string]interface{}{"in": float64(150), "status": int64(1), "descr": "uplink"},
+			Time:   time.Now(),
+			Meta:   output.PointMeta{CounterFields: map[string]bool{"in": true}},
+		},
+		// same series built by other measurement, the first one is exported
+		{
+			Name:   "ifstats",
+			Tags:   map[string]string{"ifName": "eth0", "device": "dev1"},
+			Fields: map[string]interface{}{"in": float64(999), "status": int64(2)},
+			Time:   time.Now(),
+			Meta:   output.PointMeta{CounterFields: map[string]bool{"in": true}},
+		},
+		{
+			Name:   "ifstats",
+			Tags:   map[string]string{"device": "dev1", "ifName": "eth\"1"},
+			Fields: map[string]interface{}{"in": float64(7), "status": true},
+			Time:   time.Now(),
+			Meta:   output.PointMeta{CounterFields: map[string]bool{"in": true}},
+		},
+	}
+	pm := make(promMetrics)
+	pm.addPoints(pts)
+	pm.add("snmpcollector_device_gather_time", "gauge", tags, 0.5)
+	var b bytes.Buffer
+	pm.write(&b)
+	want := `# TYPE ifstats_in counter
+ifstats_in{device="dev1",ifName="eth0"} 150
+ifstats_in{device="dev1",ifName="eth\"1"} 7
+# TYPE ifstats_status gauge
+ifstats_status{device="dev1",ifName="eth0"} 1
+ifstats_status{device="dev1",ifName="eth\"1"} 1
+# TYPE snmpcollector_device_gather_time gauge
+snmpcollector_device_gather_time{device="dev1",ifName="eth0"} 0.5
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("exposition (-want +got):\n%s", diff)
+	}
+}
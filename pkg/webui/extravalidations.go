@@ -1,11 +1,16 @@
 package webui
 
 import (
+	"net"
+	"regexp"
 	"strings"
 
 	"github.com/go-macaron/binding"
+	"github.com/toni-moreno/snmpcollector/pkg/data/snmp"
 )
 
+var hostNamePattern = regexp.MustCompile(`^[\w\-\.]+$`)
+
 func init() {
 	// UIntegerNotZero
 	binding.AddRule(&binding.Rule{
@@ -41,4 +46,23 @@ func init() {
 			return true, errs
 		},
 	})
+	// HostAddress
+	binding.AddRule(&binding.Rule{
+		IsMatch: func(rule string) bool {
+			return strings.HasPrefix(rule, "HostAddress")
+		},
+		IsValid: func(errs binding.Errors, name string, v interface{}) (bool, binding.Errors) {
+			str, ok := v.(string)
+			if !ok {
+				return false, errs
+			}
+			// port is not needed here, the host could include it
+			host, _, err := snmp.SplitHost(str, 161)
+			if err == nil && (hostNamePattern.MatchString(host) || net.ParseIP(strings.SplitN(host, "%", 2)[0]) != nil) {
+				return true, errs
+			}
+			errs.Add([]string{name}, "HostAddress", "Value should be a hostname, IPv4 or IPv6 address")
+			return false, errs
+		},
+	})
 }
@@ -0,0 +1,41 @@
+package webui
+
+import (
+	"testing"
+
+	"github.com/go-macaron/binding"
+)
+
+func Test_HostAddressRule(t *testing.T) {
+	tests := []struct {
+		host string
+		ok   bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.1:1161", true},
+		{"router1", true},
+		{"router-1.example.com:1161", true},
+		{"2001:db8::1", true},
+		{"fe80::1%eth0", true},
+		{"[2001:db8::1]", true},
+		{"[2001:db8::1]:1161", true},
+		{"[fe80::1%eth0]:1161", true},
+		{"router 1", false},
+		{"router1/24", false},
+		{"router1:70000", false},
+		{"router1:snmp", false},
+		{"[2001:db8::1", false},
+		// bare IPv6 addresses are not resolved, they should be valid addresses
+		{"2001:db8::zz", false},
+		{"a:b:c", false},
+	}
+	for _, tt := range tests {
+		v := struct {
+			Host string `binding:"HostAddress"`
+		}{tt.host}
+		errs := binding.RawValidate(&v)
+		if (len(errs) == 0) != tt.ok {
+			t.Errorf("host %q: errors %v, expected valid %t", tt.host, errs, tt.ok)
+		}
+	}
+}
@@ -191,6 +191,21 @@ func WebServer(publicPath string, httpListen string, cfg *config.HTTPConfig, id
 
 	NewAPICfgInfluxServer(m)
 
+	NewAPICfgGraphiteServer(m)
+
+	NewAPICfgOtlpServer(m)
+
+	NewAPICfgFileOutput(m)
+
+	NewAPICfgMqttBroker(m)
+	NewAPICfgKafkaOutput(m)
+
+	NewAPICfgProcessor(m)
+	NewAPICfgTrapRule(m)
+	NewAPICfgCredential(m)
+
+	NewAPICfgOutputs(m)
+
 	NewAPICfgSnmpDevice(m)
 
 	NewAPICfgCustomFilter(m)
@@ -201,6 +216,8 @@ func WebServer(publicPath string, httpListen string, cfg *config.HTTPConfig, id
 
 	NewAPIRtDevice(m)
 
+	NewAPIRtPrometheus(m)
+
 	// Begin server
 
 	var listen string
@@ -7,6 +7,14 @@ import { IMultiSelectOption, IMultiSelectSettings, IMultiSelectTexts } from '../
 
 //Services
 import { InfluxServerService } from '../../influxserver/influxservercfg.service';
+import { GraphiteServerService } from '../../graphiteserver/graphiteservercfg.service';
+import { OtlpServerService } from '../../otlpserver/otlpservercfg.service';
+import { FileOutputService } from '../../fileoutput/fileoutputcfg.service';
+import { ProcessorService } from '../../processor/processorcfg.service';
+import { TrapRuleService } from '../../traprule/traprulecfg.service';
+import { CredentialService } from '../../credential/credentialcfg.service';
+import { MqttBrokerService } from '../../mqttbroker/mqttbrokercfg.service';
+import { KafkaOutputService } from '../../kafkaoutput/kafkaoutputcfg.service';
 import { SnmpDeviceService } from '../../snmpdevice/snmpdevicecfg.service';
 import { MeasurementService } from '../../measurement/measurementcfg.service';
 import { OidConditionService } from '../../oidcondition/oidconditioncfg.service';
@@ -149,7 +157,7 @@ import { Subscription } from 'rxjs';
           </div>
         </div>`,
         styleUrls: ['./import-modal-styles.css'],
-        providers: [ExportServiceCfg, InfluxServerService, SnmpDeviceService, SnmpMetricService, MeasurementService, OidConditionService,MeasGroupService, MeasFilterService, CustomFilterService, VarCatalogService, TreeView]
+        providers: [ExportServiceCfg, InfluxServerService, GraphiteServerService, OtlpServerService, FileOutputService, MqttBrokerService, KafkaOutputService, ProcessorService, TrapRuleService, CredentialService, SnmpDeviceService, SnmpMetricService, MeasurementService, OidConditionService,MeasGroupService, MeasFilterService, CustomFilterService, VarCatalogService, TreeView]
 })
 
 export class ExportFileModal {
@@ -173,7 +181,7 @@ export class ExportFileModal {
   public mySubscriber: Subscription;
 
   constructor(builder: FormBuilder, public exportServiceCfg : ExportServiceCfg,
-    public influxServerService: InfluxServerService, public metricMeasService: SnmpMetricService,
+    public influxServerService: InfluxServerService, public graphiteServerService: GraphiteServerService, public otlpServerService: OtlpServerService, public fileOutputService: FileOutputService, public mqttBrokerService: MqttBrokerService, public kafkaOutputService: KafkaOutputService, public processorService: ProcessorService, public trapRuleService: TrapRuleService, public credentialService: CredentialService, public metricMeasService: SnmpMetricService,
     public measurementService: MeasurementService, public oidConditionService : OidConditionService,
     public snmpDeviceService: SnmpDeviceService, public measGroupService: MeasGroupService,
     public measFilterService: MeasFilterService, public customFilterService: CustomFilterService,
@@ -199,7 +207,15 @@ export class ExportFileModal {
    //Single Object
   public colorsObject : Object = {
    "snmpdevicecfg" : 'danger',
+   "credentialcfg" : 'danger',
    "influxcfg" : 'info',
+   "graphitecfg" : 'info',
+   "otlpcfg" : 'info',
+   "filecfg" : 'info',
+   "mqttcfg" : 'info',
+   "kafkacfg" : 'info',
+   "processorcfg" : 'info',
+   "traprulecfg" : 'default',
    "measfiltercfg": 'warning',
    "oidconditioncfg" : 'success',
    "customfiltercfg" : 'default',
@@ -234,7 +250,15 @@ export class ExportFileModal {
   //Bulk Objects
   public objectTypes : any = [
    {'Type':"snmpdevicecfg", 'Class' : 'danger', 'Visible': false},
+   {'Type':"credentialcfg", 'Class' : 'danger', 'Visible': false},
    {'Type':"influxcfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"graphitecfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"otlpcfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"filecfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"mqttcfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"kafkacfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"processorcfg" ,'Class' : 'info', 'Visible': false},
+   {'Type':"traprulecfg" ,'Class' : 'default', 'Visible': false},
    {'Type':"measfiltercfg", 'Class' : 'warning','Visible': false},
    {'Type':"oidconditioncfg", 'Class' : 'success', 'Visible': false},
    {'Type':"customfiltercfg", 'Class' : 'default', 'Visible': false},
@@ -450,6 +474,118 @@ export class ExportFileModal {
        () => {console.log("DONE")}
        );
       break;
+      case 'graphitecfg':
+      this.mySubscriber = this.graphiteServerService.getGraphiteServer(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'otlpcfg':
+      this.mySubscriber = this.otlpServerService.getOtlpServer(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'filecfg':
+      this.mySubscriber = this.fileOutputService.getFileOutput(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'mqttcfg':
+      this.mySubscriber = this.mqttBrokerService.getMqttBroker(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'kafkacfg':
+      this.mySubscriber = this.kafkaOutputService.getKafkaOutput(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'processorcfg':
+      this.mySubscriber = this.processorService.getProcessor(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'credentialcfg':
+      this.mySubscriber = this.credentialService.getCredential(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
+      case 'traprulecfg':
+      this.mySubscriber = this.trapRuleService.getTrapRule(filter)
+       .subscribe(
+       data => {
+         this.dataArray=data;
+         this.resultArray = this.dataArray;
+         for (let i in this.dataArray[0]) {
+           this.listFilterProp.push({ 'id': i, 'name': i });
+         }
+       },
+       err => {console.log(err)},
+       () => {console.log("DONE")}
+       );
+      break;
       case 'oidconditioncfg':
       this.mySubscriber = this.oidConditionService.getConditions(filter)
        .subscribe(
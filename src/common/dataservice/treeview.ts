@@ -56,7 +56,15 @@ export class TreeView {
 
   public colorsObject : Object = {
    "snmpdevicecfg" : 'danger',
+   "credentialcfg" : 'danger',
    "influxcfg" : 'info',
+   "graphitecfg" : 'info',
+   "otlpcfg" : 'info',
+   "filecfg" : 'info',
+   "mqttcfg" : 'info',
+   "kafkacfg" : 'info',
+   "processorcfg" : 'info',
+   "traprulecfg" : 'default',
    "measfiltercfg": 'warning',
    "oidconditioncfg" : 'success',
    "customfiltercfg" : 'default',
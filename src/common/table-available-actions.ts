@@ -16,6 +16,22 @@ export class AvailableTableActions {
         return this.getMetricAvailableActions();
       case 'influxcfg':
         return this.getInfluxServersAvailableActions();
+      case 'graphitecfg':
+        return this.getGraphiteServersAvailableActions();
+      case 'otlpcfg':
+        return this.getOtlpServersAvailableActions();
+      case 'filecfg':
+        return this.getFileOutputsAvailableActions();
+      case 'mqttcfg':
+        return this.getMqttBrokersAvailableActions();
+      case 'kafkacfg':
+        return this.getKafkaOutputsAvailableActions();
+      case 'processorcfg':
+        return this.getProcessorsAvailableActions();
+      case 'traprulecfg':
+        return this.getTrapRulesAvailableActions();
+      case 'credentialcfg':
+        return this.getCredentialsAvailableActions();
       case 'oidconditioncfg':
         return this.getOIDConditionsAvailableActions();
       case 'measgroupcfg':
@@ -97,6 +113,11 @@ export class AvailableTableActions {
               formControl : new FormControl('', Validators.required)
             })
           },
+          {'title': 'Credential','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('')
+            })
+          },
           {'title' : 'MeasurementGroups', 'type':'multiselector', 'options' :
             data[0]
           },
@@ -193,6 +214,184 @@ export class AvailableTableActions {
     return tableAvailableActions;
   }
 
+  getGraphiteServersAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title': 'PathTemplate','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.required)
+            })
+          },
+          {'title': 'Timeout','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator]))
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getOtlpServersAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title': 'Timeout','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator]))
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getFileOutputsAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title' : 'Compress', 'type':'boolean', 'options' : [
+            'true','false']
+          },
+          {'title': 'MaxFiles','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.compose([Validators.required, ValidationService.uintegerValidator]))
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getProcessorsAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title': 'Order','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.compose([Validators.required, ValidationService.integerValidator]))
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getTrapRulesAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title': 'Measurement','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.required)
+            })
+          },
+          {'title': 'Severity','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('')
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getCredentialsAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getMqttBrokersAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title' : 'QoS', 'type':'boolean', 'options' : [
+            '0','1','2']
+          },
+          {'title' : 'Retain', 'type':'boolean', 'options' : [
+            'true','false']
+          },
+          {'title': 'TopicTemplate','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.required)
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
+  getKafkaOutputsAvailableActions (data ? : any) : any {
+    let tableAvailableActions = [
+    //Remove Action
+      {'title': 'Remove', 'content' :
+        {'type' : 'button','action' : 'RemoveAllSelected'}
+      },
+    //Change Property Action
+      {'title': 'Change property', 'content' :
+        {'type' : 'selector', 'action' : 'ChangeProperty', 'options' : [
+          {'title' : 'Format', 'type':'boolean', 'options' : [
+            'line','json']
+          },
+          {'title' : 'Compression', 'type':'boolean', 'options' : [
+            'none','gzip','snappy']
+          },
+          {'title' : 'RequiredAcks', 'type':'boolean', 'options' : [
+            '0','1','-1']
+          },
+          {'title': 'Topic','type':'input', 'options':
+            new FormGroup({
+              formControl : new FormControl('', Validators.required)
+            })
+          }
+        ]},
+      }
+    ];
+    return tableAvailableActions;
+  }
+
   getMeasGroupsAvailableActions (data ? : any) : any {
     let tableAvailableActions = [
     //Remove Action
@@ -179,6 +179,9 @@ export class ValidationService {
             // From https://stackoverflow.com/questions/106179/regular-expression-to-match-dns-hostname-or-ip-address
             if (control.value.toString().match(/^[a-z\d]([a-z\d\-]{0,61}[a-z\d])?(\.[a-z\d]([a-z\d\-]{0,61}[a-z\d])?)*$/i)) {
                 return null;
+            } else if (control.value.toString().match(/^\[?[a-f\d]*:[a-f\d:.]*(%[\w.\-]+)?\]?$/i)) {
+                // IPv6 address, with or without brackets and zone
+                return null;
             } else {
                 return { 'invalidFQDNHost': true };
             }
@@ -0,0 +1,303 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { CredentialService } from './credentialcfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { CredentialCfgComponentConfig, TableRole, OverrideRoleActions } from './credentialcfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'credentials',
+  providers: [CredentialService, ValidationService],
+  templateUrl: './credentialeditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class CredentialCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  credentials: Array<any>;
+  filter: string;
+  credentialForm: any;
+  myFilterValue: any;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = CredentialCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public credentialService: CredentialService, public influxserverCredentialService: InfluxServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.credentialForm = this.builder.group({
+      ID: [this.credentialForm ? this.credentialForm.value.ID : '', Validators.required],
+      SnmpVersion: [this.credentialForm ? this.credentialForm.value.SnmpVersion : '2c', Validators.required],
+      Community: [this.credentialForm ? this.credentialForm.value.Community : 'public'],
+      V3SecLevel: [this.credentialForm ? this.credentialForm.value.V3SecLevel : 'NoAuthNoPriv'],
+      V3AuthUser: [this.credentialForm ? this.credentialForm.value.V3AuthUser : ''],
+      V3AuthPass: [this.credentialForm ? this.credentialForm.value.V3AuthPass : ''],
+      V3AuthProt: [this.credentialForm ? this.credentialForm.value.V3AuthProt : ''],
+      V3PrivPass: [this.credentialForm ? this.credentialForm.value.V3PrivPass : ''],
+      V3PrivProt: [this.credentialForm ? this.credentialForm.value.V3PrivProt : ''],
+      V3ContextEngineID: [this.credentialForm ? this.credentialForm.value.V3ContextEngineID : ''],
+      V3ContextName: [this.credentialForm ? this.credentialForm.value.V3ContextName : ''],
+      Description: [this.credentialForm ? this.credentialForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.credentialService.getCredential(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.credentials = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newCredential()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editCredential(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteCredential(myArray[i].ID,true);
+      obsArray.push(this.deleteCredential(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.credentialService.checkOnDeleteCredential(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newCredential() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editCredential(row) {
+    let id = row.ID;
+    this.credentialService.getCredentialById(id)
+      .subscribe(data => {
+        this.credentialForm = {};
+        this.credentialForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteCredential(id, recursive?) {
+    if (!recursive) {
+    this.credentialService.deleteCredential(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.credentialService.deleteCredential(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveCredential() {
+    if (this.credentialForm.valid) {
+      this.credentialService.addCredential(this.credentialForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateCredential(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateCredential(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateCredential(recursive?, component?) {
+    if(!recursive) {
+      if (this.credentialForm.valid) {
+        var r = true;
+        if (this.credentialForm.value.ID != this.oldID) {
+          r = confirm("Changing Credential ID from " + this.oldID + " to " + this.credentialForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.credentialService.editCredential(this.credentialForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.credentialService.editCredential(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
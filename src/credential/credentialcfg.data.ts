@@ -0,0 +1,22 @@
+export const CredentialCfgComponentConfig: any =
+  {
+    'name' : 'Credential Profile',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'SnmpVersion', name: 'SnmpVersion' },
+      { title: 'V3SecLevel', name: 'V3SecLevel' },
+      { title: 'V3AuthUser', name: 'V3AuthUser' },
+      { title: 'V3AuthProt', name: 'V3AuthProt' },
+      { title: 'V3PrivProt', name: 'V3PrivProt' },
+      { title: 'Description', name: 'Description' }
+    ],
+    'slug' : 'credentialcfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file
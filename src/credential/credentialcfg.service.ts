@@ -0,0 +1,86 @@
+import { Injectable } from '@angular/core';
+import { HttpService } from '../core/http.service';
+import { Observable } from 'rxjs/Observable';
+
+declare var _:any;
+
+@Injectable()
+export class CredentialService {
+
+    constructor(public httpAPI: HttpService) {
+    }
+
+    addCredential(dev) {
+        return this.httpAPI.post('/api/cfg/credentials',JSON.stringify(dev))
+        .map( (responseData) => responseData.json());
+
+    }
+
+    editCredential(dev, id, hideAlert?) {
+        return this.httpAPI.put('/api/cfg/credentials/'+id,JSON.stringify(dev),null,hideAlert)
+        .map( (responseData) => responseData.json());
+    }
+
+    getCredential(filter_s: string) {
+        // return an observable
+        return this.httpAPI.get('/api/cfg/credentials')
+        .map( (responseData) => {
+            return responseData.json();
+        })
+        .map((credentials) => {
+            console.log("MAP SERVICE",credentials);
+            let result = [];
+            if (credentials) {
+                _.forEach(credentials,function(value,key){
+                    console.log("FOREACH LOOP",value,value.ID);
+                    if(filter_s && filter_s.length > 0 ) {
+                        console.log("maching: "+value.ID+ "filter: "+filter_s);
+                        var re = new RegExp(filter_s, 'gi');
+                        if (value.ID.match(re)){
+                            result.push(value);
+                        }
+                        console.log(value.ID.match(re));
+                    } else {
+                        result.push(value);
+                    }
+                });
+            }
+            return result;
+        });
+    }
+    getCredentialById(id : string) {
+        // return an observable
+        console.log("ID: ",id);
+        return this.httpAPI.get('/api/cfg/credentials/'+id)
+        .map( (responseData) =>
+            responseData.json()
+    )};
+
+    checkOnDeleteCredential(id : string){
+      return this.httpAPI.get('/api/cfg/credentials/checkondel/'+id)
+      .map( (responseData) =>
+       responseData.json()
+      ).map((deleteobject) => {
+          console.log("MAP SERVICE",deleteobject);
+          let result : any = {'ID' : id};
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc] = [];
+          });
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc].Description=value.Action;
+              result[value.TypeDesc].push(value.ObID);
+          });
+          return result;
+      });
+    };
+
+    deleteCredential(id : string, hideAlert?) {
+        // return an observable
+        console.log("ID: ",id);
+        console.log("DELETING");
+        return this.httpAPI.delete('/api/cfg/credentials/'+id, null, hideAlert)
+        .map( (responseData) =>
+         responseData.json()
+        );
+    };
+}
@@ -0,0 +1,300 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { FileOutputService } from './fileoutputcfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { FileOutputCfgComponentConfig, TableRole, OverrideRoleActions } from './fileoutputcfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'fileoutputs',
+  providers: [FileOutputService, ValidationService],
+  templateUrl: './fileoutputeditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class FileOutputCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  fileoutputs: Array<any>;
+  filter: string;
+  fileoutputForm: any;
+  myFilterValue: any;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = FileOutputCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public fileOutputService: FileOutputService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.fileoutputForm = this.builder.group({
+      ID: [this.fileoutputForm ? this.fileoutputForm.value.ID : '', Validators.required],
+      Directory: [this.fileoutputForm ? this.fileoutputForm.value.Directory : ''],
+      Format: [this.fileoutputForm ? this.fileoutputForm.value.Format : 'line', Validators.required],
+      MaxSize: [this.fileoutputForm ? this.fileoutputForm.value.MaxSize : 100, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      RotateInterval: [this.fileoutputForm ? this.fileoutputForm.value.RotateInterval : 60, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      Compress: [this.fileoutputForm ? this.fileoutputForm.value.Compress : 'false'],
+      MaxFiles: [this.fileoutputForm ? this.fileoutputForm.value.MaxFiles : 24, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      BufferSize: [this.fileoutputForm ? this.fileoutputForm.value.BufferSize : 65535, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      Description: [this.fileoutputForm ? this.fileoutputForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.fileOutputService.getFileOutput(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.fileoutputs = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newFileOutput()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editFileOutput(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteFileOutput(myArray[i].ID,true);
+      obsArray.push(this.deleteFileOutput(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.fileOutputService.checkOnDeleteFileOutput(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newFileOutput() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editFileOutput(row) {
+    let id = row.ID;
+    this.fileOutputService.getFileOutputById(id)
+      .subscribe(data => {
+        this.fileoutputForm = {};
+        this.fileoutputForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteFileOutput(id, recursive?) {
+    if (!recursive) {
+    this.fileOutputService.deleteFileOutput(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.fileOutputService.deleteFileOutput(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveFileOutput() {
+    if (this.fileoutputForm.valid) {
+      this.fileOutputService.addFileOutput(this.fileoutputForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateFileOutput(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateFileOutput(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateFileOutput(recursive?, component?) {
+    if(!recursive) {
+      if (this.fileoutputForm.valid) {
+        var r = true;
+        if (this.fileoutputForm.value.ID != this.oldID) {
+          r = confirm("Changing File Output ID from " + this.oldID + " to " + this.fileoutputForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.fileOutputService.editFileOutput(this.fileoutputForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.fileOutputService.editFileOutput(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
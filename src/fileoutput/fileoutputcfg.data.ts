@@ -0,0 +1,23 @@
+export const FileOutputCfgComponentConfig: any =
+  {
+    'name' : 'File Output',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'Directory', name: 'Directory' },
+      { title: 'Format', name: 'Format' },
+      { title: 'Max Size (MB)', name: 'MaxSize' },
+      { title: 'Rotate Interval (min)', name: 'RotateInterval' },
+      { title: 'Compress', name: 'Compress' },
+      { title: 'Max Files', name: 'MaxFiles' },
+      { title: 'Buffer Size', name: 'BufferSize' }
+    ],
+    'slug' : 'filecfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file
@@ -0,0 +1,97 @@
+import { Injectable } from '@angular/core';
+import { HttpService } from '../core/http.service';
+import { Observable } from 'rxjs/Observable';
+
+declare var _:any;
+
+@Injectable()
+export class FileOutputService {
+
+    constructor(public httpAPI: HttpService) {
+    }
+
+    parseJSON(key,value) {
+        if ( key == 'MaxSize'  ||
+        key == 'RotateInterval' ||
+        key == 'MaxFiles' ||
+        key == 'BufferSize' ) {
+          return parseInt(value);
+        }
+        if ( key == 'Compress') return ( value === "true" || value === true);
+        return value;
+    }
+
+    addFileOutput(dev) {
+        return this.httpAPI.post('/api/cfg/fileoutputs',JSON.stringify(dev,this.parseJSON))
+        .map( (responseData) => responseData.json());
+
+    }
+
+    editFileOutput(dev, id, hideAlert?) {
+        return this.httpAPI.put('/api/cfg/fileoutputs/'+id,JSON.stringify(dev,this.parseJSON),null,hideAlert)
+        .map( (responseData) => responseData.json());
+    }
+
+    getFileOutput(filter_s: string) {
+        // return an observable
+        return this.httpAPI.get('/api/cfg/fileoutputs')
+        .map( (responseData) => {
+            return responseData.json();
+        })
+        .map((fileoutputs) => {
+            console.log("MAP SERVICE",fileoutputs);
+            let result = [];
+            if (fileoutputs) {
+                _.forEach(fileoutputs,function(value,key){
+                    console.log("FOREACH LOOP",value,value.ID);
+                    if(filter_s && filter_s.length > 0 ) {
+                        console.log("maching: "+value.ID+ "filter: "+filter_s);
+                        var re = new RegExp(filter_s, 'gi');
+                        if (value.ID.match(re)){
+                            result.push(value);
+                        }
+                        console.log(value.ID.match(re));
+                    } else {
+                        result.push(value);
+                    }
+                });
+            }
+            return result;
+        });
+    }
+    getFileOutputById(id : string) {
+        // return an observable
+        console.log("ID: ",id);
+        return this.httpAPI.get('/api/cfg/fileoutputs/'+id)
+        .map( (responseData) =>
+            responseData.json()
+    )};
+
+    checkOnDeleteFileOutput(id : string){
+      return this.httpAPI.get('/api/cfg/fileoutputs/checkondel/'+id)
+      .map( (responseData) =>
+       responseData.json()
+      ).map((deleteobject) => {
+          console.log("MAP SERVICE",deleteobject);
+          let result : any = {'ID' : id};
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc] = [];
+          });
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc].Description=value.Action;
+              result[value.TypeDesc].push(value.ObID);
+          });
+          return result;
+      });
+    };
+
+    deleteFileOutput(id : string, hideAlert?) {
+        // return an observable
+        console.log("ID: ",id);
+        console.log("DELETING");
+        return this.httpAPI.delete('/api/cfg/fileoutputs/'+id, null, hideAlert)
+        .map( (responseData) =>
+         responseData.json()
+        );
+    };
+}
@@ -0,0 +1,315 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { GraphiteServerService } from './graphiteservercfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { GraphiteServerCfgComponentConfig, TableRole, OverrideRoleActions } from './graphiteservercfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'graphiteservers',
+  providers: [GraphiteServerService, ValidationService],
+  templateUrl: './graphiteservereditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class GraphiteServerCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  graphiteservers: Array<any>;
+  filter: string;
+  graphiteserverForm: any;
+  myFilterValue: any;
+  alertHandler : any = null;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = GraphiteServerCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public graphiteServerService: GraphiteServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.graphiteserverForm = this.builder.group({
+      ID: [this.graphiteserverForm ? this.graphiteserverForm.value.ID : '', Validators.required],
+      Host: [this.graphiteserverForm ? this.graphiteserverForm.value.Host : '', Validators.required],
+      Port: [this.graphiteserverForm ? this.graphiteserverForm.value.Port : 2003, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      PathTemplate: [this.graphiteserverForm ? this.graphiteserverForm.value.PathTemplate : 'snmpcollector.{device}.{measurement}.{index}.{field}', Validators.required],
+      Timeout: [this.graphiteserverForm ? this.graphiteserverForm.value.Timeout : 10, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BatchSize: [this.graphiteserverForm ? this.graphiteserverForm.value.BatchSize : 1000, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      FlushInterval: [this.graphiteserverForm ? this.graphiteserverForm.value.FlushInterval : 10, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BufferSize: [this.graphiteserverForm ? this.graphiteserverForm.value.BufferSize : 65535, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      Description: [this.graphiteserverForm ? this.graphiteserverForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.alertHandler = null;
+    this.graphiteServerService.getGraphiteServer(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.graphiteservers = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newGraphiteServer()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editGraphiteServer(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteGraphiteServer(myArray[i].ID,true);
+      obsArray.push(this.deleteGraphiteServer(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.graphiteServerService.checkOnDeleteGraphiteServer(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newGraphiteServer() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editGraphiteServer(row) {
+    let id = row.ID;
+    this.graphiteServerService.getGraphiteServerById(id)
+      .subscribe(data => {
+        this.graphiteserverForm = {};
+        this.graphiteserverForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteGraphiteServer(id, recursive?) {
+    if (!recursive) {
+    this.graphiteServerService.deleteGraphiteServer(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.graphiteServerService.deleteGraphiteServer(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveGraphiteServer() {
+    if (this.graphiteserverForm.valid) {
+      this.graphiteServerService.addGraphiteServer(this.graphiteserverForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateGraphiteServer(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateGraphiteServer(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateGraphiteServer(recursive?, component?) {
+    if(!recursive) {
+      if (this.graphiteserverForm.valid) {
+        var r = true;
+        if (this.graphiteserverForm.value.ID != this.oldID) {
+          r = confirm("Changing Graphite Server ID from " + this.oldID + " to " + this.graphiteserverForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.graphiteServerService.editGraphiteServer(this.graphiteserverForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.graphiteServerService.editGraphiteServer(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  testGraphiteServerConnection() {
+    this.graphiteServerService.testGraphiteServer(this.graphiteserverForm.value, true)
+    .subscribe(
+    data =>  this.alertHandler = {msg: data['Message'], result : data['Result'], elapsed: data['Elapsed'], type: 'success', closable: true},
+    err => {
+        let error = err.json();
+        this.alertHandler = {msg: error['Message'], elapsed: error['Elapsed'], result : error['Result'], type: 'danger', closable: true}
+      },
+    () =>  { console.log("DONE")}
+  );
+
+  }
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
@@ -0,0 +1,103 @@
+import { Injectable } from '@angular/core';
+import { HttpService } from '../core/http.service';
+import { Observable } from 'rxjs/Observable';
+
+declare var _:any;
+
+@Injectable()
+export class GraphiteServerService {
+
+    constructor(public httpAPI: HttpService) {
+    }
+
+    parseJSON(key,value) {
+        if ( key == 'Port'  ||
+        key == 'Timeout' ||
+        key == 'BatchSize' ||
+        key == 'FlushInterval' ||
+        key == 'BufferSize' ) {
+          return parseInt(value);
+        }
+        return value;
+    }
+
+    addGraphiteServer(dev) {
+        return this.httpAPI.post('/api/cfg/graphiteservers',JSON.stringify(dev,this.parseJSON))
+        .map( (responseData) => responseData.json());
+
+    }
+
+    editGraphiteServer(dev, id, hideAlert?) {
+        return this.httpAPI.put('/api/cfg/graphiteservers/'+id,JSON.stringify(dev,this.parseJSON),null,hideAlert)
+        .map( (responseData) => responseData.json());
+    }
+
+    getGraphiteServer(filter_s: string) {
+        // return an observable
+        return this.httpAPI.get('/api/cfg/graphiteservers')
+        .map( (responseData) => {
+            return responseData.json();
+        })
+        .map((graphiteservers) => {
+            console.log("MAP SERVICE",graphiteservers);
+            let result = [];
+            if (graphiteservers) {
+                _.forEach(graphiteservers,function(value,key){
+                    console.log("FOREACH LOOP",value,value.ID);
+                    if(filter_s && filter_s.length > 0 ) {
+                        console.log("maching: "+value.ID+ "filter: "+filter_s);
+                        var re = new RegExp(filter_s, 'gi');
+                        if (value.ID.match(re)){
+                            result.push(value);
+                        }
+                        console.log(value.ID.match(re));
+                    } else {
+                        result.push(value);
+                    }
+                });
+            }
+            return result;
+        });
+    }
+    getGraphiteServerById(id : string) {
+        // return an observable
+        console.log("ID: ",id);
+        return this.httpAPI.get('/api/cfg/graphiteservers/'+id)
+        .map( (responseData) =>
+            responseData.json()
+    )};
+
+    checkOnDeleteGraphiteServer(id : string){
+      return this.httpAPI.get('/api/cfg/graphiteservers/checkondel/'+id)
+      .map( (responseData) =>
+       responseData.json()
+      ).map((deleteobject) => {
+          console.log("MAP SERVICE",deleteobject);
+          let result : any = {'ID' : id};
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc] = [];
+          });
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc].Description=value.Action;
+              result[value.TypeDesc].push(value.ObID);
+          });
+          return result;
+      });
+    };
+
+    testGraphiteServer(graphiteserver,hideAlert?) {
+      // return an observable
+      return this.httpAPI.post('/api/cfg/graphiteservers/ping/',JSON.stringify(graphiteserver,this.parseJSON), null, hideAlert)
+      .map((responseData) => responseData.json());
+    };
+
+    deleteGraphiteServer(id : string, hideAlert?) {
+        // return an observable
+        console.log("ID: ",id);
+        console.log("DELETING");
+        return this.httpAPI.delete('/api/cfg/graphiteservers/'+id, null, hideAlert)
+        .map( (responseData) =>
+         responseData.json()
+        );
+    };
+}
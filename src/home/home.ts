@@ -34,12 +34,20 @@ export class Home {
   configurationItems : Array<any> = [
   {'title': 'Variable Catalog', 'selector' : 'varcatalog'},
   {'title': 'Influx Servers', 'selector' : 'influxserver'},
+  {'title': 'Graphite Servers', 'selector' : 'graphiteserver'},
+  {'title': 'OTLP Receivers', 'selector' : 'otlpserver'},
+  {'title': 'File Outputs', 'selector' : 'fileoutput'},
+  {'title': 'MQTT Brokers', 'selector' : 'mqttbroker'},
+  {'title': 'Kafka Outputs', 'selector' : 'kafkaoutput'},
+  {'title': 'Output Processors', 'selector' : 'processor'},
+  {'title': 'Trap Rules', 'selector' : 'traprule'},
   {'title': 'OID Conditions', 'selector' : 'oidcondition'},
   {'title': 'SNMP Metrics', 'selector' : 'snmpmetric'},
   {'title': 'Measurements', 'selector' : 'measurement'},
   {'title': 'Measurement Groups', 'selector' : 'measgroup'},
   {'title': 'Measurement Filters', 'selector' : 'measfilter'},
   {'title': 'Custom Filters', 'selector' : 'customfilter'},
+  {'title': 'Credential Profiles', 'selector' : 'credential'},
   {'title': 'SNMP Devices', 'selector' : 'snmpdevice'},
   ];
 
@@ -80,10 +80,10 @@ export class InfluxServerCfgComponent {
       ID: [this.influxserverForm ? this.influxserverForm.value.ID : '', Validators.required],
       Host: [this.influxserverForm ? this.influxserverForm.value.Host : '', Validators.required],
       Port: [this.influxserverForm ? this.influxserverForm.value.Port : '', Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
-      DB: [this.influxserverForm ? this.influxserverForm.value.DB : '', Validators.required],
-      User: [this.influxserverForm ? this.influxserverForm.value.User : '', Validators.required],
-      Password: [this.influxserverForm ? this.influxserverForm.value.Password : '', Validators.required],
-      Retention: [this.influxserverForm ? this.influxserverForm.value.Retention : 'autogen', Validators.required],
+      APIVersion: [this.influxserverForm ? this.influxserverForm.value.APIVersion : 'v1', Validators.required],
+      Transport: [this.influxserverForm ? this.influxserverForm.value.Transport : 'http', Validators.required],
+      UDPPayloadSize: [this.influxserverForm ? this.influxserverForm.value.UDPPayloadSize : 1400, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      UDPRateLimit: [this.influxserverForm ? this.influxserverForm.value.UDPRateLimit : 0, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
       Precision: [this.influxserverForm ? this.influxserverForm.value.Precision : 's', Validators.required],
       Timeout: [this.influxserverForm ? this.influxserverForm.value.Timeout : 30, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
       UserAgent: [this.influxserverForm ? this.influxserverForm.value.UserAgent : ''],
@@ -93,10 +93,66 @@ export class InfluxServerCfgComponent {
       SSLKey: [this.influxserverForm ? this.influxserverForm.value.SSLKey : ''],
       InsecureSkipVerify: [this.influxserverForm ? this.influxserverForm.value.InsecureSkipVerify : 'true'],
       BufferSize: [this.influxserverForm ? this.influxserverForm.value.BufferSize : 65535, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BatchSize: [this.influxserverForm ? this.influxserverForm.value.BatchSize : 0, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      FlushInterval: [this.influxserverForm ? this.influxserverForm.value.FlushInterval : 5, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      Gzip: [this.influxserverForm ? this.influxserverForm.value.Gzip : 'false'],
+      SpoolEnabled: [this.influxserverForm ? this.influxserverForm.value.SpoolEnabled : 'false'],
+      SpoolMaxSize: [this.influxserverForm ? this.influxserverForm.value.SpoolMaxSize : 1024, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      SpoolMaxAge: [this.influxserverForm ? this.influxserverForm.value.SpoolMaxAge : 24, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      StandbyID: [this.influxserverForm ? this.influxserverForm.value.StandbyID : ''],
+      FailoverThreshold: [this.influxserverForm ? this.influxserverForm.value.FailoverThreshold : 3, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      FailbackInterval: [this.influxserverForm ? this.influxserverForm.value.FailbackInterval : 30, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
       Description: [this.influxserverForm ? this.influxserverForm.value.Description : '']
     });
   }
 
+  createDynamicForm(fieldsArray: any) : void {
+    //Saves the actual to check later if there are shared values
+    let tmpform : any;
+    if (this.influxserverForm)  tmpform = this.influxserverForm.value;
+    this.createStaticForm();
+    //Set new values and check if we have to mantain the value!
+    for (let entry of fieldsArray) {
+      let value = entry.defVal;
+      //Check if there are common values from the previous selected item
+      if (tmpform) {
+        if (tmpform[entry.ID] && entry.override !== true) {
+          value = tmpform[entry.ID];
+        }
+      }
+      //Set different controls:
+      this.influxserverForm.addControl(entry.ID, new FormControl(value, entry.Validators));
+    }
+  }
+
+  setDynamicFields (field : any) : void  {
+    //Saves on the array all values to push into formGroup
+    let controlArray : Array<any> = [];
+    //Database and credentials are set on the listener side when sending by UDP
+    let isUDP : boolean = this.influxserverForm && this.influxserverForm.value.Transport == 'udp';
+
+    switch (field) {
+      case 'v2':
+      controlArray.push({'ID': 'Org', 'defVal' : '', 'Validators' : Validators.required });
+      controlArray.push({'ID': 'Bucket', 'defVal' : '', 'Validators' : Validators.required });
+      controlArray.push({'ID': 'Token', 'defVal' : '', 'Validators' : Validators.required });
+      break;
+      case 'v1':
+      default:
+      controlArray.push({'ID': 'DB', 'defVal' : '', 'Validators' : isUDP ? null : Validators.required });
+      controlArray.push({'ID': 'User', 'defVal' : '', 'Validators' : isUDP ? null : Validators.required });
+      controlArray.push({'ID': 'Password', 'defVal' : '', 'Validators' : isUDP ? null : Validators.required });
+      controlArray.push({'ID': 'Retention', 'defVal' : 'autogen', 'Validators' : isUDP ? null : Validators.required });
+      controlArray.push({'ID': 'AutoProvision', 'defVal' : 'false', 'Validators' : null });
+      controlArray.push({'ID': 'RetentionDuration', 'defVal' : 'INF', 'Validators' : null });
+      controlArray.push({'ID': 'ShardDuration', 'defVal' : '', 'Validators' : null });
+      controlArray.push({'ID': 'ContinuousQueries', 'defVal' : '', 'Validators' : null });
+      break;
+    }
+    //Reload the formGroup with new values saved on controlArray
+    this.createDynamicForm(controlArray);
+  }
+
   reloadData() {
     // now it's a simple subscription to the observable
     this.alertHandler = null;
@@ -190,8 +246,8 @@ export class InfluxServerCfgComponent {
       );
   }
   newInfluxServer() {
-    //No hidden fields, so create fixed Form
-    this.createStaticForm();
+    //Check for subhidden fields
+    this.setDynamicFields(this.influxserverForm ? this.influxserverForm.value.APIVersion : null);
     this.editmode = "create";
   }
 
@@ -202,7 +258,7 @@ export class InfluxServerCfgComponent {
         this.influxserverForm = {};
         this.influxserverForm.value = data;
         this.oldID = data.ID
-        this.createStaticForm();
+        this.setDynamicFields(data.APIVersion);
         this.editmode = "modify";
       },
       err => console.error(err)
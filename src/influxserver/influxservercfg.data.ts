@@ -5,10 +5,14 @@ export const InfluxServerCfgComponentConfig: any =
       { title: 'ID', name: 'ID' },
       { title: 'Host', name: 'Host' },
       { title: 'Port', name: 'Port' },
+      { title: 'Transport', name: 'Transport' },
       { title: 'Enable SSL',name:'EnableSSL'},
+      { title: 'API Version', name: 'APIVersion' },
       { title: 'DB', name: 'DB' },
       { title: 'User', name: 'User' },
       { title: 'Retention', name: 'Retention' },
+      { title: 'Org', name: 'Org' },
+      { title: 'Bucket', name: 'Bucket' },
       { title: 'Precision', name: 'Precision' },
       { title: 'Timeout', name: 'Timeout' },
       { title: 'Buffer Size', name: 'BufferSize' },
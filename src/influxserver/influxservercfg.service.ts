@@ -13,11 +13,21 @@ export class InfluxServerService {
     parseJSON(key,value) {
         if ( key == 'Port'  ||
         key == 'Timeout' ||
-        key == 'BufferSize' ) {
+        key == 'UDPPayloadSize' ||
+        key == 'UDPRateLimit' ||
+        key == 'BufferSize' ||
+        key == 'BatchSize' ||
+        key == 'FlushInterval' ||
+        key == 'SpoolMaxSize' ||
+        key == 'SpoolMaxAge' ||
+        key == 'FailoverThreshold' ||
+        key == 'FailbackInterval' ) {
           return parseInt(value);
         }
         if ( key == 'EnableSSL' ||
-        key == 'InsecureSkipVerify') return ( value === "true" || value === true);
+        key == 'InsecureSkipVerify' ||
+        key == 'AutoProvision' ||
+        key == 'Gzip') return ( value === "true" || value === true);
         return value;
     }
 
@@ -59,6 +69,12 @@ export class InfluxServerService {
             return result;
         });
     }
+    getOutputs() {
+        // return all configured outputs (influxdb, graphite, ...) as an observable
+        return this.httpAPI.get('/api/cfg/outputs')
+        .map( (responseData) => responseData.json() || [])
+    };
+
     getInfluxServerById(id : string) {
         // return an observable
         console.log("ID: ",id);
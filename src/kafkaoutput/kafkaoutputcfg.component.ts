@@ -0,0 +1,326 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { KafkaOutputService } from './kafkaoutputcfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { KafkaOutputCfgComponentConfig, TableRole, OverrideRoleActions } from './kafkaoutputcfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'kafkaoutputs',
+  providers: [KafkaOutputService, ValidationService],
+  templateUrl: './kafkaoutputeditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class KafkaOutputCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  kafkaoutputs: Array<any>;
+  filter: string;
+  kafkaoutputForm: any;
+  myFilterValue: any;
+  alertHandler : any = null;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = KafkaOutputCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public kafkaOutputService: KafkaOutputService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.kafkaoutputForm = this.builder.group({
+      ID: [this.kafkaoutputForm ? this.kafkaoutputForm.value.ID : '', Validators.required],
+      Brokers: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Brokers : '', Validators.required],
+      Topic: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Topic : 'snmp', Validators.required],
+      Format: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Format : 'line', Validators.required],
+      Compression: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Compression : 'none', Validators.required],
+      RequiredAcks: [this.kafkaoutputForm ? this.kafkaoutputForm.value.RequiredAcks : 1, Validators.required],
+      ClientID: [this.kafkaoutputForm ? this.kafkaoutputForm.value.ClientID : ''],
+      SASLMechanism: [this.kafkaoutputForm ? this.kafkaoutputForm.value.SASLMechanism : 'none', Validators.required],
+      User: [this.kafkaoutputForm ? this.kafkaoutputForm.value.User : ''],
+      Password: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Password : ''],
+      Timeout: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Timeout : 10, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BatchSize: [this.kafkaoutputForm ? this.kafkaoutputForm.value.BatchSize : 1000, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      FlushInterval: [this.kafkaoutputForm ? this.kafkaoutputForm.value.FlushInterval : 1, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BufferSize: [this.kafkaoutputForm ? this.kafkaoutputForm.value.BufferSize : 65535, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      EnableSSL: [this.kafkaoutputForm ? this.kafkaoutputForm.value.EnableSSL : 'false'],
+      SSLCA: [this.kafkaoutputForm ? this.kafkaoutputForm.value.SSLCA : ''],
+      SSLCert: [this.kafkaoutputForm ? this.kafkaoutputForm.value.SSLCert : ''],
+      SSLKey: [this.kafkaoutputForm ? this.kafkaoutputForm.value.SSLKey : ''],
+      InsecureSkipVerify: [this.kafkaoutputForm ? this.kafkaoutputForm.value.InsecureSkipVerify : 'false'],
+      Description: [this.kafkaoutputForm ? this.kafkaoutputForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.alertHandler = null;
+    this.kafkaOutputService.getKafkaOutput(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.kafkaoutputs = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newKafkaOutput()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editKafkaOutput(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteKafkaOutput(myArray[i].ID,true);
+      obsArray.push(this.deleteKafkaOutput(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.kafkaOutputService.checkOnDeleteKafkaOutput(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newKafkaOutput() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editKafkaOutput(row) {
+    let id = row.ID;
+    this.kafkaOutputService.getKafkaOutputById(id)
+      .subscribe(data => {
+        this.kafkaoutputForm = {};
+        this.kafkaoutputForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteKafkaOutput(id, recursive?) {
+    if (!recursive) {
+    this.kafkaOutputService.deleteKafkaOutput(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.kafkaOutputService.deleteKafkaOutput(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveKafkaOutput() {
+    if (this.kafkaoutputForm.valid) {
+      this.kafkaOutputService.addKafkaOutput(this.kafkaoutputForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateKafkaOutput(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateKafkaOutput(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateKafkaOutput(recursive?, component?) {
+    if(!recursive) {
+      if (this.kafkaoutputForm.valid) {
+        var r = true;
+        if (this.kafkaoutputForm.value.ID != this.oldID) {
+          r = confirm("Changing Kafka Output ID from " + this.oldID + " to " + this.kafkaoutputForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.kafkaOutputService.editKafkaOutput(this.kafkaoutputForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.kafkaOutputService.editKafkaOutput(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  testKafkaOutputConnection() {
+    this.kafkaOutputService.testKafkaOutput(this.kafkaoutputForm.value, true)
+    .subscribe(
+    data =>  this.alertHandler = {msg: data['Message'], result : data['Result'], elapsed: data['Elapsed'], type: 'success', closable: true},
+    err => {
+        let error = err.json();
+        this.alertHandler = {msg: error['Message'], elapsed: error['Elapsed'], result : error['Result'], type: 'danger', closable: true}
+      },
+    () =>  { console.log("DONE")}
+  );
+
+  }
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
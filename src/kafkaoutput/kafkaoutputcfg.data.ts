@@ -0,0 +1,25 @@
+export const KafkaOutputCfgComponentConfig: any =
+  {
+    'name' : 'Kafka Output',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'Brokers', name: 'Brokers' },
+      { title: 'Topic', name: 'Topic' },
+      { title: 'Format', name: 'Format' },
+      { title: 'Compression', name: 'Compression' },
+      { title: 'Required Acks', name: 'RequiredAcks' },
+      { title: 'SASL', name: 'SASLMechanism' },
+      { title: 'Enable SSL', name: 'EnableSSL' },
+      { title: 'Batch Size', name: 'BatchSize' },
+      { title: 'Buffer Size', name: 'BufferSize' }
+    ],
+    'slug' : 'kafkacfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file
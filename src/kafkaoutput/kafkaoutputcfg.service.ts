@@ -0,0 +1,105 @@
+import { Injectable } from '@angular/core';
+import { HttpService } from '../core/http.service';
+import { Observable } from 'rxjs/Observable';
+
+declare var _:any;
+
+@Injectable()
+export class KafkaOutputService {
+
+    constructor(public httpAPI: HttpService) {
+    }
+
+    parseJSON(key,value) {
+        if ( key == 'RequiredAcks'  ||
+        key == 'Timeout' ||
+        key == 'BatchSize' ||
+        key == 'FlushInterval' ||
+        key == 'BufferSize' ) {
+          return parseInt(value);
+        }
+        if ( key == 'EnableSSL' ||
+        key == 'InsecureSkipVerify') return ( value === "true" || value === true);
+        return value;
+    }
+
+    addKafkaOutput(dev) {
+        return this.httpAPI.post('/api/cfg/kafkaoutputs',JSON.stringify(dev,this.parseJSON))
+        .map( (responseData) => responseData.json());
+
+    }
+
+    editKafkaOutput(dev, id, hideAlert?) {
+        return this.httpAPI.put('/api/cfg/kafkaoutputs/'+id,JSON.stringify(dev,this.parseJSON),null,hideAlert)
+        .map( (responseData) => responseData.json());
+    }
+
+    getKafkaOutput(filter_s: string) {
+        // return an observable
+        return this.httpAPI.get('/api/cfg/kafkaoutputs')
+        .map( (responseData) => {
+            return responseData.json();
+        })
+        .map((kafkaoutputs) => {
+            console.log("MAP SERVICE",kafkaoutputs);
+            let result = [];
+            if (kafkaoutputs) {
+                _.forEach(kafkaoutputs,function(value,key){
+                    console.log("FOREACH LOOP",value,value.ID);
+                    if(filter_s && filter_s.length > 0 ) {
+                        console.log("maching: "+value.ID+ "filter: "+filter_s);
+                        var re = new RegExp(filter_s, 'gi');
+                        if (value.ID.match(re)){
+                            result.push(value);
+                        }
+                        console.log(value.ID.match(re));
+                    } else {
+                        result.push(value);
+                    }
+                });
+            }
+            return result;
+        });
+    }
+    getKafkaOutputById(id : string) {
+        // return an observable
+        console.log("ID: ",id);
+        return this.httpAPI.get('/api/cfg/kafkaoutputs/'+id)
+        .map( (responseData) =>
+            responseData.json()
+    )};
+
+    checkOnDeleteKafkaOutput(id : string){
+      return this.httpAPI.get('/api/cfg/kafkaoutputs/checkondel/'+id)
+      .map( (responseData) =>
+       responseData.json()
+      ).map((deleteobject) => {
+          console.log("MAP SERVICE",deleteobject);
+          let result : any = {'ID' : id};
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc] = [];
+          });
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc].Description=value.Action;
+              result[value.TypeDesc].push(value.ObID);
+          });
+          return result;
+      });
+    };
+
+    testKafkaOutput(kafkaoutput,hideAlert?) {
+      // return an observable
+      return this.httpAPI.post('/api/cfg/kafkaoutputs/ping/',JSON.stringify(kafkaoutput,this.parseJSON), null, hideAlert)
+      .map((responseData) => responseData.json());
+    };
+
+    deleteKafkaOutput(id : string, hideAlert?) {
+        // return an observable
+        console.log("ID: ",id);
+        console.log("DELETING");
+        return this.httpAPI.delete('/api/cfg/kafkaoutputs/'+id, null, hideAlert)
+        .map( (responseData) =>
+         responseData.json()
+        );
+    };
+}
@@ -30,6 +30,14 @@ import { MeasurementCfgComponent } from './measurement/measurementcfg.component'
 import { MeasGroupCfgComponent } from './measgroup/measgroupcfg.component';
 import { MeasFilterCfgComponent } from './measfilter/measfiltercfg.component';
 import { InfluxServerCfgComponent } from './influxserver/influxservercfg.component';
+import { GraphiteServerCfgComponent } from './graphiteserver/graphiteservercfg.component';
+import { OtlpServerCfgComponent } from './otlpserver/otlpservercfg.component';
+import { FileOutputCfgComponent } from './fileoutput/fileoutputcfg.component';
+import { MqttBrokerCfgComponent } from './mqttbroker/mqttbrokercfg.component';
+import { KafkaOutputCfgComponent } from './kafkaoutput/kafkaoutputcfg.component';
+import { ProcessorCfgComponent } from './processor/processorcfg.component';
+import { TrapRuleCfgComponent } from './traprule/traprulecfg.component';
+import { CredentialCfgComponent } from './credential/credentialcfg.component';
 import { RuntimeComponent } from './runtime/runtime.component';
 import { CustomFilterCfgComponent } from './customfilter/customfiltercfg.component';
 import { BlockUIService } from './common/blockui/blockui-service';
@@ -82,6 +90,14 @@ import { SwaggerUiComponent} from './swagger-ui/swagger-ui.component';
     MeasGroupCfgComponent,
     MeasFilterCfgComponent,
     InfluxServerCfgComponent,
+    GraphiteServerCfgComponent,
+    OtlpServerCfgComponent,
+    FileOutputCfgComponent,
+    MqttBrokerCfgComponent,
+    KafkaOutputCfgComponent,
+    ProcessorCfgComponent,
+    TrapRuleCfgComponent,
+    CredentialCfgComponent,
     CustomFilterCfgComponent,
     VarCatalogCfgComponent,
     TableListComponent,
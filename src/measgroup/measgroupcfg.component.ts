@@ -3,6 +3,7 @@ import { FormBuilder, Validators} from '@angular/forms';
 import { IMultiSelectOption, IMultiSelectSettings, IMultiSelectTexts } from '../common/multiselect-dropdown';
 import { MeasGroupService } from './measgroupcfg.service';
 import { MeasurementService } from '../measurement/measurementcfg.service';
+import { InfluxServerService } from '../influxserver/influxservercfg.service';
 import { ValidationService } from '../common/validation.service'
 import { FormArray, FormGroup, FormControl} from '@angular/forms';
 import { ExportServiceCfg } from '../common/dataservice/export.service'
@@ -22,7 +23,7 @@ declare var _:any;
 
 @Component({
   selector: 'measgroups',
-  providers: [MeasGroupService, MeasurementService],
+  providers: [MeasGroupService, MeasurementService, InfluxServerService],
   templateUrl: './measgroupeditor.html',
   styleUrls: ['../css/component-styles.css']
 })
@@ -45,6 +46,7 @@ export class MeasGroupCfgComponent {
   testmeasgroups: any;
   measurement: Array<any>;
   selectmeas: IMultiSelectOption[] = [];
+  selectinfluxservers: IMultiSelectOption[] = [];
   public defaultConfig : any = MeasGroupCfgComponentConfig;
   public tableRole : any = TableRole;
   public overrideRoleActions: any = OverrideRoleActions;
@@ -70,7 +72,7 @@ export class MeasGroupCfgComponent {
   };
 
 
-  constructor(public measGroupService: MeasGroupService, public measMeasGroupService: MeasurementService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+  constructor(public measGroupService: MeasGroupService, public measMeasGroupService: MeasurementService, public influxserverMeasGroupService: InfluxServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
     this.editmode = 'list';
     this.reloadData();
     this.builder = builder;
@@ -80,6 +82,7 @@ export class MeasGroupCfgComponent {
     this.measgroupForm = this.builder.group({
       ID: [this.measgroupForm ? this.measgroupForm.value.ID : '', Validators.required],
       Measurements: [this.measgroupForm ? this.measgroupForm.value.Measurements : null, Validators.compose([Validators.required, ValidationService.emptySelector])],
+      OutDBs: [this.measgroupForm ? this.measgroupForm.value.OutDBs : null],
       Description: [this.measgroupForm ? this.measgroupForm.value.Description : '']
     });
   }
@@ -174,12 +177,14 @@ export class MeasGroupCfgComponent {
   newMeasGroup() {
     this.createStaticForm();
     this.getMeasforMeasGroups();
+    this.getInfluxServersforMeasGroups();
     this.editmode = "create";
   }
 
   editMeasGroup(row) {
     let id = row.ID;
     this.getMeasforMeasGroups();
+    this.getInfluxServersforMeasGroups();
     this.measGroupService.getMeasGroupById(id)
       .subscribe(
       data => {
@@ -293,6 +298,20 @@ export class MeasGroupCfgComponent {
       );
   }
 
+  getInfluxServersforMeasGroups() {
+    this.influxserverMeasGroupService.getOutputs()
+      .subscribe(
+      data => {
+        this.selectinfluxservers = [];
+        for (let entry of data) {
+          this.selectinfluxservers.push({ 'id': entry.ID, 'name': entry.ID + ' (' + entry.Type + ')' });
+        }
+      },
+      err => console.error(err),
+      () => { console.log('DONE') }
+      );
+  }
+
   genericForkJoin(obsArray: any) {
     Observable.forkJoin(obsArray)
               .subscribe(
@@ -4,6 +4,7 @@ export const MeasGroupCfgComponentConfig: any =
     'table-columns' : [
       { title: 'ID', name: 'ID' },
       { title: 'Measurements', name: 'Measurements' },
+      { title: 'Extra Influx DBs', name: 'OutDBs' },
     ],
     'slug' : 'measgroupcfg'
   }; 
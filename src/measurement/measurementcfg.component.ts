@@ -3,6 +3,7 @@ import { FormBuilder, Validators } from '@angular/forms';
 import { MeasurementService } from './measurementcfg.service';
 import { IMultiSelectOption, IMultiSelectSettings, IMultiSelectTexts } from '../common/multiselect-dropdown';
 import { SnmpMetricService } from '../snmpmetric/snmpmetriccfg.service';
+import { InfluxServerService } from '../influxserver/influxservercfg.service';
 import { ValidationService } from '../common/validation.service'
 import { FormArray, FormGroup, FormControl} from '@angular/forms';
 import { ExportServiceCfg } from '../common/dataservice/export.service'
@@ -21,7 +22,7 @@ declare var _:any;
 
 @Component({
   selector: 'measurement',
-  providers: [MeasurementService, SnmpMetricService],
+  providers: [MeasurementService, SnmpMetricService, InfluxServerService],
   templateUrl: './measurementeditor.html',
   styleUrls: ['../css/component-styles.css']
 })
@@ -44,6 +45,10 @@ export class MeasurementCfgComponent {
   testmeasurement: any;
   snmpmetrics: Array<any>;
   selectmetrics: IMultiSelectOption[] = [];
+  selectinfluxservers: IMultiSelectOption[] = [];
+  private mySettingsInflux: IMultiSelectSettings = {
+      singleSelect: true,
+  };
   public defaultConfig : any = MeasurementCfgComponentConfig;
   public tableRole : any = TableRole;
   public overrideRoleActions: any = OverrideRoleActions;
@@ -78,7 +83,7 @@ export class MeasurementCfgComponent {
     className: ['table-striped', 'table-bordered']
   };
 
-  constructor(public measurementService: MeasurementService, public metricMeasService: SnmpMetricService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+  constructor(public measurementService: MeasurementService, public metricMeasService: SnmpMetricService, public influxserverMeasService: InfluxServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
     this.editmode = 'list';
     this.reloadData();
     this.builder = builder;
@@ -92,6 +97,14 @@ export class MeasurementCfgComponent {
       Freq: [this.measurementForm ? this.measurementForm.value.Freq : ''],
       UpdateFltFreq: [this.measurementForm ? this.measurementForm.value.UpdateFltFreq : ''],
       Fields: this.builder.array(this.measurementForm ? ((this.measurementForm.value.Fields) !== null ? this.measurementForm.value.Fields : []) : []),
+      OutDB: [this.measurementForm ? this.measurementForm.value.OutDB : ''],
+      OutDatabase: [this.measurementForm ? this.measurementForm.value.OutDatabase : ''],
+      OutRetention: [this.measurementForm ? this.measurementForm.value.OutRetention : ''],
+      Community: [this.measurementForm ? this.measurementForm.value.Community : ''],
+      ContextName: [this.measurementForm ? this.measurementForm.value.ContextName : ''],
+      Contexts: [this.measurementForm ? this.measurementForm.value.Contexts : ''],
+      ContextOID: [this.measurementForm ? this.measurementForm.value.ContextOID : '', ValidationService.OIDValidator],
+      ContextTag: [this.measurementForm ? this.measurementForm.value.ContextTag : 'context'],
       Description: [this.measurementForm ? this.measurementForm.value.Description : '']
     });
   }
@@ -508,6 +521,7 @@ export class MeasurementCfgComponent {
     }
     this.editmode = "create";
     this.getMetricsforMeas();
+    this.getInfluxServersforMeas();
   }
 
   editMeas(row) {
@@ -526,6 +540,7 @@ export class MeasurementCfgComponent {
         }
         this.setDynamicFields(row.GetMode, false);
         this.getMetricsforMeas();
+        this.getInfluxServersforMeas();
         this.oldID = data.ID
         this.editmode = "modify"
       },
@@ -533,6 +548,20 @@ export class MeasurementCfgComponent {
       );
   }
 
+  getInfluxServersforMeas() {
+    this.influxserverMeasService.getOutputs()
+      .subscribe(
+      data => {
+        this.selectinfluxservers = [];
+        for (let entry of data) {
+          this.selectinfluxservers.push({ 'id': entry.ID, 'name': entry.ID + ' (' + entry.Type + ')' });
+        }
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
   deleteMeasurement(id, recursive?) {
     if(!recursive) {
       this.measurementService.deleteMeas(id)
@@ -7,6 +7,7 @@ export const MeasurementCfgComponentConfig: any =
       { title: 'GetMode', name: 'GetMode' },
       { title: 'Polling Period (sec)', name: 'Freq' },
       { title: 'Update Filter (Cycles)', name: 'UpdateFltFreq' },
+      { title: 'Influx DB', name: 'OutDB' },
       { title: 'Index OID', name: 'IndexOID', transform: 'multi' },
       { title: 'Tag OID', name: 'TagOID', transform: 'multi' },
       { title: 'Index Tag', name: 'IndexTag', transform: 'multi' },
@@ -0,0 +1,325 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { MqttBrokerService } from './mqttbrokercfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { MqttBrokerCfgComponentConfig, TableRole, OverrideRoleActions } from './mqttbrokercfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'mqttbrokers',
+  providers: [MqttBrokerService, ValidationService],
+  templateUrl: './mqttbrokereditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class MqttBrokerCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  mqttbrokers: Array<any>;
+  filter: string;
+  mqttbrokerForm: any;
+  myFilterValue: any;
+  alertHandler : any = null;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = MqttBrokerCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public mqttBrokerService: MqttBrokerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.mqttbrokerForm = this.builder.group({
+      ID: [this.mqttbrokerForm ? this.mqttbrokerForm.value.ID : '', Validators.required],
+      Host: [this.mqttbrokerForm ? this.mqttbrokerForm.value.Host : '', Validators.required],
+      Port: [this.mqttbrokerForm ? this.mqttbrokerForm.value.Port : 1883, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      ProtocolVersion: [this.mqttbrokerForm ? this.mqttbrokerForm.value.ProtocolVersion : '3.1.1', Validators.required],
+      ClientID: [this.mqttbrokerForm ? this.mqttbrokerForm.value.ClientID : ''],
+      User: [this.mqttbrokerForm ? this.mqttbrokerForm.value.User : ''],
+      Password: [this.mqttbrokerForm ? this.mqttbrokerForm.value.Password : ''],
+      TopicTemplate: [this.mqttbrokerForm ? this.mqttbrokerForm.value.TopicTemplate : 'snmp/{device}/{measurement}/{index}', Validators.required],
+      QoS: [this.mqttbrokerForm ? this.mqttbrokerForm.value.QoS : 0, Validators.required],
+      Retain: [this.mqttbrokerForm ? this.mqttbrokerForm.value.Retain : 'false'],
+      KeepAlive: [this.mqttbrokerForm ? this.mqttbrokerForm.value.KeepAlive : 60, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      Timeout: [this.mqttbrokerForm ? this.mqttbrokerForm.value.Timeout : 10, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BufferSize: [this.mqttbrokerForm ? this.mqttbrokerForm.value.BufferSize : 65535, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      EnableSSL: [this.mqttbrokerForm ? this.mqttbrokerForm.value.EnableSSL : 'false'],
+      SSLCA: [this.mqttbrokerForm ? this.mqttbrokerForm.value.SSLCA : ''],
+      SSLCert: [this.mqttbrokerForm ? this.mqttbrokerForm.value.SSLCert : ''],
+      SSLKey: [this.mqttbrokerForm ? this.mqttbrokerForm.value.SSLKey : ''],
+      InsecureSkipVerify: [this.mqttbrokerForm ? this.mqttbrokerForm.value.InsecureSkipVerify : 'false'],
+      Description: [this.mqttbrokerForm ? this.mqttbrokerForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.alertHandler = null;
+    this.mqttBrokerService.getMqttBroker(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.mqttbrokers = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newMqttBroker()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editMqttBroker(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteMqttBroker(myArray[i].ID,true);
+      obsArray.push(this.deleteMqttBroker(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.mqttBrokerService.checkOnDeleteMqttBroker(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newMqttBroker() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editMqttBroker(row) {
+    let id = row.ID;
+    this.mqttBrokerService.getMqttBrokerById(id)
+      .subscribe(data => {
+        this.mqttbrokerForm = {};
+        this.mqttbrokerForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteMqttBroker(id, recursive?) {
+    if (!recursive) {
+    this.mqttBrokerService.deleteMqttBroker(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.mqttBrokerService.deleteMqttBroker(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveMqttBroker() {
+    if (this.mqttbrokerForm.valid) {
+      this.mqttBrokerService.addMqttBroker(this.mqttbrokerForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateMqttBroker(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateMqttBroker(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateMqttBroker(recursive?, component?) {
+    if(!recursive) {
+      if (this.mqttbrokerForm.valid) {
+        var r = true;
+        if (this.mqttbrokerForm.value.ID != this.oldID) {
+          r = confirm("Changing MQTT Broker ID from " + this.oldID + " to " + this.mqttbrokerForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.mqttBrokerService.editMqttBroker(this.mqttbrokerForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.mqttBrokerService.editMqttBroker(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  testMqttBrokerConnection() {
+    this.mqttBrokerService.testMqttBroker(this.mqttbrokerForm.value, true)
+    .subscribe(
+    data =>  this.alertHandler = {msg: data['Message'], result : data['Result'], elapsed: data['Elapsed'], type: 'success', closable: true},
+    err => {
+        let error = err.json();
+        this.alertHandler = {msg: error['Message'], elapsed: error['Elapsed'], result : error['Result'], type: 'danger', closable: true}
+      },
+    () =>  { console.log("DONE")}
+  );
+
+  }
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
@@ -0,0 +1,25 @@
+export const MqttBrokerCfgComponentConfig: any =
+  {
+    'name' : 'MQTT Broker',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'Host', name: 'Host' },
+      { title: 'Port', name: 'Port' },
+      { title: 'Protocol', name: 'ProtocolVersion' },
+      { title: 'Topic Template', name: 'TopicTemplate' },
+      { title: 'QoS', name: 'QoS' },
+      { title: 'Retain', name: 'Retain' },
+      { title: 'Enable SSL', name: 'EnableSSL' },
+      { title: 'Timeout', name: 'Timeout' },
+      { title: 'Buffer Size', name: 'BufferSize' }
+    ],
+    'slug' : 'mqttcfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file
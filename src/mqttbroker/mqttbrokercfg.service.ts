@@ -0,0 +1,106 @@
+import { Injectable } from '@angular/core';
+import { HttpService } from '../core/http.service';
+import { Observable } from 'rxjs/Observable';
+
+declare var _:any;
+
+@Injectable()
+export class MqttBrokerService {
+
+    constructor(public httpAPI: HttpService) {
+    }
+
+    parseJSON(key,value) {
+        if ( key == 'Port'  ||
+        key == 'QoS' ||
+        key == 'KeepAlive' ||
+        key == 'Timeout' ||
+        key == 'BufferSize' ) {
+          return parseInt(value);
+        }
+        if ( key == 'Retain' ||
+        key == 'EnableSSL' ||
+        key == 'InsecureSkipVerify') return ( value === "true" || value === true);
+        return value;
+    }
+
+    addMqttBroker(dev) {
+        return this.httpAPI.post('/api/cfg/mqttbrokers',JSON.stringify(dev,this.parseJSON))
+        .map( (responseData) => responseData.json());
+
+    }
+
+    editMqttBroker(dev, id, hideAlert?) {
+        return this.httpAPI.put('/api/cfg/mqttbrokers/'+id,JSON.stringify(dev,this.parseJSON),null,hideAlert)
+        .map( (responseData) => responseData.json());
+    }
+
+    getMqttBroker(filter_s: string) {
+        // return an observable
+        return this.httpAPI.get('/api/cfg/mqttbrokers')
+        .map( (responseData) => {
+            return responseData.json();
+        })
+        .map((mqttbrokers) => {
+            console.log("MAP SERVICE",mqttbrokers);
+            let result = [];
+            if (mqttbrokers) {
+                _.forEach(mqttbrokers,function(value,key){
+                    console.log("FOREACH LOOP",value,value.ID);
+                    if(filter_s && filter_s.length > 0 ) {
+                        console.log("maching: "+value.ID+ "filter: "+filter_s);
+                        var re = new RegExp(filter_s, 'gi');
+                        if (value.ID.match(re)){
+                            result.push(value);
+                        }
+                        console.log(value.ID.match(re));
+                    } else {
+                        result.push(value);
+                    }
+                });
+            }
+            return result;
+        });
+    }
+    getMqttBrokerById(id : string) {
+        // return an observable
+        console.log("ID: ",id);
+        return this.httpAPI.get('/api/cfg/mqttbrokers/'+id)
+        .map( (responseData) =>
+            responseData.json()
+    )};
+
+    checkOnDeleteMqttBroker(id : string){
+      return this.httpAPI.get('/api/cfg/mqttbrokers/checkondel/'+id)
+      .map( (responseData) =>
+       responseData.json()
+      ).map((deleteobject) => {
+          console.log("MAP SERVICE",deleteobject);
+          let result : any = {'ID' : id};
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc] = [];
+          });
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc].Description=value.Action;
+              result[value.TypeDesc].push(value.ObID);
+          });
+          return result;
+      });
+    };
+
+    testMqttBroker(mqttbroker,hideAlert?) {
+      // return an observable
+      return this.httpAPI.post('/api/cfg/mqttbrokers/ping/',JSON.stringify(mqttbroker,this.parseJSON), null, hideAlert)
+      .map((responseData) => responseData.json());
+    };
+
+    deleteMqttBroker(id : string, hideAlert?) {
+        // return an observable
+        console.log("ID: ",id);
+        console.log("DELETING");
+        return this.httpAPI.delete('/api/cfg/mqttbrokers/'+id, null, hideAlert)
+        .map( (responseData) =>
+         responseData.json()
+        );
+    };
+}
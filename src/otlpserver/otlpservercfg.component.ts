@@ -0,0 +1,315 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { OtlpServerService } from './otlpservercfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { OtlpServerCfgComponentConfig, TableRole, OverrideRoleActions } from './otlpservercfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'otlpservers',
+  providers: [OtlpServerService, ValidationService],
+  templateUrl: './otlpservereditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class OtlpServerCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  otlpservers: Array<any>;
+  filter: string;
+  otlpserverForm: any;
+  myFilterValue: any;
+  alertHandler : any = null;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = OtlpServerCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public otlpServerService: OtlpServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.otlpserverForm = this.builder.group({
+      ID: [this.otlpserverForm ? this.otlpserverForm.value.ID : '', Validators.required],
+      URL: [this.otlpserverForm ? this.otlpserverForm.value.URL : 'http://localhost:4318/v1/metrics', Validators.required],
+      Headers: [this.otlpserverForm ? this.otlpserverForm.value.Headers : ''],
+      Timeout: [this.otlpserverForm ? this.otlpserverForm.value.Timeout : 10, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      InsecureSkipVerify: [this.otlpserverForm ? this.otlpserverForm.value.InsecureSkipVerify : 'false'],
+      BatchSize: [this.otlpserverForm ? this.otlpserverForm.value.BatchSize : 1000, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      FlushInterval: [this.otlpserverForm ? this.otlpserverForm.value.FlushInterval : 10, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      BufferSize: [this.otlpserverForm ? this.otlpserverForm.value.BufferSize : 65535, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      Description: [this.otlpserverForm ? this.otlpserverForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.alertHandler = null;
+    this.otlpServerService.getOtlpServer(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.otlpservers = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newOtlpServer()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editOtlpServer(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteOtlpServer(myArray[i].ID,true);
+      obsArray.push(this.deleteOtlpServer(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.otlpServerService.checkOnDeleteOtlpServer(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newOtlpServer() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editOtlpServer(row) {
+    let id = row.ID;
+    this.otlpServerService.getOtlpServerById(id)
+      .subscribe(data => {
+        this.otlpserverForm = {};
+        this.otlpserverForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteOtlpServer(id, recursive?) {
+    if (!recursive) {
+    this.otlpServerService.deleteOtlpServer(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.otlpServerService.deleteOtlpServer(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveOtlpServer() {
+    if (this.otlpserverForm.valid) {
+      this.otlpServerService.addOtlpServer(this.otlpserverForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateOtlpServer(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateOtlpServer(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateOtlpServer(recursive?, component?) {
+    if(!recursive) {
+      if (this.otlpserverForm.valid) {
+        var r = true;
+        if (this.otlpserverForm.value.ID != this.oldID) {
+          r = confirm("Changing OTLP Receiver ID from " + this.oldID + " to " + this.otlpserverForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.otlpServerService.editOtlpServer(this.otlpserverForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.otlpServerService.editOtlpServer(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  testOtlpServerConnection() {
+    this.otlpServerService.testOtlpServer(this.otlpserverForm.value, true)
+    .subscribe(
+    data =>  this.alertHandler = {msg: data['Message'], result : data['Result'], elapsed: data['Elapsed'], type: 'success', closable: true},
+    err => {
+        let error = err.json();
+        this.alertHandler = {msg: error['Message'], elapsed: error['Elapsed'], result : error['Result'], type: 'danger', closable: true}
+      },
+    () =>  { console.log("DONE")}
+  );
+
+  }
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
@@ -0,0 +1,22 @@
+export const OtlpServerCfgComponentConfig: any =
+  {
+    'name' : 'OTLP Receiver',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'URL', name: 'URL' },
+      { title: 'Insecure Skip Verify', name: 'InsecureSkipVerify' },
+      { title: 'Timeout', name: 'Timeout' },
+      { title: 'Batch Size', name: 'BatchSize' },
+      { title: 'Flush Interval', name: 'FlushInterval' },
+      { title: 'Buffer Size', name: 'BufferSize' }
+    ],
+    'slug' : 'otlpcfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file
@@ -0,0 +1,104 @@
+import { Injectable } from '@angular/core';
+import { HttpService } from '../core/http.service';
+import { Observable } from 'rxjs/Observable';
+
+declare var _:any;
+
+@Injectable()
+export class OtlpServerService {
+
+    constructor(public httpAPI: HttpService) {
+    }
+
+    parseJSON(key,value) {
+        if ( key == 'Port'  ||
+        key == 'Timeout' ||
+        key == 'BatchSize' ||
+        key == 'FlushInterval' ||
+        key == 'BufferSize' ) {
+          return parseInt(value);
+        }
+        if ( key == 'InsecureSkipVerify') return ( value === "true" || value === true);
+        return value;
+    }
+
+    addOtlpServer(dev) {
+        return this.httpAPI.post('/api/cfg/otlpservers',JSON.stringify(dev,this.parseJSON))
+        .map( (responseData) => responseData.json());
+
+    }
+
+    editOtlpServer(dev, id, hideAlert?) {
+        return this.httpAPI.put('/api/cfg/otlpservers/'+id,JSON.stringify(dev,this.parseJSON),null,hideAlert)
+        .map( (responseData) => responseData.json());
+    }
+
+    getOtlpServer(filter_s: string) {
+        // return an observable
+        return this.httpAPI.get('/api/cfg/otlpservers')
+        .map( (responseData) => {
+            return responseData.json();
+        })
+        .map((otlpservers) => {
+            console.log("MAP SERVICE",otlpservers);
+            let result = [];
+            if (otlpservers) {
+                _.forEach(otlpservers,function(value,key){
+                    console.log("FOREACH LOOP",value,value.ID);
+                    if(filter_s && filter_s.length > 0 ) {
+                        console.log("maching: "+value.ID+ "filter: "+filter_s);
+                        var re = new RegExp(filter_s, 'gi');
+                        if (value.ID.match(re)){
+                            result.push(value);
+                        }
+                        console.log(value.ID.match(re));
+                    } else {
+                        result.push(value);
+                    }
+                });
+            }
+            return result;
+        });
+    }
+    getOtlpServerById(id : string) {
+        // return an observable
+        console.log("ID: ",id);
+        return this.httpAPI.get('/api/cfg/otlpservers/'+id)
+        .map( (responseData) =>
+            responseData.json()
+    )};
+
+    checkOnDeleteOtlpServer(id : string){
+      return this.httpAPI.get('/api/cfg/otlpservers/checkondel/'+id)
+      .map( (responseData) =>
+       responseData.json()
+      ).map((deleteobject) => {
+          console.log("MAP SERVICE",deleteobject);
+          let result : any = {'ID' : id};
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc] = [];
+          });
+          _.forEach(deleteobject,function(value,key){
+              result[value.TypeDesc].Description=value.Action;
+              result[value.TypeDesc].push(value.ObID);
+          });
+          return result;
+      });
+    };
+
+    testOtlpServer(otlpserver,hideAlert?) {
+      // return an observable
+      return this.httpAPI.post('/api/cfg/otlpservers/ping/',JSON.stringify(otlpserver,this.parseJSON), null, hideAlert)
+      .map((responseData) => responseData.json());
+    };
+
+    deleteOtlpServer(id : string, hideAlert?) {
+        // return an observable
+        console.log("ID: ",id);
+        console.log("DELETING");
+        return this.httpAPI.delete('/api/cfg/otlpservers/'+id, null, hideAlert)
+        .map( (responseData) =>
+         responseData.json()
+        );
+    };
+}
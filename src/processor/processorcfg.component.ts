@@ -0,0 +1,319 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+import { IMultiSelectOption, IMultiSelectSettings, IMultiSelectTexts } from '../common/multiselect-dropdown';
+
+import { ProcessorService } from './processorcfg.service';
+import { InfluxServerService } from '../influxserver/influxservercfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { ProcessorCfgComponentConfig, TableRole, OverrideRoleActions } from './processorcfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'processors',
+  providers: [ProcessorService, InfluxServerService, ValidationService],
+  templateUrl: './processoreditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class ProcessorCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  processors: Array<any>;
+  filter: string;
+  processorForm: any;
+  myFilterValue: any;
+  selectoutputs: IMultiSelectOption[] = [];
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = ProcessorCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public processorService: ProcessorService, public influxserverProcessorService: InfluxServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.processorForm = this.builder.group({
+      ID: [this.processorForm ? this.processorForm.value.ID : '', Validators.required],
+      Type: [this.processorForm ? this.processorForm.value.Type : 'rename_tag', Validators.required],
+      Order: [this.processorForm ? this.processorForm.value.Order : 0, Validators.compose([Validators.required, ValidationService.integerValidator])],
+      Measurement: [this.processorForm ? this.processorForm.value.Measurement : ''],
+      Key: [this.processorForm ? this.processorForm.value.Key : ''],
+      Value: [this.processorForm ? this.processorForm.value.Value : ''],
+      Target: [this.processorForm ? this.processorForm.value.Target : ''],
+      Outputs: [this.processorForm ? this.processorForm.value.Outputs : null],
+      Description: [this.processorForm ? this.processorForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.processorService.getProcessor(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.processors = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newProcessor()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editProcessor(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteProcessor(myArray[i].ID,true);
+      obsArray.push(this.deleteProcessor(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.processorService.checkOnDeleteProcessor(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newProcessor() {
+    this.createStaticForm();
+    this.getOutputsforProcessors();
+    this.editmode = "create";
+  }
+
+  editProcessor(row) {
+    let id = row.ID;
+    this.getOutputsforProcessors();
+    this.processorService.getProcessorById(id)
+      .subscribe(data => {
+        this.processorForm = {};
+        this.processorForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteProcessor(id, recursive?) {
+    if (!recursive) {
+    this.processorService.deleteProcessor(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.processorService.deleteProcessor(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveProcessor() {
+    if (this.processorForm.valid) {
+      this.processorService.addProcessor(this.processorForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateProcessor(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateProcessor(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateProcessor(recursive?, component?) {
+    if(!recursive) {
+      if (this.processorForm.valid) {
+        var r = true;
+        if (this.processorForm.value.ID != this.oldID) {
+          r = confirm("Changing Processor ID from " + this.oldID + " to " + this.processorForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.processorService.editProcessor(this.processorForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.processorService.editProcessor(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  getOutputsforProcessors() {
+    this.influxserverProcessorService.getOutputs()
+      .subscribe(
+      data => {
+        this.selectoutputs = [];
+        for (let entry of data) {
+          this.selectoutputs.push({ 'id': entry.ID, 'name': entry.ID + ' (' + entry.Type + ')' });
+        }
+      },
+      err => console.error(err),
+      () => { console.log('DONE') }
+      );
+  }
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
@@ -0,0 +1,23 @@
+export const ProcessorCfgComponentConfig: any =
+  {
+    'name' : 'Processor',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'Type', name: 'Type' },
+      { title: 'Order', name: 'Order' },
+      { title: 'Measurement', name: 'Measurement' },
+      { title: 'Key', name: 'Key' },
+      { title: 'Value', name: 'Value' },
+      { title: 'Target', name: 'Target' },
+      { title: 'Outputs', name: 'Outputs' }
+    ],
+    'slug' : 'processorcfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file
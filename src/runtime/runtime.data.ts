@@ -49,7 +49,7 @@ export const DeviceCounterDef: CounterType[] = [
   { show: false, source: "counters", id: "SnmpWalkQueries", idx:1, label: "SnmpWalk Queries", type: "counter", tooltip: "Number of snmp walks" },
   { show: false, source: "counters", id: "SnmpGetErrors", idx:2, label: "SnmpGet Errors", type: "counter", tooltip: "Number of walk errors" },
   { show: false, source: "counters", id: "SnmpWalkErrors", idx:3, label: "SnmpWalk Errors", type: "counter", tooltip: "Walk Error" },
-  { show: false, source: "counters", id: "SnmpQueryTimeouts", idx:4, label: "Snmp Errors by Timeout", type: "counter", tooltip: "Number of registered errors by timeouts" },
+  { show: true, source: "counters", id: "SnmpQueryTimeouts", idx:4, label: "Snmp Errors by Timeout", type: "counter", tooltip: "Number of SNMP requests with no response after all the retries" },
   { show: true, source: "counters", id: "SnmpOIDGetAll", idx:5, label: "OID Gets ALL", type: "counter", tooltip: "All Gathered snmp metrics (sum of SNMPGET OID's and all received OID's in SNMPWALK queries)" },
   { show: true, source: "counters", id: "SnmpOIDGetProcessed", idx:6, label: "OID Processed", type: "counter", tooltip: "Gathered and processed snmp metrics after filters are applied ( not always sent to the backend it depens on the report flag)" },
   { show: true, source: "counters", id: "SnmpOIDGetErrors", idx:7, label: "OID With Errors", type: "counter", tooltip: "Number of OIDs with errors for all measurements" },
@@ -66,6 +66,17 @@ export const DeviceCounterDef: CounterType[] = [
   { show: true, source: "counters", id: "FilterDuration", idx:18, label: "Filter update Duration", type: "duration", tooltip: "Elapsed time taken to compute all applicable filters on the device" },
   { show: false, source: "counters", id: "BackEndSentStartTime", idx:19, label: "BackEnd DB Sent Start Time", type: "time", tooltip: "Last sent time" },
   { show: false, source: "counters", id: "BackEndSentDuration", idx:20, label: "BackEnd DB Sent Duration", type: "duration", tooltip: "Elapsed time taken to send data to the db backend" },
+  { show: true, source: "counters", id: "SnmpRateLimitWait", idx:23, label: "Rate Limit Wait", type: "duration", tooltip: "Time waited by all measurements for the device SNMP rate limit and max in flight requests" },
+  { show: true, source: "counters", id: "SnmpRetries", idx:24, label: "Snmp Retries", type: "counter", tooltip: "Number of SNMP requests sent again after getting no response (lost requests or responses)" },
+  { show: true, source: "counters", id: "SnmpGetLatencyP50", idx:25, label: "SnmpGet Latency p50", type: "duration", tooltip: "Median latency of the SNMP GET requests" },
+  { show: true, source: "counters", id: "SnmpGetLatencyP95", idx:26, label: "SnmpGet Latency p95", type: "duration", tooltip: "95th percentile latency of the SNMP GET requests" },
+  { show: true, source: "counters", id: "SnmpGetLatencyP99", idx:27, label: "SnmpGet Latency p99", type: "duration", tooltip: "99th percentile latency of the SNMP GET requests" },
+  { show: true, source: "counters", id: "SnmpWalkLatencyP50", idx:28, label: "SnmpWalk Latency p50", type: "duration", tooltip: "Median latency of the SNMP GETNEXT requests done on walks" },
+  { show: true, source: "counters", id: "SnmpWalkLatencyP95", idx:29, label: "SnmpWalk Latency p95", type: "duration", tooltip: "95th percentile latency of the SNMP GETNEXT requests done on walks" },
+  { show: true, source: "counters", id: "SnmpWalkLatencyP99", idx:30, label: "SnmpWalk Latency p99", type: "duration", tooltip: "99th percentile latency of the SNMP GETNEXT requests done on walks" },
+  { show: true, source: "counters", id: "SnmpBulkLatencyP50", idx:31, label: "SnmpBulk Latency p50", type: "duration", tooltip: "Median latency of the SNMP GETBULK requests done on walks" },
+  { show: true, source: "counters", id: "SnmpBulkLatencyP95", idx:32, label: "SnmpBulk Latency p95", type: "duration", tooltip: "95th percentile latency of the SNMP GETBULK requests done on walks" },
+  { show: true, source: "counters", id: "SnmpBulkLatencyP99", idx:33, label: "SnmpBulk Latency p99", type: "duration", tooltip: "99th percentile latency of the SNMP GETBULK requests done on walks" },
 ];
 
 export const MeasurementCounterDef: CounterType[] = [
@@ -73,7 +84,7 @@ export const MeasurementCounterDef: CounterType[] = [
   { show: false, source: "counters", id: "SnmpWalkQueries", idx: 1, label: "SnmpWalk Queries", type: "counter", tooltip: "Number of snmp walks" },
   { show: false, source: "counters", id: "SnmpGetErrors", idx: 2, label: "SnmpGet Errors", type: "counter", tooltip: "Number of get errors" },
   { show: false, source: "counters", id: "SnmpWalkErrors", idx: 3, label: "SnmpWalk Errors", type: "counter", tooltip: "Number of walk errors" },
-  { show: false, source: "counters", id: "SnmpQueryTimeouts", idx: 4, label: "Snmp Errors by Timeout", type: "counter", tooltip: "Number of registered errors by timeouts" },
+  { show: true, source: "counters", id: "SnmpQueryTimeouts", idx: 4, label: "Snmp Errors by Timeout", type: "counter", tooltip: "Number of SNMP requests with no response after all the retries" },
   { show: true, source: "counters", id: "SnmpOIDGetAll", idx: 5, label: "OID Gets ALL", type: "counter", tooltip: "All Gathered snmp metrics (sum of snmpget oid's and all received oid's in snmpwalk queries)" },
   { show: true, source: "counters", id: "SnmpOIDGetProcessed", idx: 6, label: "OID Processed", type: "counter", tooltip: "Gathered and processed snmp metrics after filters are applied ( not always sent to the backend it depens on the report flag)" },
   { show: true, source: "counters", id: "SnmpOIDGetErrors", idx: 7, label: "OID With Errors", type: "counter", tooltip: "Number of OID with errors for all measurements" },
@@ -88,6 +99,17 @@ export const MeasurementCounterDef: CounterType[] = [
   { show: true, source: "counters", id: "FilterDuration", idx: 18, label: "Filter update Duration", type: "duration", tooltip: "Elapsed time taken to compute all applicable filters on the device" },
   { show: false, source: "counters", id: "BackEndSentStartTime", idx: 19, label: "BackEnd DB Sent Start Time", type: "time", tooltip: "Last sent time" },
   { show: true, source: "counters", id: "BackEndSentDuration", idx:20, label: "BackEnd DB Sent Duration", type: "duration", tooltip: "Elapsed time taken to send data to the db backend" },
+  { show: true, source: "counters", id: "SnmpRateLimitWait", idx: 23, label: "Rate Limit Wait", type: "duration", tooltip: "Time waited for the device SNMP rate limit and max in flight requests" },
+  { show: true, source: "counters", id: "SnmpRetries", idx: 24, label: "Snmp Retries", type: "counter", tooltip: "Number of SNMP requests sent again after getting no response (lost requests or responses)" },
+  { show: true, source: "counters", id: "SnmpGetLatencyP50", idx: 25, label: "SnmpGet Latency p50", type: "duration", tooltip: "Median latency of the SNMP GET requests" },
+  { show: true, source: "counters", id: "SnmpGetLatencyP95", idx: 26, label: "SnmpGet Latency p95", type: "duration", tooltip: "95th percentile latency of the SNMP GET requests" },
+  { show: true, source: "counters", id: "SnmpGetLatencyP99", idx: 27, label: "SnmpGet Latency p99", type: "duration", tooltip: "99th percentile latency of the SNMP GET requests" },
+  { show: true, source: "counters", id: "SnmpWalkLatencyP50", idx: 28, label: "SnmpWalk Latency p50", type: "duration", tooltip: "Median latency of the SNMP GETNEXT requests done on walks" },
+  { show: true, source: "counters", id: "SnmpWalkLatencyP95", idx: 29, label: "SnmpWalk Latency p95", type: "duration", tooltip: "95th percentile latency of the SNMP GETNEXT requests done on walks" },
+  { show: true, source: "counters", id: "SnmpWalkLatencyP99", idx: 30, label: "SnmpWalk Latency p99", type: "duration", tooltip: "99th percentile latency of the SNMP GETNEXT requests done on walks" },
+  { show: true, source: "counters", id: "SnmpBulkLatencyP50", idx: 31, label: "SnmpBulk Latency p50", type: "duration", tooltip: "Median latency of the SNMP GETBULK requests done on walks" },
+  { show: true, source: "counters", id: "SnmpBulkLatencyP95", idx: 32, label: "SnmpBulk Latency p95", type: "duration", tooltip: "95th percentile latency of the SNMP GETBULK requests done on walks" },
+  { show: true, source: "counters", id: "SnmpBulkLatencyP99", idx: 33, label: "SnmpBulk Latency p99", type: "duration", tooltip: "99th percentile latency of the SNMP GETBULK requests done on walks" },
   { show: true, source: "stats", id: "GatherFreq", label: "Gather Frequency", type: "duration", tooltip: "Gather frequency" },
   { show: true, source: "counters", id: "CycleGatherStartTime", idx: 15, label: "Cycle Gather Start Time", type: "time", tooltip: "Last gather time" },
   { show: true, source: "counters", id: "CycleGatherDuration", idx: 16, label: "Cycle Gather Duration", type: "duration", tooltip: "Elapsed time taken to get all measurement info" },
@@ -6,6 +6,7 @@ import { InfluxServerService } from '../influxserver/influxservercfg.service';
 import { MeasGroupService } from '../measgroup/measgroupcfg.service';
 import { MeasFilterService } from '../measfilter/measfiltercfg.service';
 import { VarCatalogService } from '../varcatalog/varcatalogcfg.service';
+import { CredentialService } from '../credential/credentialcfg.service';
 import { ValidationService } from '../common/validation.service';
 import { Observable } from 'rxjs/Rx';
 import { FormArray, FormGroup, FormControl} from '@angular/forms';
@@ -30,7 +31,7 @@ declare var _:any;
 
 @Component({
   selector: 'snmpdevs',
-  providers: [SnmpDeviceService, InfluxServerService, MeasGroupService, MeasFilterService, VarCatalogService,BlockUIService],
+  providers: [SnmpDeviceService, InfluxServerService, MeasGroupService, MeasFilterService, VarCatalogService, CredentialService, BlockUIService],
   templateUrl: './snmpdeviceeditor.html',
   styleUrls: ['../css/component-styles.css']
 })
@@ -65,6 +66,7 @@ export class SnmpDeviceCfgComponent {
   selectfilters: IMultiSelectOption[] = [];
   selectinfluxservers: IMultiSelectOption[] = [];
   selectvarcatalogs: IMultiSelectOption[] = [];
+  selectcredentials: IMultiSelectOption[] = [];
   private mySettingsInflux: IMultiSelectSettings = {
       singleSelect: true,
   };
@@ -98,7 +100,7 @@ export class SnmpDeviceCfgComponent {
   selectedVars: Array<any> = [];
   public extraActions: any = ExtraActions;
 
-  constructor(public snmpDeviceService: SnmpDeviceService, public varCatalogService: VarCatalogService, public influxserverDeviceService: InfluxServerService, public measgroupsDeviceService: MeasGroupService, public measfiltersDeviceService: MeasFilterService,  public exportServiceCfg : ExportServiceCfg, builder: FormBuilder, private _blocker: BlockUIService) {
+  constructor(public snmpDeviceService: SnmpDeviceService, public varCatalogService: VarCatalogService, public influxserverDeviceService: InfluxServerService, public measgroupsDeviceService: MeasGroupService, public measfiltersDeviceService: MeasFilterService, public credentialDeviceService: CredentialService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder, private _blocker: BlockUIService) {
     this.editmode = 'list';
     this.reloadData();
     this.builder = builder;
@@ -122,17 +124,25 @@ export class SnmpDeviceCfgComponent {
       ID: [this.snmpdevForm ? this.snmpdevForm.value.ID : '', Validators.required],
       Host: [this.snmpdevForm ? this.snmpdevForm.value.Host : '', Validators.compose([Validators.required, ValidationService.hostNameValidator])],
       Port: [this.snmpdevForm ? this.snmpdevForm.value.Port : 161, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
+      Transport: [this.snmpdevForm ? this.snmpdevForm.value.Transport : 'udp', Validators.required],
       Retries: [this.snmpdevForm ? this.snmpdevForm.value.Retries : 5, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
       Timeout: [this.snmpdevForm ? this.snmpdevForm.value.Timeout : 20, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
       Active: [this.snmpdevForm ? this.snmpdevForm.value.Active : 'true', Validators.required],
+      Credential: [this.snmpdevForm ? this.snmpdevForm.value.Credential : ''],
+      FallbackCredentials: [this.snmpdevForm ? this.snmpdevForm.value.FallbackCredentials : null],
       SnmpVersion: [this.snmpdevForm ? this.snmpdevForm.value.SnmpVersion : '2c', Validators.required],
       DisableBulk: [this.snmpdevForm ? this.snmpdevForm.value.DisableBulk : 'false'],
+      DisableSharedSession: [this.snmpdevForm ? this.snmpdevForm.value.DisableSharedSession : 'false'],
+      AdaptivePDUSize: [this.snmpdevForm ? this.snmpdevForm.value.AdaptivePDUSize : 'false'],
       MaxOids: [this.snmpdevForm ? this.snmpdevForm.value.MaxOids : 60, Validators.compose([Validators.required,ValidationService.uintegerNotZeroValidator])],
       MaxRepetitions: [this.snmpdevForm ? this.snmpdevForm.value.MaxRepetitions : 50, Validators.compose([Validators.required,ValidationService.uinteger8NotZeroValidator])],
+      RateLimit: [this.snmpdevForm ? this.snmpdevForm.value.RateLimit : 0, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
+      MaxInFlight: [this.snmpdevForm ? this.snmpdevForm.value.MaxInFlight : 0, Validators.compose([Validators.required, ValidationService.uintegerValidator])],
       Freq: [this.snmpdevForm ? this.snmpdevForm.value.Freq : 60, Validators.compose([Validators.required, ValidationService.uintegerNotZeroValidator])],
       UpdateFltFreq: [this.snmpdevForm ? this.snmpdevForm.value.UpdateFltFreq : 60, Validators.compose([Validators.required, ValidationService.uintegerAndLessOneValidator])],
       ConcurrentGather: [this.snmpdevForm ? this.snmpdevForm.value.ConcurrentGather : 'true', Validators.required],
       OutDB: [this.snmpdevForm ? this.snmpdevForm.value.OutDB :  '', Validators.required],
+      ExtraOutDBs: [this.snmpdevForm ? this.snmpdevForm.value.ExtraOutDBs : null],
       LogLevel: [this.snmpdevForm ? this.snmpdevForm.value.LogLevel : 'info', Validators.required],
       SnmpDebug: [this.snmpdevForm ? this.snmpdevForm.value.SnmpDebug : 'false', Validators.required],
       DeviceTagName: [this.snmpdevForm ? this.snmpdevForm.value.DeviceTagName : '', Validators.required],
@@ -383,6 +393,7 @@ export class SnmpDeviceCfgComponent {
     this.getMeasGroupsforDevices();
     this.getMeasFiltersforDevices();
     this.getVarCatalogsforDevices();
+    this.getCredentialsforDevices();
     this.editmode = "create";
   }
 
@@ -393,6 +404,7 @@ export class SnmpDeviceCfgComponent {
     this.getMeasGroupsforDevices();
     this.getMeasFiltersforDevices();
     this.getVarCatalogsforDevices();
+    this.getCredentialsforDevices();
 
     this.snmpDeviceService.getDevicesById(id)
       .subscribe(data => {
@@ -561,14 +573,28 @@ updateAllSelectedItems(mySelectedArray,field,value, append?) {
   }
 
   getInfluxServersforDevices() {
-    this.influxserverDeviceService.getInfluxServer(null)
+    this.influxserverDeviceService.getOutputs()
       .subscribe(
       data => {
       //  this.influxservers = data;
         this.selectinfluxservers = [];
         for (let entry of data) {
           console.log(entry)
-          this.selectinfluxservers.push({ 'id': entry.ID, 'name': entry.ID });
+          this.selectinfluxservers.push({ 'id': entry.ID, 'name': entry.ID + ' (' + entry.Type + ')' });
+        }
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  getCredentialsforDevices() {
+    this.credentialDeviceService.getCredential(null)
+      .subscribe(
+      data => {
+        this.selectcredentials = [];
+        for (let entry of data) {
+          this.selectcredentials.push({ 'id': entry.ID, 'name': entry.ID + ' (v' + entry.SnmpVersion + ')' });
         }
       },
       err => console.error(err),
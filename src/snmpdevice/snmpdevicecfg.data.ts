@@ -8,11 +8,13 @@ export const SnmpDeviceCfgComponentConfig: any =
       { title: 'Active', name: 'Active' },
       { title: 'Alternate System OIDs', name: 'SystemOIDs' },
       { title: 'Snmp Version', name: 'SnmpVersion' },
+      { title: 'Credential', name: 'Credential' },
       { title: 'Snmp Debug', name: 'SnmpDebug' },
       { title: 'Polling Period (sec)', name: 'Freq' },
       { title: 'Update Filter (Cycles)', name: 'UpdateFltFreq' },
       { title: 'Concurrent Gather', name: 'ConcurrentGather' },
       { title: 'Influx DB', name: 'OutDB' },
+      { title: 'Extra Influx DBs', name: 'ExtraOutDBs' },
       { title: 'Log Level', name: 'LogLevel' },
       { title: 'Disable Snmp Bulk Queries', name: 'DisableBulk' },
       { title: 'MaxOids for SNMP GET', name: 'MaxOids' },
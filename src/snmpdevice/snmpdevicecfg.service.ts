@@ -20,18 +20,23 @@ export class SnmpDeviceService {
         key == 'Freq' ||
         key == 'MaxRepetitions'  ||
         key == 'MaxOids' ||
+        key == 'RateLimit' ||
+        key == 'MaxInFlight' ||
         key == 'UpdateFltFreq') {
             return parseInt(value);
         }
         if ( key == 'Active' ||
         key == 'SnmpDebug' ||
         key == 'DisableBulk' ||
+        key == 'DisableSharedSession' ||
+        key == 'AdaptivePDUSize' ||
         key == 'ConcurrentGather') return ( value === "true" || value === true);
         if ( key == 'ExtraTags' ||
              key == 'SystemOIDs')
              return  String(value).split(',');
         if ( key == 'MeasFilters' ||
         key == 'MeasurementGroups' ||
+        key == 'FallbackCredentials' ||
         key == 'DeviceVars') {
             if (value == "") return null;
             else return value;
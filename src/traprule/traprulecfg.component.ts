@@ -0,0 +1,300 @@
+import { Component, ChangeDetectionStrategy, ViewChild } from '@angular/core';
+import { FormBuilder, Validators} from '@angular/forms';
+import { FormArray, FormGroup, FormControl} from '@angular/forms';
+
+import { TrapRuleService } from './traprulecfg.service';
+import { ValidationService } from '../common/validation.service'
+import { ExportServiceCfg } from '../common/dataservice/export.service'
+
+import { GenericModal } from '../common/generic-modal';
+import { ExportFileModal } from '../common/dataservice/export-file-modal';
+import { Observable } from 'rxjs/Rx';
+
+import { ItemsPerPageOptions } from '../common/global-constants';
+import { TableActions } from '../common/table-actions';
+import { AvailableTableActions } from '../common/table-available-actions';
+
+import { TableListComponent } from '../common/table-list.component';
+import { TrapRuleCfgComponentConfig, TableRole, OverrideRoleActions } from './traprulecfg.data';
+
+declare var _:any;
+
+@Component({
+  selector: 'traprules',
+  providers: [TrapRuleService, ValidationService],
+  templateUrl: './trapruleeditor.html',
+  styleUrls: ['../css/component-styles.css']
+})
+
+export class TrapRuleCfgComponent {
+  @ViewChild('viewModal') public viewModal: GenericModal;
+  @ViewChild('viewModalDelete') public viewModalDelete: GenericModal;
+  @ViewChild('exportFileModal') public exportFileModal : ExportFileModal;
+
+  itemsPerPageOptions : any = ItemsPerPageOptions;
+  editmode: string; //list , create, modify
+  traprules: Array<any>;
+  filter: string;
+  trapRuleForm: any;
+  myFilterValue: any;
+
+
+  //Initialization data, rows, colunms for Table
+  private data: Array<any> = [];
+  public rows: Array<any> = [];
+  public tableAvailableActions : any;
+
+  selectedArray : any = [];
+  public defaultConfig : any = TrapRuleCfgComponentConfig;
+  public tableRole : any = TableRole;
+  public overrideRoleActions: any = OverrideRoleActions;
+  public isRequesting : boolean;
+  public counterItems : number = null;
+  public counterErrors: any = [];
+
+  public page: number = 1;
+  public itemsPerPage: number = 20;
+  public maxSize: number = 5;
+  public numPages: number = 1;
+  public length: number = 0;
+  private builder;
+  private oldID : string;
+  
+  //Set config
+  public config: any = {
+    paging: true,
+    sorting: { columns: this.defaultConfig['table-columns'] },
+    filtering: { filterString: '' },
+    className: ['table-striped', 'table-bordered']
+  };
+
+  constructor(public trapRuleService: TrapRuleService, public influxserverTrapRuleService: InfluxServerService, public exportServiceCfg : ExportServiceCfg, builder: FormBuilder) {
+    this.editmode = 'list';
+    this.reloadData();
+    this.builder = builder;
+  }
+
+  createStaticForm() {
+    this.trapRuleForm = this.builder.group({
+      ID: [this.trapRuleForm ? this.trapRuleForm.value.ID : '', Validators.required],
+      TrapOID: [this.trapRuleForm ? this.trapRuleForm.value.TrapOID : '', Validators.required],
+      Mode: [this.trapRuleForm ? this.trapRuleForm.value.Mode : 'event', Validators.required],
+      Measurement: [this.trapRuleForm ? this.trapRuleForm.value.Measurement : '', Validators.required],
+      VarBinds: [this.trapRuleForm ? (this.trapRuleForm.value.VarBinds ? this.trapRuleForm.value.VarBinds : '') : ''],
+      Message: [this.trapRuleForm ? this.trapRuleForm.value.Message : ''],
+      Severity: [this.trapRuleForm ? this.trapRuleForm.value.Severity : ''],
+      ExtraTags: [this.trapRuleForm ? (this.trapRuleForm.value.ExtraTags ? this.trapRuleForm.value.ExtraTags : '') : '', Validators.compose([ValidationService.extraTags])],
+      Description: [this.trapRuleForm ? this.trapRuleForm.value.Description : '']
+    });
+  }
+
+  reloadData() {
+    // now it's a simple subscription to the observable
+    this.trapRuleService.getTrapRule(null)
+      .subscribe(
+      data => {
+        this.isRequesting = false;
+        this.traprules = data
+        this.data = data;
+      },
+      err => console.error(err),
+      () => console.log('DONE')
+      );
+  }
+
+  applyAction(test : any, data? : Array<any>) : void {
+    this.selectedArray = data || [];
+    switch(test.action) {
+       case "RemoveAllSelected": {
+          this.removeAllSelectedItems(this.selectedArray);
+          break;
+       }
+       case "ChangeProperty": {
+          this.updateAllSelectedItems(this.selectedArray,test.field,test.value)
+          break;
+       }
+       case "AppendProperty": {
+         this.updateAllSelectedItems(this.selectedArray,test.field,test.value,true);
+       }
+       default: {
+          break;
+       }
+    }
+  }
+
+  customActions(action : any) {
+    switch (action.option) {
+      case 'export' : 
+        this.exportItem(action.event);
+      break;
+      case 'new' :
+        this.newTrapRule()
+      case 'view':
+        this.viewItem(action.event);
+      break;
+      case 'edit':
+        this.editTrapRule(action.event);
+      break;
+      case 'remove':
+        this.removeItem(action.event);
+      break;
+      case 'tableaction':
+        this.applyAction(action.event, action.data);
+      break;
+    }
+  }
+
+  viewItem(id) {
+    console.log('view', id);
+    this.viewModal.parseObject(id);
+  }
+
+  exportItem(item : any) : void {
+    this.exportFileModal.initExportModal(item);
+  }
+
+  removeAllSelectedItems(myArray) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    for (let i in myArray) {
+      console.log("Removing ",myArray[i].ID)
+      this.deleteTrapRule(myArray[i].ID,true);
+      obsArray.push(this.deleteTrapRule(myArray[i].ID,true));
+    }
+    this.genericForkJoin(obsArray);
+  }
+
+  removeItem(row) {
+    let id = row.ID;
+    console.log('remove', id);
+    this.trapRuleService.checkOnDeleteTrapRule(id)
+      .subscribe(
+      data => {
+        console.log(data);
+        let temp = data;
+        this.viewModalDelete.parseObject(temp)
+      },
+      err => console.error(err),
+      () => { }
+      );
+  }
+  newTrapRule() {
+    this.createStaticForm();
+    this.editmode = "create";
+  }
+
+  editTrapRule(row) {
+    let id = row.ID;
+    this.trapRuleService.getTrapRuleById(id)
+      .subscribe(data => {
+        this.trapRuleForm = {};
+        this.trapRuleForm.value = data;
+        this.oldID = data.ID
+        this.createStaticForm();
+        this.editmode = "modify";
+      },
+      err => console.error(err)
+      );
+ 	}
+
+  deleteTrapRule(id, recursive?) {
+    if (!recursive) {
+    this.trapRuleService.deleteTrapRule(id)
+      .subscribe(data => { },
+      err => console.error(err),
+      () => { this.viewModalDelete.hide(); this.editmode = "list"; this.reloadData() }
+      );
+    } else {
+      return this.trapRuleService.deleteTrapRule(id, true)
+      .do(
+        (test) =>  { this.counterItems++},
+        (err) => { this.counterErrors.push({'ID': id, 'error' : err})}
+      );
+    }
+  }
+
+  cancelEdit() {
+    this.editmode = "list";
+    this.reloadData();
+  }
+
+  saveTrapRule() {
+    if (this.trapRuleForm.valid) {
+      this.trapRuleService.addTrapRule(this.trapRuleForm.value)
+        .subscribe(data => { console.log(data) },
+        err => {
+          console.log(err);
+        },
+        () => { this.editmode = "list"; this.reloadData() }
+        );
+    }
+  }
+
+  updateAllSelectedItems(mySelectedArray,field,value, append?) {
+    let obsArray = [];
+    this.counterItems = 0;
+    this.isRequesting = true;
+    if (!append)
+    for (let component of mySelectedArray) {
+      component[field] = value;
+      obsArray.push(this.updateTrapRule(true,component));
+    } else {
+      let tmpArray = [];
+      if(!Array.isArray(value)) value = value.split(',');
+      console.log(value);
+      for (let component of mySelectedArray) {
+        console.log(value);
+        //check if there is some new object to append
+        let newEntries = _.differenceWith(value,component[field],_.isEqual);
+        tmpArray = newEntries.concat(component[field])
+        console.log(tmpArray);
+        component[field] = tmpArray;
+        obsArray.push(this.updateTrapRule(true,component));
+      }
+    }
+    this.genericForkJoin(obsArray);
+    //Make sync calls and wait the result
+    this.counterErrors = [];
+  }
+
+  updateTrapRule(recursive?, component?) {
+    if(!recursive) {
+      if (this.trapRuleForm.valid) {
+        var r = true;
+        if (this.trapRuleForm.value.ID != this.oldID) {
+          r = confirm("Changing TrapRule ID from " + this.oldID + " to " + this.trapRuleForm.value.ID + ". Proceed?");
+        }
+        if (r == true) {
+          this.trapRuleService.editTrapRule(this.trapRuleForm.value, this.oldID, true)
+            .subscribe(data => { console.log(data) },
+            err => console.error(err),
+            () => { this.editmode = "list"; this.reloadData() }
+            );
+        }
+      }
+    } else {
+      return this.trapRuleService.editTrapRule(component, component.ID)
+      .do(
+        (test) =>  { this.counterItems++ },
+        (err) => { this.counterErrors.push({'ID': component['ID'], 'error' : err['_body']})}
+      )
+      .catch((err) => {
+        return Observable.of({'ID': component.ID , 'error': err['_body']})
+      })
+    }
+  }
+
+
+  genericForkJoin(obsArray: any) {
+    Observable.forkJoin(obsArray)
+              .subscribe(
+                data => {
+                  this.selectedArray = [];
+                  this.reloadData()
+                },
+                err => console.error(err),
+              );
+  }
+
+}
@@ -0,0 +1,22 @@
+export const TrapRuleCfgComponentConfig: any =
+  {
+    'name' : 'Trap Rule',
+    'table-columns' : [
+      { title: 'ID', name: 'ID' },
+      { title: 'Trap OID', name: 'TrapOID' },
+      { title: 'Mode', name: 'Mode' },
+      { title: 'Measurement', name: 'Measurement' },
+      { title: 'VarBinds', name: 'VarBinds' },
+      { title: 'Severity', name: 'Severity' },
+      { title: 'Extra Tags', name: 'ExtraTags' }
+    ],
+    'slug' : 'traprulecfg'
+  };
+
+  export const TableRole : string = 'fulledit';
+  export const OverrideRoleActions : Array<Object> = [
+    {'name':'export', 'type':'icon', 'icon' : 'glyphicon glyphicon-download-alt text-default', 'tooltip': 'Export item'},
+    {'name':'view', 'type':'icon', 'icon' : 'glyphicon glyphicon-eye-open text-success', 'tooltip': 'View item'},
+    {'name':'edit', 'type':'icon', 'icon' : 'glyphicon glyphicon-edit text-warning', 'tooltip': 'Edit item'},
+    {'name':'remove', 'type':'icon', 'icon' : 'glyphicon glyphicon glyphicon-remove text-danger', 'tooltip': 'Remove item'}
+  ]
\ No newline at end of file